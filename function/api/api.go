@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
+
 	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
 	"github.com/crossplane-contrib/function-hcl/function/internal/format"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/hashicorp/hcl/v2"
 )
 
@@ -24,6 +27,43 @@ func Analyze(files ...File) hcl.Diagnostics {
 	return e.AnalyzeHCLFiles(files...)
 }
 
+// AnalyzerConfigFile is the well-named file that sets the severities of analyzer rules.
+const AnalyzerConfigFile = evaluator.AnalyzerConfigFile
+
+// AnalyzerConfig sets the severities of analyzer rules, for all files or for the files that match some patterns.
+type AnalyzerConfig = evaluator.AnalyzerConfig
+
+// LoadAnalyzerConfig loads the analyzer configuration from the supplied directory. It returns nil when the
+// directory does not have an AnalyzerConfigFile.
+func LoadAnalyzerConfig(fs FS, dir string) (*AnalyzerConfig, error) {
+	return composition.LoadAnalyzerConfig(fs, dir)
+}
+
+// AnalyzeWithConfig analyzes the supplied files for correctness, setting the severities of the diagnostics of
+// rules as configured. It returns an error when the configuration refers to unknown rules.
+func AnalyzeWithConfig(cfg *AnalyzerConfig, files ...File) (hcl.Diagnostics, error) {
+	e, err := evaluator.New(evaluator.Options{AnalyzerConfig: cfg})
+	if err != nil {
+		return nil, err
+	}
+	return e.AnalyzeHCLFiles(files...), nil
+}
+
+// AnalyzerRule is a custom lint rule run by Analyze in addition to the built-in checks.
+type AnalyzerRule = evaluator.AnalyzerRule
+
+// AnalyzerRuleBase provides no-op visit methods and should be embedded by analyzer rules.
+type AnalyzerRuleBase = evaluator.AnalyzerRuleBase
+
+// RuleContext describes where a node visited by an analyzer rule appears in the source.
+type RuleContext = evaluator.RuleContext
+
+// RegisterAnalyzerRule registers a rule that is run for all subsequent analysis, including by fn-hcl-tools
+// when the package that registers it is compiled into the tools.
+func RegisterAnalyzerRule(rule AnalyzerRule) {
+	evaluator.RegisterAnalyzerRule(rule)
+}
+
 // FS is a minimal filesystem implementation that the caller can implement.
 type FS = composition.FS
 
@@ -46,3 +86,77 @@ func LoadModule(fs FS, dir string, ignoreMetadataErrors bool) (*XRD, []string, e
 	}
 	return xrd, files, nil
 }
+
+// SourceFile is a named file with HCL source code.
+type SourceFile = evaluator.File
+
+// ResourceHook is called with the desired resources after the HCL has been evaluated and before a response is
+// created, and may annotate or reject resources.
+type ResourceHook = evaluator.ResourceHook
+
+// HookFinding is a finding reported by a resource hook.
+type HookFinding = evaluator.HookFinding
+
+// HookSeverity is the severity of a finding reported by a resource hook.
+type HookSeverity = evaluator.HookSeverity
+
+// Severities of hook findings.
+const (
+	HookSeverityWarning = evaluator.HookSeverityWarning
+	HookSeverityFatal   = evaluator.HookSeverityFatal
+)
+
+// BatchOptions are options for batch evaluation.
+type BatchOptions struct {
+	Flags []string       // feature flags that are set
+	Hooks []ResourceHook // hooks to check desired resources before they are returned
+}
+
+// BatchResult is the result of evaluating a single request in a batch.
+type BatchResult struct {
+	Response   *fnv1.RunFunctionResponse // the response, nil on errors
+	Incomplete bool                      // true if any item was discarded because its values were incomplete
+	Err        error                     // evaluation error, if any
+}
+
+// Batch evaluates the same composition against many requests. The files are parsed and user functions are
+// processed only once. A batch is not safe for concurrent use.
+type Batch struct {
+	program *evaluator.Program
+}
+
+// NewBatch returns a batch for the supplied files.
+func NewBatch(opts BatchOptions, files ...SourceFile) (*Batch, error) {
+	p, err := evaluator.Compile(evaluator.Options{Flags: opts.Flags, Hooks: opts.Hooks}, files...)
+	if err != nil {
+		return nil, err
+	}
+	return &Batch{program: p}, nil
+}
+
+// Eval evaluates the composition against a single request.
+func (b *Batch) Eval(ctx context.Context, req *fnv1.RunFunctionRequest) BatchResult {
+	e, err := b.program.NewEvaluator()
+	if err != nil {
+		return BatchResult{Err: err}
+	}
+	res, err := e.Eval(ctx, req)
+	if err != nil {
+		return BatchResult{Err: err}
+	}
+	return BatchResult{Response: res, Incomplete: e.Incomplete()}
+}
+
+// EvalAll evaluates the composition against all supplied requests and returns results in the same order.
+// It stops early when the context is done, in which case the remaining results have the context error.
+func (b *Batch) EvalAll(ctx context.Context, reqs []*fnv1.RunFunctionRequest) []BatchResult {
+	ret := make([]BatchResult, len(reqs))
+	for i, req := range reqs {
+		if err := ctx.Err(); err != nil {
+			ret[i] = BatchResult{Err: err}
+			continue
+		}
+		ret[i] = b.Eval(ctx, req)
+	}
+	return ret
+}
@@ -13,11 +13,21 @@ func main() {
 	root.AddCommand(
 		formatCommand(),
 		analyzeCommand(),
+		docsCommand(),
 		packageScriptCommand(),
 		versionCommand(),
 		extractCRDsCommand(),
+		simulateCommand(),
+		renderCommand(),
+		captureCommand(),
+		migrateNamesCommand(),
 	)
-	if err := root.Execute(); err != nil {
-		os.Exit(1)
+	cmd, err := root.ExecuteC()
+	code := exitCode(cmd, err)
+	for _, s := range summaryFlags {
+		if err := s.write(os.Stderr, code); err != nil {
+			code = exitFailure
+		}
 	}
+	os.Exit(code)
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	"github.com/crossplane-contrib/function-hcl/function/internal/format"
+	"github.com/spf13/cobra"
+)
+
+// exit codes of the tool, such that CI scripts can distinguish failures.
+const (
+	exitOK          = 0 // the command succeeded
+	exitUsage       = 1 // the command line is invalid
+	exitAnalysis    = 2 // analysis reported errors
+	exitUnformatted = 3 // files are not formatted
+	exitFailure     = 4 // any other failure, like unreadable files
+)
+
+// summaryJSON is the only supported summary format.
+const summaryJSON = "json"
+
+// exitCode returns the exit code for the error returned when running the supplied command. Errors returned
+// before a command silences usage, like invalid flags or arguments, are usage errors.
+func exitCode(cmd *cobra.Command, err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, composition.ErrAnalysisFailed):
+		return exitAnalysis
+	case errors.Is(err, format.ErrUnformatted):
+		return exitUnformatted
+	case cmd == nil || !cmd.SilenceUsage:
+		return exitUsage
+	default:
+		return exitFailure
+	}
+}
+
+// summary has the counts for a command that are printed when a summary is requested.
+type summary struct {
+	Command     string `json:"command"`
+	Files       int    `json:"files"`
+	Errors      int    `json:"errors"`
+	Warnings    int    `json:"warnings"`
+	Unformatted int    `json:"unformatted,omitempty"`
+	ExitCode    int    `json:"exitCode"`
+}
+
+// summaryFlag is the value of the summary flag of a command along with the summary that it produced.
+type summaryFlag struct {
+	format string
+	result *summary
+}
+
+// summaryFlags are the summary flags of all commands.
+var summaryFlags []*summaryFlag
+
+// addSummaryFlag adds a summary flag to the supplied command.
+func addSummaryFlag(c *cobra.Command, s *summaryFlag) {
+	summaryFlags = append(summaryFlags, s)
+	c.Flags().StringVar(&s.format, "summary", "", `print a summary of the number of files, errors and warnings to stderr in the supplied format, only "json" is supported`)
+}
+
+// check returns an error if the summary format is not supported.
+func (s *summaryFlag) check() error {
+	if s.format != "" && s.format != summaryJSON {
+		return fmt.Errorf("unsupported summary format %q", s.format)
+	}
+	return nil
+}
+
+// set records the summary of the command when one was requested.
+func (s *summaryFlag) set(v summary) {
+	if s.format != "" {
+		s.result = &v
+	}
+}
+
+// write writes the recorded summary, if any, with the supplied exit code.
+func (s *summaryFlag) write(w io.Writer, code int) error {
+	if s.result == nil {
+		return nil
+	}
+	s.result.ExitCode = code
+	b, err := json.Marshal(s.result)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
@@ -5,7 +5,12 @@ import (
 	"os"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	"github.com/crossplane-contrib/function-hcl/function/internal/docs"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
 	"github.com/crossplane-contrib/function-hcl/function/internal/format"
+	"github.com/crossplane-contrib/function-hcl/function/internal/rename"
+	"github.com/crossplane-contrib/function-hcl/function/internal/render"
+	"github.com/crossplane-contrib/function-hcl/function/internal/simulate"
 	"github.com/spf13/cobra"
 )
 
@@ -21,33 +26,155 @@ func getDir(args []string) (string, error) {
 }
 
 func analyzeCommand() *cobra.Command {
+	var opts composition.AnalyzeOptions
+	var sf summaryFlag
 	c := &cobra.Command{
-		Use:   "analyze [dir]",
-		Short: "perform a static analysis of the supplied directory (default is current directory)",
+		Use:   "analyze [dir | -]",
+		Short: "perform a static analysis of the supplied directory (default is current directory), or of a txtar archive on stdin with -",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir, err := getDir(args)
 			if err != nil {
 				return err
 			}
+			if err := sf.check(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			report, err := composition.Analyze(dir, opts)
+			sf.set(summary{Command: cmd.Name(), Files: report.Files, Errors: report.Errors, Warnings: report.Warnings})
+			return err
+		},
+	}
+	addSummaryFlag(c, &sf)
+	f := c.Flags()
+	f.BoolVar(&opts.SimulateConditions, "simulate-conditions", false, "warn about references to objects that only exist when a condition holds")
+	f.StringSliceVar(&opts.Flags, "flags", nil, "feature flags to analyze with, default is to analyze all combinations of flags used")
+	f.StringVar(&opts.Request, "request", "", "sample RunFunctionRequest file in JSON or YAML format to also evaluate the composition against")
+	f.StringSliceVar(&opts.ConnectionKinds, "connection-kinds", nil, "kinds of resources that publish connection details, as kind or kind.group, to warn about reads of connection details of other kinds")
+	f.StringVar(&opts.ConfigFile, "config", "", "analyzer config file that sets the severities of rules, default is "+evaluator.AnalyzerConfigFile+" in the analyzed directory")
+	return c
+}
+
+func simulateCommand() *cobra.Command {
+	var opts simulate.Options
+	c := &cobra.Command{
+		Use:   "simulate [dir]",
+		Short: "evaluate the composition in the supplied directory against composites in a cluster and report changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := getDir(args)
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			return simulate.Run(cmd.Context(), dir, opts, os.Stdout)
+		},
+	}
+	f := c.Flags()
+	f.StringVar(&opts.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file, default is to use the standard loading rules")
+	f.StringVar(&opts.XRD, "xrd", "", "kind of the composites as kind or kind.group, default is the XRD in "+composition.ConfigFile)
+	f.StringVarP(&opts.Namespace, "namespace", "n", "", "namespace of the composites, default is all namespaces")
+	f.StringSliceVar(&opts.Flags, "flags", nil, "feature flags to evaluate with")
+	f.StringSliceVar(&opts.Targets, "target", nil, "only evaluate groups, resources and resource collections with one of these labels, others keep their observed state")
+	return c
+}
+
+func renderCommand() *cobra.Command {
+	opts := render.Options{Concurrency: 4}
+	c := &cobra.Command{
+		Use:   "render [dir]",
+		Short: "evaluate the composition in the supplied directory against every composite in a directory of YAML files and write the desired resources of each",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := getDir(args)
+			if err != nil {
+				return err
+			}
+			if opts.XRs == "" || opts.Output == "" {
+				return fmt.Errorf("--xrs and --output are required")
+			}
 			cmd.SilenceUsage = true
-			return composition.Analyze(dir)
+			return render.Run(cmd.Context(), dir, opts, os.Stdout)
 		},
 	}
+	f := c.Flags()
+	f.StringVar(&opts.XRs, "xrs", "", "directory with one composite per YAML file")
+	f.StringVarP(&opts.Output, "output", "o", "", "directory under which a directory with the desired resources is written for every composite")
+	f.StringSliceVar(&opts.Flags, "flags", nil, "feature flags to evaluate with")
+	f.StringSliceVar(&opts.Targets, "target", nil, "only evaluate groups, resources and resource collections with one of these labels")
+	f.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "number of composites to evaluate at the same time")
+	f.BoolVar(&opts.Proto, "proto", false, "also write the request and response of every composite as protobuf JSON, to compare behavior across versions or attach to bug reports")
+	return c
+}
+
+func captureCommand() *cobra.Command {
+	var opts simulate.CaptureOptions
+	c := &cobra.Command{
+		Use:   "capture [dir]",
+		Short: "write a sanitized function request for a composite in a cluster, for use as a test fixture",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := getDir(args)
+			if err != nil {
+				return err
+			}
+			if opts.Name == "" {
+				return fmt.Errorf("--xr is required")
+			}
+			cmd.SilenceUsage = true
+			return simulate.Capture(cmd.Context(), dir, opts, os.Stdout)
+		},
+	}
+	f := c.Flags()
+	f.StringVar(&opts.Name, "xr", "", "name of the composite to capture")
+	f.StringVar(&opts.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file, default is to use the standard loading rules")
+	f.StringVar(&opts.XRD, "xrd", "", "kind of the composite as kind or kind.group, default is the XRD in "+composition.ConfigFile)
+	f.StringVarP(&opts.Namespace, "namespace", "n", "", "namespace of the composite, default is all namespaces")
+	f.StringSliceVar(&opts.Flags, "flags", nil, "feature flags to evaluate with when resolving extra resources")
+	f.StringVarP(&opts.Format, "output", "o", "yaml", "format of the request, json or yaml")
+	return c
+}
+
+func migrateNamesCommand() *cobra.Command {
+	var opts rename.Options
+	var kind string
+	c := &cobra.Command{
+		Use:   "migrate-names [dir]",
+		Short: "rename a resource, resource collection or local in all files of the supplied directory, updating all references",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := getDir(args)
+			if err != nil {
+				return err
+			}
+			if opts.From == "" || opts.To == "" {
+				return fmt.Errorf("--from and --to are required")
+			}
+			opts.Kind = rename.Kind(kind)
+			cmd.SilenceUsage = true
+			return rename.Run(dir, opts, os.Stdout)
+		},
+	}
+	f := c.Flags()
+	f.StringVar(&kind, "kind", string(rename.KindResource), fmt.Sprintf("kind of object to rename, one of %v", rename.Kinds))
+	f.StringVar(&opts.From, "from", "", "current name of the object")
+	f.StringVar(&opts.To, "to", "", "new name of the object")
 	return c
 }
 
 func packageScriptCommand() *cobra.Command {
 	var skipAnalysis bool
+	var sf summaryFlag
 	c := &cobra.Command{
-		Use:   "package [dir]",
-		Short: "generate a txtar script for the supplied directory (default is current directory)",
+		Use:   "package [dir | -]",
+		Short: "generate a txtar script for the supplied directory (default is current directory), or from a txtar archive on stdin with -",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir, err := getDir(args)
 			if err != nil {
 				return err
 			}
+			if err := sf.check(); err != nil {
+				return err
+			}
 			cmd.SilenceUsage = true
-			b, err := composition.Package(dir, skipAnalysis)
+			b, report, err := composition.Package(dir, skipAnalysis)
+			sf.set(summary{Command: cmd.Name(), Files: report.Files, Errors: report.Errors, Warnings: report.Warnings})
 			if err != nil {
 				return err
 			}
@@ -55,6 +182,7 @@ func packageScriptCommand() *cobra.Command {
 			return nil
 		},
 	}
+	addSummaryFlag(c, &sf)
 	f := c.Flags()
 	f.BoolVar(&skipAnalysis, "skip-analysis", false, "skip analysis of files before packaging")
 	return c
@@ -68,17 +196,42 @@ func formatCommand() *cobra.Command {
 			StandardizeObjectLiterals: true,
 		},
 	}
+	var sf summaryFlag
 	c := &cobra.Command{
 		Use:   "fmt file1.hcl file2.hcl dir/ ...",
 		Short: "format HCL files",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sf.check(); err != nil {
+				return err
+			}
 			cmd.SilenceUsage = true
-			return fc.Execute(args)
+			err := fc.Execute(args)
+			sf.set(summary{Command: cmd.Name(), Files: fc.Summary.Files, Warnings: fc.Summary.Warnings, Unformatted: fc.Summary.Unformatted})
+			return err
 		},
 	}
+	addSummaryFlag(c, &sf)
 	f := c.Flags()
 	f.BoolVar(&fc.Opts.StandardizeObjectLiterals, "normalize-literals", fc.Opts.StandardizeObjectLiterals, "normalize object literals to always use key = value syntax")
+	f.IntVar(&fc.Opts.MaxLineLength, "max-line-length", fc.Opts.MaxLineLength, "break expressions on lines longer than this many columns across lines, 0 disables wrapping")
 	f.BoolVarP(&fc.Check, "check", "c", fc.Check, "check if files are formatted, log names of unformatted files and exit appropriately")
+	f.BoolVarP(&fc.Diff, "diff", "d", fc.Diff, "print unified diffs of the changes instead of rewriting files")
+	f.BoolVar(&fc.Stdout, "stdout", fc.Stdout, "print formatted contents to stdout instead of rewriting files")
 	f.BoolVarP(&fc.Recursive, "recursive", "r", fc.Recursive, "recursively process directories")
 	return c
 }
+
+func docsCommand() *cobra.Command {
+	var dc docs.DocsCmd
+	c := &cobra.Command{
+		Use:   "docs file1.hcl file2.hcl dir/ ...",
+		Short: "generate Markdown documentation for the functions, parameters, resources and requirements of a composition",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return dc.Execute(args)
+		},
+	}
+	f := c.Flags()
+	f.StringSliceVar(&dc.Flags, "flags", nil, "feature flags to set, blocks that are not enabled for these flags are not documented")
+	return c
+}
@@ -7,16 +7,10 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/crossplane-contrib/function-hcl/function/internal/version"
 	"github.com/spf13/cobra"
 )
 
-// Build information. Populated at build-time.
-var (
-	Version   = "dev"
-	Commit    = "unknown"
-	BuildDate = "unknown"
-)
-
 // info contains detailed information about the binary.
 type buildInfo struct {
 	version, commit, buildDate string
@@ -42,7 +36,7 @@ func versionCommand() *cobra.Command {
 		Use:   "version",
 		Short: "print program version",
 		Run: func(cmd *cobra.Command, args []string) {
-			info := buildInfo{Version, Commit, BuildDate}
+			info := buildInfo{version.Version, version.Commit, version.BuildDate}
 			fmt.Println(info)
 		},
 	}
@@ -0,0 +1,73 @@
+//go:build js && wasm
+
+// Command fn-hcl-wasm exposes the analyzer and formatter to JavaScript when compiled to WebAssembly.
+// It registers the following global functions:
+//
+//   - fnHclFormat(code) returns the formatted code.
+//   - fnHclAnalyze(files) analyzes an object of file names to source code and returns a list of diagnostics.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/crossplane-contrib/function-hcl/function/api"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func pos(p hcl.Pos) map[string]any {
+	return map[string]any{"line": p.Line, "column": p.Column, "byte": p.Byte}
+}
+
+// toJS converts the supplied diagnostics to values that can be passed to JavaScript.
+func toJS(diags hcl.Diagnostics) []any {
+	ret := make([]any, 0, len(diags))
+	for _, d := range diags {
+		severity := "error"
+		if d.Severity == hcl.DiagWarning {
+			severity = "warning"
+		}
+		item := map[string]any{
+			"severity": severity,
+			"summary":  d.Summary,
+			"detail":   d.Detail,
+		}
+		if d.Subject != nil {
+			item["filename"] = d.Subject.Filename
+			item["start"] = pos(d.Subject.Start)
+			item["end"] = pos(d.Subject.End)
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+func format(_ js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return js.Null()
+	}
+	return api.FormatHCL(args[0].String())
+}
+
+func analyze(_ js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return js.Null()
+	}
+	var diags hcl.Diagnostics
+	var files []api.File
+	names := js.Global().Get("Object").Call("keys", args[0])
+	for i := 0; i < names.Length(); i++ {
+		name := names.Index(i).String()
+		f, ds := hclsyntax.ParseConfig([]byte(args[0].Get(name).String()), name, hcl.InitialPos)
+		diags = diags.Extend(ds)
+		files = append(files, api.File{Name: name, File: f})
+	}
+	diags = diags.Extend(api.Analyze(files...))
+	return toJS(diags)
+}
+
+func main() {
+	js.Global().Set("fnHclFormat", js.FuncOf(format))
+	js.Global().Set("fnHclAnalyze", js.FuncOf(analyze))
+	select {} // keep the functions available for the lifetime of the page
+}
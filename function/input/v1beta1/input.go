@@ -6,6 +6,7 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // This isn't a custom resource, in the sense that we never install its CRD.
@@ -20,10 +21,45 @@ const (
 	ScriptSourceInline ScriptSource = "Inline"
 )
 
+// CollectionIndexFormat is the format of collection index annotations.
+type CollectionIndexFormat struct {
+	// Prefix is the text that precedes the index.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Width is the minimum number of digits of the index, which is padded with
+	// zeros to this width.
+	// +optional
+	Width int `json:"width,omitempty"`
+}
+
+// CompositeSchema has the OpenAPI v3 schemas of the spec and status of the
+// composite resource, as found in its composite resource definition.
+type CompositeSchema struct {
+	// Spec is the schema of the spec of the composite.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +optional
+	Spec *runtime.RawExtension `json:"spec,omitempty"`
+	// Status is the schema of the status of the composite.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +optional
+	Status *runtime.RawExtension `json:"status,omitempty"`
+}
+
 // HclInput can be used to provide input to the function.
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 // +kubebuilder:resource:categories=crossplane
+// InputFile is a single named HCL source file.
+type InputFile struct {
+	// Name is the name of the file, which must be unique. It is only used for
+	// error reporting and to identify values files by their extension.
+	Name string `json:"name"`
+	// Content is the content of the file.
+	Content string `json:"content"`
+}
+
 type HclInput struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -36,6 +72,109 @@ type HclInput struct {
 	// the files are irrelevant and only used for error reporting.
 	// +optional
 	HCL string `json:"hcl,omitempty"`
+	// Files specifies inline hcl as a list of named files. It is an alternative
+	// to HCL that is easier to produce and patch with YAML tooling than a single
+	// txtar string. Exactly one of HCL and Files must be specified.
+	// +optional
+	Files []InputFile `json:"files,omitempty"`
+	// Flags is a list of feature flags that are set for the script. Groups,
+	// resources and resource collections that have a `when` attribute are only
+	// processed when the expression evaluates to true for these flags. This allows
+	// the same script to be used for multiple variants of a composition.
+	// +optional
+	Flags []string `json:"flags,omitempty"`
+	// Values is a list of values files, in the same format as the ".hclvars" files
+	// in the HCL input, whose attributes are available to the script under the
+	// "arg" namespace. They are applied after the values files in the HCL input
+	// and later values override earlier ones, such that environment specific
+	// values can be kept out of the composition logic.
+	// +optional
+	Values []string `json:"values,omitempty"`
+	// OnIncompleteTTL is the response TTL, as a duration string like "30s", that is
+	// requested when some items could not be rendered because their values were
+	// incomplete. Setting it shorter than the default TTL causes Crossplane to retry
+	// sooner, which speeds up convergence for new XRs. It has no effect when it is
+	// longer than the TTL that would otherwise be used.
+	// +optional
+	OnIncompleteTTL string `json:"onIncompleteTTL,omitempty"`
+	// DiscardsInContext emits the list of all items discarded by the script, along with
+	// the reason for discarding them, into the response context under the
+	// "hcl.fn.crossplane.io/discards" key. This allows observability tooling to
+	// inspect exactly what was skipped without parsing result messages.
+	// +optional
+	DiscardsInContext bool `json:"discardsInContext,omitempty"`
+	// MaxDiscardsToDisplay is the maximum number of discarded items, and of failed
+	// policies, that are listed by name in the messages of the FullyResolved and
+	// PolicyCompliant conditions. Defaults to 3.
+	// +optional
+	MaxDiscardsToDisplay int `json:"maxDiscardsToDisplay,omitempty"`
+	// DiscardCountsOnly leaves the names of discarded items and failed policies out
+	// of condition messages, such that they only have the number of items. The names
+	// are still reported in the results of the function.
+	// +optional
+	DiscardCountsOnly bool `json:"discardCountsOnly,omitempty"`
+	// IsolateGroupErrors reports evaluation errors in a group as a failed group,
+	// instead of failing the whole evaluation. Nothing that the failed group adds is
+	// rendered, but the resources of all other groups are, such that an optional part
+	// of a large composition that fails does not block the core resources. The
+	// failure is reported as a warning, since a fatal result would stop the whole
+	// pipeline.
+	// +optional
+	IsolateGroupErrors bool `json:"isolateGroupErrors,omitempty"`
+	// MergeComposite merges the spec and status that the script sets on the
+	// desired composite into the desired composite produced by earlier functions
+	// in the pipeline. By default, the desired composite of the script replaces
+	// it, along with any status fields that earlier functions set.
+	// +optional
+	MergeComposite bool `json:"mergeComposite,omitempty"`
+	// ContextNamespace nests all keys that the function writes to the pipeline context
+	// under this key, such that multiple function-hcl steps in a pipeline do not overwrite
+	// each other's values. The values under it in the request context are visible at the
+	// top level of req.context.
+	// +optional
+	ContextNamespace string `json:"contextNamespace,omitempty"`
+	// Targets restricts evaluation to the groups, resources and resource
+	// collections that have one of these values in their `labels` attribute,
+	// along with everything in the groups that do. The observed state of
+	// resources that are not evaluated is copied to the desired state, such that
+	// a subsystem can be rolled out or debugged in isolation without changing
+	// or deleting the others.
+	// +optional
+	Targets []string `json:"targets,omitempty"`
+	// SkipUntargeted leaves resources that are not evaluated because of Targets
+	// out of the desired state instead of copying their observed state.
+	// +optional
+	SkipUntargeted bool `json:"skipUntargeted,omitempty"`
+	// ChangeSummary stores a manifest of hashes of desired resources in the
+	// "hclManifest" field of the status of the composite, which the XRD must
+	// declare, and in the response context under the "hcl.fn.crossplane.io/manifest"
+	// key. When the observed composite has a manifest from a previous reconcile, a
+	// result listing the names of resources that were added, removed, or changed
+	// since then is also returned.
+	// +optional
+	ChangeSummary bool `json:"changeSummary,omitempty"`
+	// ResourceSummary stores the number of desired resources, in total and broken
+	// down by kind and by API group, in the response context under the
+	// "hcl.fn.crossplane.io/resource-summary" key. This allows operators to monitor
+	// how the number of resources rendered for a composite grows over time.
+	// +optional
+	ResourceSummary bool `json:"resourceSummary,omitempty"`
+	// MaxInvokeDepth is the maximum depth of nested user function and lambda calls.
+	// Evaluation fails with the chain of calls when it is exceeded. Defaults to 100
+	// and cannot be larger than 1000.
+	// +optional
+	MaxInvokeDepth int `json:"maxInvokeDepth,omitempty"`
+	// CollectionIndex controls the format of the "hcl.fn.crossplane.io/collection-index"
+	// annotation added to resources created by resource collections. The default is
+	// the zero-based index padded with zeros to 6 digits, with a prefix of "s".
+	// +optional
+	CollectionIndex *CollectionIndexFormat `json:"collectionIndex,omitempty"`
+	// CompositeSchema provides the types of the spec and status of the
+	// composite, which are otherwise inferred from the values of the observed
+	// composite. Attributes that are not set are missing in either case, such
+	// that try expressions still fall back to their defaults.
+	// +optional
+	CompositeSchema *CompositeSchema `json:"compositeSchema,omitempty"`
 	// Debug prints inputs to and outputs of the hcl script for all XRs.
 	// Inputs are pre-processed to remove typically irrelevant information like
 	// the last applied kubectl annotation, managed fields etc.
@@ -8,11 +8,81 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectionIndexFormat) DeepCopyInto(out *CollectionIndexFormat) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectionIndexFormat.
+func (in *CollectionIndexFormat) DeepCopy() *CollectionIndexFormat {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectionIndexFormat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositeSchema) DeepCopyInto(out *CompositeSchema) {
+	*out = *in
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositeSchema.
+func (in *CompositeSchema) DeepCopy() *CompositeSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositeSchema)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HclInput) DeepCopyInto(out *HclInput) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]InputFile, len(*in))
+		copy(*out, *in)
+	}
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CollectionIndex != nil {
+		in, out := &in.CollectionIndex, &out.CollectionIndex
+		*out = new(CollectionIndexFormat)
+		**out = **in
+	}
+	if in.CompositeSchema != nil {
+		in, out := &in.CompositeSchema, &out.CompositeSchema
+		*out = new(CompositeSchema)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HclInput.
@@ -32,3 +102,18 @@ func (in *HclInput) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputFile) DeepCopyInto(out *InputFile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputFile.
+func (in *InputFile) DeepCopy() *InputFile {
+	if in == nil {
+		return nil
+	}
+	out := new(InputFile)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -6,11 +6,16 @@ package composition
 import (
 	"io/fs"
 
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/pkg/errors"
 	"golang.org/x/tools/txtar"
 )
 
 const ConfigFile = "composition.yaml"
 
+// Stdin is the directory argument that reads a txtar archive from standard input instead of a directory.
+const Stdin = "-"
+
 type XRD struct {
 	APIVersion string `json:"apiVersion"`
 	Kind       string `json:"kind"`
@@ -38,31 +43,80 @@ func Load(fs FS, dir string, ignoreMetadataErrors bool) (*Config, []string, erro
 	return l.load(dir)
 }
 
+// LoadFiles returns the composition information and the contents of all HCL files and any additional
+// library files in the supplied directory.
+func LoadFiles(dir string) (*Config, []evaluator.File, error) {
+	cfg, _, files, err := newLoader(osFs{}).loadArchive(dir)
+	return cfg, files, err
+}
+
+// LoadAnalyzerConfig returns the analyzer configuration in the evaluator.AnalyzerConfigFile of the supplied
+// directory, or nil when the directory does not have one.
+func LoadAnalyzerConfig(fs FS, dir string) (*evaluator.AnalyzerConfig, error) {
+	return newLoader(fs).loadAnalyzerConfig(dir, "")
+}
+
+// ErrAnalysisFailed is returned when the analysis of a composition reports errors.
+var ErrAnalysisFailed = errors.New("analysis failed")
+
+// Report has the number of files processed and the number of diagnostics reported for them.
+type Report struct {
+	Files    int
+	Errors   int
+	Warnings int
+}
+
 // Package combines all HCL files and any additional library files and returns a byte array
-// that contains the entire package in txtar format.
-func Package(dir string, skipAnalysis bool) ([]byte, error) {
+// that contains the entire package in txtar format, along with a report of the analysis.
+// When the directory is Stdin, the files are read from a txtar archive on standard input.
+func Package(dir string, skipAnalysis bool) ([]byte, Report, error) {
 	l := newLoader(osFs{})
-	archive, files, err := l.loadArchive(dir)
+	archive, files, err := l.loadSource(dir)
 	if err != nil {
-		return nil, err
+		return nil, Report{}, err
 	}
+	report := Report{Files: len(files)}
 	if !skipAnalysis {
-		if err = doAnalyze(files); err != nil {
-			return nil, err
+		cfg, err := l.loadAnalyzerConfig(dir, "")
+		if err != nil {
+			return nil, report, err
+		}
+		if report, err = doAnalyze(files, AnalyzeOptions{}, cfg); err != nil {
+			return nil, report, err
 		}
 	}
-	return txtar.Format(archive), nil
+	return txtar.Format(archive), report, nil
 }
 
-// Analyze analyzes all HCL files and any additional library files and returns an error on a failed analysis.
-func Analyze(dir string) error {
+// AnalyzeOptions control the checks performed by Analyze.
+type AnalyzeOptions struct {
+	// SimulateConditions reports references to objects that only exist when a condition holds.
+	SimulateConditions bool
+	// Flags are the feature flags to analyze with. When nil, every combination of flags used is analyzed.
+	Flags []string
+	// Request is an optional file that contains a sample RunFunctionRequest in JSON or YAML format. When set,
+	// the composition is also evaluated against it to find problems that only show up with actual data.
+	Request string
+	// ConnectionKinds are the kinds of resources that publish connection details, as kind or kind.group. When set,
+	// reads of the connection details of resources of other kinds are reported.
+	ConnectionKinds []string
+	// ConfigFile is the analyzer configuration file that sets the severities of rules. When not set, the
+	// evaluator.AnalyzerConfigFile in the directory is used if it exists.
+	ConfigFile string
+}
+
+// Analyze analyzes all HCL files and any additional library files and returns a report of the analysis.
+// It returns ErrAnalysisFailed when errors were reported. When the directory is Stdin, the files are read
+// from a txtar archive on standard input.
+func Analyze(dir string, opts AnalyzeOptions) (Report, error) {
 	l := newLoader(osFs{})
-	_, files, err := l.loadArchive(dir)
+	_, files, err := l.loadSource(dir)
 	if err != nil {
-		return err
+		return Report{}, err
 	}
-	if err = doAnalyze(files); err != nil {
-		return err
+	cfg, err := l.loadAnalyzerConfig(dir, opts.ConfigFile)
+	if err != nil {
+		return Report{}, err
 	}
-	return nil
+	return doAnalyze(files, opts, cfg)
 }
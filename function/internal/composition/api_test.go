@@ -20,21 +20,21 @@ func TestPackageLib(t *testing.T) {
 	assert.Contains(t, files, "main.hcl")
 	assert.Contains(t, files, "lib/bar.hcl")
 
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 2)
-	err = Analyze(dir)
+	_, err = Analyze(dir, AnalyzeOptions{})
 	require.NoError(t, err)
 }
 
 func TestPackageNoLib(t *testing.T) {
 	dir := filepath.Join("testdata", "dir-only")
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 1)
-	err = Analyze(dir)
+	_, err = Analyze(dir, AnalyzeOptions{})
 	require.NoError(t, err)
 }
 
@@ -1,36 +1,136 @@
 package composition
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/crossplane-contrib/function-hcl/function/internal/ignore"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/ghodss/yaml"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/pkg/errors"
 	"golang.org/x/tools/txtar"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
-func doAnalyze(files []evaluator.File) error {
+func doAnalyze(files []evaluator.File, opts AnalyzeOptions, cfg *evaluator.AnalyzerConfig) (Report, error) {
+	report := Report{Files: len(files)}
 	logger := log.New(os.Stderr, "", 0)
-	e, err := evaluator.New(evaluator.Options{})
+	e, err := evaluator.New(evaluator.Options{
+		SimulateConditions: opts.SimulateConditions,
+		Flags:              opts.Flags,
+		ConnectionKinds:    opts.ConnectionKinds,
+		AnalyzerConfig:     cfg,
+	})
 	if err != nil {
-		return err
+		return report, err
 	}
 	diags := e.Analyze(files...)
+	if opts.Request != "" && !diags.HasErrors() {
+		ds, err := evalWithRequest(files, opts)
+		if err != nil {
+			return report, err
+		}
+		diags = mergeDiags(diags, ds)
+	}
 	for _, diag := range diags {
 		sev := "ERROR:"
 		if diag.Severity == hcl.DiagWarning {
 			sev = "WARN :"
+			report.Warnings++
+		} else {
+			report.Errors++
 		}
-		logger.Println("\t", sev, diag.Error())
+		logger.Println("\t", sev, diagString(diag))
 	}
 	if diags.HasErrors() {
-		return fmt.Errorf("analysis failed")
+		return report, ErrAnalysisFailed
+	}
+	return report, nil
+}
+
+// diagString returns the string representation of the supplied diagnostic, omitting the source range
+// when it has none.
+func diagString(d *hcl.Diagnostic) string {
+	if d.Subject != nil {
+		return d.Error()
+	}
+	if d.Detail == "" {
+		return d.Summary
+	}
+	return d.Summary + "; " + d.Detail
+}
+
+// loadRequest loads a RunFunctionRequest from the supplied file in JSON or YAML format.
+func loadRequest(file string) (*fnv1.RunFunctionRequest, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	b, err = yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "convert %s to JSON", file)
+	}
+	var req fnv1.RunFunctionRequest
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, &req); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal request from %s", file)
+	}
+	return &req, nil
+}
+
+// evalWithRequest evaluates the files using the request in the supplied options and returns diagnostics for
+// problems that only show up with actual data. Errors are returned as is, warning results of the response
+// are converted to warning diagnostics.
+func evalWithRequest(files []evaluator.File, opts AnalyzeOptions) (hcl.Diagnostics, error) {
+	req, err := loadRequest(opts.Request)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	e, err := evaluator.New(evaluator.Options{Flags: opts.Flags})
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.Eval(context.Background(), req, files...)
+	if err != nil {
+		var diags hcl.Diagnostics
+		if !errors.As(err, &diags) {
+			diags = hcl.Diagnostics{{Severity: hcl.DiagError, Summary: err.Error()}}
+		}
+		return diags, nil
+	}
+	var diags hcl.Diagnostics
+	for _, r := range res.GetResults() {
+		// diagnostic summaries are skipped since the diagnostics are already part of discard messages
+		if r.GetSeverity() != fnv1.Severity_SEVERITY_WARNING || evaluator.IsDiagnosticsResult(r) {
+			continue
+		}
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  r.GetMessage(),
+		})
+	}
+	return diags, nil
+}
+
+// mergeDiags adds diagnostics to the base list that are not already present in it.
+func mergeDiags(base hcl.Diagnostics, more hcl.Diagnostics) hcl.Diagnostics {
+	seen := map[string]bool{}
+	for _, d := range base {
+		seen[diagString(d)] = true
+	}
+	for _, d := range more {
+		if seen[diagString(d)] {
+			continue
+		}
+		seen[diagString(d)] = true
+		base = base.Append(d)
+	}
+	return base
 }
 
 type loader struct {
@@ -62,10 +162,10 @@ func (l *loader) load(dir string) (*Config, []string, error) {
 	return cfg, fsFiles, nil
 }
 
-func (l *loader) loadArchive(dir string) (*txtar.Archive, []evaluator.File, error) {
-	_, fsFiles, err := l.load(dir)
+func (l *loader) loadArchive(dir string) (*Config, *txtar.Archive, []evaluator.File, error) {
+	cfg, fsFiles, err := l.load(dir)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	var archive txtar.Archive
 	var files []evaluator.File
@@ -74,7 +174,7 @@ func (l *loader) loadArchive(dir string) (*txtar.Archive, []evaluator.File, erro
 		// we need to make it relative to the working directory instead.
 		contents, err := l.fs.ReadFile(filepath.Join(dir, file))
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		archive.Files = append(archive.Files, txtar.File{
 			Name: file,
@@ -85,7 +185,35 @@ func (l *loader) loadArchive(dir string) (*txtar.Archive, []evaluator.File, erro
 			Content: string(contents),
 		})
 	}
-	return &archive, files, nil
+	return cfg, &archive, files, nil
+}
+
+// stdin is the reader used for archives supplied on standard input.
+var stdin io.Reader = os.Stdin
+
+// loadSource returns the archive and files for the supplied directory, or for the txtar archive on
+// standard input when the directory is Stdin.
+func (l *loader) loadSource(dir string) (*txtar.Archive, []evaluator.File, error) {
+	if dir != Stdin {
+		_, archive, files, err := l.loadArchive(dir)
+		return archive, files, err
+	}
+	b, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "read stdin")
+	}
+	archive := txtar.Parse(b)
+	if len(archive.Files) == 0 {
+		return nil, nil, errors.New("no files found in txtar archive on stdin")
+	}
+	var files []evaluator.File
+	for _, file := range archive.Files {
+		files = append(files, evaluator.File{
+			Name:    file.Name,
+			Content: string(file.Data),
+		})
+	}
+	return archive, files, nil
 }
 
 func (l *loader) checkDir(dir string) (string, error) {
@@ -123,6 +251,50 @@ func (l *loader) loadConfig(dir string) (*Config, error) {
 	return &cfg, nil
 }
 
+// loadAnalyzerConfig returns the analyzer configuration in the supplied file or, when no file is supplied, the one
+// in the supplied directory if it has one.
+func (l *loader) loadAnalyzerConfig(dir, file string) (*evaluator.AnalyzerConfig, error) {
+	if file == "" {
+		if dir == Stdin {
+			return nil, nil
+		}
+		file = filepath.Join(dir, evaluator.AnalyzerConfigFile)
+		if _, err := l.fs.Stat(file); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	b, err := l.fs.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "read analyzer config")
+	}
+	// file names of a composition are relative to its directory, which is what the patterns of overrides match
+	cfg, err := evaluator.ParseAnalyzerConfig(b, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s", file)
+	}
+	return cfg, nil
+}
+
+// loadIgnore returns the matcher for the ignore file in the supplied directory, if any.
+func (l *loader) loadIgnore(dir string) (*ignore.Matcher, error) {
+	file := filepath.Join(dir, ignore.FileName)
+	st, err := l.fs.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ignore.Matcher{}, nil
+		}
+		return nil, err
+	}
+	if st.IsDir() {
+		return nil, errors.Errorf("%s is a directory", file)
+	}
+	b, err := l.fs.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ignore.Parse(b), nil
+}
+
 func (l *loader) fileList(dir string, cfg *Config) ([]string, error) {
 	var err error
 	var files []string
@@ -130,12 +302,20 @@ func (l *loader) fileList(dir string, cfg *Config) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	ignored, err := l.loadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, entry := range allFiles {
-		if filepath.Ext(entry.Name()) != ".hcl" {
+		if filepath.Ext(entry.Name()) != ".hcl" && !evaluator.IsValuesFile(entry.Name()) {
 			continue
 		}
 		file := filepath.Join(dir, entry.Name())
+		// library files are listed explicitly and are never ignored.
+		if ignored.Match(entry.Name(), entry.IsDir()) {
+			continue
+		}
 		s, err := l.fs.Stat(file)
 		if err != nil {
 			return nil, errors.Wrapf(err, "stat %s", file)
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/tools/txtar"
@@ -27,7 +29,7 @@ const validResourceHCL = `resource cmap {
 
 func TestPackage_NonExistentDirectory(t *testing.T) {
 	dir := filepath.Join(t.TempDir(), "does-not-exist")
-	_, err := Package(dir, false)
+	_, _, err := Package(dir, false)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "does-not-exist")
 }
@@ -37,14 +39,14 @@ func TestPackage_FileNotDirectory(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, f.Close())
 
-	_, err = Package(f.Name(), false)
+	_, _, err = Package(f.Name(), false)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "not a directory")
 }
 
 func TestPackage_EmptyDirectory(t *testing.T) {
 	dir := t.TempDir()
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Empty(t, archive.Files)
@@ -56,19 +58,31 @@ func TestPackage_NonHCLFilesAreExcluded(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("some text"), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("key: value"), 0o644))
 
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 1, "only .hcl files should be packaged")
 }
 
+func TestPackage_ValuesFilesAreIncluded(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(validResourceHCL), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prod.hclvars"), []byte(`region = "us-east-1"`+"\n"), 0o644))
+
+	b, _, err := Package(dir, false)
+	require.NoError(t, err)
+	archive := txtar.Parse(b)
+	require.Len(t, archive.Files, 2)
+	assert.Equal(t, "prod.hclvars", archive.Files[1].Name)
+}
+
 func TestPackage_HCLSubdirectoryIsExcluded(t *testing.T) {
 	// A subdirectory that happens to match the *.hcl glob (unusual but possible) must be skipped.
 	dir := t.TempDir()
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(validResourceHCL), 0o644))
 	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub.hcl"), 0o755))
 
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 1, "directory matching *.hcl glob must not be included")
@@ -76,7 +90,7 @@ func TestPackage_HCLSubdirectoryIsExcluded(t *testing.T) {
 
 func TestPackage_MultipleHCLFiles(t *testing.T) {
 	dir := filepath.Join("testdata", "multi-hcl")
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 2)
@@ -84,7 +98,7 @@ func TestPackage_MultipleHCLFiles(t *testing.T) {
 
 func TestPackage_ArchiveFileNamesAreRelativeToProcessedDir(t *testing.T) {
 	dir := filepath.Join("testdata", "dir-only")
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 1)
@@ -94,7 +108,7 @@ func TestPackage_ArchiveFileNamesAreRelativeToProcessedDir(t *testing.T) {
 
 func TestPackage_ArchiveFileContentsMatchDisk(t *testing.T) {
 	dir := filepath.Join("testdata", "dir-only")
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 1)
@@ -106,7 +120,7 @@ func TestPackage_ArchiveFileContentsMatchDisk(t *testing.T) {
 
 func TestPackage_WithLibs_ArchiveContainsBothHCLAndLibFiles(t *testing.T) {
 	dir := filepath.Join("testdata", "with-libs")
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 2)
@@ -124,7 +138,7 @@ func TestPackage_WithLibs_ArchiveContainsBothHCLAndLibFiles(t *testing.T) {
 func TestPackage_WithLibs_LibFilesAppendedAfterHCLFiles(t *testing.T) {
 	// Library files are appended after the glob'd HCL files.
 	dir := filepath.Join("testdata", "with-libs")
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 2)
@@ -135,20 +149,20 @@ func TestPackage_WithLibs_LibFilesAppendedAfterHCLFiles(t *testing.T) {
 
 func TestPackage_MissingLibraryFile(t *testing.T) {
 	dir := filepath.Join("testdata", "missing-lib")
-	_, err := Package(dir, false)
+	_, _, err := Package(dir, false)
 	require.Error(t, err)
 }
 
 func TestPackage_LibraryFileIsDirectory(t *testing.T) {
 	dir := filepath.Join("testdata", "dir-as-lib")
-	_, err := Package(dir, false)
+	_, _, err := Package(dir, false)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "cannot be a directory")
 }
 
 func TestPackage_InvalidCompositionYAML(t *testing.T) {
 	dir := filepath.Join("testdata", "invalid-yaml-config")
-	_, err := Package(dir, false)
+	_, _, err := Package(dir, false)
 	require.Error(t, err)
 }
 
@@ -158,7 +172,7 @@ func TestPackage_CompositionYAMLIsADirectory(t *testing.T) {
 	require.NoError(t, os.Mkdir(filepath.Join(dir, ConfigFile), 0o755))
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(validResourceHCL), 0o644))
 
-	_, err := Package(dir, false)
+	_, _, err := Package(dir, false)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "is a directory")
 }
@@ -166,7 +180,7 @@ func TestPackage_CompositionYAMLIsADirectory(t *testing.T) {
 func TestPackage_SkipAnalysis_WithInvalidHCL(t *testing.T) {
 	// With skipAnalysis=true, packaging succeeds even if HCL is invalid.
 	dir := filepath.Join("testdata", "invalid-hcl")
-	b, err := Package(dir, true)
+	b, _, err := Package(dir, true)
 	require.NoError(t, err)
 
 	archive := txtar.Parse(b)
@@ -175,7 +189,7 @@ func TestPackage_SkipAnalysis_WithInvalidHCL(t *testing.T) {
 
 func TestPackage_WithAnalysis_InvalidHCL(t *testing.T) {
 	dir := filepath.Join("testdata", "invalid-hcl")
-	_, err := Package(dir, false)
+	_, _, err := Package(dir, false)
 	require.Error(t, err)
 	require.Equal(t, "analysis failed", err.Error())
 }
@@ -198,7 +212,7 @@ func TestPackage_AbsoluteLibraryPath(t *testing.T) {
 	configContent := fmt.Sprintf("libraryFiles:\n  - %s\n", libFile)
 	require.NoError(t, os.WriteFile(filepath.Join(compDir, ConfigFile), []byte(configContent), 0o644))
 
-	_, err := Package(compDir, true) // skip analysis; lib function isn't used
+	_, _, err := Package(compDir, true) // skip analysis; lib function isn't used
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "is an absolute path, not allowed")
 }
@@ -221,18 +235,37 @@ func TestPackage_RelativeLibraryPath(t *testing.T) {
 	configContent := "version: \"1.0\"\nlibraryFiles:\n  - libs/helper.hcl\n"
 	require.NoError(t, os.WriteFile(filepath.Join(compDir, ConfigFile), []byte(configContent), 0o644))
 
-	b, err := Package(compDir, true)
+	b, _, err := Package(compDir, true)
 	require.NoError(t, err)
 
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 2)
 }
 
+func TestPackage_IgnoredFilesAreExcluded(t *testing.T) {
+	// Files matched by the ignore file are skipped but library files are always included.
+	compDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, "main.hcl"), []byte(validResourceHCL), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, "broken.gen.hcl"), []byte("resource {"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, "lib.gen.hcl"), []byte("locals {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, ".fnhclignore"), []byte("*.gen.hcl\n"), 0o644))
+
+	configContent := "version: \"1.0\"\nlibraryFiles:\n  - lib.gen.hcl\n"
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, ConfigFile), []byte(configContent), 0o644))
+
+	b, _, err := Package(compDir, false)
+	require.NoError(t, err)
+	archive := txtar.Parse(b)
+	require.Len(t, archive.Files, 2)
+	assert.Equal(t, "main.hcl", archive.Files[0].Name)
+	assert.Equal(t, "lib.gen.hcl", archive.Files[1].Name)
+}
+
 // --- Analyze tests ---
 
 func TestAnalyze_NonExistentDirectory(t *testing.T) {
 	dir := filepath.Join(t.TempDir(), "does-not-exist")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.Error(t, err)
 }
 
@@ -241,58 +274,58 @@ func TestAnalyze_FileNotDirectory(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, f.Close())
 
-	err = Analyze(f.Name())
+	_, err = Analyze(f.Name(), AnalyzeOptions{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "not a directory")
 }
 
 func TestAnalyze_EmptyDirectory(t *testing.T) {
 	dir := t.TempDir()
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.NoError(t, err)
 }
 
 func TestAnalyze_InvalidHCL(t *testing.T) {
 	dir := filepath.Join("testdata", "invalid-hcl")
-	err := Analyze(dir)
-	require.Error(t, err)
-	require.Equal(t, "analysis failed", err.Error())
+	report, err := Analyze(dir, AnalyzeOptions{})
+	require.ErrorIs(t, err, ErrAnalysisFailed)
+	assert.Positive(t, report.Errors)
 }
 
 func TestAnalyze_MissingLibraryFile(t *testing.T) {
 	dir := filepath.Join("testdata", "missing-lib")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.Error(t, err)
 }
 
 func TestAnalyze_LibraryFileIsDirectory(t *testing.T) {
 	dir := filepath.Join("testdata", "dir-as-lib")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "cannot be a directory")
 }
 
 func TestAnalyze_InvalidCompositionYAML(t *testing.T) {
 	dir := filepath.Join("testdata", "invalid-yaml-config")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.Error(t, err)
 }
 
 func TestAnalyze_ValidSingleFile(t *testing.T) {
 	dir := filepath.Join("testdata", "dir-only")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.NoError(t, err)
 }
 
 func TestAnalyze_ValidWithLibs(t *testing.T) {
 	dir := filepath.Join("testdata", "with-libs")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.NoError(t, err)
 }
 
 func TestAnalyze_ValidMultipleFiles(t *testing.T) {
 	dir := filepath.Join("testdata", "multi-hcl")
-	err := Analyze(dir)
+	_, err := Analyze(dir, AnalyzeOptions{})
 	require.NoError(t, err)
 }
 
@@ -303,7 +336,7 @@ func TestPackage_NoCompositionYAML_UsesEmptyConfig(t *testing.T) {
 	dir := t.TempDir()
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(validResourceHCL), 0o644))
 
-	b, err := Package(dir, false)
+	b, _, err := Package(dir, false)
 	require.NoError(t, err)
 
 	archive := txtar.Parse(b)
@@ -322,9 +355,141 @@ xrd:
 `
 	require.NoError(t, os.WriteFile(filepath.Join(compDir, ConfigFile), []byte(configContent), 0o644))
 
-	b, err := Package(compDir, false)
+	b, _, err := Package(compDir, false)
 	require.NoError(t, err)
 
 	archive := txtar.Parse(b)
 	require.Len(t, archive.Files, 1)
 }
+
+func TestAnalyze_SimulateConditions(t *testing.T) {
+	dir := filepath.Join("testdata", "conditional-refs")
+	report, err := Analyze(dir, AnalyzeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Warnings)
+	report, err = Analyze(dir, AnalyzeOptions{SimulateConditions: true})
+	require.NoError(t, err) // only warnings are produced
+	assert.Equal(t, 0, report.Errors)
+	assert.Equal(t, 1, report.Warnings)
+	assert.Positive(t, report.Files)
+
+	_, _, files, err := newLoader(osFs{}).loadArchive(dir)
+	require.NoError(t, err)
+	e, err := evaluator.New(evaluator.Options{SimulateConditions: true})
+	require.NoError(t, err)
+	diags := e.Analyze(files...)
+	require.Len(t, diags, 1)
+	assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+	assert.Equal(t, "resource bucket only exists when the condition at main.hcl:2,15-46 holds", diags[0].Summary)
+}
+
+func TestAnalyze_Request(t *testing.T) {
+	dir := filepath.Join("testdata", "with-request")
+	_, _, files, err := newLoader(osFs{}).loadArchive(dir)
+	require.NoError(t, err)
+	tests := []struct {
+		name    string
+		request string
+		warning string
+	}{
+		{name: "good", request: "good-request.yaml"},
+		{name: "type mismatch", request: "bad-request.yaml", warning: "Invalid template interpolation value"},
+		{name: "incomplete", request: "incomplete-request.json", warning: `This object does not have an attribute named "name"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := AnalyzeOptions{Request: filepath.Join(dir, test.request)}
+			_, err := Analyze(dir, opts)
+			require.NoError(t, err) // only warnings are produced
+			diags, err := evalWithRequest(files, opts)
+			require.NoError(t, err)
+			if test.warning == "" {
+				assert.Empty(t, diags)
+				return
+			}
+			require.Len(t, diags, 1)
+			assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+			assert.Contains(t, diagString(diags[0]), "discarded resource bucket")
+			assert.Contains(t, diagString(diags[0]), test.warning)
+		})
+	}
+}
+
+func TestAnalyze_BadRequestFile(t *testing.T) {
+	dir := filepath.Join("testdata", "with-request")
+	_, err := Analyze(dir, AnalyzeOptions{Request: filepath.Join(dir, "no-such-file.yaml")})
+	require.Error(t, err)
+	_, err = Analyze(dir, AnalyzeOptions{Request: filepath.Join(dir, "main.hcl")})
+	require.Error(t, err)
+}
+
+// --- stdin tests ---
+
+// withStdin replaces the reader for standard input with the supplied contents for the duration of the test.
+func withStdin(t *testing.T, contents string) {
+	old := stdin
+	stdin = strings.NewReader(contents)
+	t.Cleanup(func() { stdin = old })
+}
+
+func TestPackage_Stdin(t *testing.T) {
+	withStdin(t, "-- main.hcl --\n"+validResourceHCL+"-- prod.hclvars --\nregion = \"us-east-1\"\n")
+	b, report, err := Package(Stdin, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Files)
+	archive := txtar.Parse(b)
+	require.Len(t, archive.Files, 2)
+	assert.Equal(t, "main.hcl", archive.Files[0].Name)
+	assert.Equal(t, validResourceHCL, string(archive.Files[0].Data))
+	assert.Equal(t, "prod.hclvars", archive.Files[1].Name)
+}
+
+func TestAnalyze_Stdin(t *testing.T) {
+	withStdin(t, "-- main.hcl --\n"+validResourceHCL)
+	report, err := Analyze(Stdin, AnalyzeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Files)
+
+	withStdin(t, "-- main.hcl --\nresource foo {\n  body = bar.baz\n}\n")
+	_, err = Analyze(Stdin, AnalyzeOptions{})
+	require.ErrorIs(t, err, ErrAnalysisFailed)
+}
+
+func TestAnalyze_StdinWithoutFiles(t *testing.T) {
+	withStdin(t, "resource foo {}\n")
+	_, err := Analyze(Stdin, AnalyzeOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no files found")
+}
+
+func TestAnalyze_AnalyzerConfig(t *testing.T) {
+	compDir := t.TempDir()
+	hcl := `
+context {
+  key   = "hcl.fn.crossplane.io/env"
+  value = "prod"
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, "main.hcl"), []byte(hcl), 0o644))
+
+	report, err := Analyze(compDir, AnalyzeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Warnings)
+
+	// the config file in the directory is used by default
+	require.NoError(t, os.WriteFile(filepath.Join(compDir, ".fnhcl-analyze.yaml"), []byte("rules:\n  reserved: error\n"), 0o644))
+	report, err = Analyze(compDir, AnalyzeOptions{})
+	require.ErrorIs(t, err, ErrAnalysisFailed)
+	assert.Equal(t, 1, report.Errors)
+
+	// an explicit config file replaces it
+	configFile := filepath.Join(t.TempDir(), "analyze.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("rules:\n  reserved: off\n"), 0o644))
+	report, err = Analyze(compDir, AnalyzeOptions{ConfigFile: configFile})
+	require.NoError(t, err)
+	assert.Equal(t, Report{Files: 1}, report)
+
+	_, err = Analyze(compDir, AnalyzeOptions{ConfigFile: filepath.Join(compDir, "missing.yaml")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "read analyzer config")
+}
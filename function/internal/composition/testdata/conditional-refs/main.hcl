@@ -0,0 +1,19 @@
+resource bucket {
+  condition = req.composite.spec.createBucket
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+}
+
+resource policy {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "BucketPolicy"
+    spec = {
+      forProvider = {
+        bucket = req.resource.bucket.metadata.name
+      }
+    }
+  }
+}
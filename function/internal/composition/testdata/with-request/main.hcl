@@ -0,0 +1,9 @@
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    metadata = {
+      name = "${req.composite.spec.name}-bucket"
+    }
+  }
+}
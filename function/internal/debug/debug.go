@@ -7,8 +7,11 @@ import (
 	"io"
 	"log"
 	"os"
+	"path"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
@@ -16,6 +19,8 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 var (
@@ -34,8 +39,63 @@ func cleanName(filename string) string {
 	return strings.ReplaceAll(cleaned, "/", "--")
 }
 
+// redactedValue replaces values of sensitive context keys in the output.
+const redactedValue = "(sensitive value redacted)"
+
 type Options struct {
-	Raw bool
+	Raw                  bool
+	SensitiveContextKeys []string // context keys whose values are not displayed
+	Resources            []string // globs for names of resources to display, all resources if empty
+	MaxResources         int      // maximum number of resources to display, unlimited if zero
+	OnlyChanged          bool     // only display desired resources that are not reflected in their observed state
+	Proto                bool     // also display the whole request and response as protobuf JSON
+}
+
+// ParseOptions parses options from the value of a debug annotation. The value is either "true" or a comma-separated
+// list of settings, e.g. "resources=db-*,resources=cache-*,max=10,changed,proto".
+func ParseOptions(value string) (Options, error) {
+	var o Options
+	if value == "true" {
+		return o, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		key, val, hasValue := strings.Cut(item, "=")
+		switch {
+		case key == "resources" && hasValue:
+			if _, err := path.Match(val, ""); err != nil {
+				return o, fmt.Errorf("invalid resources glob %q: %v", val, err)
+			}
+			o.Resources = append(o.Resources, val)
+		case key == "max" && hasValue:
+			n, err := strconv.Atoi(val)
+			if err != nil || n <= 0 {
+				return o, fmt.Errorf("invalid max value %q, must be a positive integer", val)
+			}
+			o.MaxResources = n
+		case key == "changed":
+			b := true
+			if hasValue {
+				var err error
+				if b, err = strconv.ParseBool(val); err != nil {
+					return o, fmt.Errorf("invalid changed value %q", val)
+				}
+			}
+			o.OnlyChanged = b
+		case key == "proto":
+			b := true
+			if hasValue {
+				var err error
+				if b, err = strconv.ParseBool(val); err != nil {
+					return o, fmt.Errorf("invalid proto value %q", val)
+				}
+			}
+			o.Proto = b
+		default:
+			return o, fmt.Errorf("invalid debug setting %q", item)
+		}
+	}
+	return o, nil
 }
 
 type Printer struct {
@@ -87,6 +147,17 @@ func (w *bufWriter) jsonFile(file string, o any) {
 	w.buf.WriteString("\n")
 }
 
+// protoFile writes the supplied message as protobuf JSON to a new file.
+func (w *bufWriter) protoFile(file string, m proto.Message) error {
+	b, err := ProtoJSON(m)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s", file)
+	}
+	w.file(file)
+	w.buf.Write(b)
+	return nil
+}
+
 func (w *bufWriter) yamlDoc(o object, leadingComment string) {
 	if w.firstDoc {
 		w.firstDoc = false
@@ -116,10 +187,15 @@ func (p *Printer) Request(req *fnv1.RunFunctionRequest) error {
 
 	// write observed
 	w.file("observed.yaml")
-	for name, o := range req.GetObserved().GetResources() {
-		k := p.cleanObject(o.Resource.AsMap())
+	observed := req.GetObserved().GetResources()
+	names, omitted := p.selectResources(observed, nil)
+	for _, name := range names {
+		k := p.cleanObject(observed[name].Resource.AsMap())
 		w.yamlDoc(k, fmt.Sprintf("crossplane name: %s", name))
 	}
+	if omitted > 0 {
+		w.comment(fmt.Sprintf("%d observed resources omitted", omitted))
+	}
 
 	// write a context JSON file for each key
 	c0 := req.GetContext()
@@ -132,7 +208,7 @@ func (p *Printer) Request(req *fnv1.RunFunctionRequest) error {
 		sort.Strings(keys)
 		for _, k := range keys {
 			fileName := fmt.Sprintf("context-%s.json", cleanName(k))
-			w.jsonFile(fileName, c[k])
+			w.jsonFile(fileName, p.contextValue(k, c[k]))
 		}
 	}
 
@@ -147,6 +223,15 @@ func (p *Printer) Request(req *fnv1.RunFunctionRequest) error {
 			}
 		}
 	}
+
+	// write the exact request when requested
+	if p.opts.Proto {
+		r := proto.Clone(req).(*fnv1.RunFunctionRequest)
+		r.Context = p.redactContext(r.GetContext())
+		if err := w.protoFile("request.json", r); err != nil {
+			return err
+		}
+	}
 	return w.done()
 }
 
@@ -281,8 +366,17 @@ func (p *Printer) Response(req *fnv1.RunFunctionRequest, res *fnv1.RunFunctionRe
 		return errors.Wrap(err, "get claimRef")
 	}
 
-	for name, o := range res.GetDesired().GetResources() {
-		r := o.Resource.AsMap()
+	desired := res.GetDesired().GetResources()
+	var unchanged func(name string) bool
+	if p.opts.OnlyChanged {
+		unchanged = func(name string) bool {
+			o, ok := req.GetObserved().GetResources()[name]
+			return ok && isSubset(desired[name].GetResource().AsMap(), o.GetResource().AsMap())
+		}
+	}
+	names, omitted := p.selectResources(desired, unchanged)
+	for _, name := range names {
+		r := desired[name].Resource.AsMap()
 		// mimic what crossplane does after calling the function successfully
 		paved := fieldpath.Pave(r)
 		if err = paved.SetValue("metadata.generateName", compName+"-"); err != nil {
@@ -307,10 +401,16 @@ func (p *Printer) Response(req *fnv1.RunFunctionRequest, res *fnv1.RunFunctionRe
 		}
 		w.yamlDoc(r, "desired object: "+name)
 	}
+	if omitted > 0 {
+		w.comment(fmt.Sprintf("%d desired resources omitted", omitted))
+	}
 	{
 		var ctx object
 		if res.GetContext() != nil {
 			ctx = res.GetContext().AsMap()
+			for k, v := range ctx {
+				ctx[k] = p.contextValue(k, v)
+			}
 		}
 		obj := object{
 			"apiVersion": "render.crossplane.io/v1beta1",
@@ -350,9 +450,112 @@ func (p *Printer) Response(req *fnv1.RunFunctionRequest, res *fnv1.RunFunctionRe
 		}
 		w.yamlDoc(er, "")
 	}
+
+	// write the exact response when requested
+	if p.opts.Proto {
+		r := proto.Clone(res).(*fnv1.RunFunctionResponse)
+		r.Context = p.redactContext(r.GetContext())
+		if err := w.protoFile("response.json", r); err != nil {
+			return err
+		}
+	}
 	return w.done()
 }
 
+// selectResources returns the sorted names of the supplied resources that should be displayed, along with the
+// number of resources that were omitted. Resources for which the skip function returns true are omitted.
+func (p *Printer) selectResources(resources map[string]*fnv1.Resource, skip func(name string) bool) ([]string, int) {
+	var names []string
+	for name := range resources {
+		if p.matchesResource(name) && (skip == nil || !skip(name)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if p.opts.MaxResources > 0 && len(names) > p.opts.MaxResources {
+		names = names[:p.opts.MaxResources]
+	}
+	return names, len(resources) - len(names)
+}
+
+// matchesResource returns true if the supplied resource name matches the configured globs.
+func (p *Printer) matchesResource(name string) bool {
+	if len(p.opts.Resources) == 0 {
+		return true
+	}
+	for _, glob := range p.opts.Resources {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubset returns true if all values in the desired object are present with the same values in the observed one.
+func isSubset(desired, observed any) bool {
+	switch d := desired.(type) {
+	case object:
+		o, ok := observed.(object)
+		if !ok {
+			return false
+		}
+		for k, v := range d {
+			if !isSubset(v, o[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		o, ok := observed.([]any)
+		if !ok || len(o) != len(d) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], o[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(desired, observed)
+	}
+}
+
+// contextValue returns the value to display for the supplied context key.
+func (p *Printer) contextValue(key string, value any) any {
+	for _, k := range p.opts.SensitiveContextKeys {
+		if k == key {
+			return redactedValue
+		}
+	}
+	return value
+}
+
+// redactContext replaces the values of sensitive keys in the supplied context, which is changed in place.
+func (p *Printer) redactContext(ctx *structpb.Struct) *structpb.Struct {
+	for _, k := range p.opts.SensitiveContextKeys {
+		if _, ok := ctx.GetFields()[k]; ok {
+			ctx.Fields[k] = structpb.NewStringValue(redactedValue)
+		}
+	}
+	return ctx
+}
+
+// ProtoJSON returns the protobuf JSON representation of the supplied message, indented with two spaces. Unlike the
+// output of protojson, the whitespace of the output is stable, such that outputs can be compared across versions.
+func ProtoJSON(m proto.Message) ([]byte, error) {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
 func (p *Printer) cleanObject(k8sObject object) object {
 	if p.opts.Raw {
 		return k8sObject
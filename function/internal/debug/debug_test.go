@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 //go:embed testdata/request.yaml
@@ -80,3 +81,131 @@ func TestResponseExample(t *testing.T) {
 	// log.Println(buf.String())
 	assert.Equal(t, strings.TrimSpace(buf.String()), strings.TrimSpace(runFunctionResponseExpectedOutput))
 }
+
+func TestResponseSensitiveContext(t *testing.T) {
+	req := loadRequest(t)
+	res := loadResponse(t)
+	buf := bytes.NewBuffer(nil)
+	outputWriter = buf
+	defer func() {
+		outputWriter = os.Stderr
+	}()
+
+	p := New(Options{SensitiveContextKeys: []string{"my-key"}})
+	err := p.Response(req, res)
+	require.NoError(t, err)
+	expected := strings.Replace(runFunctionResponseExpectedOutput, "my-key:\n    foo:\n      bar: 10", "my-key: "+redactedValue, 1)
+	require.NotEqual(t, expected, runFunctionResponseExpectedOutput)
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buf.String()))
+}
+
+func TestResponseProto(t *testing.T) {
+	req := loadRequest(t)
+	res := loadResponse(t)
+	buf := bytes.NewBuffer(nil)
+	outputWriter = buf
+	defer func() {
+		outputWriter = os.Stderr
+	}()
+
+	p := New(Options{Proto: true, SensitiveContextKeys: []string{"my-key"}})
+	require.NoError(t, p.Response(req, res))
+	out := buf.String()
+	_, after, ok := strings.Cut(out, "-- response.json --\n")
+	require.True(t, ok)
+	b, _, ok := strings.Cut(after, "\n## end response ##")
+	require.True(t, ok)
+
+	var got fnv1.RunFunctionResponse
+	require.NoError(t, protojson.Unmarshal([]byte(b), &got))
+	assert.Equal(t, redactedValue, got.GetContext().GetFields()["my-key"].GetStringValue())
+	got.Context = nil
+	expected := proto.Clone(res).(*fnv1.RunFunctionResponse)
+	expected.Context = nil
+	assert.True(t, proto.Equal(expected, &got))
+	// the supplied response is not changed
+	assert.NotNil(t, res.GetContext().GetFields()["my-key"].GetStructValue())
+
+	buf.Reset()
+	require.NoError(t, p.Request(req))
+	assert.Contains(t, buf.String(), "-- request.json --\n{\n  \"observed\": {")
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected Options
+		errMsg   string
+	}{
+		{name: "true", value: "true", expected: Options{}},
+		{
+			name:     "all settings",
+			value:    "resources=db-*, resources=cache-*,max=10,changed",
+			expected: Options{Resources: []string{"db-*", "cache-*"}, MaxResources: 10, OnlyChanged: true},
+		},
+		{name: "changed with value", value: "changed=false", expected: Options{}},
+		{name: "proto", value: "proto", expected: Options{Proto: true}},
+		{name: "bad proto", value: "proto=maybe", errMsg: `invalid proto value "maybe"`},
+		{name: "bad max", value: "max=-1", errMsg: `invalid max value "-1", must be a positive integer`},
+		{name: "bad glob", value: "resources=[", errMsg: `invalid resources glob "["`},
+		{name: "unknown setting", value: "yes", errMsg: `invalid debug setting "yes"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o, err := ParseOptions(test.value)
+			if test.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, o)
+		})
+	}
+}
+
+func makeResource(t *testing.T, o map[string]any) *fnv1.Resource {
+	s, err := structpb.NewStruct(o)
+	require.NoError(t, err)
+	return &fnv1.Resource{Resource: s}
+}
+
+func TestResponseResourceSelection(t *testing.T) {
+	req := loadRequest(t)
+	res := loadResponse(t)
+	spec := map[string]any{"forProvider": map[string]any{"region": "us-east-1"}}
+	for _, name := range []string{"db-1", "db-2", "db-3", "cache-1"} {
+		res.Desired.Resources[name] = makeResource(t, map[string]any{"kind": "Bucket", "spec": spec})
+	}
+	// db-2 is already reflected in its observed state
+	req.Observed.Resources["db-2"] = makeResource(t, map[string]any{
+		"kind":   "Bucket",
+		"spec":   spec,
+		"status": map[string]any{"ready": true},
+	})
+
+	buf := bytes.NewBuffer(nil)
+	outputWriter = buf
+	defer func() {
+		outputWriter = os.Stderr
+	}()
+
+	p := New(Options{Resources: []string{"db-*"}, MaxResources: 1, OnlyChanged: true})
+	err := p.Response(req, res)
+	require.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "# desired object: db-1\n")
+	assert.NotContains(t, out, "# desired object: db-2\n")
+	assert.NotContains(t, out, "# desired object: db-3\n")
+	assert.NotContains(t, out, "# desired object: cache-1\n")
+	assert.NotContains(t, out, "# desired object: my-bucket\n")
+	assert.Contains(t, out, "# 4 desired resources omitted\n")
+
+	buf.Reset()
+	err = p.Request(req)
+	require.NoError(t, err)
+	out = buf.String()
+	assert.Contains(t, out, "# crossplane name: db-2\n")
+	assert.Contains(t, out, "# 1 observed resources omitted\n")
+}
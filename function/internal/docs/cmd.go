@@ -0,0 +1,71 @@
+package docs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+)
+
+var outWriter io.Writer = os.Stdout
+
+// DocsCmd generates Markdown documentation for the composition implemented by a set of HCL files.
+type DocsCmd struct {
+	Flags []string // feature flags that are set, blocks that are not enabled are not documented
+}
+
+// Execute documents the supplied files. Directories are expanded to the HCL files directly under them.
+func (d *DocsCmd) Execute(args []string) error {
+	files, err := collectFiles(args)
+	if err != nil {
+		return err
+	}
+	var evalFiles []evaluator.File
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		evalFiles = append(evalFiles, evaluator.File{Name: file, Content: string(b)})
+	}
+	e, err := evaluator.New(evaluator.Options{Flags: d.Flags})
+	if err != nil {
+		return err
+	}
+	doc, diags := e.Document(evalFiles...)
+	if diags.HasErrors() {
+		return diags
+	}
+	_, _ = fmt.Fprint(outWriter, Markdown(doc))
+	return nil
+}
+
+func collectFiles(args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var ret []string
+	for _, arg := range args {
+		s, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !s.IsDir() {
+			ret = append(ret, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.hcl"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		ret = append(ret, matches...)
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no HCL files found")
+	}
+	return ret, nil
+}
@@ -0,0 +1,93 @@
+package docs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Markdown renders the supplied documentation as Markdown. Sections without content are omitted.
+func Markdown(doc *evaluator.Documentation) string {
+	var b strings.Builder
+	title := doc.Title
+	if title == "" {
+		title = "Composition"
+	}
+	fmt.Fprintf(&b, "# %s\n", title)
+	if doc.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(doc.Description))
+	}
+
+	if len(doc.Parameters) > 0 {
+		b.WriteString("\n## Parameters\n\nThe composition uses the following fields of the composite resource.\n\n")
+		for _, p := range doc.Parameters {
+			fmt.Fprintf(&b, "- `%s`\n", p)
+		}
+	}
+	writeObjects(&b, "Resources", doc.Resources)
+	writeObjects(&b, "Resource Collections", doc.Collections)
+	writeObjects(&b, "Requirements", doc.Requirements)
+
+	if len(doc.Functions) > 0 {
+		b.WriteString("\n## Functions\n")
+		for _, fn := range doc.Functions {
+			fmt.Fprintf(&b, "\n### `%s`\n\n", fn.Name)
+			if fn.Description != "" {
+				fmt.Fprintf(&b, "%s\n\n", fn.Description)
+			}
+			fmt.Fprintf(&b, "Declared at %s.\n", source(fn.Range))
+			if len(fn.Args) == 0 {
+				continue
+			}
+			b.WriteString("\n| Argument | Description | Default |\n|----------|-------------|---------|\n")
+			for _, arg := range fn.Args {
+				def := "*required*"
+				if arg.Default != "" {
+					def = code(arg.Default)
+				}
+				fmt.Fprintf(&b, "| `%s` | %s | %s |\n", arg.Name, cell(arg.Description), def)
+			}
+		}
+	}
+	return b.String()
+}
+
+func writeObjects(b *strings.Builder, title string, objects []evaluator.ObjectDoc) {
+	if len(objects) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	b.WriteString("| Name | API Version | Kind | Conditional | Source |\n|------|-------------|------|-------------|--------|\n")
+	for _, o := range objects {
+		conditional := "no"
+		if o.Conditional {
+			conditional = "yes"
+		}
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n", o.Name, orUnknown(o.APIVersion), orUnknown(o.Kind), conditional, source(o.Range))
+	}
+}
+
+// source returns the file and line of the supplied range.
+func source(r hcl.Range) string {
+	return fmt.Sprintf("`%s:%d`", r.Filename, r.Start.Line)
+}
+
+// orUnknown returns the value in code format, or a placeholder for values that are not constant.
+func orUnknown(s string) string {
+	if s == "" {
+		return "*dynamic*"
+	}
+	return code(s)
+}
+
+func code(s string) string {
+	return "`" + cell(s) + "`"
+}
+
+// cell escapes the supplied text for use in a table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,54 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	r := func(line int) hcl.Range {
+		return hcl.Range{Filename: "main.hcl", Start: hcl.Pos{Line: line}}
+	}
+	doc := &evaluator.Documentation{
+		Functions: []evaluator.FunctionDoc{
+			{
+				Name:        "truncate",
+				Description: "truncates a string",
+				Args: []evaluator.ArgDoc{
+					{Name: "length", Default: "8"},
+					{Name: "s", Description: "the string | text"},
+				},
+				Range: r(3),
+			},
+			{Name: "now", Range: r(10)},
+		},
+		Parameters: []string{"spec.parameters.region"},
+		Resources: []evaluator.ObjectDoc{
+			{Name: "bucket", APIVersion: "aws.com/v1", Kind: "Bucket", Range: r(20)},
+			{Name: "db-instance", APIVersion: "db.aws.com/v1", Conditional: true, Range: r(30)},
+		},
+	}
+	expected := "# Composition\n" +
+		"\n## Parameters\n\nThe composition uses the following fields of the composite resource.\n\n" +
+		"- `spec.parameters.region`\n" +
+		"\n## Resources\n\n" +
+		"| Name | API Version | Kind | Conditional | Source |\n" +
+		"|------|-------------|------|-------------|--------|\n" +
+		"| `bucket` | `aws.com/v1` | `Bucket` | no | `main.hcl:20` |\n" +
+		"| `db-instance` | `db.aws.com/v1` | *dynamic* | yes | `main.hcl:30` |\n" +
+		"\n## Functions\n" +
+		"\n### `truncate`\n\ntruncates a string\n\nDeclared at `main.hcl:3`.\n" +
+		"\n| Argument | Description | Default |\n|----------|-------------|---------|\n" +
+		"| `length` |  | `8` |\n" +
+		"| `s` | the string \\| text | *required* |\n" +
+		"\n### `now`\n\nDeclared at `main.hcl:10`.\n"
+	assert.Equal(t, expected, Markdown(doc))
+}
+
+func TestMarkdownTitle(t *testing.T) {
+	doc := &evaluator.Documentation{Title: "S3 Buckets", Description: "Creates buckets.\n"}
+	assert.Equal(t, "# S3 Buckets\n\nCreates buckets.\n", Markdown(doc))
+}
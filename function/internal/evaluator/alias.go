@@ -0,0 +1,101 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// reservedNames cannot be used as alias names.
+var reservedNames = map[string]bool{
+	reservedReq:  true,
+	reservedSelf: true,
+	reservedArg:  true,
+	iteratorName: true,
+}
+
+// collectAliases returns the targets of aliases declared in top-level alias blocks keyed by alias name.
+// Alias targets must be static references to values under req.
+func collectAliases(content *hcl.BodyContent) (map[string]hcl.Traversal, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	ret := map[string]hcl.Traversal{}
+	seen := map[string]hcl.Range{}
+	for _, block := range content.Blocks {
+		if block.Type != blockAlias {
+			continue
+		}
+		attrs, ds := block.Body.JustAttributes()
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			continue
+		}
+		for _, attr := range sortedAttributes(attrs) {
+			if reservedNames[attr.Name] {
+				diags = diags.Extend(hclutils.ToErrorDiag("alias name is reserved", attr.Name, attr.NameRange))
+				continue
+			}
+			if _, ok := seen[attr.Name]; ok {
+				diags = diags.Extend(hclutils.ToErrorDiag("alias defined more than once", attr.Name, attr.NameRange))
+				continue
+			}
+			seen[attr.Name] = attr.NameRange
+			t, ds := hcl.AbsTraversalForExpr(attr.Expr)
+			if ds.HasErrors() || t.RootName() != reservedReq {
+				diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("alias %s must be a static reference to a value under %s", attr.Name, reservedReq),
+					"", attr.Expr.Range()))
+				continue
+			}
+			ret[attr.Name] = t
+		}
+	}
+	return ret, diags
+}
+
+// sortedAttributes returns the supplied attributes in source order.
+func sortedAttributes(attrs hcl.Attributes) []*hcl.Attribute {
+	var ret []*hcl.Attribute
+	for _, attr := range attrs {
+		ret = append(ret, attr)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Range.Filename != ret[j].Range.Filename {
+			return ret[i].Range.Filename < ret[j].Range.Filename
+		}
+		return ret[i].Range.Start.Byte < ret[j].Range.Start.Byte
+	})
+	return ret
+}
+
+// aliasContext returns a child context that has the values of the supplied aliases. An alias whose target
+// does not exist has a null value, such that references through it fail in the same way as direct references.
+func aliasContext(ctx *hcl.EvalContext, aliases map[string]hcl.Traversal) *hcl.EvalContext {
+	if len(aliases) == 0 {
+		return ctx
+	}
+	vars := DynamicObject{}
+	for name, t := range aliases {
+		val, diags := t.TraverseAbs(ctx)
+		if diags.HasErrors() {
+			val = cty.NullVal(cty.DynamicPseudoType)
+		}
+		vars[name] = val
+	}
+	child := ctx.NewChild()
+	child.Variables = vars
+	return child
+}
+
+// expandAlias returns the traversal that the supplied traversal refers to when its root is an alias, or
+// the traversal unchanged otherwise.
+func expandAlias(t hcl.Traversal, aliases map[string]hcl.Traversal) hcl.Traversal {
+	target, ok := aliases[t.RootName()]
+	if !ok {
+		return t
+	}
+	ret := make(hcl.Traversal, 0, len(target)+len(t)-1)
+	ret = append(ret, target...)
+	return append(ret, t[1:]...)
+}
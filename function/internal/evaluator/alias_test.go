@@ -0,0 +1,163 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasEval(t *testing.T) {
+	hcl := `
+alias {
+  params  = req.composite.spec.parameters
+  bucket  = req.resource.primary-bucket
+  missing = req.resource.no-such-bucket
+}
+
+resource replica {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "S3Bucket"
+    spec = {
+      forProvider = {
+        region  = params.region
+        zone    = params.azs[0]
+        source  = bucket.metadata.name
+        missing = try(missing.metadata.name, "none")
+      }
+    }
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	replica := res.Desired.Resources["replica"].Resource.AsMap()
+	fp := replica["spec"].(map[string]any)["forProvider"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"region":  "us-east-1",
+		"zone":    "us-east-1a",
+		"source":  "comp-a7df3-primary-bucket",
+		"missing": "none",
+	}, fp)
+}
+
+func TestAliasAnalyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name: "good",
+			hcl: `
+alias {
+  params = req.composite.spec.parameters
+  vpc    = req.resource.vpc
+}
+resource vpc {
+  body = {
+    region = params.region
+  }
+}
+resource subnet {
+  body = {
+    vpcId = vpc.status.atProvider.id
+  }
+}
+`,
+		},
+		{
+			name: "bad target",
+			hcl: `
+alias {
+  params = req.composit.spec.parameters
+}
+`,
+			errMsg: `test.hcl:3,12-40: no such attribute "composit"; req.composit.spec.parameters, did you mean "composite"?`,
+		},
+		{
+			name: "bad reference through alias",
+			hcl: `
+alias {
+  res = req.resource
+}
+locals {
+  foo = res.vpc
+}
+`,
+			errMsg: `test.hcl:6,9-16: invalid resource name reference; vpc`,
+		},
+		{
+			name: "not a reference",
+			hcl: `
+alias {
+  region = "us-east-1"
+}
+`,
+			errMsg: `test.hcl:3,12-23: alias region must be a static reference to a value under req`,
+		},
+		{
+			name: "not under req",
+			hcl: `
+locals {
+  foo = {}
+}
+alias {
+  bar = foo.bar
+}
+`,
+			errMsg: `test.hcl:6,9-16: alias bar must be a static reference to a value under req`,
+		},
+		{
+			name: "reserved name",
+			hcl: `
+alias {
+  self = req.composite
+}
+`,
+			errMsg: `test.hcl:3,3-7: alias name is reserved; self`,
+		},
+		{
+			name: "duplicate",
+			hcl: `
+alias {
+  xr = req.composite
+}
+alias {
+  xr = req.composite
+}
+`,
+			errMsg: `test.hcl:6,3-5: alias defined more than once; xr`,
+		},
+		{
+			name: "read-only",
+			hcl: `
+alias {
+  xr = req.composite
+}
+locals {
+  xr = {}
+}
+`,
+			errMsg: `attempt to shadow variable; xr`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(evaluator.File{Name: "test.hcl", Content: test.hcl})
+			if test.errMsg == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// guard is a condition under which a block is processed.
+type guard struct {
+	text string    // normalized source text of the condition
+	r    hcl.Range // range of the condition expression
+}
+
+// guardedRef is a reference to a named object made from a block processed under a set of guards.
+type guardedRef struct {
+	kind   string    // the kind of object referenced, one of resource, resource collection or requirement
+	name   string    // the name of the object referenced
+	r      hcl.Range // source range of the reference
+	guards []guard   // conditions in effect where the reference is made
+}
+
+// conditionSimulator simulates each condition evaluating to both true and false and finds references
+// that are only valid when a condition holds. When a condition is false, the resources, collections and
+// requirements guarded by it are never created, so references to them from blocks that are not guarded by
+// the same condition will never be satisfied.
+type conditionSimulator struct {
+	a      *analyzer
+	defs   map[string]map[string][]guard // guards for definitions keyed by kind and name
+	refs   []guardedRef
+	kinds  map[string]string // kind of object referenced keyed by the second step under req
+	prefix string            // name prefix of the groups being walked
+}
+
+func newConditionSimulator(a *analyzer) *conditionSimulator {
+	return &conditionSimulator{
+		a: a,
+		defs: map[string]map[string][]guard{
+			blockResource:    {},
+			blockResources:   {},
+			blockRequirement: {},
+		},
+		kinds: map[string]string{
+			reqObservedResource:    blockResource,
+			reqObservedConnection:  blockResource,
+			reqObservedResources:   blockResources,
+			reqObservedConnections: blockResources,
+			reqExtraResources:      blockRequirement,
+		},
+	}
+}
+
+// displayKind returns the kind of object in a form suitable for messages.
+func displayKind(kind string) string {
+	switch kind {
+	case blockResources:
+		return "resource collection"
+	default:
+		return kind
+	}
+}
+
+// addRefs records references to resources, collections and requirements made by the supplied expression.
+func (c *conditionSimulator) addRefs(expr hcl.Expression, guards []guard) {
+	for _, t := range hclexpr.UnguardedTraversals(expr) {
+		r := t.SourceRange()
+		t = expandAlias(hclexpr.NormalizeTraversal(t), c.a.aliases)
+		if len(t) < 3 {
+			continue
+		}
+		second, ok := t[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		var kind string
+		switch t.RootName() {
+		case reservedReq:
+			kind, ok = c.kinds[second.Name]
+		case reservedSelf:
+			kind, ok = blockRequirement, second.Name == selfExtra
+		default:
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+		third, ok := t[2].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		c.refs = append(c.refs, guardedRef{
+			kind:   kind,
+			name:   third.Name,
+			r:      r,
+			guards: guards,
+		})
+	}
+}
+
+// walk records definitions and references in the supplied content which is processed under the supplied guards.
+func (c *conditionSimulator) walk(parent *hcl.Block, content *hcl.BodyContent, guards []guard) hcl.Diagnostics {
+	// the condition itself is evaluated under the guards of the parent, everything else under the condition.
+	if attr, ok := content.Attributes[attrCondition]; ok {
+		c.addRefs(attr.Expr, guards)
+		text := strings.Join(strings.Fields(c.a.e.sourceCode(attr.Expr.Range())), " ")
+		guards = append(guards[:len(guards):len(guards)], guard{text: text, r: attr.Expr.Range()})
+	}
+	defType := parent.Type
+	if defType == blockObserve {
+		defType = blockRequirement // observe blocks define requirements
+	}
+	if defs, ok := c.defs[defType]; ok && len(parent.Labels) > 0 {
+		name, known := parent.Labels[0], true
+		switch defType {
+		case blockResource:
+			name, known = staticResourceName(c.prefix, parent, content)
+		case blockResources:
+			name = c.prefix + name
+		}
+		if known {
+			defs[name] = guards
+		}
+	}
+	if parent.Type == blockGroup {
+		p, _ := groupNamePrefix(content)
+		parentPrefix := c.prefix
+		c.prefix += p
+		defer func() { c.prefix = parentPrefix }()
+	}
+	for name, attr := range content.Attributes {
+		if name == attrCondition {
+			continue
+		}
+		c.addRefs(attr.Expr, guards)
+	}
+	var diags hcl.Diagnostics
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case blockFunction, blockAlias:
+			continue
+		case blockLocals, blockFileLocals:
+			attrs, ds := block.Body.JustAttributes()
+			diags = diags.Extend(ds)
+			for _, attr := range attrs {
+				c.addRefs(attr.Expr, guards)
+			}
+			continue
+		}
+		childContent, ds := block.Body.Content(schemasByBlockType[block.Type])
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			continue
+		}
+		diags = diags.Extend(c.walk(block, childContent, guards))
+	}
+	return diags
+}
+
+// missingGuard returns the first guard in the definition guards that is not present in the reference guards.
+func missingGuard(def, ref []guard) (guard, bool) {
+	present := map[string]bool{}
+	for _, g := range ref {
+		present[g.text] = true
+	}
+	for _, g := range def {
+		if !present[g.text] {
+			return g, true
+		}
+	}
+	return guard{}, false
+}
+
+// check returns warnings for every reference to an object that does not exist when a condition that guards the
+// object evaluates to false, while the block making the reference is still processed.
+func (c *conditionSimulator) check() hcl.Diagnostics {
+	// attributes are processed in map order, sort references to produce stable output.
+	sort.SliceStable(c.refs, func(i, j int) bool {
+		ri, rj := c.refs[i].r, c.refs[j].r
+		if ri.Filename != rj.Filename {
+			return ri.Filename < rj.Filename
+		}
+		return ri.Start.Byte < rj.Start.Byte
+	})
+	var ret hcl.Diagnostics
+	for _, ref := range c.refs {
+		defGuards, ok := c.defs[ref.kind][ref.name]
+		if !ok { // bad references are reported by the reference checks
+			continue
+		}
+		g, ok := missingGuard(defGuards, ref.guards)
+		if !ok {
+			continue
+		}
+		r := ref.r
+		ret = ret.Extend(hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary: fmt.Sprintf("%s %s only exists when the condition at %s holds",
+				displayKind(ref.kind), ref.name, g.r.String()),
+			Detail: fmt.Sprintf("%s is used outside that condition and will never be available when %s is false; "+
+				"guard the reference with the same condition or use try/can",
+				c.a.e.sourceCode(r), g.text),
+			Subject: &r,
+		}})
+	}
+	return ret
+}
+
+// simulateConditions checks that the supplied content is valid for both outcomes of every condition in it.
+func (a *analyzer) simulateConditions(content *hcl.BodyContent) hcl.Diagnostics {
+	c := newConditionSimulator(a)
+	diags := c.walk(&hcl.Block{}, content, nil)
+	if diags.HasErrors() {
+		return diags
+	}
+	return diags.Extend(c.check())
+}
@@ -0,0 +1,144 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeSimulateConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		warnings []string
+	}{
+		{
+			name: "unguarded reference",
+			hcl: `
+resource bucket {
+  condition = req.composite.spec.createBucket
+  body = { apiVersion = "v1", kind = "ConfigMap" }
+}
+resource policy {
+  body = {
+    apiVersion = "iam.aws.upbound.io/v1beta1"
+    kind       = "Policy"
+    arn        = req.resource.bucket.status.atProvider.arn
+  }
+}
+`,
+			warnings: []string{
+				"test.hcl:10,18-59: resource bucket only exists when the condition at test.hcl:3,15-46 holds",
+			},
+		},
+		{
+			name: "same condition",
+			hcl: `
+resource bucket {
+  condition = req.composite.spec.createBucket
+  body = { apiVersion = "v1", kind = "ConfigMap" }
+}
+resource policy {
+  condition = req.composite.spec.createBucket
+  body = {
+    apiVersion = "iam.aws.upbound.io/v1beta1"
+    kind       = "Policy"
+    arn        = req.resource.bucket.status.atProvider.arn
+  }
+}
+`,
+		},
+		{
+			name: "guarded by group",
+			hcl: `
+group {
+  condition = req.composite.spec.createBucket
+  resource bucket {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+  resource policy {
+    body = {
+      apiVersion = "iam.aws.upbound.io/v1beta1"
+      kind       = "Policy"
+      arn        = req.resource.bucket.status.atProvider.arn
+    }
+  }
+}
+`,
+		},
+		{
+			name: "try and can",
+			hcl: `
+resource bucket {
+  condition = req.composite.spec.createBucket
+  body = { apiVersion = "v1", kind = "ConfigMap" }
+}
+resource policy {
+  condition = can(req.resource.bucket)
+  body = {
+    apiVersion = "iam.aws.upbound.io/v1beta1"
+    kind       = "Policy"
+    arn        = try(req.resource.bucket.status.atProvider.arn, "")
+  }
+}
+`,
+		},
+		{
+			name: "condition references",
+			hcl: `
+group {
+  condition = req.composite.spec.createBucket
+  resources buckets {
+    for_each = range(2)
+    template {
+      body = { apiVersion = "v1", kind = "ConfigMap" }
+    }
+  }
+}
+requirement cm {
+  condition = req.composite.spec.useConfig
+  select {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    matchName  = "foo"
+  }
+}
+locals {
+  first  = req.resources.buckets[0]
+  config = req.extra_resources.cm[0]
+}
+resource policy {
+  condition = length(req.connections.buckets) > 0
+  body = { apiVersion = "v1", kind = "ConfigMap" }
+}
+`,
+			warnings: []string{
+				"test.hcl:20,12-36: resource collection buckets only exists when the condition at test.hcl:3,15-46 holds",
+				"test.hcl:21,12-37: requirement cm only exists when the condition at test.hcl:12,15-43 holds",
+				"test.hcl:24,22-45: resource collection buckets only exists when the condition at test.hcl:3,15-46 holds",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{SimulateConditions: true})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				require.Equal(t, hcl.DiagWarning, d.Severity)
+				warnings = append(warnings, d.Subject.String()+": "+d.Summary)
+			}
+			assert.Equal(t, test.warnings, warnings)
+
+			// no warnings without the option
+			e, err = New(Options{})
+			require.NoError(t, err)
+			diags = e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			assert.Empty(t, diags)
+		})
+	}
+}
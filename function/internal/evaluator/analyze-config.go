@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/crossplane-contrib/function-hcl/function/internal/ignore"
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+)
+
+// AnalyzerConfigFile is the name of the file in a composition directory that configures the severities of the
+// analyzer rules.
+const AnalyzerConfigFile = ".fnhcl-analyze.yaml"
+
+// RuleSeverity is the severity of the diagnostics reported by an analyzer rule.
+type RuleSeverity string
+
+const (
+	SeverityError RuleSeverity = "error" // diagnostics of the rule are errors
+	SeverityWarn  RuleSeverity = "warn"  // diagnostics of the rule are warnings
+	SeverityOff   RuleSeverity = "off"   // the diagnostics of the rule are dropped
+)
+
+// UnmarshalJSON reads a severity, accepting false for off since YAML parses an unquoted off as a boolean, which
+// is converted to a string when it is read into a string.
+func (s *RuleSeverity) UnmarshalJSON(b []byte) error {
+	if string(b) == "false" || string(b) == `"false"` {
+		*s = SeverityOff
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return fmt.Errorf("invalid severity %s, must be one of %q, %q or %q", b, SeverityError, SeverityWarn, SeverityOff)
+	}
+	*s = RuleSeverity(str)
+	return nil
+}
+
+// secretsRuleName is the name of the check for credentials in source files, which is not a rule walker.
+const secretsRuleName = "secrets"
+
+// builtinRuleNames returns the names of the built-in analyzer rules whose severities can be configured. Checks of
+// the structure and references of the source are not rules and always report errors.
+func builtinRuleNames() []string {
+	return []string{
+		constantsRule{}.Name(),
+		(&contextsRule{}).Name(),
+		reservedRule{}.Name(),
+		connectionRule{}.Name(),
+		resourceTypeRule{}.Name(),
+		selfReferenceRule{}.Name(),
+		deterministicRule{}.Name(),
+		credentialsRule{}.Name(),
+		secretsRuleName,
+	}
+}
+
+// AnalyzerOverride sets the severities of rules for the files that match some patterns.
+type AnalyzerOverride struct {
+	// Files are patterns in the syntax of ignore files that are matched against paths relative to the directory
+	// of the configuration file, such as legacy/** or *.gen.hcl.
+	Files []string `json:"files"`
+	// Rules map rule names to their severities for the matching files.
+	Rules map[string]RuleSeverity `json:"rules"`
+}
+
+// AnalyzerConfig configures the severities of analyzer rules, such that new rules can be adopted gradually.
+type AnalyzerConfig struct {
+	// Rules map rule names to their severities. Rules that are not listed report diagnostics with the severity
+	// they choose.
+	Rules map[string]RuleSeverity `json:"rules,omitempty"`
+	// Overrides set the severities of rules for some files. When several overrides match a file, the last one
+	// that sets a rule wins.
+	Overrides []AnalyzerOverride `json:"overrides,omitempty"`
+
+	dir      string // directory that file patterns are relative to
+	matchers []*ignore.Matcher
+}
+
+// ParseAnalyzerConfig parses an analyzer configuration in YAML or JSON format. File patterns of overrides are
+// matched against file names relative to the supplied directory, or against file names as is when it is empty.
+func ParseAnalyzerConfig(data []byte, dir string) (*AnalyzerConfig, error) {
+	var c AnalyzerConfig
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	c.dir = dir
+	for i, o := range c.Overrides {
+		if len(o.Files) == 0 {
+			return nil, fmt.Errorf("override %d does not have any file patterns", i+1)
+		}
+		c.matchers = append(c.matchers, ignore.Parse([]byte(strings.Join(o.Files, "\n"))))
+	}
+	return &c, nil
+}
+
+// validate returns an error if the configuration refers to rules that do not exist or has invalid severities.
+func (c *AnalyzerConfig) validate(rules []AnalyzerRule) error {
+	known := map[string]bool{}
+	for _, name := range builtinRuleNames() {
+		known[name] = true
+	}
+	for _, r := range rules {
+		known[r.Name()] = true
+	}
+	check := func(where string, m map[string]RuleSeverity) error {
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !known[name] {
+				return errors.New(hclutils.DidYouMean(fmt.Sprintf("%s: unknown analyzer rule %q", where, name), name, setKeys(known)))
+			}
+			switch m[name] {
+			case SeverityError, SeverityWarn, SeverityOff:
+			default:
+				return fmt.Errorf("%s: invalid severity %q for rule %q, must be one of %q, %q or %q",
+					where, m[name], name, SeverityError, SeverityWarn, SeverityOff)
+			}
+		}
+		return nil
+	}
+	if err := check("rules", c.Rules); err != nil {
+		return err
+	}
+	for i, o := range c.Overrides {
+		if err := check(fmt.Sprintf("override %d", i+1), o.Rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// severity returns the configured severity of the supplied rule for a file.
+func (c *AnalyzerConfig) severity(rule, file string) (RuleSeverity, bool) {
+	if file != "" {
+		rel := file
+		if c.dir != "" {
+			if r, err := filepath.Rel(c.dir, file); err == nil && !strings.HasPrefix(r, "..") {
+				rel = r
+			}
+		}
+		rel = filepath.ToSlash(rel)
+		for i := len(c.Overrides) - 1; i >= 0; i-- {
+			if sev, ok := c.Overrides[i].Rules[rule]; ok && c.matchers[i].Match(rel, false) {
+				return sev, true
+			}
+		}
+	}
+	sev, ok := c.Rules[rule]
+	return sev, ok
+}
+
+// apply sets the severities of the diagnostics reported by the supplied rule, dropping the ones of rules that are
+// turned off. Diagnostics without a source range are matched against the top-level rules only.
+func (c *AnalyzerConfig) apply(rule string, diags hcl.Diagnostics) hcl.Diagnostics {
+	if c == nil {
+		return diags
+	}
+	var ret hcl.Diagnostics
+	for _, d := range diags {
+		file := ""
+		if d.Subject != nil {
+			file = d.Subject.Filename
+		}
+		sev, ok := c.severity(rule, file)
+		switch {
+		case !ok:
+		case sev == SeverityOff:
+			continue
+		case sev == SeverityError:
+			d.Severity = hcl.DiagError
+		case sev == SeverityWarn:
+			d.Severity = hcl.DiagWarning
+		}
+		ret = append(ret, d)
+	}
+	return ret
+}
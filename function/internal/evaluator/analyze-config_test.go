@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzerConfig(t *testing.T) {
+	reserved := `
+context {
+  key   = "hcl.fn.crossplane.io/env"
+  value = "prod"
+}
+`
+	conflicts := `
+context {
+  key   = "tier"
+  value = "gold"
+}
+context {
+  key   = "tier"
+  value = "silver"
+}
+`
+	tests := []struct {
+		name     string
+		config   string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name:     "no config",
+			errors:   []string{"conflicts.hcl"},
+			warnings: []string{"legacy/reserved.hcl", "reserved.hcl"},
+		},
+		{
+			name: "rule severities",
+			config: `
+rules:
+  reserved: error
+  contexts: warn
+`,
+			errors:   []string{"legacy/reserved.hcl", "reserved.hcl"},
+			warnings: []string{"conflicts.hcl"},
+		},
+		{
+			name: "overrides",
+			config: `
+rules:
+  reserved: error
+overrides:
+  - files: ["legacy/**"]
+    rules:
+      reserved: off
+  - files: ["*.hcl"]
+    rules:
+      contexts: off
+`,
+			errors: []string{"reserved.hcl"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg *AnalyzerConfig
+			if test.config != "" {
+				var err error
+				cfg, err = ParseAnalyzerConfig([]byte(test.config), "")
+				require.NoError(t, err)
+			}
+			e, err := New(Options{AnalyzerConfig: cfg})
+			require.NoError(t, err)
+			diags := e.Analyze(
+				File{Name: "reserved.hcl", Content: reserved},
+				File{Name: "legacy/reserved.hcl", Content: reserved},
+				File{Name: "conflicts.hcl", Content: conflicts},
+			)
+			var errors, warnings []string
+			for _, d := range diags {
+				if d.Severity == hcl.DiagError {
+					errors = append(errors, d.Subject.Filename)
+				} else {
+					warnings = append(warnings, d.Subject.Filename)
+				}
+			}
+			assert.ElementsMatch(t, test.errors, errors)
+			assert.ElementsMatch(t, test.warnings, warnings)
+		})
+	}
+}
+
+func TestAnalyzerConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "unknown rule",
+			config: "rules:\n  reserve: off\n",
+			err:    `analyzer config: rules: unknown analyzer rule "reserve", did you mean "reserved"?`,
+		},
+		{
+			name:   "invalid severity",
+			config: "overrides:\n  - files: [a.hcl]\n    rules:\n      secrets: fatal\n",
+			err:    `analyzer config: override 1: invalid severity "fatal" for rule "secrets", must be one of "error", "warn" or "off"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := ParseAnalyzerConfig([]byte(test.config), "")
+			require.NoError(t, err)
+			_, err = New(Options{AnalyzerConfig: cfg})
+			require.Error(t, err)
+			assert.Equal(t, test.err, err.Error())
+		})
+	}
+
+	_, err := ParseAnalyzerConfig([]byte("overrides:\n  - rules:\n      secrets: off\n"), "")
+	require.Error(t, err)
+	assert.Equal(t, "override 1 does not have any file patterns", err.Error())
+}
@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// builtinGroups are the API groups of built-in Kubernetes kinds, which never publish connection details.
+var builtinGroups = map[string]bool{
+	"":                             true,
+	"apps":                         true,
+	"batch":                        true,
+	"autoscaling":                  true,
+	"policy":                       true,
+	"networking.k8s.io":            true,
+	"rbac.authorization.k8s.io":    true,
+	"storage.k8s.io":               true,
+	"scheduling.k8s.io":            true,
+	"coordination.k8s.io":          true,
+	"discovery.k8s.io":             true,
+	"node.k8s.io":                  true,
+	"admissionregistration.k8s.io": true,
+	"apiextensions.k8s.io":         true,
+	"certificates.k8s.io":          true,
+}
+
+// connectionRule warns about reads of the observed connection details of resources whose kind never publishes
+// them. Such expressions stay unknown forever and silently discard everything that depends on them.
+type connectionRule struct {
+	AnalyzerRuleBase
+	kinds map[string]bool // kinds that publish connection details as kind or kind.group, nil when not configured
+}
+
+func (connectionRule) Name() string {
+	return "connections"
+}
+
+// toKindSet returns the set of the supplied kinds, or nil when no kinds are supplied.
+func toKindSet(kinds []string) map[string]bool {
+	if kinds == nil {
+		return nil
+	}
+	ret := map[string]bool{}
+	for _, k := range kinds {
+		ret[k] = true
+	}
+	return ret
+}
+
+// publishesConnections returns false if resources of the supplied API version and kind are known to never
+// publish connection details.
+func (r connectionRule) publishesConnections(apiVersion, kind string) bool {
+	group, _, ok := strings.Cut(apiVersion, "/")
+	if !ok {
+		group = ""
+	}
+	if builtinGroups[group] {
+		return false
+	}
+	if r.kinds == nil {
+		return true
+	}
+	return r.kinds[kind] || r.kinds[kind+"."+group]
+}
+
+// connectionSource returns the expression of the body of the resource whose connection details are read by
+// an expression in the supplied innermost blocks, along with the name of the self variable that has them.
+func connectionSource(blocks []*hcl.Block) (hcl.Expression, string) {
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		switch block.Type {
+		case blockResource, blockTemplate:
+			content, _ := block.Body.Content(schemasByBlockType[block.Type])
+			if body, ok := content.Attributes[attrBody]; ok {
+				return body.Expr, selfObservedConnection
+			}
+			return nil, ""
+		case blockResources:
+			content, _ := block.Body.Content(resourcesSchema())
+			for _, b := range content.Blocks {
+				if b.Type != blockTemplate {
+					continue
+				}
+				templateContent, _ := b.Body.Content(templateSchema())
+				if body, ok := templateContent.Attributes[attrBody]; ok {
+					return body.Expr, selfObservedConnections
+				}
+			}
+			return nil, ""
+		}
+	}
+	return nil, ""
+}
+
+func (r connectionRule) VisitExpression(ctx RuleContext, expr hclsyntax.Expression) hcl.Diagnostics {
+	st, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil
+	}
+	t := hclexpr.NormalizeTraversal(st.Traversal)
+	if len(t) < 2 || t.RootName() != reservedSelf {
+		return nil
+	}
+	attr, ok := t[1].(hcl.TraverseAttr)
+	if !ok || (attr.Name != selfObservedConnection && attr.Name != selfObservedConnections) {
+		return nil
+	}
+	body, name := connectionSource(ctx.Blocks)
+	if body == nil || name != attr.Name {
+		return nil
+	}
+	apiVersion, kind := staticObjectString(body, attrAPIVersion), staticObjectString(body, attrKind)
+	if apiVersion == "" || kind == "" || r.publishesConnections(apiVersion, kind) {
+		return nil
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagWarning,
+		Summary:  fmt.Sprintf("%s %s never publishes connection details", apiVersion, kind),
+		Detail:   fmt.Sprintf("self.%s is never known for this resource and everything that depends on it is discarded", attr.Name),
+		Subject:  st.SrcRange.Ptr(),
+	}}
+}
+
+// checkConnections returns warnings for reads of connection details of resources that never publish them.
+func (a *analyzer) checkConnections(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(connectionRule{kinds: a.e.connectionKinds}).walkContent(nil, content)
+}
@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeConnections(t *testing.T) {
+	tests := []struct {
+		name     string
+		kinds    []string
+		hcl      string
+		warnings []string
+	}{
+		{
+			name: "managed resources without kinds",
+			hcl: `
+resource db {
+  body = {
+    apiVersion = "rds.aws.upbound.io/v1beta1"
+    kind       = "Instance"
+  }
+  composite connection {
+    body = { password = self.connection.password }
+  }
+}
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = req.composite.spec.kind
+  }
+  composite connection {
+    body = { password = self.connection.password }
+  }
+}
+`,
+		},
+		{
+			name: "built-in kinds",
+			hcl: `
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+  composite connection {
+    body = { password = self.connection.password }
+  }
+}
+resources deployments {
+  for_each = ["a", "b"]
+  template {
+    body = {
+      apiVersion = "apps/v1"
+      kind       = "Deployment"
+    }
+  }
+  composite status {
+    body = { count = length(self.connections) }
+  }
+}
+`,
+			warnings: []string{
+				`test.hcl:8,25-49: v1 ConfigMap never publishes connection details; self.connection is never known for this resource and everything that depends on it is discarded`,
+				`test.hcl:20,29-45: apps/v1 Deployment never publishes connection details; self.connections is never known for this resource and everything that depends on it is discarded`,
+			},
+		},
+		{
+			name:  "configured kinds",
+			kinds: []string{"Instance.rds.aws.upbound.io", "Cluster"},
+			hcl: `
+resource db {
+  body = {
+    apiVersion = "rds.aws.upbound.io/v1beta1"
+    kind       = "Instance"
+  }
+  composite connection {
+    body = { password = self.connection.password }
+  }
+}
+resource cluster {
+  body = {
+    apiVersion = "eks.aws.upbound.io/v1beta1"
+    kind       = "Cluster"
+  }
+  composite connection {
+    body = { kubeconfig = self.connection.kubeconfig }
+  }
+}
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+  composite connection {
+    body = { name = self.connection["name"] }
+  }
+}
+`,
+			warnings: []string{
+				`test.hcl:26,21-44: s3.aws.upbound.io/v1beta1 Bucket never publishes connection details; self.connection is never known for this resource and everything that depends on it is discarded`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{ConnectionKinds: test.kinds})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				if d.Severity == hcl.DiagWarning {
+					warnings = append(warnings, d.Error())
+				}
+			}
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+}
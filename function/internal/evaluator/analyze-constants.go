@@ -0,0 +1,212 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// constantBool returns the value of the supplied expression if it is a boolean constant. Expressions are
+// folded when they do not refer to any variables or call any functions, such as comparisons of literals.
+func constantBool(expr hcl.Expression) (value bool, ok bool) {
+	if len(expr.Variables()) > 0 {
+		return false, false
+	}
+	v, diags := expr.Value(nil) // function calls fail without a context
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || !v.Type().Equals(cty.Bool) {
+		return false, false
+	}
+	return v.True(), true
+}
+
+// constantsRule warns about conditions that are statically always true or false, and conditional expressions
+// whose branches are identical. These are usually leftovers from refactoring. It also checks the types of
+// conditions and for_each expressions whose values are known statically.
+type constantsRule struct {
+	AnalyzerRuleBase
+	e     *Evaluator
+	funcs *hcl.EvalContext // context with the built-in and user functions for folding for_each expressions
+}
+
+func (constantsRule) Name() string {
+	return "constants"
+}
+
+// staticNonBoolType returns the name of the type of the supplied expression if it can be determined without
+// evaluating it and is not a bool.
+func staticNonBoolType(expr hcl.Expression) (string, bool) {
+	switch expr.(type) {
+	case *hclsyntax.TemplateExpr:
+		return cty.String.FriendlyName(), true
+	case *hclsyntax.ObjectConsExpr:
+		return "object", true
+	case *hclsyntax.TupleConsExpr:
+		return "tuple", true
+	}
+	if len(expr.Variables()) > 0 {
+		return "", false
+	}
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.Type().Equals(cty.Bool) || v.Type() == cty.DynamicPseudoType {
+		return "", false
+	}
+	return v.Type().FriendlyName(), true
+}
+
+// checkForEach reports for_each expressions that statically evaluate to values that cannot be iterated, and
+// warns about sets of values that are not strings, since set elements are used as keys in resource names.
+func (r constantsRule) checkForEach(attr *hcl.Attribute) hcl.Diagnostics {
+	src := r.normalizedSource(attr.Expr.Range())
+	notIterable := func(typeName string) hcl.Diagnostics {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("for_each must be a list, map, set or object, got %s", typeName),
+			Detail:   fmt.Sprintf("%s cannot be iterated", src),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	if _, ok := attr.Expr.(*hclsyntax.TemplateExpr); ok {
+		return notIterable(cty.String.FriendlyName())
+	}
+	if len(attr.Expr.Variables()) > 0 {
+		return nil
+	}
+	v, diags := attr.Expr.Value(r.funcs)
+	if diags.HasErrors() || !v.IsWhollyKnown() {
+		return nil
+	}
+	ty := v.Type()
+	switch {
+	case v.IsNull():
+		return notIterable("null")
+	case ty.IsListType() || ty.IsTupleType() || ty.IsMapType() || ty.IsObjectType():
+		return nil
+	case ty.IsSetType():
+		elem := ty.ElementType()
+		if elem.Equals(cty.String) || elem == cty.DynamicPseudoType {
+			return nil
+		}
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("for_each is a set of %s values", elem.FriendlyName()),
+			Detail: fmt.Sprintf("for sets, each.key is the element itself, so resource names are built from the %s "+
+				"values of %s; convert them to strings or use a list or map instead", elem.FriendlyName(), src),
+			Subject: attr.Expr.Range().Ptr(),
+		}}
+	default:
+		return notIterable(ty.FriendlyName())
+	}
+}
+
+// checkReadyValue reports constant ready values that are not valid, suggesting the closest valid value. Both
+// branches of conditional expressions are checked so that typos in values that are rarely used are also found.
+func (r constantsRule) checkReadyValue(expr hcl.Expression) hcl.Diagnostics {
+	if cond, ok := expr.(*hclsyntax.ConditionalExpr); ok {
+		return append(r.checkReadyValue(cond.TrueResult), r.checkReadyValue(cond.FalseResult)...)
+	}
+	if len(expr.Variables()) > 0 {
+		return nil
+	}
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() {
+		return nil
+	}
+	if !v.Type().Equals(cty.String) {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("ready value must be a string, got %s", v.Type().FriendlyName()),
+			Detail:   fmt.Sprintf("must be one of %s", validReadyValues),
+			Subject:  expr.Range().Ptr(),
+		}}
+	}
+	s := v.AsString()
+	if _, ok := fnv1.Ready_value[s]; ok {
+		return nil
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("invalid ready value %q", s),
+		Detail:   hclutils.DidYouMean(fmt.Sprintf("must be one of %s", validReadyValues), s, readyValueNames),
+		Subject:  expr.Range().Ptr(),
+	}}
+}
+
+func (r constantsRule) VisitAttribute(ctx RuleContext, attr *hcl.Attribute) hcl.Diagnostics {
+	parent := ctx.Parent()
+	if attr.Name == attrForEach && parent != nil && parent.Type == blockResources {
+		return r.checkForEach(attr)
+	}
+	if attr.Name == attrValue && parent != nil && (parent.Type == blockReady || parent.Type == blockReadyDefault) {
+		return r.checkReadyValue(attr.Expr)
+	}
+	if attr.Name != attrCondition {
+		return nil
+	}
+	if typeName, ok := staticNonBoolType(attr.Expr); ok {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("condition must be a bool, got %s", typeName),
+			Detail:   fmt.Sprintf("%s can never be true or false", r.normalizedSource(attr.Expr.Range())),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	v, ok := constantBool(attr.Expr)
+	if !ok {
+		return nil
+	}
+	detail := "the blocks it guards are always processed; remove the condition"
+	if !v {
+		detail = "the blocks it guards are never processed; remove them along with the condition"
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagWarning,
+		Summary:  fmt.Sprintf("condition is always %t", v),
+		Detail:   detail,
+		Subject:  attr.Expr.Range().Ptr(),
+	}}
+}
+
+func (r constantsRule) VisitExpression(_ RuleContext, expr hclsyntax.Expression) hcl.Diagnostics {
+	cond, ok := expr.(*hclsyntax.ConditionalExpr)
+	if !ok {
+		return nil
+	}
+	if v, ok := constantBool(cond.Condition); ok {
+		unused := cond.FalseResult
+		if !v {
+			unused = cond.TrueResult
+		}
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("conditional expression is always %t", v),
+			Detail:   fmt.Sprintf("%s is never used", r.normalizedSource(unused.Range())),
+			Subject:  cond.Condition.Range().Ptr(),
+		}}
+	}
+	trueText := r.normalizedSource(cond.TrueResult.Range())
+	if trueText == r.normalizedSource(cond.FalseResult.Range()) {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  "conditional expression has identical branches",
+			Detail:   fmt.Sprintf("both branches are %s; use the value directly", trueText),
+			Subject:  cond.Range().Ptr(),
+		}}
+	}
+	return nil
+}
+
+// normalizedSource returns the source code for the supplied range with whitespace collapsed.
+func (r constantsRule) normalizedSource(rng hcl.Range) string {
+	return strings.Join(strings.Fields(r.e.sourceCode(rng)), " ")
+}
+
+// checkConstants returns warnings for constant conditions and redundant conditional expressions, along with
+// errors for conditions and for_each expressions of the wrong type.
+func (a *analyzer) checkConstants(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(constantsRule{e: a.e, funcs: a.p.RootContext(nil)}).walkContent(nil, content)
+}
@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeConstants(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		warnings []string
+	}{
+		{
+			name: "clean",
+			hcl: `
+locals {
+  region = req.composite.spec.region
+  zone   = region == "us-east-1" ? "a" : "b"
+}
+group {
+  condition = region != ""
+  resource foo {
+    body = { apiVersion = "v1", kind = "ConfigMap", data = { zone = zone } }
+  }
+}
+`,
+		},
+		{
+			name: "constant condition",
+			hcl: `
+group {
+  condition = "a" == "b"
+  resource foo {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+}
+resource bar {
+  condition = 1 < 2 && true
+  body      = { apiVersion = "v1", kind = "ConfigMap" }
+}
+`,
+			warnings: []string{
+				"test.hcl:3,15-25: condition is always false; the blocks it guards are never processed; remove them along with the condition",
+				"test.hcl:9,15-28: condition is always true; the blocks it guards are always processed; remove the condition",
+			},
+		},
+		{
+			name: "function calls are not folded",
+			hcl: `
+resource foo {
+  condition = length([]) == 0
+  body      = { apiVersion = "v1", kind = "ConfigMap" }
+}
+`,
+		},
+		{
+			name: "constant conditional expression",
+			hcl: `
+locals {
+  zone = 3 > 2 ? "a" : req.composite.spec.zone
+}
+`,
+			warnings: []string{
+				"test.hcl:3,10-15: conditional expression is always true; req.composite.spec.zone is never used",
+			},
+		},
+		{
+			name: "identical branches",
+			hcl: `
+function zone {
+  arg region {}
+  body = region == "us-east-1" ? "${region}a" : "${region}a"
+}
+locals {
+  zone = req.composite.spec.ha ? {
+    name = "a"
+  } : { name = "a" }
+}
+`,
+			warnings: []string{
+				`test.hcl:4,10-61: conditional expression has identical branches; both branches are "${region}a"; use the value directly`,
+				`test.hcl:7,10-9,21: conditional expression has identical branches; both branches are { name = "a" }; use the value directly`,
+			},
+		},
+		{
+			name: "for_each set of numbers",
+			hcl: `
+resources numbered {
+  for_each = toset([1, 2, 3])
+  template {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+}
+resources named {
+  for_each = toset(["a", "b"])
+  template {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+}
+resources listed {
+  for_each = range(3)
+  template {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+}
+`,
+			warnings: []string{
+				"test.hcl:3,14-30: for_each is a set of number values; for sets, each.key is the element itself, so resource names are built from the number values of toset([1, 2, 3]); convert them to strings or use a list or map instead",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				warnings = append(warnings, d.Error())
+			}
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package evaluator
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// staticWrite is a value written to a context key or a composite status field, described by its shape: objects
+// with constant keys are Objects of shapes, constants are their values, and other values are a staticType when
+// their type can be seen without evaluating them, or an unknownShape.
+type staticWrite struct {
+	shape any
+	r     hcl.Range
+}
+
+// staticType is the shape of a value that is not constant but whose type is known.
+type staticType string
+
+// unknownShape is the shape of a value about which nothing is known statically.
+type unknownShape struct{}
+
+// contextsRule reports context, contexts and composite status blocks that are always processed and write values to the
+// same context key or status field that cannot be unified, because they are different constants or have
+// different types. Such blocks are guaranteed to fail at runtime, with an error that does not say where the
+// values come from.
+type contextsRule struct {
+	AnalyzerRuleBase
+	contexts map[string][]staticWrite
+	statuses map[string][]staticWrite
+}
+
+func (*contextsRule) Name() string {
+	return "contexts"
+}
+
+// unconditional returns true if the supplied enclosing blocks are always processed, i.e. they are all groups
+// without conditions or with conditions that are always true.
+func unconditional(blocks []*hcl.Block) bool {
+	for _, b := range blocks {
+		if b.Type != blockGroup {
+			return false
+		}
+		attrs, _ := b.Body.JustAttributes()
+		if attr, ok := attrs[attrCondition]; ok {
+			if v, ok := constantBool(attr.Expr); !ok || !v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// constantValue returns the value of the supplied expression if it does not depend on any variables or functions.
+func constantValue(expr hcl.Expression) (cty.Value, bool) {
+	if len(expr.Variables()) > 0 {
+		return cty.NilVal, false
+	}
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() {
+		return cty.NilVal, false
+	}
+	return v, true
+}
+
+// shapeOf returns the shape of the value of the supplied expression.
+func shapeOf(expr hcl.Expression) any {
+	if v, ok := constantValue(expr); ok {
+		goVal, err := valueToInterface(v)
+		if err != nil || goVal == nil {
+			return unknownShape{}
+		}
+		return goVal
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		ret := Object{}
+		for _, item := range e.Items {
+			k, ok := constantValue(item.KeyExpr)
+			if !ok || k.IsNull() || k.Type() != cty.String {
+				return staticType("object")
+			}
+			ret[k.AsString()] = shapeOf(item.ValueExpr)
+		}
+		return ret
+	case *hclsyntax.TupleConsExpr:
+		return staticType("tuple")
+	case *hclsyntax.TemplateExpr:
+		return staticType("string")
+	}
+	return unknownShape{}
+}
+
+// shapeType returns the name of the type of a shape, or an empty string if it is not known.
+func shapeType(shape any) string {
+	switch s := shape.(type) {
+	case staticType:
+		return string(s)
+	case Object:
+		return "object"
+	case []any:
+		return "tuple"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int64, float64:
+		return "number"
+	}
+	return ""
+}
+
+// unifyShapes returns an error like the one of unify when values of the supplied shapes can never be unified.
+// The returned flag is true when the types of the values differ.
+func unifyShapes(path string, a, b any) (typeMismatch bool, err error) {
+	at, bt := shapeType(a), shapeType(b)
+	switch {
+	case at == "" || bt == "":
+		return false, nil
+	case at != bt:
+		return true, fmt.Errorf("type mismatch for key %s: %s v/s %s", path, bt, at)
+	}
+	if ao, ok := a.(Object); ok {
+		bo, ok := b.(Object)
+		if !ok {
+			return false, nil
+		}
+		for _, k := range sortedKeys(ao) {
+			if bv, ok := bo[k]; ok {
+				if typeMismatch, err := unifyShapes(path+"."+k, ao[k], bv); err != nil {
+					return typeMismatch, err
+				}
+			}
+		}
+		return false, nil
+	}
+	_, aStatic := a.(staticType)
+	_, bStatic := b.(staticType)
+	if !aStatic && !bStatic && !reflect.DeepEqual(a, b) {
+		return false, fmt.Errorf("values for key %s not equal", path)
+	}
+	return false, nil
+}
+
+// addWrite records a write of the supplied shape to a key and returns errors for the writes of the key recorded
+// before it that it conflicts with. The kind describes the key in messages.
+func addWrite(seen map[string][]staticWrite, kind, key string, current staticWrite) hcl.Diagnostics {
+	var ret hcl.Diagnostics
+	for _, prev := range seen[key] {
+		typeMismatch, err := unifyShapes(key, prev.shape, current.shape)
+		if err == nil {
+			continue
+		}
+		what := "values"
+		if typeMismatch {
+			what = "types"
+		}
+		ret = ret.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("conflicting %s for %s %q", what, kind, key),
+			Detail:   fmt.Sprintf("the value conflicts with the one at %s: %s", prev.r, err.Error()),
+			Subject:  current.r.Ptr(),
+		})
+	}
+	seen[key] = append(seen[key], current)
+	return ret
+}
+
+// constantKeyItems returns the items of the supplied object constructor whose keys are constant strings, by key.
+// Items whose keys are set more than once are returned in the order in which they appear.
+func constantKeyItems(obj *hclsyntax.ObjectConsExpr) ([]string, map[string][]hclsyntax.ObjectConsItem) {
+	var keys []string
+	items := map[string][]hclsyntax.ObjectConsItem{}
+	for _, item := range obj.Items {
+		k, ok := constantValue(item.KeyExpr)
+		if !ok || k.IsNull() || k.Type() != cty.String {
+			continue
+		}
+		if _, seen := items[k.AsString()]; !seen {
+			keys = append(keys, k.AsString())
+		}
+		items[k.AsString()] = append(items[k.AsString()], item)
+	}
+	return keys, items
+}
+
+// contextsBody returns the body of the supplied contexts block if it is an object constructor.
+func contextsBody(block *hcl.Block) (*hclsyntax.ObjectConsExpr, bool) {
+	content, diags := block.Body.Content(contextsSchema())
+	if diags.HasErrors() {
+		return nil, false
+	}
+	obj, ok := content.Attributes[attrBody].Expr.(*hclsyntax.ObjectConsExpr)
+	return obj, ok
+}
+
+// duplicateContextKeys returns errors for keys that are set more than once in the body of a contexts block, where
+// the last value would silently win.
+func duplicateContextKeys(block *hcl.Block) hcl.Diagnostics {
+	obj, ok := contextsBody(block)
+	if !ok {
+		return nil
+	}
+	var ret hcl.Diagnostics
+	keys, items := constantKeyItems(obj)
+	for _, k := range keys {
+		for _, item := range items[k][1:] {
+			ret = ret.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("duplicate context key %q in contexts block", k),
+				Detail:   fmt.Sprintf("the key is also set at %s", items[k][0].KeyExpr.Range()),
+				Subject:  item.KeyExpr.Range().Ptr(),
+			})
+		}
+	}
+	return ret
+}
+
+func (r *contextsRule) VisitBlock(ctx RuleContext, block *hcl.Block) hcl.Diagnostics {
+	if block.Type == blockContexts {
+		if ds := duplicateContextKeys(block); ds.HasErrors() {
+			return ds
+		}
+	}
+	if !unconditional(ctx.Blocks) {
+		return nil
+	}
+	switch {
+	case block.Type == blockContext:
+		content, diags := block.Body.Content(contextSchema())
+		if diags.HasErrors() {
+			return nil
+		}
+		key, ok := constantValue(content.Attributes[attrKey].Expr)
+		if !ok || key.IsNull() || key.Type() != cty.String {
+			return nil
+		}
+		valueExpr := content.Attributes[attrValue].Expr
+		return addWrite(r.contexts, "context key", key.AsString(), staticWrite{shape: shapeOf(valueExpr), r: valueExpr.Range()})
+	case block.Type == blockContexts:
+		obj, ok := contextsBody(block)
+		if !ok {
+			return nil
+		}
+		var ret hcl.Diagnostics
+		keys, items := constantKeyItems(obj)
+		for _, k := range keys {
+			item := items[k][0]
+			ret = ret.Extend(addWrite(r.contexts, "context key", k, staticWrite{shape: shapeOf(item.ValueExpr), r: item.ValueExpr.Range()}))
+		}
+		return ret
+	case block.Type == blockComposite && len(block.Labels) == 1 && block.Labels[0] == blockLabelStatus:
+		content, diags := block.Body.Content(compositeSchema())
+		if diags.HasErrors() {
+			return nil
+		}
+		obj, ok := content.Attributes[attrBody].Expr.(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			return nil
+		}
+		var ret hcl.Diagnostics
+		for _, item := range obj.Items {
+			k, ok := constantValue(item.KeyExpr)
+			if !ok || k.IsNull() || k.Type() != cty.String {
+				continue
+			}
+			ret = ret.Extend(addWrite(r.statuses, "composite status field", k.AsString(),
+				staticWrite{shape: shapeOf(item.ValueExpr), r: item.ValueExpr.Range()}))
+		}
+		return ret
+	}
+	return nil
+}
+
+// checkContexts returns errors for context, contexts and composite status blocks that are guaranteed to write
+// conflicting values, and for keys set more than once in a contexts block.
+func (a *analyzer) checkContexts(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(&contextsRule{
+		contexts: map[string][]staticWrite{},
+		statuses: map[string][]staticWrite{},
+	}).walkContent(nil, content)
+}
@@ -0,0 +1,184 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeContexts(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errors []string
+	}{
+		{
+			name: "compatible values",
+			hcl: `
+context {
+  key   = "env"
+  value = { region = "us-east-1" }
+}
+group {
+  context {
+    key   = "env"
+    value = { zone = "a", region = "us-east-1" }
+  }
+}
+`,
+		},
+		{
+			name: "conditional or non-constant values",
+			hcl: `
+context {
+  key   = "env"
+  value = "prod"
+}
+group {
+  condition = req.composite.spec.dev
+  context {
+    key   = "env"
+    value = "dev"
+  }
+}
+resource foo {
+  body = {}
+  context {
+    key   = "env"
+    value = "test"
+  }
+}
+context {
+  key   = "env"
+  value = req.composite.spec.env
+}
+`,
+		},
+		{
+			name: "conflicting values",
+			hcl: `
+context {
+  key   = "env"
+  value = { region = "us-east-1" }
+}
+group {
+  condition = true
+  context {
+    key   = "env"
+    value = { region = "us-west-2" }
+  }
+}
+`,
+			errors: []string{
+				`test.hcl:10,13-37: conflicting values for context key "env"; the value conflicts with the one at test.hcl:4,11-35: values for key env.region not equal`,
+			},
+		},
+		{
+			name: "conflicting types",
+			hcl: `
+context {
+  key   = "env"
+  value = { region = "${req.composite.spec.region}-a" }
+}
+context {
+  key   = "env"
+  value = { region = { name = req.composite.spec.region } }
+}
+context {
+  key   = "tags"
+  value = ["${req.composite.metadata.name}"]
+}
+context {
+  key   = "tags"
+  value = "${req.composite.metadata.name}-tag"
+}
+`,
+			errors: []string{
+				`test.hcl:8,11-60: conflicting types for context key "env"; the value conflicts with the one at test.hcl:4,11-56: type mismatch for key env.region: object v/s string`,
+				`test.hcl:16,11-47: conflicting types for context key "tags"; the value conflicts with the one at test.hcl:12,11-45: type mismatch for key tags: string v/s tuple`,
+			},
+		},
+		{
+			name: "contexts blocks",
+			hcl: `
+contexts {
+  body = {
+    env  = { region = "us-east-1" }
+    tier = "gold"
+  }
+}
+context {
+  key   = "tier"
+  value = "silver"
+}
+contexts {
+  body = {
+    env = { zone = "a" }
+    env = { zone = "b" }
+  }
+}
+`,
+			errors: []string{
+				`test.hcl:10,11-19: conflicting values for context key "tier"; the value conflicts with the one at test.hcl:5,12-18: values for key tier not equal`,
+				`test.hcl:15,5-8: duplicate context key "env" in contexts block; the key is also set at test.hcl:14,5-8`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			var errors []string
+			for _, d := range diags {
+				if d.Severity == hcl.DiagError {
+					errors = append(errors, d.Error())
+				}
+			}
+			assert.Equal(t, test.errors, errors)
+		})
+	}
+}
+
+func TestAnalyzeCompositeStatusConflicts(t *testing.T) {
+	e, err := New(Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(
+		File{Name: "a.hcl", Content: `
+composite status {
+  body = {
+    ready    = true
+    endpoint = { host = req.composite.spec.host }
+    name     = req.composite.metadata.name
+  }
+}
+`},
+		File{Name: "b.hcl", Content: `
+composite status {
+  body = {
+    ready    = false
+    endpoint = "${req.composite.spec.host}:443"
+    name     = "other"
+  }
+}
+resource foo {
+  body = { apiVersion = "v1", kind = "ConfigMap" }
+  composite status {
+    body = { ready = "yes" }
+  }
+}
+`},
+	)
+	var errors []string
+	for _, d := range diags {
+		if d.Severity == hcl.DiagError {
+			errors = append(errors, d.Error())
+		}
+	}
+	assert.Equal(t, []string{
+		`b.hcl:4,16-21: conflicting values for composite status field "ready"; the value conflicts with the one at a.hcl:4,16-20: values for key ready not equal`,
+		`b.hcl:5,16-48: conflicting types for composite status field "endpoint"; the value conflicts with the one at a.hcl:5,16-50: type mismatch for key endpoint: string v/s object`,
+	}, errors)
+}
@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// reservedPrefix is the prefix of annotations, labels and context keys that are managed by the function.
+const reservedPrefix = "hcl.fn.crossplane.io/"
+
+// futureReservedWords are names that are not used yet but are reserved for future namespaces and
+// functions. Compositions that use them for locals or user functions may break when they are introduced.
+var futureReservedWords = map[string]bool{
+	"var":    true,
+	"env":    true,
+	"data":   true,
+	"input":  true,
+	"module": true,
+	"output": true,
+}
+
+// reservedRule warns about locals and user functions that use reserved words as names, and about context keys,
+// annotations and labels with the prefix reserved for keys that are managed by the function.
+type reservedRule struct {
+	AnalyzerRuleBase
+}
+
+func (reservedRule) Name() string {
+	return "reserved"
+}
+
+func reservedWarning(summary, detail string, r hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  r.Ptr(),
+	}
+}
+
+func (reservedRule) VisitBlock(_ RuleContext, block *hcl.Block) hcl.Diagnostics {
+	switch block.Type {
+	case blockFunction:
+		name := block.Labels[0]
+		if first, _, _ := strings.Cut(name, "."); futureReservedWords[first] {
+			return hcl.Diagnostics{reservedWarning(fmt.Sprintf("function %s uses the reserved name %q", name, first),
+				"this name is reserved for future use, rename the function", block.LabelRanges[0])}
+		}
+	case blockContext:
+		content, diags := block.Body.Content(contextSchema())
+		if diags.HasErrors() {
+			return nil
+		}
+		attr := content.Attributes[attrKey]
+		key, ok := constantValue(attr.Expr)
+		if ok && !key.IsNull() && key.Type() == cty.String && strings.HasPrefix(key.AsString(), reservedPrefix) {
+			return hcl.Diagnostics{reservedWarning(fmt.Sprintf("context key %q uses the reserved prefix %q", key.AsString(), reservedPrefix),
+				"keys with this prefix are managed by the function", attr.Expr.Range())}
+		}
+	case blockContexts:
+		obj, ok := contextsBody(block)
+		if !ok {
+			return nil
+		}
+		var ret hcl.Diagnostics
+		keys, items := constantKeyItems(obj)
+		for _, k := range keys {
+			if strings.HasPrefix(k, reservedPrefix) {
+				ret = append(ret, reservedWarning(fmt.Sprintf("context key %q uses the reserved prefix %q", k, reservedPrefix),
+					"keys with this prefix are managed by the function", items[k][0].KeyExpr.Range()))
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+func (reservedRule) VisitAttribute(ctx RuleContext, attr *hcl.Attribute) hcl.Diagnostics {
+	parent := ctx.Parent()
+	if parent == nil || (parent.Type != blockLocals && parent.Type != blockFileLocals) {
+		return nil
+	}
+	if futureReservedWords[attr.Name] {
+		return hcl.Diagnostics{reservedWarning(fmt.Sprintf("local %s uses a reserved name", attr.Name),
+			"this name is reserved for future use, rename the local", attr.NameRange)}
+	}
+	return nil
+}
+
+func (reservedRule) VisitExpression(ctx RuleContext, expr hclsyntax.Expression) hcl.Diagnostics {
+	if ctx.Attribute == nil || ctx.Attribute.Name != attrBody {
+		return nil
+	}
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil
+	}
+	var ret hcl.Diagnostics
+	for _, item := range obj.Items {
+		key, ok := constantValue(item.KeyExpr)
+		if !ok || key.IsNull() || key.Type() != cty.String || !strings.HasPrefix(key.AsString(), reservedPrefix) {
+			continue
+		}
+		ret = ret.Append(reservedWarning(fmt.Sprintf("key %q uses the reserved prefix %q", key.AsString(), reservedPrefix),
+			"annotations and labels with this prefix are managed by the function", item.KeyExpr.Range()))
+	}
+	return ret
+}
+
+// checkReserved returns warnings for uses of names and keys that are reserved by the function.
+func (a *analyzer) checkReserved(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(reservedRule{}).walkContent(nil, content)
+}
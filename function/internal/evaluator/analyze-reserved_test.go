@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeReserved(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		warnings []string
+	}{
+		{
+			name: "no reserved names",
+			hcl: `
+locals {
+  variable = "foo"
+}
+function inputs {
+  body = 1
+}
+context {
+  key   = "example.com/env"
+  value = "prod"
+}
+resource foo {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    metadata = {
+      annotations = {
+        "example.com/owner"           = "team"
+        "crossplane.io/external-name" = "foo"
+      }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "reserved names",
+			hcl: `
+locals {
+  var = "foo"
+}
+file_locals {
+  env = "prod"
+}
+function "data.lookup" {
+  body = 1
+}
+context {
+  key   = "hcl.fn.crossplane.io/discards"
+  value = []
+}
+resource foo {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    metadata = {
+      labels = {
+        "hcl.fn.crossplane.io/collection-base-name" = "foo"
+      }
+    }
+  }
+}
+`,
+			warnings: []string{
+				`test.hcl:3,3-6: local var uses a reserved name; this name is reserved for future use, rename the local`,
+				`test.hcl:6,3-6: local env uses a reserved name; this name is reserved for future use, rename the local`,
+				`test.hcl:8,10-23: function data.lookup uses the reserved name "data"; this name is reserved for future use, rename the function`,
+				`test.hcl:12,11-42: context key "hcl.fn.crossplane.io/discards" uses the reserved prefix "hcl.fn.crossplane.io/"; keys with this prefix are managed by the function`,
+				`test.hcl:21,9-52: key "hcl.fn.crossplane.io/collection-base-name" uses the reserved prefix "hcl.fn.crossplane.io/"; annotations and labels with this prefix are managed by the function`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				if d.Severity == hcl.DiagWarning {
+					warnings = append(warnings, d.Error())
+				}
+			}
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+}
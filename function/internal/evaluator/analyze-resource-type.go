@@ -0,0 +1,89 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// resourceTypeRule warns about resource bodies that are object literals without an API version or kind, or that
+// set them to values that are not strings. Crossplane cannot apply such resources and reports confusing errors.
+type resourceTypeRule struct {
+	AnalyzerRuleBase
+}
+
+func (resourceTypeRule) Name() string {
+	return "resource-type"
+}
+
+func (r resourceTypeRule) VisitAttribute(ctx RuleContext, attr *hcl.Attribute) hcl.Diagnostics {
+	parent := ctx.Parent()
+	if attr.Name != attrBody || parent == nil || (parent.Type != blockResource && parent.Type != blockTemplate) {
+		return nil
+	}
+	obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil
+	}
+	items := map[string]hclsyntax.Expression{}
+	for _, item := range obj.Items {
+		k, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || !k.IsKnown() || k.IsNull() || k.Type() != cty.String {
+			return nil // keys that are not constant may be the fields being checked
+		}
+		items[k.AsString()] = item.ValueExpr
+	}
+	var ret hcl.Diagnostics
+	for _, field := range typeFields {
+		expr, ok := items[field]
+		if !ok {
+			ret = ret.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("resource body has no %s", field),
+				Detail:   "Crossplane cannot apply resources without an apiVersion and kind",
+				Subject:  obj.SrcRange.Ptr(),
+			})
+			continue
+		}
+		if typeName, ok := staticNonStringType(expr); ok {
+			ret = ret.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("%s of resource body must be a string, got %s", field, typeName),
+				Detail:   "Crossplane cannot apply resources without an apiVersion and kind",
+				Subject:  expr.Range().Ptr(),
+			})
+		}
+	}
+	return ret
+}
+
+// staticNonStringType returns the name of the type of the supplied expression if it can be determined without
+// evaluating it and is not a string.
+func staticNonStringType(expr hclsyntax.Expression) (string, bool) {
+	switch expr.(type) {
+	case *hclsyntax.TemplateExpr:
+		return "", false
+	case *hclsyntax.ObjectConsExpr:
+		return "object", true
+	case *hclsyntax.TupleConsExpr:
+		return "tuple", true
+	}
+	if len(expr.Variables()) > 0 {
+		return "", false
+	}
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.Type() == cty.String || v.Type() == cty.DynamicPseudoType {
+		return "", false
+	}
+	if v.IsNull() {
+		return "null", true
+	}
+	return v.Type().FriendlyName(), true
+}
+
+// checkResourceTypes returns warnings for resource bodies without an API version or kind.
+func (a *analyzer) checkResourceTypes(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(resourceTypeRule{}).walkContent(nil, content)
+}
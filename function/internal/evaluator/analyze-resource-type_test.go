@@ -0,0 +1,125 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeResourceTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		warnings []string
+	}{
+		{
+			name: "complete",
+			hcl: `
+resource foo {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+resources bar {
+  for_each = ["a"]
+  template {
+    body = { apiVersion = "${req.composite.apiVersion}", kind = req.composite.kind }
+  }
+}
+`,
+		},
+		{
+			name: "not statically known",
+			hcl: `
+locals {
+  key = "kind"
+}
+resource foo {
+  body = merge({ apiVersion = "v1" }, { kind = "ConfigMap" })
+}
+resource bar {
+  body = {
+    apiVersion = "v1"
+    (key)      = "ConfigMap"
+  }
+}
+`,
+		},
+		{
+			name: "missing",
+			hcl: `
+resource foo {
+  body = {
+    kind = "ConfigMap"
+  }
+}
+resources bar {
+  for_each = ["a"]
+  template {
+    body = {}
+  }
+}
+`,
+			warnings: []string{
+				"test.hcl:3,10-5,4: resource body has no apiVersion",
+				"test.hcl:10,12-14: resource body has no apiVersion",
+				"test.hcl:10,12-14: resource body has no kind",
+			},
+		},
+		{
+			name: "not strings",
+			hcl: `
+resource foo {
+  body = {
+    apiVersion = 1
+    kind       = { name = "ConfigMap" }
+  }
+}
+`,
+			warnings: []string{
+				"test.hcl:4,18-19: apiVersion of resource body must be a string, got number",
+				"test.hcl:5,18-40: kind of resource body must be a string, got object",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				require.Equal(t, hcl.DiagWarning, d.Severity)
+				warnings = append(warnings, d.Subject.String()+": "+d.Summary)
+			}
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+}
+
+func TestResourceTypeWarnings(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, `
+resource complete {
+  body = { apiVersion = "v1", kind = "ConfigMap" }
+}
+resource incomplete {
+  body = { apiVersion = "", metadata = { name = "foo" } }
+}
+`, "main.hcl")
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	var warnings []string
+	for _, d := range diags {
+		warnings = append(warnings, d.Summary)
+	}
+	assert.Equal(t, []string{
+		"apiVersion of resource incomplete must be a non-empty string",
+		"body of resource incomplete has no kind",
+	}, warnings)
+	assert.Contains(t, e.desiredResources, "incomplete")
+}
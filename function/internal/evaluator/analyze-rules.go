@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// RuleContext describes where a node visited by an analyzer rule appears in the source.
+type RuleContext struct {
+	Blocks    []*hcl.Block   // enclosing blocks, outermost first, empty at top-level
+	Attribute *hcl.Attribute // the attribute being visited or whose expression is being visited, if any
+}
+
+// Parent returns the innermost enclosing block or nil at top-level.
+func (c RuleContext) Parent() *hcl.Block {
+	if len(c.Blocks) == 0 {
+		return nil
+	}
+	return c.Blocks[len(c.Blocks)-1]
+}
+
+// AnalyzerRule is a custom lint rule that is run as part of analysis, after the built-in checks have passed.
+// The analyzer walks the merged content of all files and calls the rule for every block, every attribute
+// and every expression nested in attribute values, including those in locals and alias blocks. Diagnostics
+// returned by the rule are reported along with the diagnostics of the analyzer.
+//
+// Rules should embed AnalyzerRuleBase such that they only need to implement the visits they care about.
+type AnalyzerRule interface {
+	// Name returns the name of the rule, which is added to the detail of every diagnostic it reports.
+	Name() string
+	// VisitBlock is called for every block before its contents are visited.
+	VisitBlock(ctx RuleContext, block *hcl.Block) hcl.Diagnostics
+	// VisitAttribute is called for every attribute before its expression is visited.
+	VisitAttribute(ctx RuleContext, attr *hcl.Attribute) hcl.Diagnostics
+	// VisitExpression is called for every expression in the value of an attribute, outermost first.
+	VisitExpression(ctx RuleContext, expr hclsyntax.Expression) hcl.Diagnostics
+}
+
+// AnalyzerRuleBase provides no-op implementations of the visit methods of an analyzer rule.
+type AnalyzerRuleBase struct{}
+
+func (AnalyzerRuleBase) VisitBlock(RuleContext, *hcl.Block) hcl.Diagnostics {
+	return nil
+}
+
+func (AnalyzerRuleBase) VisitAttribute(RuleContext, *hcl.Attribute) hcl.Diagnostics {
+	return nil
+}
+
+func (AnalyzerRuleBase) VisitExpression(RuleContext, hclsyntax.Expression) hcl.Diagnostics {
+	return nil
+}
+
+var (
+	rulesLock       sync.Mutex
+	registeredRules []AnalyzerRule
+)
+
+// RegisterAnalyzerRule registers a rule that is run by every evaluator created after the call. This is
+// typically called from the init function of a package that is compiled into a build of the tools.
+func RegisterAnalyzerRule(rule AnalyzerRule) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+	registeredRules = append(registeredRules, rule)
+}
+
+// analyzerRules returns the registered rules followed by the supplied ones.
+func analyzerRules(rules []AnalyzerRule) []AnalyzerRule {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+	ret := make([]AnalyzerRule, 0, len(registeredRules)+len(rules))
+	ret = append(ret, registeredRules...)
+	return append(ret, rules...)
+}
+
+// runRules runs all analyzer rules against the supplied content.
+func (a *analyzer) runRules(content *hcl.BodyContent) hcl.Diagnostics {
+	var ret hcl.Diagnostics
+	for _, rule := range a.e.rules {
+		for _, d := range a.e.analyzerConfig.apply(rule.Name(), newRuleWalker(rule).walkContent(nil, content)) {
+			d.Detail = strings.TrimSpace(fmt.Sprintf("%s (rule %s)", d.Detail, rule.Name()))
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// ruleWalker walks content, calling a rule for every node.
+type ruleWalker struct {
+	rule  AnalyzerRule
+	diags hcl.Diagnostics
+}
+
+func newRuleWalker(rule AnalyzerRule) *ruleWalker {
+	return &ruleWalker{rule: rule}
+}
+
+func (w *ruleWalker) walkContent(blocks []*hcl.Block, content *hcl.BodyContent) hcl.Diagnostics {
+	w.walkAttributes(blocks, content.Attributes)
+	for _, block := range content.Blocks {
+		childBlocks := append(append([]*hcl.Block{}, blocks...), block)
+		w.diags = w.diags.Extend(w.rule.VisitBlock(RuleContext{Blocks: blocks}, block))
+		schema, ok := schemasByBlockType[block.Type]
+		if !ok || schema == nil {
+			attrs, _ := block.Body.JustAttributes()
+			w.walkAttributes(childBlocks, attrs)
+			continue
+		}
+		childContent, _ := block.Body.Content(schema)
+		w.walkContent(childBlocks, childContent)
+	}
+	return w.diags
+}
+
+func (w *ruleWalker) walkAttributes(blocks []*hcl.Block, attrs hcl.Attributes) {
+	for _, attr := range sortedAttributes(attrs) {
+		ctx := RuleContext{Blocks: blocks, Attribute: attr}
+		w.diags = w.diags.Extend(w.rule.VisitAttribute(ctx, attr))
+		node, ok := attr.Expr.(hclsyntax.Node)
+		if !ok {
+			continue
+		}
+		_ = hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+			if expr, ok := n.(hclsyntax.Expression); ok {
+				w.diags = w.diags.Extend(w.rule.VisitExpression(ctx, expr))
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package evaluator
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var kebabCase = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// namingRule requires resource names to be in kebab case.
+type namingRule struct {
+	AnalyzerRuleBase
+}
+
+func (namingRule) Name() string {
+	return "naming"
+}
+
+func (namingRule) VisitBlock(_ RuleContext, block *hcl.Block) hcl.Diagnostics {
+	if block.Type != blockResource || kebabCase.MatchString(block.Labels[0]) {
+		return nil
+	}
+	return hclutils.ToErrorDiag("resource name is not in kebab case", block.Labels[0], block.LabelRanges[0])
+}
+
+// forbiddenKindRule warns about resource bodies with a forbidden kind.
+type forbiddenKindRule struct {
+	AnalyzerRuleBase
+	kind string
+}
+
+func (forbiddenKindRule) Name() string {
+	return "forbidden-kind"
+}
+
+func (r forbiddenKindRule) VisitExpression(ctx RuleContext, expr hclsyntax.Expression) hcl.Diagnostics {
+	parent := ctx.Parent()
+	if parent == nil || parent.Type != blockResource || ctx.Attribute.Name != attrBody {
+		return nil
+	}
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil
+	}
+	for _, item := range obj.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || !key.Type().Equals(cty.String) || key.AsString() != "kind" {
+			continue
+		}
+		val, diags := item.ValueExpr.Value(nil)
+		if diags.HasErrors() || !val.Type().Equals(cty.String) || val.AsString() != r.kind {
+			continue
+		}
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  "forbidden kind",
+			Detail:   r.kind,
+			Subject:  item.ValueExpr.Range().Ptr(),
+		}}
+	}
+	return nil
+}
+
+// countingRule counts the nodes visited.
+type countingRule struct {
+	AnalyzerRuleBase
+	blocks, attributes, expressions int
+}
+
+func (*countingRule) Name() string {
+	return "counting"
+}
+
+func (r *countingRule) VisitBlock(RuleContext, *hcl.Block) hcl.Diagnostics {
+	r.blocks++
+	return nil
+}
+
+func (r *countingRule) VisitAttribute(RuleContext, *hcl.Attribute) hcl.Diagnostics {
+	r.attributes++
+	return nil
+}
+
+func (r *countingRule) VisitExpression(RuleContext, hclsyntax.Expression) hcl.Diagnostics {
+	r.expressions++
+	return nil
+}
+
+func TestAnalyzerRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		errMsg   string
+		warnings []string
+	}{
+		{
+			name: "clean",
+			hcl: `
+resource my-bucket {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "Bucket"
+  }
+}
+`,
+		},
+		{
+			name: "bad name",
+			hcl: `
+group {
+  resource myBucket {
+    body = {
+      apiVersion = "aws.com/v1"
+      kind       = "Bucket"
+    }
+  }
+}
+`,
+			errMsg: `test.hcl:3,12-20: resource name is not in kebab case; myBucket (rule naming)`,
+		},
+		{
+			name: "forbidden kind",
+			hcl: `
+locals {
+  kind = "Bucket"
+}
+resource my-bucket {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "Cluster"
+  }
+}
+`,
+			warnings: []string{`test.hcl:8,18-27: forbidden kind; Cluster (rule forbidden-kind)`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{
+				AnalyzerRules: []AnalyzerRule{namingRule{}, forbiddenKindRule{kind: "Cluster"}},
+			})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			if test.errMsg != "" {
+				require.True(t, diags.HasErrors())
+				assert.Contains(t, diags.Error(), test.errMsg)
+				return
+			}
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				warnings = append(warnings, d.Error())
+			}
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+}
+
+func TestAnalyzerRulesWalk(t *testing.T) {
+	hcl := `
+alias {
+  params = req.composite.spec
+}
+locals {
+  a = params.a
+}
+resource foo {
+  locals {
+    b = "${a}-b"
+  }
+  body = {
+    kind = b
+  }
+}
+`
+	r := &countingRule{}
+	e, err := New(Options{AnalyzerRules: []AnalyzerRule{r}})
+	require.NoError(t, err)
+	diags := e.Analyze(File{Name: "test.hcl", Content: hcl})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, 4, r.blocks)
+	assert.Equal(t, 4, r.attributes)
+	// the alias target, params.a, the template and its two parts, and the object with its key and value
+	assert.Equal(t, 8, r.expressions)
+}
+
+func TestRegisterAnalyzerRule(t *testing.T) {
+	defer func() { registeredRules = nil }()
+	RegisterAnalyzerRule(namingRule{})
+	e, err := New(Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(File{Name: "test.hcl", Content: `resource Foo { body = {} }`})
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "resource name is not in kebab case; Foo (rule naming)")
+}
@@ -0,0 +1,36 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/secrets"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// checkSecrets returns warnings for what look like credentials in the source files, such that they are not
+// committed into compositions by mistake.
+func (a *analyzer) checkSecrets() hcl.Diagnostics {
+	var names []string
+	for name := range a.e.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ret hcl.Diagnostics
+	for _, name := range names {
+		for _, f := range secrets.Scan(a.e.files[name].Bytes) {
+			ret = ret.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("possible %s in source", f.Kind),
+				Detail: fmt.Sprintf("credentials should be read from secrets instead of being committed; "+
+					"add a comment with %q to the line or the line before it to allow it", secrets.AllowMarker),
+				Subject: &hcl.Range{
+					Filename: name,
+					Start:    hcl.Pos{Line: f.Line, Column: f.Column, Byte: f.Offset},
+					End:      hcl.Pos{Line: f.Line, Column: f.Column + f.Length, Byte: f.Offset + f.Length},
+				},
+			})
+		}
+	}
+	return ret
+}
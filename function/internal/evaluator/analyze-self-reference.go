@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// isSelfResourceField returns true if the supplied traversal reads a field of the observed resource of the
+// resource being rendered, such as self.resource.status.id.
+func isSelfResourceField(t hcl.Traversal) bool {
+	t = hclexpr.NormalizeTraversal(t)
+	if len(t) < 3 || t.RootName() != reservedSelf {
+		return false
+	}
+	attr, ok := t[1].(hcl.TraverseAttr)
+	return ok && attr.Name == selfObservedResource
+}
+
+// selfReferenceRule warns about resource bodies that read fields of their own observed resource without a
+// fallback, and about resources that wait for them. The observed resource only exists once the resource has
+// been created, so such a resource is discarded on every reconcile and never created.
+type selfReferenceRule struct {
+	AnalyzerRuleBase
+}
+
+func (selfReferenceRule) Name() string {
+	return "self-reference"
+}
+
+func (selfReferenceRule) VisitAttribute(ctx RuleContext, attr *hcl.Attribute) hcl.Diagnostics {
+	parent := ctx.Parent()
+	if parent == nil || (parent.Type != blockResource && parent.Type != blockTemplate) {
+		return nil
+	}
+	if attr.Name == attrWaitFor {
+		return selfWaits(attr)
+	}
+	if attr.Name != attrBody {
+		return nil
+	}
+	guarded := selfResourceGuards(attr.Expr)
+	var ret hcl.Diagnostics
+outer:
+	for _, t := range hclexpr.UnguardedTraversals(attr.Expr) {
+		if !isSelfResourceField(t) {
+			continue
+		}
+		r := t.SourceRange()
+		for _, g := range guarded {
+			if g.Filename == r.Filename && g.ContainsOffset(r.Start.Byte) {
+				continue outer
+			}
+		}
+		ret = ret.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "resource body reads its own observed resource, which does not exist before the first reconcile",
+			Detail:   "the resource is discarded until it has been created, which never happens; use try with a default value",
+			Subject:  r.Ptr(),
+		})
+	}
+	return ret
+}
+
+// selfWaits warns about wait_for attributes that wait for fields of the observed resource itself, since a guard
+// makes no sense for a value that must be known.
+func selfWaits(attr *hcl.Attribute) hcl.Diagnostics {
+	var ret hcl.Diagnostics
+	for _, t := range attr.Expr.Variables() {
+		if !isSelfResourceField(t) {
+			continue
+		}
+		ret = ret.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "resource waits for its own observed resource, which does not exist before the first reconcile",
+			Detail:   "the resource is discarded until it has been created, which never happens; wait for values of other resources instead",
+			Subject:  ptr(t.SourceRange()),
+		})
+	}
+	return ret
+}
+
+// selfResourceGuards returns the ranges of the conditional expressions in the supplied expression whose
+// condition refers to the observed resource, such as self.resource == null ? "" : self.resource.status.id.
+func selfResourceGuards(expr hcl.Expression) []hcl.Range {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return nil
+	}
+	var ret []hcl.Range
+	_ = hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		cond, ok := n.(*hclsyntax.ConditionalExpr)
+		if !ok {
+			return nil
+		}
+		for _, t := range cond.Condition.Variables() {
+			t = hclexpr.NormalizeTraversal(t)
+			if len(t) < 2 || t.RootName() != reservedSelf {
+				continue
+			}
+			if attr, ok := t[1].(hcl.TraverseAttr); ok && attr.Name == selfObservedResource {
+				ret = append(ret, cond.Range())
+				break
+			}
+		}
+		return nil
+	})
+	return ret
+}
+
+// checkSelfReferences returns warnings for resource bodies that depend on their own observed resource.
+func (a *analyzer) checkSelfReferences(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(selfReferenceRule{}).walkContent(nil, content)
+}
@@ -0,0 +1,111 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeSelfReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		warnings []string
+	}{
+		{
+			name: "guarded",
+			hcl: `
+resource foo {
+  locals {
+    id = self.resource.status.id
+  }
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data = {
+      a = try(self.resource.status.id, "")
+      b = can(self.resource.status.id) ? "yes" : "no"
+      c = self.resource == null ? "" : self.resource.status.id
+      d = self.name
+    }
+  }
+  ready {
+    value = self.resource.status.ready ? "READY_TRUE" : "READY_FALSE"
+  }
+}
+`,
+		},
+		{
+			name: "unguarded",
+			hcl: `
+resource foo {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { id = self.resource.status.id }
+  }
+}
+resources bar {
+  for_each = ["a"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { id = "${self.resource["status"].id}-${each.value}" }
+    }
+  }
+}
+`,
+			warnings: []string{
+				"test.hcl:6,25-48: resource body reads its own observed resource, which does not exist before the first reconcile",
+				"test.hcl:15,30-56: resource body reads its own observed resource, which does not exist before the first reconcile",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.False(t, diags.HasErrors(), diags.Error())
+			var warnings []string
+			for _, d := range diags {
+				require.Equal(t, hcl.DiagWarning, d.Severity)
+				warnings = append(warnings, d.Subject.String()+": "+d.Summary)
+			}
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+}
+
+func TestSelfReferenceDiscards(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, `
+resource self {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { id = self.resource.status.id }
+  }
+}
+resource mixed {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { id = self.resource.status.id, name = req.composite.status.name }
+  }
+}
+`, "main.hcl")
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, e.discards, 2)
+
+	assert.Equal(t, "self", e.discards[0].Name)
+	assert.Contains(t, e.discards[0].Context, "waiting for first reconcile of this resource: self.resource.status.id "+
+		"cannot be read before it has been created, use try with a default value to create it")
+
+	assert.Equal(t, "mixed", e.discards[1].Name)
+	assert.Contains(t, e.discards[1].Context, "unknown values: self.resource.status.id, req.composite.status.name")
+}
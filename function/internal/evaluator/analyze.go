@@ -2,10 +2,13 @@ package evaluator
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/locals"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
@@ -14,13 +17,28 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
+// validIteratorAttrs are the attributes of the iterator variable of a resource collection.
+var validIteratorAttrs = map[string]bool{
+	attrKey:       true,
+	attrValue:     true,
+	iteratorIndex: true,
+	iteratorFirst: true,
+	iteratorLast:  true,
+	iteratorCount: true,
+}
+
 // analyzer provides facilities for HCL analysis.
 type analyzer struct {
 	e                *Evaluator
 	p                *functions.Processor
 	resourceNames    map[string]bool
+	dynamicNames     []namePattern // patterns of resource names that are only known at evaluation time
 	collectionNames  map[string]bool
 	requirementNames map[string]bool
+	readyDefaults    map[string]bool
+	policyNames      map[string]bool
+	aliases          map[string]hcl.Traversal
+	docRange         *hcl.Range // range of the doc block, if there is one
 }
 
 func newAnalyzer(e *Evaluator) *analyzer {
@@ -29,6 +47,8 @@ func newAnalyzer(e *Evaluator) *analyzer {
 		resourceNames:    map[string]bool{},
 		collectionNames:  map[string]bool{},
 		requirementNames: map[string]bool{},
+		readyDefaults:    map[string]bool{},
+		policyNames:      map[string]bool{},
 	}
 }
 
@@ -56,10 +76,34 @@ func (a *analyzer) addRequirement(name string, r hcl.Range) hcl.Diagnostics {
 	return nil
 }
 
+func (a *analyzer) addReadyDefault(block *hcl.Block) hcl.Diagnostics {
+	rd, diags := a.e.checkReadyDefaultBlock(block)
+	if diags.HasErrors() {
+		return diags
+	}
+	if a.readyDefaults[rd.key()] {
+		return hclutils.ToErrorDiag(fmt.Sprintf("duplicate %s block", blockReadyDefault), rd.key(), block.DefRange)
+	}
+	a.readyDefaults[rd.key()] = true
+	return nil
+}
+
+func (a *analyzer) addPolicy(block *hcl.Block) hcl.Diagnostics {
+	if _, diags := a.e.checkPolicyBlock(block); diags.HasErrors() {
+		return diags
+	}
+	name := block.Labels[0]
+	if a.policyNames[name] {
+		return hclutils.ToErrorDiag("policy defined more than once", name, block.LabelRanges[0])
+	}
+	a.policyNames[name] = true
+	return nil
+}
+
 func (a *analyzer) checkReferences(ctx *hcl.EvalContext, tables map[string]DynamicObject, expr hcl.Traversal) hcl.Diagnostics {
 	var ret hcl.Diagnostics
 	sr := expr.SourceRange()
-	expr = hclutils.NormalizeTraversal(expr)
+	expr = expandAlias(hclexpr.NormalizeTraversal(expr), a.aliases)
 	getText := func() string {
 		return a.e.sourceCode(sr)
 	}
@@ -75,7 +119,15 @@ func (a *analyzer) checkReferences(ctx *hcl.EvalContext, tables map[string]Dynam
 			break
 		}
 		if _, ok := root[second.Name]; !ok {
-			ret = ret.Extend(hclutils.ToErrorDiag(fmt.Sprintf("no such attribute %q", second.Name), getText(), sr))
+			var names []string
+			for name := range root {
+				names = append(names, name)
+			}
+			detail := hclutils.DidYouMean(getText(), second.Name, names)
+			if expr.RootName() == reservedReq {
+				detail = suggestRequestKey(getText(), second.Name, names)
+			}
+			ret = ret.Extend(hclutils.ToErrorDiag(fmt.Sprintf("no such attribute %q", second.Name), detail, sr))
 			break
 		}
 
@@ -92,13 +144,29 @@ func (a *analyzer) checkReferences(ctx *hcl.EvalContext, tables map[string]Dynam
 		}
 
 		switch {
+		case expr.RootName() == reservedReq && second.Name == reqComposite:
+			if msg := checkCompositeField(thirdStep); msg != "" {
+				ret = ret.Extend(hclutils.ToErrorDiag("invalid composite reference", msg, sr))
+			}
 		case expr.RootName() == reservedReq && second.Name == "resource":
-			if !a.resourceNames[thirdStep] {
-				ret = ret.Extend(hclutils.ToErrorDiag("invalid resource name reference", thirdStep, sr))
+			if !a.resourceNames[thirdStep] && !a.maybeDynamicName(thirdStep) {
+				ret = ret.Extend(hclutils.ToErrorDiag("invalid resource name reference",
+					hclutils.DidYouMean(thirdStep, thirdStep, setKeys(a.resourceNames)), sr))
 			}
 		case expr.RootName() == reservedReq && second.Name == "resources":
 			if !a.collectionNames[thirdStep] {
-				ret = ret.Extend(hclutils.ToErrorDiag("invalid resource collection name reference", thirdStep, sr))
+				ret = ret.Extend(hclutils.ToErrorDiag("invalid resource collection name reference",
+					hclutils.DidYouMean(thirdStep, thirdStep, setKeys(a.collectionNames)), sr))
+			}
+		case expr.RootName() == reservedSelf && second.Name == selfExtra:
+			extra := root[selfExtra]
+			if extra.Type().IsObjectType() && !extra.Type().HasAttribute(thirdStep) {
+				var names []string
+				for name := range extra.Type().AttributeTypes() {
+					names = append(names, name)
+				}
+				ret = ret.Extend(hclutils.ToErrorDiag("invalid requirement name reference",
+					hclutils.DidYouMean(thirdStep, thirdStep, names), sr))
 			}
 		case expr.RootName() == reservedSelf && second.Name == "each":
 			if thirdStep != "key" && thirdStep != "value" {
@@ -106,6 +174,26 @@ func (a *analyzer) checkReferences(ctx *hcl.EvalContext, tables map[string]Dynam
 			}
 		}
 
+	case reservedArg:
+		// without values files, all values are supplied at runtime and nothing can be checked. Values may also be
+		// supplied in the function input, so values that no file sets are only reported as warnings.
+		values := tables[reservedArg]
+		if len(expr) < 2 || len(values) == 0 {
+			return nil
+		}
+		second, ok := expr[1].(hcl.TraverseAttr)
+		if !ok {
+			return nil
+		}
+		if _, ok := values[second.Name]; !ok {
+			ret = ret.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("value %q is not set by any values file", second.Name),
+				Detail:   hclutils.DidYouMean(getText(), second.Name, sortedKeys(values)),
+				Subject:  sr.Ptr(),
+			})
+		}
+
 	case iteratorName:
 		if len(expr) < 2 {
 			return nil
@@ -115,8 +203,8 @@ func (a *analyzer) checkReferences(ctx *hcl.EvalContext, tables map[string]Dynam
 			ret = ret.Extend(hclutils.ToErrorDiag("invalid index expression", getText(), sr))
 			break
 		}
-		if second.Name != "key" && second.Name != "value" {
-			ret = ret.Extend(hclutils.ToErrorDiag("invalid each reference, must be one of 'key' or 'value'", second.Name, sr))
+		if !validIteratorAttrs[second.Name] {
+			ret = ret.Extend(hclutils.ToErrorDiag("invalid each reference, must be one of 'key', 'value', 'index', 'first', 'last' or 'count'", second.Name, sr))
 			break
 		}
 		fallthrough // since each is a local variable added on demand, add the local variable ref checks as well
@@ -125,7 +213,8 @@ func (a *analyzer) checkReferences(ctx *hcl.EvalContext, tables map[string]Dynam
 		reference := expr.RootName()
 		if !hasVariable(ctx, reference) {
 			r := expr[0].SourceRange()
-			ret = ret.Extend(hclutils.ToErrorDiag("invalid local variable reference", reference, r))
+			ret = ret.Extend(hclutils.ToErrorDiag("invalid local variable reference",
+				hclutils.DidYouMean(reference, reference, hclutils.VariableNames(ctx)), r))
 		}
 	}
 	return ret
@@ -152,6 +241,19 @@ func (a *analyzer) analyzeContent(ctx *hcl.EvalContext, parent *hcl.Block, conte
 			return diags
 		}
 	}
+	if parent.Type == blockObserve {
+		_, diags := contentToSelection(parent.Labels[0], content, parent.DefRange)
+		if diags.HasErrors() {
+			return diags
+		}
+	}
+	// groups and the top-level expose the extra resources of the requirements declared in them
+	if parent.Type == blockGroup || parent.Type == "" {
+		ctx = createSelfChildContext(ctx, DynamicObject{
+			selfExtra: scopedExtraResources(ctx, content),
+		})
+	}
+
 	// if in a resources block add the expected self vars
 	if parent.Type == blockResources {
 		ctx = createSelfChildContext(ctx, DynamicObject{
@@ -161,7 +263,40 @@ func (a *analyzer) analyzeContent(ctx *hcl.EvalContext, parent *hcl.Block, conte
 		})
 	}
 
-	if parent.Type == blockResource || parent.Type == blockTemplate {
+	if parent.Type == blockObserve {
+		ctx = createSelfChildContext(ctx, DynamicObject{
+			selfObserved: cty.DynamicVal,
+		})
+	}
+
+	if parent.Type == blockTemplate {
+		ctx = createSelfChildContext(ctx, DynamicObject{
+			selfIndex: cty.NumberIntVal(0),
+		})
+	}
+
+	// the resource name of a resource block is evaluated before its self variables and locals are set up
+	var ret hcl.Diagnostics
+	if parent.Type == blockResource || parent.Type == blockResources {
+		_, ds := isEnabled(content, parent.Type)
+		ret = ret.Extend(ds)
+	}
+	if parent.Type == blockGroup || parent.Type == blockResource || parent.Type == blockResources {
+		_, ds := allowsNondeterminism(content, parent.Type)
+		ret = ret.Extend(ds)
+		_, ds = blockLabels(content, parent.Type)
+		ret = ret.Extend(ds)
+	}
+	_, ds := waitForExpressions(content)
+	ret = ret.Extend(ds)
+	if attr, ok := content.Attributes[attrResourceName]; ok && parent.Type == blockResource {
+		tables := makeTables(ctx)
+		for _, v := range attr.Expr.Variables() {
+			ret = ret.Extend(a.checkReferences(ctx, tables, v))
+		}
+	}
+
+	if parent.Type == blockResource || parent.Type == blockTemplate || parent.Type == blockReadyDefault {
 		ctx = createSelfChildContext(ctx, map[string]cty.Value{
 			selfName:               cty.StringVal("dummy"),
 			selfObservedResource:   cty.DynamicVal,
@@ -169,18 +304,23 @@ func (a *analyzer) analyzeContent(ctx *hcl.EvalContext, parent *hcl.Block, conte
 		})
 	}
 
+	if parent.Type == blockPolicy {
+		ctx = createSelfChildContext(ctx, DynamicObject{
+			selfName: cty.StringVal("dummy"),
+			selfBody: cty.DynamicVal,
+		})
+	}
+
 	// evaluate locals, checking for bad refs
 	ctx, localExpressions, diags := a.processLocals(ctx, content)
 	if diags.HasErrors() {
-		return diags
+		return ret.Extend(diags)
 	}
 
 	// now ensure that all expressions including ones in local and attributes refer to
 	// locals, resources, and collections that exist.
 	tables := makeTables(ctx)
 
-	var ret hcl.Diagnostics
-
 	// first locals
 	for _, expr := range localExpressions {
 		vars := expr.Variables()
@@ -189,11 +329,30 @@ func (a *analyzer) analyzeContent(ctx *hcl.EvalContext, parent *hcl.Block, conte
 		}
 	}
 
+	// then file locals, each of which are checked in the context of the file that declares them
+	scopes, diags := processFileLocals(ctx, content)
+	if diags.HasErrors() {
+		return ret.Extend(diags)
+	}
+	for file, blocks := range fileLocalBlocks(content) {
+		for _, block := range blocks {
+			attrs, _ := block.Body.JustAttributes()
+			for _, attr := range attrs {
+				for _, v := range attr.Expr.Variables() {
+					ret = ret.Extend(a.checkReferences(scopes[file], tables, v))
+				}
+			}
+		}
+	}
+
 	// then attributes
 	for _, attr := range content.Attributes {
-		// unlike any other attribute, the name attribute for the `resources` block is special because
-		// it has access to the iterator.
-		if attr.Name == "name" && parent.Type == blockResources {
+		// unlike any other attribute, the name and rename_from attributes for the `resources` block are
+		// special because they have access to the iterator.
+		if (attr.Name == attrName || attr.Name == attrRenameFrom) && parent.Type == blockResources {
+			continue
+		}
+		if attr.Name == attrResourceName && parent.Type == blockResource {
 			continue
 		}
 		vars := attr.Expr.Variables()
@@ -207,15 +366,20 @@ func (a *analyzer) analyzeContent(ctx *hcl.EvalContext, parent *hcl.Block, conte
 		ctx = ctx.NewChild()
 		ctx.Variables = DynamicObject{
 			iteratorName: cty.ObjectVal(DynamicObject{
-				attrKey:   cty.DynamicVal,
-				attrValue: cty.DynamicVal,
+				attrKey:       cty.DynamicVal,
+				attrValue:     cty.DynamicVal,
+				iteratorIndex: cty.UnknownVal(cty.Number),
+				iteratorFirst: cty.UnknownVal(cty.Bool),
+				iteratorLast:  cty.UnknownVal(cty.Bool),
+				iteratorCount: cty.UnknownVal(cty.Number),
 			}),
 		}
-		// check the name attribute if one exists
-		if nameAttr, ok := content.Attributes[attrName]; ok {
-			vars := nameAttr.Expr.Variables()
-			for _, v := range vars {
-				ret = ret.Extend(a.checkReferences(ctx, tables, v))
+		// check the name and rename_from attributes if they exist
+		for _, name := range []string{attrName, attrRenameFrom} {
+			if attr, ok := content.Attributes[name]; ok {
+				for _, v := range attr.Expr.Variables() {
+					ret = ret.Extend(a.checkReferences(ctx, tables, v))
+				}
 			}
 		}
 	}
@@ -223,25 +387,41 @@ func (a *analyzer) analyzeContent(ctx *hcl.EvalContext, parent *hcl.Block, conte
 	// process child blocks
 	for _, block := range content.Blocks {
 		// function blocks have already been statically analyzed at load for bad references.
-		if block.Type == blockLocals || block.Type == blockFunction {
+		// alias blocks have already been checked before the content was analyzed.
+		// file locals have been checked along with the other locals.
+		switch block.Type {
+		case blockLocals, blockFunction, blockAlias, blockFileLocals:
 			continue
 		}
 		childContent, d := block.Body.Content(schemasByBlockType[block.Type])
 		if d.HasErrors() { // should never happen if structure has already been checked
 			return d
 		}
-		ret = ret.Extend(a.analyzeContent(ctx, block, childContent))
+		childCtx := scopes.context(ctx, block)
+		// composite and context blocks of a resources block can see the rendered items of the collection
+		if parent.Type == blockResources && (block.Type == blockComposite || block.Type == blockContext || block.Type == blockContexts) {
+			childCtx = createSelfChildContext(childCtx, DynamicObject{
+				selfItems: cty.DynamicVal,
+			})
+		}
+		ret = ret.Extend(a.analyzeContent(childCtx, block, childContent))
 	}
 	return ret
 }
 
-func (a *analyzer) analyze(files ...File) hcl.Diagnostics {
-	// parse all files
-	bodies, diags := a.e.toBodies(files)
-	if diags.HasErrors() {
-		return diags
+// analyzeAliases checks that alias targets refer to objects that exist.
+func (a *analyzer) analyzeAliases(ctx *hcl.EvalContext, aliases map[string]hcl.Traversal) hcl.Diagnostics {
+	var ret hcl.Diagnostics
+	tables := makeTables(ctx)
+	var names []string
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ret = ret.Extend(a.checkReferences(ctx, tables, aliases[name]))
 	}
-	return a.analyzeBodies(bodies...)
+	return ret
 }
 
 func (a *analyzer) checkFunctionRefs(content *hcl.BodyContent) hcl.Diagnostics {
@@ -257,7 +437,9 @@ func (a *analyzer) checkFunctionRefs(content *hcl.BodyContent) hcl.Diagnostics {
 	}
 	for _, block := range content.Blocks {
 		switch block.Type {
-		case blockLocals:
+		case blockAlias:
+			continue
+		case blockLocals, blockFileLocals:
 			attrs, _ := block.Body.JustAttributes()
 			for _, attr := range attrs {
 				doCheckFunctionRefs(attr.Expr)
@@ -271,9 +453,12 @@ func (a *analyzer) checkFunctionRefs(content *hcl.BodyContent) hcl.Diagnostics {
 }
 
 func (a *analyzer) analyzeBodies(bodies ...hcl.Body) hcl.Diagnostics {
-	var diags hcl.Diagnostics
+	diags := a.e.checkRequires(bodies)
+	if diags.HasErrors() {
+		return diags
+	}
 	for _, body := range bodies {
-		diags = diags.Extend(a.checkStructure(body, topLevelSchema()))
+		diags = diags.Extend(a.checkStructure(body, topLevelSchema(), ""))
 	}
 	if diags.HasErrors() {
 		return diags
@@ -312,13 +497,55 @@ func (a *analyzer) analyzeBodies(bodies ...hcl.Body) hcl.Diagnostics {
 	if err != nil {
 		return []*hcl.Diagnostic{{Severity: hcl.DiagError, Summary: "internal error: setup dummy vars", Detail: err.Error()}}
 	}
+	ctx = a.e.stableContext(ctx)
+
+	aliases, ds := collectAliases(content)
+	if ds.HasErrors() {
+		return ds
+	}
+	ret := a.analyzeAliases(ctx, aliases)
+	if ret.HasErrors() {
+		return ret
+	}
+	a.aliases = aliases
+	// arg has the values of the values files. Without any, values can only be supplied at runtime.
+	arg := cty.DynamicVal
+	if len(a.e.analyzedValues) > 0 {
+		arg = cty.ObjectVal(a.e.analyzedValues)
+	}
+	vars := DynamicObject{
+		reservedArg: arg,
+	}
+	for name := range aliases {
+		vars[name] = cty.DynamicVal
+	}
+	ctx = ctx.NewChild()
+	ctx.Variables = vars
 
-	ret := a.analyzeContent(ctx, &hcl.Block{}, content)
+	ret = ret.Extend(a.analyzeContent(ctx, &hcl.Block{}, content))
 	ret = ret.Extend(a.checkFunctionRefs(content))
+	if !ret.HasErrors() {
+		cfg := a.e.analyzerConfig
+		ret = ret.Extend(cfg.apply(constantsRule{}.Name(), a.checkConstants(content)))
+		ret = ret.Extend(cfg.apply((&contextsRule{}).Name(), a.checkContexts(content)))
+		ret = ret.Extend(cfg.apply(reservedRule{}.Name(), a.checkReserved(content)))
+		ret = ret.Extend(cfg.apply(connectionRule{}.Name(), a.checkConnections(content)))
+		ret = ret.Extend(cfg.apply(resourceTypeRule{}.Name(), a.checkResourceTypes(content)))
+		ret = ret.Extend(cfg.apply(selfReferenceRule{}.Name(), a.checkSelfReferences(content)))
+		ret = ret.Extend(cfg.apply(deterministicRule{}.Name(), a.checkDeterministic(content)))
+		ret = ret.Extend(cfg.apply(credentialsRule{}.Name(), a.checkCredentials(content)))
+		ret = ret.Extend(cfg.apply(secretsRuleName, a.checkSecrets()))
+		ret = ret.Extend(a.runRules(content))
+	}
+	if a.e.simulateConditions && !ret.HasErrors() {
+		ret = ret.Extend(a.simulateConditions(content))
+	}
 	return ret
 }
 
-func (a *analyzer) checkStructure(body hcl.Body, s *hcl.BodySchema) hcl.Diagnostics {
+// checkStructure checks the supplied body against its schema, recording the names of the objects defined in it.
+// The prefix is the name prefix of the enclosing groups.
+func (a *analyzer) checkStructure(body hcl.Body, s *hcl.BodySchema, prefix string) hcl.Diagnostics {
 	if s == nil {
 		_, diags := body.JustAttributes()
 		if diags.HasErrors() {
@@ -328,20 +555,36 @@ func (a *analyzer) checkStructure(body hcl.Body, s *hcl.BodySchema) hcl.Diagnost
 	}
 	content, diags := body.Content(s)
 	if diags.HasErrors() {
-		return diags
+		return explainUnsupportedAttributes(body, s, diags)
+	}
+	if _, ok := content.Attributes[attrNamePrefix]; ok {
+		p, ds := groupNamePrefix(content)
+		diags = diags.Extend(ds)
+		prefix += p
 	}
 	for _, block := range content.Blocks {
 		switch block.Type {
 		case blockResource:
-			diags = diags.Extend(a.addResource(block.Labels[0], block.LabelRanges[0]))
+			resourceContent, _ := block.Body.Content(resourceSchema()) // errors are reported when checking the body
+			if name, ok := staticResourceName(prefix, block, resourceContent); ok {
+				diags = diags.Extend(a.addResource(name, block.LabelRanges[0]))
+			} else {
+				a.dynamicNames = append(a.dynamicNames, dynamicNamePattern(prefix, resourceContent.Attributes[attrResourceName].Expr))
+			}
 		case blockResources:
-			diags = diags.Extend(a.addCollection(block.Labels[0], block.LabelRanges[0]))
-		case blockRequirement:
+			diags = diags.Extend(a.addCollection(prefix+block.Labels[0], block.LabelRanges[0]))
+		case blockRequirement, blockObserve:
 			diags = diags.Extend(a.addRequirement(block.Labels[0], block.LabelRanges[0]))
+		case blockReadyDefault:
+			diags = diags.Extend(a.addReadyDefault(block))
+		case blockPolicy:
+			diags = diags.Extend(a.addPolicy(block))
+		case blockDoc:
+			diags = diags.Extend(a.addDoc(block))
 		}
-		diags = diags.Extend(a.checkStructure(block.Body, schemasByBlockType[block.Type]))
+		diags = diags.Extend(a.checkStructure(block.Body, schemasByBlockType[block.Type], prefix))
 	}
-	return diags
+	return diags.Extend(checkReadyBlocks(content))
 }
 
 func (e *Evaluator) doAnalyze(files ...File) (finalErr hcl.Diagnostics) {
@@ -351,6 +594,44 @@ func (e *Evaluator) doAnalyze(files ...File) (finalErr hcl.Diagnostics) {
 		finalErr = sortDiagsBySeverity(finalErr)
 	}()
 
-	a := newAnalyzer(e)
-	return a.analyze(files...)
+	// values files are checked for syntax and constant values, and their values are used for arg
+	files, valuesFiles := splitValuesFiles(files)
+	values, diags := e.parseValues(valuesFiles)
+	if diags.HasErrors() {
+		return diags
+	}
+	e.analyzedValues = values
+
+	// parse all files
+	bodies, diags := e.toBodies(files)
+	if diags.HasErrors() {
+		return diags
+	}
+	return e.analyzeBodies(bodies...)
+}
+
+// analyzeBodies analyzes the supplied bodies for the flags that are set. If no flags were specified, it analyzes
+// the bodies for every combination of flags used in when attributes, such that all variants are checked.
+func (e *Evaluator) analyzeBodies(bodies ...hcl.Body) hcl.Diagnostics {
+	if e.flags != nil {
+		return newAnalyzer(e).analyzeBodies(bodies...)
+	}
+	defer func() { e.flags = nil }()
+	combinations, diags := flagCombinations(e.usedFlags())
+	seen := map[string]bool{}
+	for _, flags := range combinations {
+		e.flags = toFlagSet(flags)
+		for _, d := range newAnalyzer(e).analyzeBodies(bodies...) {
+			key := d.Error()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if len(combinations) > 1 {
+				d.Detail = strings.TrimSpace(fmt.Sprintf("%s (with %s)", d.Detail, describeFlags(flags)))
+			}
+			diags = append(diags, d)
+		}
+	}
+	return diags
 }
@@ -56,6 +56,60 @@ resource foo  {
 `,
 			errMsg: `test.hcl:5,2-7: Unsupported argument; An argument named "ready" is not expected here`,
 		},
+		{
+			name: "stray top-level attribute",
+			hcl: `
+resources buckets {
+  template {
+    body = {}
+  }
+}
+for_each = ["a", "b"]
+`,
+			errMsg: `test.hcl:7,1-9: Unsupported argument; An argument named "for_each" is not expected here. It is an attribute of resources blocks`,
+		},
+		{
+			name:   "top-level value",
+			hcl:    `region = "us-west-2"`,
+			errMsg: `Only blocks are allowed here, use a locals block to declare values.`,
+		},
+		{
+			name: "stray group attribute",
+			hcl: `
+group {
+  resource foo {
+  }
+  body = {}
+}
+`,
+			errMsg: `test.hcl:5,3-7: Unsupported argument; An argument named "body" is not expected here. It is an attribute of composite, contexts, function, resource, template blocks`,
+		},
+		{
+			name: "condition in template",
+			hcl: `
+resources buckets {
+  for_each = ["a", "b"]
+  template {
+    condition = each.value == "a"
+    body      = {}
+  }
+}
+`,
+			errMsg: `test.hcl:5,5-14: Unsupported argument; An argument named "condition" is not expected here. Template blocks cannot have a condition.`,
+		},
+		{
+			name: "resource name in template",
+			hcl: `
+resources buckets {
+  for_each = ["a", "b"]
+  template {
+    resource_name = each.value
+    body          = {}
+  }
+}
+`,
+			errMsg: `Use the name attribute of the enclosing resources block to name the resources of the collection.`,
+		},
 		{
 			name:   "bad group block",
 			hcl:    `group foo bar {}`,
@@ -157,7 +211,79 @@ resource foo {
 	}
 }
 `,
-			errMsg: `test.hcl:4,9-27: no such attribute "resources0"; req.resources0.foo`,
+			errMsg: `test.hcl:4,9-27: no such attribute "resources0"; req.resources0.foo, did you mean "resources"?`,
+		},
+		{
+			name: "camel case req second",
+			hcl: `
+resource foo {
+	body = {
+		bar = req.compositeConnection.password
+	}
+}
+`,
+			errMsg: `test.hcl:4,9-41: no such attribute "compositeConnection"; req.compositeConnection.password, did you mean "composite_connection"?`,
+		},
+		{
+			name: "aliased req second",
+			hcl: `
+resource foo {
+	body = {
+		bar = req.connection_details.password
+	}
+}
+`,
+			errMsg: `test.hcl:4,9-40: no such attribute "connection_details"; req.connection_details.password, did you mean "composite_connection"?`,
+		},
+		{
+			name: "composite connection field",
+			hcl: `
+resource foo {
+	body = {
+		bar = req.composite.connectionDetails.password
+	}
+}
+`,
+			errMsg: `test.hcl:4,9-49: invalid composite reference; the composite has no field "connectionDetails", its connection details are available as req.composite_connection`,
+		},
+		{
+			name: "unprefixed resource ref",
+			hcl: `
+group {
+	name_prefix = "net-"
+	resource vpc {
+		body = {
+			foo = "bar"
+		}
+	}
+}
+locals {
+	good = req.resource.net-vpc
+	bad = req.resource.vpc
+}
+`,
+			errMsg: `test.hcl:12,8-24: invalid resource name reference; vpc`,
+		},
+		{
+			name: "misspelled resource ref",
+			hcl: `
+resource bucket {
+	body = {
+		bar = req.resource.buckt.metadata
+	}
+}
+`,
+			errMsg: `test.hcl:4,9-36: invalid resource name reference; buckt, did you mean "bucket"?`,
+		},
+		{
+			name: "misspelled local",
+			hcl: `
+locals {
+	region = "us-east-1"
+	foo = regoin
+}
+`,
+			errMsg: `test.hcl:4,8-14: reference to non-existent variable; regoin, did you mean "region"?`,
 		},
 		{
 			name: "bad resource ref",
@@ -189,7 +315,7 @@ resources foo {
 	}
 }
 `,
-			errMsg: `test.hcl:6,10-21: invalid each reference, must be one of 'key' or 'value'; foobar`,
+			errMsg: `test.hcl:6,10-21: invalid each reference, must be one of 'key', 'value', 'index', 'first', 'last' or 'count'; foobar`,
 		},
 		{
 			name: "bad for_each expr",
@@ -235,6 +361,142 @@ resources foo {
 `,
 			errMsg: `test.hcl:3,40-49: no such attribute "name"; self.name`,
 		},
+		{
+			name: "self index outside template",
+			hcl: `
+resources foo {
+	condition = self.index == 0
+	for_each = range(10)
+	template {
+		body = {
+			bar = self.index
+		}
+	}
+}
+`,
+			errMsg: `test.hcl:3,14-24: no such attribute "index"; self.index`,
+		},
+		{
+			name: "self items in template",
+			hcl: `
+resources foo {
+	for_each = range(10)
+	template {
+		body = {
+			bar = length(self.items)
+		}
+	}
+	composite status {
+		body = {
+			count = length(self.items)
+		}
+	}
+}
+`,
+			errMsg: `test.hcl:6,17-27: no such attribute "items"; self.items`,
+		},
+		{
+			name: "ready value typo",
+			hcl: `
+resource foo {
+	body = {}
+	ready {
+		value = req.composite.spec.wait ? "READY_TRUE" : "READY_FLASE"
+	}
+}
+`,
+			errMsg: `test.hcl:5,52-65: invalid ready value "READY_FLASE"; must be one of READY_FALSE, READY_TRUE, READY_UNSPECIFIED, did you mean "READY_FALSE"?`,
+		},
+		{
+			name: "default ready value not a string",
+			hcl: `
+default_ready {
+	value = true
+}
+`,
+			errMsg: `test.hcl:3,10-14: ready value must be a string, got bool; must be one of READY_FALSE, READY_TRUE, READY_UNSPECIFIED`,
+		},
+		{
+			name: "self observed outside observe",
+			hcl: `
+resource foo {
+	body = {
+		bar = self.observed
+	}
+}
+`,
+			errMsg: `test.hcl:4,9-22: no such attribute "observed"; self.observed`,
+		},
+		{
+			name: "unreachable ready block",
+			hcl: `
+resource foo {
+	body = {}
+	ready {
+		value = "READY_TRUE"
+	}
+	ready {
+		condition = true
+		value = "READY_FALSE"
+	}
+}
+`,
+			errMsg: `test.hcl:7,2-7: unreachable ready block; the ready block at test.hcl:4,2-7 has no condition`,
+		},
+		{
+			name: "non-boolean condition",
+			hcl: `
+resource foo {
+	condition = "enabled"
+	body = {}
+}
+`,
+			errMsg: `test.hcl:3,14-23: condition must be a bool, got string; "enabled" can never be true or false`,
+		},
+		{
+			name: "string for_each",
+			hcl: `
+resources foo {
+	for_each = "${req.composite.metadata.name}-a"
+	template {
+		body = {}
+	}
+}
+`,
+			errMsg: `test.hcl:3,13-47: for_each must be a list, map, set or object, got string; "${req.composite.metadata.name}-a" cannot be iterated`,
+		},
+		{
+			name: "constant number for_each",
+			hcl: `
+resources foo {
+	for_each = 1 + 2
+	template {
+		body = {}
+	}
+}
+`,
+			errMsg: `test.hcl:3,13-18: for_each must be a list, map, set or object, got number; 1 + 2 cannot be iterated`,
+		},
+		{
+			name: "requirement from another scope",
+			hcl: `
+group {
+	requirement config {
+		select {
+			apiVersion = "v1"
+			kind = "ConfigMap"
+			matchName = "network"
+		}
+	}
+}
+resource foo {
+	body = {
+		cidr = self.extra.config[0].data.cidr
+	}
+}
+`,
+			errMsg: `test.hcl:13,10-40: invalid requirement name reference; config`,
+		},
 		{
 			name: "duplicate requirements",
 			hcl: `
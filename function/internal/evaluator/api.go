@@ -2,11 +2,14 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/locals"
+	"github.com/crossplane-contrib/function-hcl/function/internal/version"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	fn "github.com/crossplane/function-sdk-go"
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
@@ -36,18 +39,29 @@ const (
 
 // supported blocks and attributes.
 const (
-	blockGroup       = "group"
-	blockResource    = "resource"
-	blockResources   = "resources"
-	blockComposite   = "composite"
-	blockContext     = "context"
-	blockLocals      = locals.BlockLocals
-	blockTemplate    = "template"
-	blockReady       = "ready"
-	blockFunction    = functions.BlockFunction
-	blockArg         = functions.BlockArg
-	blockRequirement = "requirement"
-	blockSelect      = "select"
+	blockGroup              = "group"
+	blockResource           = "resource"
+	blockResources          = "resources"
+	blockComposite          = "composite"
+	blockContext            = "context"
+	blockContexts           = "contexts"
+	blockLocals             = locals.BlockLocals
+	blockTemplate           = "template"
+	blockReady              = "ready"
+	blockReadyDefault       = "default_ready"
+	blockReadyFromCondition = "ready_from_condition"
+	blockFunction           = functions.BlockFunction
+	blockArg                = functions.BlockArg
+	blockValidate           = functions.BlockValidate
+	blockRequirement        = "requirement"
+	blockSelect             = "select"
+	blockObserve            = "observe"
+	blockPolicy             = "policy"
+	blockRequires           = "requires"
+	blockAlias              = "alias"
+	blockFileLocals         = "file_locals"
+	blockExportConn         = "export_connection"
+	blockDoc                = "doc"
 
 	attrBody        = "body"
 	attrCondition   = "condition"
@@ -59,9 +73,28 @@ const (
 	attrKind        = "kind"
 	attrMatchName   = "matchName"
 	attrMatchLabels = "matchLabels"
+	attrTarget      = "target"
+	attrSeverity    = "severity"
+	attrAssert      = "assert"
+	attrMessage     = "message"
+	attrSensitive   = "sensitive"
+	attrFunctionHCL = "function_hcl"
+	attrNamePrefix  = "name_prefix"
+	attrFrom        = "from"
+	attrMap         = "map"
+	attrTitle       = "title"
+	attrDescription = "description"
+	attrType        = "type"
+	attrStatus      = "status"
+
+	attrResourceName            = "resource_name"
+	attrExternalName            = "external_name"
+	attrAllowExternalNameChange = "allow_external_name_change"
+	attrRenameFrom              = "rename_from"
 
 	blockLabelStatus     = "status"
 	blockLabelConnection = "connection"
+	blockLabelSpec       = "spec"
 )
 
 const (
@@ -70,6 +103,12 @@ const (
 	reservedArg  = "arg"
 )
 
+// MaxInvokeDepthLimit is the largest maximum depth of nested user function and lambda calls that can be set.
+const MaxInvokeDepthLimit = functions.MaxDepthLimit
+
+// discardsContextKey is the context key under which the discard report is emitted when requested.
+const discardsContextKey = "hcl.fn.crossplane.io/discards"
+
 // automatic annotations we will add to resources that are created in a for_each loop.
 const (
 	annotationBaseName = "hcl.fn.crossplane.io/collection-base-name"
@@ -80,11 +119,20 @@ const (
 const (
 	selfName                = "name"
 	selfBaseName            = "basename"
+	selfIndex               = "index"
 	selfObservedResource    = "resource"
 	selfObservedConnection  = "connection"
 	selfObservedResources   = "resources"
 	selfObservedConnections = "connections"
+	selfBody                = "body"
+	selfObserved            = "observed"
+	selfExtra               = "extra"
+	selfItems               = "items"
 	iteratorName            = "each"
+	iteratorIndex           = "index"
+	iteratorFirst           = "first"
+	iteratorLast            = "last"
+	iteratorCount           = "count"
 )
 
 // DiscardType describes what was discarded by the function.
@@ -96,9 +144,11 @@ const (
 	discardTypeGroup        DiscardType = "group"
 	discardTypeStatus       DiscardType = "composite-status"
 	discardTypeConnection   DiscardType = "composite-connection"
+	discardTypeSpec         DiscardType = "composite-spec"
 	discardTypeReady        DiscardType = "resource-ready"
 	discardTypeContext      DiscardType = "context"
 	discardTypeRequirement  DiscardType = "requirement"
+	discardTypePolicy       DiscardType = "policy"
 )
 
 // DiscardReason describes the reason for the elision.
@@ -109,6 +159,8 @@ const (
 	discardReasonUserCondition DiscardReason = "user-condition"
 	discardReasonIncomplete    DiscardReason = "incomplete"
 	discardReasonBadSecret     DiscardReason = "bad-secret"
+	discardReasonFailed        DiscardReason = "failed"
+	discardReasonUntargeted    DiscardReason = "untargeted"
 )
 
 // File is an HCL file to evaluate.
@@ -121,6 +173,82 @@ type File struct {
 type Options struct {
 	Logger logging.Logger
 	Debug  bool
+	// SimulateConditions is only used for analysis. When set, every condition is considered to be both true and
+	// false, and references to objects that only exist when a condition holds are reported as warnings.
+	SimulateConditions bool
+	// Flags are the feature flags that are set. Blocks with a when attribute are only processed when the
+	// expression, which can only use the flag function, evaluates to true. When flags are not specified
+	// for analysis, every combination of flags used in the source is analyzed.
+	Flags []string
+	// Hooks are called in order with the desired resources before a response is created.
+	Hooks []ResourceHook
+	// CompositeSchema, when set, provides the types used to convert the observed composite.
+	CompositeSchema *CompositeSchema
+	// DiscardsInContext emits the list of all discarded items into the response context such that external
+	// tooling can inspect what was skipped and why.
+	DiscardsInContext bool
+	// AnalyzerRules are custom rules run during analysis in addition to the rules registered using
+	// RegisterAnalyzerRule.
+	AnalyzerRules []AnalyzerRule
+	// AnalyzerConfig, when set, changes the severities of the diagnostics of analyzer rules or turns rules off,
+	// for all files or for some of them.
+	AnalyzerConfig *AnalyzerConfig
+	// IndexFormat is the format of the collection index annotation added to resources created by resource
+	// collections. DefaultIndexFormat is used when not set.
+	IndexFormat *IndexFormat
+	// MaxInvokeDepth is the maximum depth of nested user function and lambda calls. The default of 100 is used
+	// when it is not positive. It cannot be larger than MaxInvokeDepthLimit.
+	MaxInvokeDepth int
+	// ConnectionKinds is only used for analysis. When set, it lists the kinds of resources that publish connection
+	// details, as kind or kind.group, and reads of the connection details of resources of other kinds are reported
+	// as warnings. Such reads are always reported for built-in Kubernetes kinds.
+	ConnectionKinds []string
+	// CollectionKeyTransform transforms the keys of resource collections that are used in the default names of
+	// their resources. SanitizeCollectionKey is used when not set.
+	CollectionKeyTransform func(key string) string
+	// MaxDiscardsToDisplay is the maximum number of discarded items, and of failed policies, that are listed in the
+	// messages of the FullyResolved and PolicyCompliant conditions. The default of 3 is used when it is not positive.
+	MaxDiscardsToDisplay int
+	// DiscardCountsOnly leaves the names of discarded items and failed policies out of condition messages, such that
+	// they only have the number of items. The names are still available in the results.
+	DiscardCountsOnly bool
+	// IsolateGroupErrors reports errors in a group as a discarded group instead of failing the evaluation, such
+	// that the objects of the other groups are still rendered. Nothing that the failed group would add is rendered.
+	// Errors are reported as warnings rather than fatal results, since a fatal result stops the whole pipeline.
+	IsolateGroupErrors bool
+	// ContextNamespace nests all keys that the function writes to the context under this key, such that multiple
+	// steps of a pipeline do not overwrite each other's values. The values under it in the request context are
+	// visible at the top level of req.context. Keys are written at the top level when it is empty.
+	ContextNamespace string
+	// Targets restricts evaluation to the groups, resources and resource collections that have one of these values
+	// in their labels attribute, along with all blocks in the groups that do. The observed state of resources that
+	// are not evaluated is copied to the desired state, such that they are neither changed nor deleted. All blocks
+	// are evaluated when it is empty.
+	Targets []string
+	// SkipUntargeted leaves resources that are not evaluated because of Targets out of the desired state instead of
+	// copying their observed state.
+	SkipUntargeted bool
+	// Sandbox, when set, disables functions and limits the sizes of collections and the depth of user function calls
+	// for compositions that are not trusted. The enforced profile is emitted into the response context.
+	Sandbox *SandboxProfile
+}
+
+// IndexFormat controls how the zero-based iteration number of a resource in a collection is formatted
+// in its collection index annotation. The number is padded with zeros to the width and follows the prefix.
+// Numbers that need more digits than the width are not truncated.
+type IndexFormat struct {
+	Prefix string
+	Width  int
+}
+
+// DefaultIndexFormat is the index format used when none is specified, producing values like s000001.
+var DefaultIndexFormat = IndexFormat{Prefix: "s", Width: 6}
+
+// MaxIndexWidth is the largest width of an index format, which is enough for any int.
+const MaxIndexWidth = 20
+
+func (f IndexFormat) format(index int) string {
+	return fmt.Sprintf("%s%0*d", f.Prefix, f.Width, index)
 }
 
 // DiscardItem is an instance of a resource, resource list, group, connection detail or a composite status
@@ -134,6 +262,11 @@ type DiscardItem struct {
 	Context     []string      `json:"context,omitempty"`     // relevant messages with more details
 }
 
+// expected returns true if the item was discarded on purpose, because of a user condition or targeting.
+func (di DiscardItem) expected() bool {
+	return di.Reason == discardReasonUserCondition || di.Reason == discardReasonUntargeted
+}
+
 func (di DiscardItem) MessageString() string {
 	base := []string{fmt.Sprintf("%s:discarded %s %s", di.SourceRange, di.Type, di.Name)}
 	base = append(base, di.Context...)
@@ -145,17 +278,63 @@ func (di DiscardItem) MessageString() string {
 type Evaluator struct {
 	log                      logging.Logger                    // the logger to use
 	debug                    bool                              // whether we are in debug mode
+	simulateConditions       bool                              // whether analysis simulates condition outcomes
+	flags                    map[string]bool                   // feature flags that are set, nil when not specified
+	hooks                    []ResourceHook                    // hooks called with desired resources
+	hookWarnings             []string                          // warnings reported by hooks
+	rules                    []AnalyzerRule                    // custom rules run during analysis
+	analyzerConfig           *AnalyzerConfig                   // severities of analyzer rules
+	compositeSchema          *CompositeSchema                  // types of the composite spec and status, if known
+	discardsInContext        bool                              // whether discards are emitted into the response context
+	indexFormat              IndexFormat                       // format of the collection index annotation
+	maxInvokeDepth           int                               // maximum depth of nested user function calls
+	connectionKinds          map[string]bool                   // kinds that publish connection details, nil when not set
+	keyTransform             func(string) string               // transform of collection keys for default resource names
+	maxDiscardsToDisplay     int                               // maximum number of items listed in condition messages
+	discardCountsOnly        bool                              // whether condition messages only have the number of items
+	isolateGroupErrors       bool                              // whether errors in groups are reported as discarded groups
+	contextNamespace         string                            // key under which all context values are nested, if any
+	targets                  map[string]bool                   // labels of the blocks that are evaluated, nil when not set
+	skipUntargeted           bool                              // whether resources that are not targeted are left out
+	sandbox                  *SandboxProfile                   // restrictions for compositions that are not trusted, if any
+	targeted                 bool                              // whether the group being processed is targeted
+	namespaceContext         *structpb.Struct                  // values under the context namespace of the request
+	analyzedValues           DynamicObject                     // values from the values files being analyzed, if any
+	stableRounds             map[string]int                    // rounds for which stable values were unknown, from the request
+	unstableRounds           map[string]int                    // rounds for which stable values are unknown, for the response
+	credentials              map[string]map[string][]byte      // data of the credentials supplied to the function, by name
+	nondeterministicDepth    int                               // number of enclosing blocks that allow non-deterministic functions
+	nondeterministicCalls    map[string]bool                   // non-deterministic functions called outside of such blocks
+	version                  string                            // version of the function, checked against requires blocks
+	runCtx                   context.Context                   // context of the evaluation, checked for cancellation
+	program                  *Program                          // the program to evaluate, if created for one
+	position                 string                            // description of the item being processed
+	positionRange            hcl.Range                         // source range of the item being processed, if any
+	namePrefix               string                            // name prefix of the groups that are being processed
+	policyCount              int                               // number of policies checked
+	policyViolations         []policyViolation                 // violations of policies with warning severity
 	files                    map[string]*hcl.File              // map of HCL files keyed by source filename
+	aliases                  map[string]hcl.Traversal          // aliases of the program being evaluated
 	existingResourceMap      DynamicObject                     // tracks resource names present in observed resources
 	existingConnectionMap    DynamicObject                     // tracks observed resource connection details.
+	observedResources        DynamicObject                     // all observed resources, including those of collections
+	observedConnections      DynamicObject                     // connection details of all observed resources
+	renames                  map[string]string                 // names of renamed observed resources, by new name
 	collectionResourcesMap   DynamicObject                     // tracks resource names present in observed resource collections
 	collectionConnectionsMap DynamicObject                     // tracks observed collection resource connection details.
+	collectionResourceNames  map[string][]string               // names of observed collection resources, by base name
 	desiredResources         map[string]*structpb.Struct       // desired resource bodies
 	requirements             map[string]*fnv1.ResourceSelector // requirements
 	compositeStatuses        []Object                          // status attributes of the composite
+	compositeStatusRanges    []hcl.Range                       // source ranges of the composite statuses
 	compositeConnections     []map[string][]byte               // composite connection details
+	compositeSpecs           []Object                          // spec attributes of the composite
+	compositeSpecRanges      []hcl.Range                       // source ranges of the composite specs
+	observedCompositeSpec    Object                            // spec of the observed composite
 	contexts                 []Object                          // desired context values
+	sensitiveContextKeys     map[string]bool                   // context keys with sensitive values
 	ready                    map[string]int32                  // readiness indicator for resource
+	readyDefaults            []*readyDefault                   // default readiness expressions by API version and kind
 	discards                 []DiscardItem                     // list of things discarded from output
 }
 
@@ -168,22 +347,107 @@ func New(opts Options) (*Evaluator, error) {
 			return nil, err
 		}
 	}
+	indexFormat := DefaultIndexFormat
+	if opts.IndexFormat != nil {
+		indexFormat = *opts.IndexFormat
+	}
+	if indexFormat.Width < 0 || indexFormat.Width > MaxIndexWidth {
+		return nil, fmt.Errorf("index format width must be between 0 and %d, got %d", MaxIndexWidth, indexFormat.Width)
+	}
+	keyTransform := opts.CollectionKeyTransform
+	if keyTransform == nil {
+		keyTransform = SanitizeCollectionKey
+	}
+	maxDiscards := opts.MaxDiscardsToDisplay
+	if maxDiscards <= 0 {
+		maxDiscards = defaultMaxDiscardsToDisplay
+	}
+	if opts.MaxInvokeDepth > MaxInvokeDepthLimit {
+		return nil, fmt.Errorf("maxInvokeDepth must not be larger than %d, got %d", MaxInvokeDepthLimit, opts.MaxInvokeDepth)
+	}
+	rules := analyzerRules(opts.AnalyzerRules)
+	if opts.AnalyzerConfig != nil {
+		if err := opts.AnalyzerConfig.validate(rules); err != nil {
+			return nil, fmt.Errorf("analyzer config: %w", err)
+		}
+	}
+	if opts.Sandbox != nil {
+		if err := opts.Sandbox.Validate(); err != nil {
+			return nil, fmt.Errorf("sandbox profile: %w", err)
+		}
+	}
 	return &Evaluator{
-		log:              opts.Logger,
-		debug:            opts.Debug,
-		files:            map[string]*hcl.File{},
-		desiredResources: map[string]*structpb.Struct{},
-		requirements:     map[string]*fnv1.ResourceSelector{},
-		ready:            map[string]int32{},
+		log:                   opts.Logger,
+		debug:                 opts.Debug,
+		simulateConditions:    opts.SimulateConditions,
+		flags:                 toFlagSet(opts.Flags),
+		hooks:                 opts.Hooks,
+		rules:                 rules,
+		analyzerConfig:        opts.AnalyzerConfig,
+		compositeSchema:       opts.CompositeSchema,
+		discardsInContext:     opts.DiscardsInContext,
+		indexFormat:           indexFormat,
+		maxInvokeDepth:        opts.Sandbox.invokeDepth(opts.MaxInvokeDepth),
+		connectionKinds:       toKindSet(opts.ConnectionKinds),
+		keyTransform:          keyTransform,
+		maxDiscardsToDisplay:  maxDiscards,
+		discardCountsOnly:     opts.DiscardCountsOnly,
+		isolateGroupErrors:    opts.IsolateGroupErrors,
+		contextNamespace:      opts.ContextNamespace,
+		targets:               toTargetSet(opts.Targets),
+		skipUntargeted:        opts.SkipUntargeted,
+		sandbox:               opts.Sandbox,
+		version:               version.Version,
+		files:                 map[string]*hcl.File{},
+		desiredResources:      map[string]*structpb.Struct{},
+		requirements:          map[string]*fnv1.ResourceSelector{},
+		ready:                 map[string]int32{},
+		sensitiveContextKeys:  map[string]bool{},
+		unstableRounds:        map[string]int{},
+		nondeterministicCalls: map[string]bool{},
+		renames:               map[string]string{},
 	}, nil
 }
 
 // Eval evaluates the supplied HCL files. Ordering of these files are not important for evaluation.
 // Internally they are just processed as though all the files were concatenated into a single file.
-func (e *Evaluator) Eval(in *fnv1.RunFunctionRequest, files ...File) (*fnv1.RunFunctionResponse, error) {
+// Evaluation stops with an error that says where it stopped when the supplied context is done. Internal
+// panics are returned as errors along with the location at which they occurred.
+func (e *Evaluator) Eval(ctx context.Context, in *fnv1.RunFunctionRequest, files ...File) (res *fnv1.RunFunctionResponse, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			res, err = nil, e.panicDiags(v)
+		}
+	}()
+	e.runCtx = ctx
 	return e.doEval(in, files...)
 }
 
+// SensitiveContextKeys returns the sorted list of context keys that were marked sensitive during evaluation.
+// Values of these keys should not be displayed in debug output.
+func (e *Evaluator) SensitiveContextKeys() []string {
+	// keys are only redacted at the top level, so the whole namespace is redacted when it has sensitive values
+	if e.contextNamespace != "" && len(e.sensitiveContextKeys) > 0 {
+		return []string{e.contextNamespace}
+	}
+	var ret []string
+	for k := range e.sensitiveContextKeys {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Incomplete returns true if any item was discarded during evaluation for a reason other than a user condition.
+func (e *Evaluator) Incomplete() bool {
+	for _, d := range e.discards {
+		if !d.expected() {
+			return true
+		}
+	}
+	return false
+}
+
 // Analyze runs static checks on the supplied HCL files that implement a composition.
 // It returns errors and warnings in the process.
 func (e *Evaluator) Analyze(files ...File) hcl.Diagnostics {
@@ -213,8 +477,7 @@ func (e *Evaluator) AnalyzeHCLFiles(files ...RawFile) (ret hcl.Diagnostics) {
 			continue
 		}
 		e.files[name] = file.File
-		bodies = append(bodies, body)
+		bodies = append(bodies, e.withFlags(body))
 	}
-	a := newAnalyzer(e)
-	return a.analyzeBodies(bodies...)
+	return e.analyzeBodies(bodies...)
 }
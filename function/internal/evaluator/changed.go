@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// changedFunction is the name of the function that compares a field of the observed and desired state of a resource.
+const changedFunction = "changed"
+
+// equalFunction is the name of the built-in function that compares values without regard to their types.
+const equalFunction = "equal"
+
+// lookupFunction returns the named function from the supplied context or its parents.
+func lookupFunction(ctx *hcl.EvalContext, name string) (function.Function, bool) {
+	for c := ctx; c != nil; c = c.Parent() {
+		if fn, ok := c.Functions[name]; ok {
+			return fn, true
+		}
+	}
+	return function.Function{}, false
+}
+
+// lookupField returns the value at the supplied path of a resource, or null if there is none.
+func lookupField(v cty.Value, path []string) cty.Value {
+	for _, seg := range path {
+		if !v.IsKnown() || v.IsNull() {
+			return cty.NullVal(cty.DynamicPseudoType)
+		}
+		ty := v.Type()
+		switch {
+		case ty.IsObjectType():
+			if !ty.HasAttribute(seg) {
+				return cty.NullVal(cty.DynamicPseudoType)
+			}
+			v = v.GetAttr(seg)
+		case ty.IsListType() || ty.IsTupleType():
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= v.LengthInt() {
+				return cty.NullVal(cty.DynamicPseudoType)
+			}
+			v = v.Index(cty.NumberIntVal(int64(i)))
+		default:
+			return cty.NullVal(cty.DynamicPseudoType)
+		}
+	}
+	return v
+}
+
+// changedValue returns true if the field at the supplied path, whose first segment is the name of a resource,
+// differs between the observed resource and the desired resource rendered so far. Fields of resources that were
+// not observed yet have always changed.
+func (e *Evaluator) changedValue(equal function.Function, path string) (cty.Value, error) {
+	segments := strings.Split(path, ".")
+	for _, seg := range segments {
+		if seg == "" {
+			return cty.NilVal, function.NewArgErrorf(0, "invalid path %q", path)
+		}
+	}
+	name := segments[0]
+	desired, ok := e.desiredResources[name]
+	if !ok {
+		return cty.NilVal, function.NewArgErrorf(0,
+			"resource %s has not been rendered, it must be declared before the expression that calls %s", name, changedFunction)
+	}
+	observed := e.getObservedResource(name)
+	if observed == cty.NilVal {
+		return cty.True, nil
+	}
+	desiredValue, err := toCtyValue(desired.AsMap())
+	if err != nil {
+		return cty.NilVal, err
+	}
+	eq, err := equal.Call([]cty.Value{lookupField(observed, segments[1:]), lookupField(desiredValue, segments[1:])})
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return eq.Not(), nil
+}
+
+// changedContext returns a child of the supplied context that has the function to detect changes of resources.
+func (e *Evaluator) changedContext(ctx *hcl.EvalContext) *hcl.EvalContext {
+	equal, ok := lookupFunction(ctx, equalFunction)
+	if !ok {
+		panic(fmt.Sprintf("internal error: no %s function", equalFunction))
+	}
+	ctx = ctx.NewChild()
+	ctx.Functions = map[string]function.Function{
+		changedFunction: function.New(&function.Spec{
+			Description: "returns true if a field of a resource differs between its observed and desired state",
+			Params: []function.Parameter{
+				{Name: "path", Type: cty.String},
+			},
+			Type: function.StaticReturnType(cty.Bool),
+			Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+				return e.changedValue(equal, args[0].AsString())
+			},
+		}),
+	}
+	return ctx
+}
@@ -0,0 +1,85 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var changedObserved = map[string]map[string]any{
+	"bucket": {
+		"apiVersion": "v1",
+		"kind":       "Bucket",
+		"spec": map[string]any{
+			"size":   "10",
+			"region": "us-east-1",
+			"tags":   []any{"a", "b"},
+		},
+	},
+}
+
+func TestChanged(t *testing.T) {
+	hcl := `
+resource bucket {
+  body = {
+    apiVersion = "v1"
+    kind       = "Bucket"
+    spec = {
+      size   = 10
+      region = "us-west-2"
+      tags   = ["a", "b"]
+    }
+  }
+}
+resource queue {
+  body = {
+    apiVersion = "v1"
+    kind       = "Queue"
+  }
+}
+composite status {
+  body = {
+    size    = changed("bucket.spec.size")
+    region  = changed("bucket.spec.region")
+    tags    = changed("bucket.spec.tags")
+    tag     = changed("bucket.spec.tags.1")
+    missing = changed("bucket.spec.missing")
+    queue   = changed("queue.spec")
+    equal   = equal({ a = 1, b = null }, { a = "1" })
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, changedObserved)), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"size":    false,
+		"region":  true,
+		"tags":    false,
+		"tag":     false,
+		"missing": false,
+		"queue":   true,
+		"equal":   true,
+	}, res.GetDesired().GetComposite().GetResource().AsMap()["status"])
+}
+
+func TestChangedNotRendered(t *testing.T) {
+	hcl := `
+composite status {
+  body = { region = changed("bucket.spec.region") }
+}
+resource bucket {
+  body = { apiVersion = "v1", kind = "Bucket" }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, changedObserved)), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.GetResults())
+	assert.Contains(t, res.GetResults()[0].GetMessage(), "resource bucket has not been rendered")
+}
@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// SanitizeCollectionKey is the default transform for the keys of resource collections that are used in the
+// default names of their resources. It replaces every character that is not a letter, digit, dash or underscore
+// with a dash, such that the names can be used in references like req.resource.name.
+func SanitizeCollectionKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, key)
+}
+
+// defaultCollectionName returns the default name of the resource of a collection for the supplied key, which is
+// the base name followed by a dash and the transformed key. The key is used as-is when an observed resource has the
+// name with the untransformed key, such that resources created before keys were transformed keep their names
+// instead of being deleted and created again under new ones. Names produced by earlier keys are tracked in the
+// supplied map, such that two keys that produce the same name are reported as an error.
+func (e *Evaluator) defaultCollectionName(baseName string, key cty.Value, keysByName map[string]string, r hcl.Range) (string, hcl.Diagnostics) {
+	k, err := convert.Convert(key, cty.String)
+	if err != nil || k.IsNull() || !k.IsKnown() {
+		return "", hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("key of resource collection %s cannot be used in a name", baseName),
+			Detail:   fmt.Sprintf("keys must be strings or numbers, got %s; use the %s attribute to name the resources", key.Type().FriendlyName(), attrName),
+			Subject:  ptr(r),
+		}}
+	}
+	keyString := k.AsString()
+	name := baseName + "-" + e.keyTransform(keyString)
+	if raw := baseName + "-" + keyString; raw != name {
+		if _, ok := e.observedResources[raw]; ok {
+			name = raw
+		}
+	}
+	if other, ok := keysByName[name]; ok && other != keyString {
+		return "", hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("keys %q and %q of resource collection %s produce the same name %q", other, keyString, baseName, name),
+			Detail:   fmt.Sprintf("keys are transformed to be valid in names; use the %s attribute to name the resources", attrName),
+			Subject:  ptr(r),
+		}}
+	}
+	keysByName[name] = keyString
+	return name, nil
+}
@@ -0,0 +1,43 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservedUnsanitizedName(t *testing.T) {
+	hcl := `
+resources base {
+  for_each = { "a.b" = 1, "c.d" = 2 }
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { name = self.name }
+    }
+  }
+}
+`
+	req := makeRequest(t, baseRequestJSON, withObserved(t, map[string]map[string]any{
+		"base-a.b": collectionMember(map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "xr-base-a.b"},
+		}, "base", "s000000"),
+	}))
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+
+	// the existing resource keeps its name, new ones use the transformed key
+	desired := res.GetDesired().GetResources()
+	require.Len(t, desired, 2)
+	require.Contains(t, desired, "base-a.b")
+	assert.Contains(t, desired, "base-c-d")
+	assert.Equal(t, "base-a.b", desired["base-a.b"].GetResource().AsMap()["data"].(map[string]any)["name"])
+}
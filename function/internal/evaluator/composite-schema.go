@@ -0,0 +1,264 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// CompositeSchema provides the types of the spec and status of the composite resource. When a type is
+// supplied, the corresponding part of the observed composite is converted using it, skipping type inference.
+// Observed resources are always converted with inferred types.
+// Attributes that are defined by the schema but not set in the composite are missing, as they are without a
+// schema, such that expressions like try(req.composite.spec.x, default) still return their defaults.
+type CompositeSchema struct {
+	Spec   cty.Type // type of the composite spec, cty.NilType to infer it
+	Status cty.Type // type of the composite status, cty.NilType to infer it
+}
+
+// TypeFromOpenAPISchema returns the cty type for the supplied OpenAPI v3 schema, such as the one found in
+// a composite resource definition. Objects that preserve unknown fields or have no properties are typed
+// dynamically, since their shape cannot be known in advance.
+func TypeFromOpenAPISchema(schema map[string]any) (cty.Type, error) {
+	return typeFromSchema(schema, "")
+}
+
+func typeFromSchema(schema map[string]any, path string) (cty.Type, error) {
+	if preserve, _ := schema["x-kubernetes-preserve-unknown-fields"].(bool); preserve {
+		return cty.DynamicPseudoType, nil
+	}
+	if intOrString, _ := schema["x-kubernetes-int-or-string"].(bool); intOrString {
+		return cty.DynamicPseudoType, nil
+	}
+	t, _ := schema["type"].(string)
+	switch t {
+	case "string":
+		return cty.String, nil
+	case "integer", "number":
+		return cty.Number, nil
+	case "boolean":
+		return cty.Bool, nil
+	case "array":
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return cty.List(cty.DynamicPseudoType), nil
+		}
+		et, err := typeFromSchema(items, path+"[]")
+		if err != nil {
+			return cty.NilType, err
+		}
+		return cty.List(et), nil
+	case "object", "":
+		props, _ := schema["properties"].(map[string]any)
+		if len(props) == 0 {
+			if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				et, err := typeFromSchema(additional, path+".*")
+				if err != nil {
+					return cty.NilType, err
+				}
+				return cty.Map(et), nil
+			}
+			return cty.DynamicPseudoType, nil
+		}
+		attrs := map[string]cty.Type{}
+		for name, p := range props {
+			ps, ok := p.(map[string]any)
+			if !ok {
+				return cty.NilType, fmt.Errorf("schema for property %s is not an object", path+"."+name)
+			}
+			at, err := typeFromSchema(ps, path+"."+name)
+			if err != nil {
+				return cty.NilType, err
+			}
+			attrs[name] = at
+		}
+		return cty.Object(attrs), nil
+	default:
+		return cty.NilType, fmt.Errorf("unsupported schema type %q at %s", t, displayPath(path))
+	}
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "the root"
+	}
+	return path
+}
+
+// compositeToValue converts the observed composite to a cty value, using the types in the composite schema, if
+// any, for its spec and status. Values are converted directly, without the JSON round trip and type inference that
+// the conversion without a schema needs. Values that do not conform to the schema are converted using inferred
+// types.
+func (e *Evaluator) compositeToValue(composite Object) (cty.Value, error) {
+	if e.compositeSchema == nil {
+		return toCtyValue(composite)
+	}
+	typeFor := map[string]cty.Type{
+		"spec":   e.compositeSchema.Spec,
+		"status": e.compositeSchema.Status,
+	}
+	attrs := DynamicObject{}
+	for k, v := range composite {
+		t := typeFor[k]
+		if t == cty.NilType {
+			t = cty.DynamicPseudoType
+		}
+		val, err := valueOfType(v, t)
+		if err != nil && typeFor[k] != cty.NilType {
+			e.log.Debug("composite does not match schema, inferring type", "attribute", k, "error", err.Error())
+			val, err = impliedValue(v)
+		}
+		if err != nil {
+			return cty.NilVal, errors.Wrapf(err, "convert composite %s", k)
+		}
+		attrs[k] = val
+	}
+	return cty.ObjectVal(attrs), nil
+}
+
+// valueOfType converts the supplied value to a cty value according to the supplied schema type. Attributes of
+// objects that the value does not have are left out, such that they are missing instead of null, and values that
+// the schema does not type are converted with inferred types.
+func valueOfType(v any, t cty.Type) (cty.Value, error) {
+	if t == cty.DynamicPseudoType {
+		return impliedValue(v)
+	}
+	if v == nil {
+		return cty.NullVal(t), nil
+	}
+	switch {
+	case t.IsObjectType():
+		m, ok := v.(map[string]any)
+		if !ok {
+			return cty.NilVal, fmt.Errorf("expected an object, got %T", v)
+		}
+		attrs := make(map[string]cty.Value, len(m))
+		for k, av := range m {
+			var val cty.Value
+			var err error
+			if t.HasAttribute(k) {
+				val, err = valueOfType(av, t.AttributeType(k))
+			} else {
+				val, err = impliedValue(av)
+			}
+			if err != nil {
+				return cty.NilVal, errors.Wrap(err, k)
+			}
+			attrs[k] = val
+		}
+		return cty.ObjectVal(attrs), nil
+	case t.IsMapType():
+		m, ok := v.(map[string]any)
+		if !ok {
+			return cty.NilVal, fmt.Errorf("expected a map, got %T", v)
+		}
+		if len(m) == 0 {
+			return cty.MapValEmpty(t.ElementType()), nil
+		}
+		attrs := make(map[string]cty.Value, len(m))
+		for k, ev := range m {
+			val, err := valueOfType(ev, t.ElementType())
+			if err != nil {
+				return cty.NilVal, errors.Wrap(err, k)
+			}
+			attrs[k] = val
+		}
+		if sameTypes(maps.Values(attrs)) {
+			return cty.MapVal(attrs), nil
+		}
+		return cty.ObjectVal(attrs), nil
+	case t.IsListType():
+		l, ok := v.([]any)
+		if !ok {
+			return cty.NilVal, fmt.Errorf("expected a list, got %T", v)
+		}
+		if len(l) == 0 {
+			return cty.ListValEmpty(t.ElementType()), nil
+		}
+		vals := make([]cty.Value, len(l))
+		for i, ev := range l {
+			val, err := valueOfType(ev, t.ElementType())
+			if err != nil {
+				return cty.NilVal, errors.Wrapf(err, "[%d]", i)
+			}
+			vals[i] = val
+		}
+		if sameTypes(slices.Values(vals)) {
+			return cty.ListVal(vals), nil
+		}
+		return cty.TupleVal(vals), nil
+	}
+	val, err := impliedValue(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return convert.Convert(val, t)
+}
+
+// sameTypes returns true when all the supplied values have the same type.
+func sameTypes(vals iter.Seq[cty.Value]) bool {
+	var first cty.Type
+	for v := range vals {
+		if first == cty.NilType {
+			first = v.Type()
+			continue
+		}
+		if !v.Type().Equals(first) {
+			return false
+		}
+	}
+	return true
+}
+
+// impliedValue converts the supplied value to a cty value with the type that is inferred when converting it from
+// JSON, without marshaling it. Values of types that are not produced by unmarshaling JSON are converted through it.
+func impliedValue(v any) (cty.Value, error) {
+	switch x := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case string:
+		return cty.StringVal(x), nil
+	case bool:
+		return cty.BoolVal(x), nil
+	case float64:
+		// parse the shortest representation of the number, exactly as it is parsed from JSON
+		return cty.ParseNumberVal(strconv.FormatFloat(x, 'g', -1, 64))
+	case map[string]any:
+		attrs := make(map[string]cty.Value, len(x))
+		for k, av := range x {
+			val, err := impliedValue(av)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[k] = val
+		}
+		return cty.ObjectVal(attrs), nil
+	case []any:
+		vals := make([]cty.Value, len(x))
+		for i, ev := range x {
+			val, err := impliedValue(ev)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = val
+		}
+		return cty.TupleVal(vals), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	t, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(b, t)
+}
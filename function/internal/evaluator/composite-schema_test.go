@@ -0,0 +1,239 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+func TestTypeFromOpenAPISchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   map[string]any
+		expected cty.Type
+		err      string
+	}{
+		{
+			name: "object with properties",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"region":  map[string]any{"type": "string"},
+					"count":   map[string]any{"type": "integer"},
+					"enabled": map[string]any{"type": "boolean"},
+					"zones":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					"tags":    map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+				},
+			},
+			expected: cty.Object(map[string]cty.Type{
+				"region":  cty.String,
+				"count":   cty.Number,
+				"enabled": cty.Bool,
+				"zones":   cty.List(cty.String),
+				"tags":    cty.Map(cty.String),
+			}),
+		},
+		{
+			name:     "preserve unknown fields",
+			schema:   map[string]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+			expected: cty.DynamicPseudoType,
+		},
+		{
+			name:     "object without properties",
+			schema:   map[string]any{"type": "object"},
+			expected: cty.DynamicPseudoType,
+		},
+		{
+			name: "bad type",
+			schema: map[string]any{
+				"properties": map[string]any{"foo": map[string]any{"type": "bar"}},
+			},
+			err: `unsupported schema type "bar" at .foo`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typ, err := TypeFromOpenAPISchema(test.schema)
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, test.expected.Equals(typ), "got %s", typ.GoString())
+		})
+	}
+}
+
+func TestCompositeToValueWithSchema(t *testing.T) {
+	e := createTestEvaluator(t)
+	e.compositeSchema = &CompositeSchema{
+		Spec: cty.Object(map[string]cty.Type{
+			"region": cty.String,
+			"size":   cty.Number,
+		}),
+		Status: cty.Object(map[string]cty.Type{
+			"ready": cty.Bool,
+		}),
+	}
+	v, err := e.compositeToValue(Object{
+		"apiVersion": "example.com/v1",
+		"spec":       map[string]any{"region": "us-east-1"},
+		"status":     map[string]any{"ready": true, "extra": "not in schema"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/v1", v.GetAttr("apiVersion").AsString())
+	spec := v.GetAttr("spec")
+	assert.Equal(t, "us-east-1", spec.GetAttr("region").AsString())
+	assert.False(t, spec.Type().HasAttribute("size"))
+	assert.True(t, spec.Type().AttributeType("region").Equals(cty.String))
+	status := v.GetAttr("status")
+	assert.Equal(t, "not in schema", status.GetAttr("extra").AsString())
+}
+
+func TestCompositeToValueSchemaMismatch(t *testing.T) {
+	composite, schema := largeComposite(2)
+	e, err := New(Options{CompositeSchema: schema})
+	require.NoError(t, err)
+	v, err := e.compositeToValue(composite)
+	require.NoError(t, err)
+	assert.True(t, v.GetAttr("spec").GetAttr("subnets").Type().IsListType())
+
+	// values that do not conform to the schema are converted with inferred types
+	composite["spec"] = map[string]any{"region": "us-east-1", "subnets": "none"}
+	v, err = e.compositeToValue(composite)
+	require.NoError(t, err)
+	assert.Equal(t, "none", v.GetAttr("spec").GetAttr("subnets").AsString())
+}
+
+func TestCompositeSchemaTryDefaults(t *testing.T) {
+	specType, err := TypeFromOpenAPISchema(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"region": map[string]any{"type": "string"},
+			"size":   map[string]any{"type": "integer"},
+			"tags":   map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			"zones":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"extra":  map[string]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+		},
+	})
+	require.NoError(t, err)
+	e, err := New(Options{CompositeSchema: &CompositeSchema{Spec: specType}})
+	require.NoError(t, err)
+	v, err := e.compositeToValue(Object{
+		"spec": map[string]any{
+			"region": "us-east-1",
+			"zones":  []any{"a", "b"},
+			"extra":  map[string]any{"nested": map[string]any{"x": 1}},
+		},
+	})
+	require.NoError(t, err)
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{"req": cty.ObjectVal(map[string]cty.Value{"composite": v})},
+		Functions: map[string]function.Function{"try": tryfunc.TryFunc},
+	}
+	for expr, expected := range map[string]cty.Value{
+		`try(req.composite.spec.size, 3)`:           cty.NumberIntVal(3),
+		`try(req.composite.spec.tags.team, "none")`: cty.StringVal("none"),
+		`try(req.composite.spec.region, "none")`:    cty.StringVal("us-east-1"),
+		`req.composite.spec.zones[1]`:               cty.StringVal("b"),
+		`req.composite.spec.extra.nested.x`:         cty.NumberIntVal(1),
+	} {
+		x, diags := hclsyntax.ParseExpression([]byte(expr), "test.hcl", hcl.Pos{Line: 1, Column: 1})
+		require.Empty(t, diags)
+		val, diags := x.Value(ctx)
+		require.Empty(t, diags, expr)
+		assert.True(t, expected.RawEquals(val), "%s: got %#v", expr, val)
+	}
+}
+
+// inferredValue converts the supplied value the way it is converted without a schema, through JSON.
+func inferredValue(t testing.TB, v any) cty.Value {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	typ, err := ctyjson.ImpliedType(b)
+	require.NoError(t, err)
+	val, err := ctyjson.Unmarshal(b, typ)
+	require.NoError(t, err)
+	return val
+}
+
+func TestImpliedValue(t *testing.T) {
+	v := map[string]any{
+		"name":    "xr",
+		"size":    float64(3),
+		"ratio":   0.1,
+		"huge":    1e21,
+		"enabled": true,
+		"missing": nil,
+		"zones":   []any{"a", float64(1), nil, map[string]any{}},
+		"empty":   []any{},
+		"nested":  map[string]any{"tags": map[string]any{"team": "x"}},
+	}
+	val, err := impliedValue(v)
+	require.NoError(t, err)
+	expected := inferredValue(t, v)
+	assert.True(t, expected.RawEquals(val), "expected %#v, got %#v", expected, val)
+}
+
+// largeComposite returns a composite with the supplied number of entries in the lists of its spec and status.
+func largeComposite(n int) (Object, *CompositeSchema) {
+	var subnets, statuses []any
+	for i := range n {
+		subnets = append(subnets, map[string]any{
+			"name": fmt.Sprintf("subnet-%d", i),
+			"cidr": fmt.Sprintf("10.0.%d.0/24", i),
+			"zone": "us-east-1a",
+			"size": float64(256),
+			"tags": map[string]any{"team": "network", "index": fmt.Sprint(i)},
+		})
+		statuses = append(statuses, map[string]any{"id": fmt.Sprintf("subnet-%08d", i), "ready": true})
+	}
+	composite := Object{
+		"apiVersion": "example.com/v1",
+		"kind":       "XNetwork",
+		"metadata":   map[string]any{"name": "xr", "labels": map[string]any{"app": "network"}},
+		"spec":       map[string]any{"region": "us-east-1", "subnets": subnets},
+		"status":     map[string]any{"subnets": statuses},
+	}
+	subnet := cty.Object(map[string]cty.Type{
+		"name": cty.String,
+		"cidr": cty.String,
+		"zone": cty.String,
+		"size": cty.Number,
+		"tags": cty.Map(cty.String),
+	})
+	status := cty.Object(map[string]cty.Type{"id": cty.String, "ready": cty.Bool})
+	return composite, &CompositeSchema{
+		Spec:   cty.Object(map[string]cty.Type{"region": cty.String, "subnets": cty.List(subnet)}),
+		Status: cty.Object(map[string]cty.Type{"subnets": cty.List(status)}),
+	}
+}
+
+func BenchmarkCompositeToValue(b *testing.B) {
+	composite, schema := largeComposite(500)
+	b.Run("inferred", func(b *testing.B) {
+		for range b.N {
+			inferredValue(b, composite)
+		}
+	})
+	b.Run("schema", func(b *testing.B) {
+		e, err := New(Options{CompositeSchema: schema})
+		require.NoError(b, err)
+		b.ResetTimer()
+		for range b.N {
+			if _, err := e.compositeToValue(composite); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -2,37 +2,60 @@ package evaluator
 
 import (
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
 	"github.com/hashicorp/hcl/v2"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
+// compositeDiscardTypes maps the labels of composite blocks to the discard type used when they are not processed.
+var compositeDiscardTypes = map[string]DiscardType{
+	blockLabelStatus:     discardTypeStatus,
+	blockLabelConnection: discardTypeConnection,
+	blockLabelSpec:       discardTypeSpec,
+}
+
 func (e *Evaluator) processComposite(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
 	content, diags := block.Body.Content(compositeSchema())
 	if diags.HasErrors() {
 		return diags
 	}
 
+	what := block.Labels[0]
+	if what == blockLabelConnection {
+		ctx = e.credentialsContext(ctx)
+	}
 	ctx, ds := e.processLocals(ctx, content)
 	diags = diags.Extend(ds)
 	if ds.HasErrors() {
 		return ds
 	}
 
+	if dt, ok := compositeDiscardTypes[what]; ok {
+		ready, ds := e.waitFor(ctx, content, dt, "")
+		diags = diags.Extend(ds)
+		if !ready {
+			return diags
+		}
+	}
+
 	values := content.Attributes[attrBody].Expr
-	what := block.Labels[0]
 	switch what {
 	case blockLabelStatus:
 		diags = diags.Extend(e.addStatus(ctx, values))
 	case blockLabelConnection:
 		diags = diags.Extend(e.addConnectionDetails(ctx, values))
+	case blockLabelSpec:
+		diags = diags.Extend(e.addSpec(ctx, values))
 	default:
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  fmt.Sprintf("invalid composite label: %s", what),
+			Detail:   hclutils.DidYouMean("", what, []string{blockLabelStatus, blockLabelConnection, blockLabelSpec}),
+			Subject:  ptr(block.LabelRanges[0]),
 		})
 	}
 	return diags
@@ -43,7 +66,83 @@ func (e *Evaluator) addStatus(ctx *hcl.EvalContext, attrs hcl.Expression) hcl.Di
 	if values == nil {
 		return diags
 	}
+	if ds := checkCompositeConflict(blockLabelStatus, values, attrs.Range(), e.compositeStatuses, e.compositeStatusRanges); ds.HasErrors() {
+		return diags.Extend(ds)
+	}
 	e.compositeStatuses = append(e.compositeStatuses, values)
+	e.compositeStatusRanges = append(e.compositeStatusRanges, attrs.Range())
+	return diags
+}
+
+// checkCompositeConflict returns an error when the supplied values of a composite block cannot be unified with
+// the values of a block processed earlier, such that the error points to both blocks and not just the key.
+func checkCompositeConflict(what string, values Object, r hcl.Range, previous []Object, ranges []hcl.Range) hcl.Diagnostics {
+	for i, prev := range previous {
+		if _, err := unify(prev, values); err != nil {
+			return hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("conflicting values for composite %s", what),
+				Detail:   fmt.Sprintf("the values conflict with the ones at %s: %s", ranges[i], err.Error()),
+				Subject:  ptr(r),
+			}}
+		}
+	}
+	return nil
+}
+
+// crossplaneSpecFields are the paths of the composite spec fields that Crossplane manages. Functions must not
+// change them since Crossplane would either overwrite the values or act on values it did not set.
+var crossplaneSpecFields = [][]string{
+	{"claimRef"},
+	{"resourceRefs"},
+	{"crossplane", "claimRef"},
+	{"crossplane", "resourceRefs"},
+}
+
+// lookupObject returns the value at the supplied path of the object.
+func lookupObject(obj Object, path []string) (any, bool) {
+	var v any = obj
+	for _, p := range path {
+		m, ok := v.(Object)
+		if !ok {
+			return nil, false
+		}
+		v, ok = m[p]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func (e *Evaluator) addSpec(ctx *hcl.EvalContext, attrs hcl.Expression) hcl.Diagnostics {
+	values, diags := e.attributesToValueMap(ctx, attrs, discardTypeSpec)
+	if values == nil {
+		return diags
+	}
+	for _, path := range crossplaneSpecFields {
+		desired, ok := lookupObject(values, path)
+		if !ok {
+			continue
+		}
+		// setting the same value is harmless, and allows the observed spec to be copied
+		if observed, ok := lookupObject(e.observedCompositeSpec, path); ok && reflect.DeepEqual(desired, observed) {
+			continue
+		}
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("composite field spec.%s is managed by Crossplane and cannot be changed", strings.Join(path, ".")),
+			Subject:  ptr(attrs.Range()),
+		})
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+	if ds := checkCompositeConflict(blockLabelSpec, values, attrs.Range(), e.compositeSpecs, e.compositeSpecRanges); ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	e.compositeSpecs = append(e.compositeSpecs, values)
+	e.compositeSpecRanges = append(e.compositeSpecRanges, attrs.Range())
 	return diags
 }
 
@@ -55,12 +154,12 @@ func (e *Evaluator) addConnectionDetails(ctx *hcl.EvalContext, attrs hcl.Express
 
 	values := map[string][]byte{}
 	hasDiscards := false
-	for name, v := range out {
-		val, ok := v.(string)
+	for _, name := range sortedKeys(out) {
+		val, ok := out[name].(string)
 		if !ok {
 			diags = diags.Append(&hcl.Diagnostic{
 				Severity: hcl.DiagError,
-				Summary:  fmt.Sprintf("connection key %q was not a string, got %T", name, v),
+				Summary:  fmt.Sprintf("connection key %q was not a string, got %T", name, out[name]),
 			})
 			// continue processing to collect additional warnings and errors
 			continue
@@ -106,6 +205,8 @@ func (e *Evaluator) attributesToValueMap(ctx *hcl.EvalContext, expr hcl.Expressi
 		// remap errors to warnings as we'll handle discarded objects later
 		return nil, hclutils.DowngradeDiags(diags)
 	}
+	// marks only guard against displaying values, they are not part of the output
+	value, _ = value.UnmarkDeep()
 	b, err := ctyjson.Marshal(value, value.Type())
 	if err != nil {
 		return nil, diags.Append(&hcl.Diagnostic{
@@ -114,8 +215,11 @@ func (e *Evaluator) attributesToValueMap(ctx *hcl.EvalContext, expr hcl.Expressi
 			Subject:  ptr(expr.Range()),
 		})
 	}
-	var ret Object
-	err = json.Unmarshal(b, &ret)
+	v, err := unmarshalJSON(b)
+	ret, ok := v.(Object)
+	if err == nil && v != nil && !ok {
+		err = fmt.Errorf("expected an object, got %T", v)
+	}
 	if err != nil {
 		return nil, diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -131,6 +133,38 @@ resource "database" {
 	assert.Equal(t, []byte("host"), connections["host"])
 }
 
+func TestEvaluator_ProcessComposite_ConnectionCredentials(t *testing.T) {
+	hclContent := `
+composite "connection" {
+  locals {
+    value = from_credentials("my-store", "api-key")
+  }
+  body = {
+    key = value
+  }
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	evaluator.credentials = map[string]map[string][]byte{"my-store": {"api-key": []byte("s3cr3t")}}
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	diags := evaluator.processGroup(ctx, content)
+	require.Empty(t, diags)
+	require.Len(t, evaluator.compositeConnections, 1)
+	assert.Equal(t, []byte("s3cr3t"), evaluator.compositeConnections[0]["key"])
+
+	// the value is redacted even though the name of the local does not suggest a credential
+	expr, diags := hclsyntax.ParseExpression([]byte(`from_credentials("my-store", "api-key")`), "test.hcl", hcl.InitialPos)
+	require.Empty(t, diags)
+	v, diags := expr.Value(evaluator.credentialsContext(ctx))
+	require.Empty(t, diags)
+	assert.True(t, v.IsMarked())
+	assert.Equal(t, redactedValue, previewValue(v))
+	assert.Equal(t, redactedValue, describeLocal("value", v))
+}
+
 func TestEvaluator_ProcessComposite_ConnectionInvalidBase64(t *testing.T) {
 	hclContent := `
 resource "database" {
@@ -234,6 +268,112 @@ resource "backend" {
 	assert.Equal(t, "common-value", backendStatus["shared_config"])
 }
 
+func TestEvaluator_ProcessComposite_Group(t *testing.T) {
+	hclContent := `
+group {
+  locals {
+    tier = "network"
+  }
+
+  resource "vpc" {
+    body = {
+      apiVersion = "ec2.aws.upbound.io/v1beta1"
+      kind       = "VPC"
+    }
+  }
+
+  resource "subnet" {
+    body = {
+      apiVersion = "ec2.aws.upbound.io/v1beta1"
+      kind       = "Subnet"
+    }
+  }
+
+  composite "status" {
+    body = {
+      network = { tier = tier, resources = 2 }
+    }
+  }
+
+  group {
+    locals {
+      zone = "a"
+    }
+    composite "status" {
+      body = {
+        network = { zone = zone }
+      }
+    }
+  }
+}
+
+group {
+  condition = false
+  composite "status" {
+    body = {
+      network = { tier = "skipped" }
+    }
+  }
+}
+
+composite "status" {
+  body = {
+    network = { tier = "network" }
+  }
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	diags := evaluator.processGroup(ctx, content)
+	require.Empty(t, diags)
+
+	// blocks of a group whose condition is false are not processed
+	require.Len(t, evaluator.compositeStatuses, 3)
+	status, err := unify(evaluator.compositeStatuses...)
+	require.NoError(t, err)
+	assert.Equal(t, Object{
+		"network": Object{"tier": "network", "resources": float64(2), "zone": "a"},
+	}, status)
+}
+
+func TestEvaluator_ProcessComposite_Conflict(t *testing.T) {
+	hclContent := `
+group {
+  locals {
+    replicas = 3
+  }
+  composite "status" {
+    body = {
+      app = { replicas = replicas }
+    }
+  }
+}
+
+resource "app" {
+  body = {
+    apiVersion = "apps/v1"
+    kind       = "Deployment"
+  }
+  composite "status" {
+    body = {
+      app = { replicas = 2 }
+    }
+  }
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	diags := evaluator.processGroup(ctx, content)
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "test.hcl:19,12-21,6: conflicting values for composite status; the values conflict with the ones at test.hcl:7,12-9,6: values for key app.replicas not equal", diags.Errs()[0].Error())
+}
+
 func TestEvaluator_ProcessComposite_StatusIncomplete(t *testing.T) {
 	hclContent := `
 resource "incomplete-status" {
@@ -326,6 +466,68 @@ resources "workers" {
 	assert.Contains(t, status, "workers_created")
 }
 
+func TestEvaluator_ProcessComposite_Spec(t *testing.T) {
+	hclContent := `
+composite spec {
+  body = {
+    crossplane = {
+      compositionRevisionRef = { name = "network-abc123" }
+    }
+  }
+}
+composite spec {
+  body = {
+    crossplane = {
+      resourceRefs = [{ name = "vpc" }]
+    }
+  }
+}
+composite status {
+  body = { ready = true }
+}
+`
+	evaluator := createTestEvaluator(t)
+	evaluator.observedCompositeSpec = Object{
+		"crossplane": Object{
+			"resourceRefs": []any{Object{"name": "vpc"}},
+		},
+	}
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+	diags := evaluator.processGroup(createTestEvalContext(), content)
+	require.Empty(t, diags)
+
+	res, err := evaluator.toResponse(diags)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"spec": map[string]any{
+			"crossplane": map[string]any{
+				"compositionRevisionRef": map[string]any{"name": "network-abc123"},
+				"resourceRefs":           []any{map[string]any{"name": "vpc"}},
+			},
+		},
+		"status": map[string]any{"ready": true},
+	}, res.GetDesired().GetComposite().GetResource().AsMap())
+}
+
+func TestEvaluator_ProcessComposite_SpecManagedField(t *testing.T) {
+	hclContent := `
+composite spec {
+  body = {
+    resourceRefs = []
+  }
+}
+`
+	evaluator := createTestEvaluator(t)
+	evaluator.observedCompositeSpec = Object{
+		"resourceRefs": []any{Object{"name": "vpc"}},
+	}
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+	diags := evaluator.processGroup(createTestEvalContext(), content)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "composite field spec.resourceRefs is managed by Crossplane and cannot be changed")
+	assert.Empty(t, evaluator.compositeSpecs)
+}
+
 func TestEvaluator_ProcessComposite_InvalidLabel(t *testing.T) {
 	hclContent := `
 resource "test-resource" {
@@ -354,6 +556,23 @@ resource "test-resource" {
 	assert.Contains(t, err.Error(), "invalid composite label")
 }
 
+func TestEvaluator_ProcessComposite_LabelSuggestion(t *testing.T) {
+	hclContent := `
+composite stauts {
+  body = {
+    some_field = "value"
+  }
+}
+`
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	err := evaluator.processGroup(ctx, content)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid composite label: stauts; did you mean "status"?`)
+}
+
 func TestEvaluator_ProcessComposite_ConnectionNonStringValue(t *testing.T) {
 	hclContent := `
 resource "database" {
@@ -0,0 +1,51 @@
+package evaluator
+
+import (
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// namespaceFromContext returns the values under the supplied namespace of the context of a request, or nil if
+// there is no namespace or no values.
+func namespaceFromContext(c *structpb.Struct, namespace string) *structpb.Struct {
+	if namespace == "" {
+		return nil
+	}
+	return c.GetFields()[namespace].GetStructValue()
+}
+
+// requestContext returns the context of the request as seen by the composition. When a context namespace is
+// configured, the values written under it by an earlier run are visible at the top level, taking precedence
+// over keys of the same name written by other functions.
+func (e *Evaluator) requestContext(c *structpb.Struct) *structpb.Struct {
+	if e.namespaceContext == nil {
+		return c
+	}
+	ret := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for k, v := range c.GetFields() {
+		ret.Fields[k] = v
+	}
+	for k, v := range e.namespaceContext.GetFields() {
+		ret.Fields[k] = v
+	}
+	return ret
+}
+
+// namespaceResponseContext nests all keys of the context of the supplied response under the context namespace,
+// if one is configured. Keys in the namespace of the request that are not written again are kept, the same way
+// keys at the top level are kept when the response is merged into the context of the pipeline.
+func (e *Evaluator) namespaceResponseContext(ret *fnv1.RunFunctionResponse) {
+	if e.contextNamespace == "" || ret.Context == nil {
+		return
+	}
+	ns := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for k, v := range e.namespaceContext.GetFields() {
+		ns.Fields[k] = v
+	}
+	for k, v := range ret.Context.GetFields() {
+		ns.Fields[k] = v
+	}
+	ret.Context = &structpb.Struct{Fields: map[string]*structpb.Value{
+		e.contextNamespace: structpb.NewStructValue(ns),
+	}}
+}
@@ -0,0 +1,66 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestContextNamespace(t *testing.T) {
+	hcl := `
+context {
+  key   = "example.com/a"
+  value = "${req.context["example.com/a"]}-new"
+}
+context {
+  key   = "example.com/b"
+  value = req.context.other
+}
+context {
+  key       = "example.com/token"
+  value     = "secret"
+  sensitive = true
+}
+`
+	req := makeRequest(t, baseRequestJSON)
+	var err error
+	req.Context, err = structpb.NewStruct(map[string]any{
+		"other":         "x",
+		"example.com/a": "top",
+		"networking": map[string]any{
+			"example.com/a":    "old",
+			"example.com/keep": "k",
+		},
+	})
+	require.NoError(t, err)
+
+	e, err := evaluator.New(evaluator.Options{ContextNamespace: "networking"})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"networking": map[string]any{
+			"example.com/a":     "old-new",
+			"example.com/b":     "x",
+			"example.com/keep":  "k",
+			"example.com/token": "secret",
+		},
+	}, res.GetContext().AsMap())
+	assert.Equal(t, []string{"networking"}, e.SensitiveContextKeys())
+
+	// without a namespace, keys are written at the top level
+	e, err = evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err = e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"example.com/a":     "top-new",
+		"example.com/b":     "x",
+		"example.com/token": "secret",
+	}, res.GetContext().AsMap())
+	assert.Equal(t, []string{"example.com/token"}, e.SensitiveContextKeys())
+}
@@ -20,6 +20,12 @@ func (e *Evaluator) processContext(ctx *hcl.EvalContext, block *hcl.Block) hcl.D
 		return ds
 	}
 
+	ready, ds := e.waitFor(ctx, content, discardTypeContext, "")
+	diags = diags.Extend(ds)
+	if !ready {
+		return diags
+	}
+
 	ex := content.Attributes[attrKey].Expr
 	key, ds := ex.Value(ctx)
 	diags = diags.Extend(ds)
@@ -47,17 +53,35 @@ func (e *Evaluator) processContext(ctx *hcl.EvalContext, block *hcl.Block) hcl.D
 	}
 	keyString := key.AsString()
 
+	sensitive, ds := e.isSensitiveContext(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+	if sensitive {
+		e.sensitiveContextKeys[keyString] = true
+	}
+
 	ex = content.Attributes[attrValue].Expr
 	val, ds := ex.Value(ctx)
 	if diags.HasErrors() || !val.IsWhollyKnown() {
-		e.discard(DiscardItem{
+		item := DiscardItem{
 			Type:        discardTypeContext,
 			Reason:      discardReasonIncomplete,
 			SourceRange: ex.Range().String(),
 			Context:     e.messagesFromDiags(diags),
-		})
+		}
+		ds = hclutils.DowngradeDiags(ds)
+		// error messages can contain parts of the value, so only keep the location for sensitive values
+		if sensitive {
+			item.Context = nil
+			for _, d := range ds {
+				d.Detail = ""
+			}
+		}
+		e.discard(item)
 		// map unknown context value errors to warnings as we'll handle them later
-		return diags.Extend(hclutils.DowngradeDiags(ds))
+		return diags.Extend(ds)
 	}
 	diags = diags.Extend(ds)
 
@@ -73,3 +97,102 @@ func (e *Evaluator) processContext(ctx *hcl.EvalContext, block *hcl.Block) hcl.D
 	e.contexts = append(e.contexts, Object{keyString: goVal})
 	return diags
 }
+
+// processContexts processes a contexts block, which sets all keys of its body at once. The block is discarded as a
+// whole when any of its values is incomplete, such that either all or none of its keys are set. Its keys are merged
+// with the ones of other context and contexts blocks using the same rules as context blocks.
+func (e *Evaluator) processContexts(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
+	content, diags := block.Body.Content(contextsSchema())
+	if diags.HasErrors() {
+		return diags
+	}
+
+	ctx, ds := e.processLocals(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return ds
+	}
+
+	ready, ds := e.waitFor(ctx, content, discardTypeContext, "")
+	diags = diags.Extend(ds)
+	if !ready {
+		return diags
+	}
+
+	sensitive, ds := e.isSensitiveContext(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+
+	ex := content.Attributes[attrBody].Expr
+	val, ds := ex.Value(ctx)
+	if ds.HasErrors() || !val.IsWhollyKnown() {
+		item := DiscardItem{
+			Type:        discardTypeContext,
+			Reason:      discardReasonIncomplete,
+			SourceRange: ex.Range().String(),
+			Context:     e.messagesFromDiags(ds),
+		}
+		ds = hclutils.DowngradeDiags(ds)
+		// error messages can contain parts of the values, so only keep the location for sensitive values
+		if sensitive {
+			item.Context = nil
+			for _, d := range ds {
+				d.Detail = ""
+			}
+		}
+		e.discard(item)
+		// map unknown context value errors to warnings as we'll handle them later
+		return diags.Extend(ds)
+	}
+	diags = diags.Extend(ds)
+
+	if val.IsNull() || !(val.Type().IsObjectType() || val.Type().IsMapType()) {
+		return diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("contexts body must be an object, got %s", val.Type().FriendlyName()),
+			Subject:  ptr(ex.Range()),
+		})
+	}
+	goVal, err := valueToInterface(val)
+	if err != nil {
+		return diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "cannot convert value to interface",
+			Detail:   err.Error(),
+			Subject:  ptr(ex.Range()),
+		})
+	}
+	values, _ := goVal.(map[string]any)
+	if len(values) == 0 {
+		return diags
+	}
+	if sensitive {
+		for k := range values {
+			e.sensitiveContextKeys[k] = true
+		}
+	}
+	e.contexts = append(e.contexts, values)
+	return diags
+}
+
+// isSensitiveContext returns true if the supplied context content has a sensitive attribute that is true.
+func (e *Evaluator) isSensitiveContext(ctx *hcl.EvalContext, content *hcl.BodyContent) (bool, hcl.Diagnostics) {
+	attr, ok := content.Attributes[attrSensitive]
+	if !ok {
+		return false, nil
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if !val.IsWhollyKnown() || val.IsNull() || val.Type() != cty.Bool {
+		return false, diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("context attribute %q must be a known boolean", attrSensitive),
+			Subject:  ptr(attr.Expr.Range()),
+		})
+	}
+	return val.True(), diags
+}
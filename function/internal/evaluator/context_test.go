@@ -575,3 +575,139 @@ group {
 	assert.Equal(t, "test-app", groupContext["app_name"])
 	assert.Equal(t, float64(1), groupContext["resources_created"])
 }
+
+func TestEvaluator_ProcessContext_Sensitive(t *testing.T) {
+	hclContent := `
+context {
+  key       = "token"
+  value     = "s3cr3t"
+  sensitive = true
+}
+
+context {
+  key       = "public"
+  value     = "hello"
+  sensitive = false
+}
+
+context {
+  key       = "pending"
+  value     = req.composite.status.token
+  sensitive = true
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	diags := evaluator.processGroup(ctx, content)
+	require.Empty(t, diags.Errs())
+
+	// sensitive values are still passed to the context
+	require.Len(t, evaluator.contexts, 2)
+	assert.Equal(t, "s3cr3t", evaluator.contexts[0]["token"])
+	assert.Equal(t, []string{"pending", "token"}, evaluator.SensitiveContextKeys())
+
+	// the discard for the incomplete value does not carry error details
+	require.Len(t, evaluator.discards, 1)
+	assert.Empty(t, evaluator.discards[0].Context)
+	for _, d := range diags {
+		assert.Empty(t, d.Detail)
+	}
+}
+
+func TestEvaluator_ProcessContext_BadSensitive(t *testing.T) {
+	hclContent := `
+context {
+  key       = "token"
+  value     = "s3cr3t"
+  sensitive = "yes"
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	err := evaluator.processGroup(ctx, content)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `context attribute "sensitive" must be a known boolean`)
+}
+
+func TestEvaluator_ProcessContexts(t *testing.T) {
+	hclContent := `
+contexts {
+  locals {
+    name = req.composite.metadata.name
+  }
+  body = {
+    environment = req.composite.spec.environment
+    app         = { name = name }
+  }
+}
+
+context {
+  key   = "app"
+  value = { region = req.composite.spec.region }
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	diags := evaluator.processGroup(ctx, content)
+	require.Empty(t, diags)
+
+	// all keys of a contexts block are added at once and merged with the other context blocks
+	require.Len(t, evaluator.contexts, 2)
+	merged, err := unify(evaluator.contexts...)
+	require.NoError(t, err)
+	assert.Equal(t, Object{
+		"environment": "production",
+		"app":         map[string]any{"name": "my-composite", "region": "us-west-2"},
+	}, merged)
+}
+
+func TestEvaluator_ProcessContexts_IncompleteValue(t *testing.T) {
+	hclContent := `
+contexts {
+  sensitive = true
+  body = {
+    environment = req.composite.spec.environment
+    token       = req.composite.status.token
+  }
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	diags := evaluator.processGroup(ctx, content)
+	require.Empty(t, diags.Errs())
+
+	// none of the keys is added when one of the values is incomplete
+	assert.Empty(t, evaluator.contexts)
+	require.Len(t, evaluator.discards, 1)
+	assert.Equal(t, discardReasonIncomplete, evaluator.discards[0].Reason)
+	assert.Equal(t, discardTypeContext, evaluator.discards[0].Type)
+	assert.Empty(t, evaluator.discards[0].Context)
+}
+
+func TestEvaluator_ProcessContexts_NonObjectBody(t *testing.T) {
+	hclContent := `
+contexts {
+  body = ["environment"]
+}
+`
+
+	evaluator := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, evaluator, hclContent, "test.hcl")
+
+	err := evaluator.processGroup(ctx, content)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "contexts body must be an object, got tuple")
+}
@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// credentialsFunction is the name of the function that returns a value of the credentials supplied to the
+// function, for use in composite connection details.
+const credentialsFunction = "from_credentials"
+
+// sensitiveMark marks values that must never be displayed, such that they are redacted from value previews and
+// snapshots of locals regardless of the names of the locals they are assigned to.
+const sensitiveMark = "sensitive"
+
+// credentialsFromRequest returns the data of the credentials in the supplied request by name.
+func credentialsFromRequest(in *fnv1.RunFunctionRequest) map[string]map[string][]byte {
+	ret := map[string]map[string][]byte{}
+	for name, c := range in.GetCredentials() {
+		ret[name] = c.GetCredentialData().GetData()
+	}
+	return ret
+}
+
+// credentialsContext returns a child of the supplied context that has the function to read credentials. Values
+// are returned in base64 format, such that they can be used as connection details without being decoded, and are
+// marked as sensitive.
+func (e *Evaluator) credentialsContext(ctx *hcl.EvalContext) *hcl.EvalContext {
+	ctx = ctx.NewChild()
+	ctx.Functions = map[string]function.Function{
+		credentialsFunction: function.New(&function.Spec{
+			Description: "returns the base64 encoded value of a key of the named credentials supplied to the function",
+			Params: []function.Parameter{
+				{Name: "name", Type: cty.String},
+				{Name: "key", Type: cty.String},
+			},
+			Type: function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+				name, key := args[0].AsString(), args[1].AsString()
+				data, ok := e.credentials[name]
+				if !ok {
+					return cty.NilVal, function.NewArgErrorf(0, "credentials %q not found, available: %v", name, sortedKeys(e.credentials))
+				}
+				b, ok := data[key]
+				if !ok { // only the keys are listed, never the values
+					return cty.NilVal, function.NewArgErrorf(1, "credentials %q have no key %q, available: %v", name, key, sortedKeys(data))
+				}
+				return cty.StringVal(base64.StdEncoding.EncodeToString(b)).Mark(sensitiveMark), nil
+			},
+		}),
+	}
+	return ctx
+}
+
+// credentialsRule reports calls to read credentials outside of composite connection blocks, such that credentials
+// are never copied into the bodies of resources or the status of the composite.
+type credentialsRule struct {
+	AnalyzerRuleBase
+}
+
+func (credentialsRule) Name() string {
+	return "credentials"
+}
+
+func (credentialsRule) VisitExpression(ctx RuleContext, expr hclsyntax.Expression) hcl.Diagnostics {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != credentialsFunction {
+		return nil
+	}
+	for _, b := range ctx.Blocks {
+		if b.Type == blockComposite && len(b.Labels) > 0 && b.Labels[0] == blockLabelConnection {
+			return nil
+		}
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("%s can only be called in composite connection blocks", credentialsFunction),
+		Subject:  call.NameRange.Ptr(),
+	}}
+}
+
+// checkCredentials returns errors for calls to read credentials outside of composite connection blocks.
+func (a *analyzer) checkCredentials(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(credentialsRule{}).walkContent(nil, content)
+}
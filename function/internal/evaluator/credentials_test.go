@@ -0,0 +1,94 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withCredentials(req *fnv1.RunFunctionRequest) {
+	req.Credentials = map[string]*fnv1.Credentials{
+		"my-store": {
+			Source: &fnv1.Credentials_CredentialData{
+				CredentialData: &fnv1.CredentialData{Data: map[string][]byte{"api-key": []byte("s3cr3t")}},
+			},
+		},
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	hcl := `
+composite connection {
+  locals {
+    store = "my-store"
+  }
+  body = {
+    api-key = from_credentials(store, "api-key")
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withCredentials), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("s3cr3t"), res.GetDesired().GetComposite().GetConnectionDetails()["api-key"])
+}
+
+func TestCredentialsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		contains string
+	}{
+		{
+			name:     "missing credentials",
+			hcl:      `composite connection { body = { key = from_credentials("other", "api-key") } }`,
+			contains: `credentials "other" not found, available: [my-store]`,
+		},
+		{
+			name:     "missing key",
+			hcl:      `composite connection { body = { key = from_credentials("my-store", "token") } }`,
+			contains: `credentials "my-store" have no key "token", available: [api-key]`,
+		},
+		{
+			name:     "outside connection",
+			hcl:      `composite status { body = { key = from_credentials("my-store", "api-key") } }`,
+			contains: "Call to unknown function",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withCredentials), evaluator.File{Name: "main.hcl", Content: test.hcl})
+			require.NoError(t, err)
+			require.NotEmpty(t, res.GetResults())
+			assert.Contains(t, res.GetResults()[0].GetMessage(), test.contains)
+			assert.Empty(t, res.GetDesired().GetComposite().GetConnectionDetails())
+			assert.NotContains(t, res.String(), "s3cr3t")
+		})
+	}
+}
+
+func TestCredentialsAnalyze(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+composite connection {
+  body = { api-key = from_credentials("my-store", "api-key") }
+}
+resource secret {
+  body = {
+    apiVersion = "v1"
+    kind       = "Secret"
+    data       = { api-key = from_credentials("my-store", "api-key") }
+  }
+}
+`})
+	require.Len(t, diags, 1)
+	assert.Equal(t, "main.hcl:9,30-46: from_credentials can only be called in composite connection blocks; ", diags[0].Error())
+}
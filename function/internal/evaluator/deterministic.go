@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// attrDeterministic is the attribute that allows calls to non-deterministic functions in a group, resource or
+// resource collection when set to false.
+const attrDeterministic = "deterministic"
+
+// nondeterministicFunctions are the functions that return a different value for every call. Their output changes
+// desired state on every reconcile, which causes the composed resources to be updated forever.
+var nondeterministicFunctions = map[string]bool{
+	"timestamp": true,
+	"uuid":      true,
+	"bcrypt":    true,
+}
+
+// allowsNondeterminism returns true if the supplied content has a deterministic attribute set to false.
+func allowsNondeterminism(content *hcl.BodyContent, blockType string) (bool, hcl.Diagnostics) {
+	deterministic, diags := constantBoolAttr(content, attrDeterministic, blockType, true)
+	return !deterministic, diags
+}
+
+// enterDeterminism marks calls to non-deterministic functions as allowed while a block whose content allows
+// them is processed. It returns a function that must be called when processing of the block is done.
+func (e *Evaluator) enterDeterminism(content *hcl.BodyContent, blockType string) (func(), hcl.Diagnostics) {
+	allowed, diags := allowsNondeterminism(content, blockType)
+	if diags.HasErrors() || !allowed {
+		return func() {}, diags
+	}
+	e.nondeterministicDepth++
+	return func() { e.nondeterministicDepth-- }, nil
+}
+
+// recordNondeterministicCall records a call to the named non-deterministic function unless it is made while a
+// block that allows such calls is processed.
+func (e *Evaluator) recordNondeterministicCall(name string) {
+	if e.nondeterministicDepth == 0 {
+		e.nondeterministicCalls[name] = true
+	}
+}
+
+// checkDeterminism returns an error if non-deterministic functions were called outside of blocks that allow them.
+// Evaluation fails rather than rendering such values, since a changed resource that is rendered again on every
+// reconcile is never stable.
+func (e *Evaluator) checkDeterminism() hcl.Diagnostics {
+	if len(e.nondeterministicCalls) == 0 {
+		return nil
+	}
+	names := sortedKeys(e.nondeterministicCalls)
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("non-deterministic functions called: %s", strings.Join(names, ", ")),
+		Detail:   fmt.Sprintf("set %s = false on the enclosing group, resource or resources block to allow them", attrDeterministic),
+	}}
+}
+
+// deterministicRule reports calls to non-deterministic functions outside of blocks that allow them.
+type deterministicRule struct {
+	AnalyzerRuleBase
+}
+
+func (deterministicRule) Name() string {
+	return "deterministic"
+}
+
+// allowedBlockSchemas are the schemas of the blocks that may allow non-deterministic functions.
+var allowedBlockSchemas = map[string]func() *hcl.BodySchema{
+	blockGroup:     groupSchema,
+	blockResource:  resourceSchema,
+	blockResources: resourcesSchema,
+}
+
+func (deterministicRule) VisitExpression(ctx RuleContext, expr hclsyntax.Expression) hcl.Diagnostics {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || !nondeterministicFunctions[call.Name] {
+		return nil
+	}
+	for _, b := range ctx.Blocks {
+		// user functions are evaluated outside the blocks that call them and can never allow these calls
+		if b.Type == blockFunction {
+			return hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("call to non-deterministic function %s in user function %s", call.Name, b.Labels[0]),
+				Detail:   "user functions may only call deterministic functions",
+				Subject:  call.NameRange.Ptr(),
+			}}
+		}
+		schema, ok := allowedBlockSchemas[b.Type]
+		if !ok {
+			continue
+		}
+		content, _, _ := b.Body.PartialContent(schema())
+		if allowed, _ := allowsNondeterminism(content, b.Type); allowed {
+			return nil
+		}
+	}
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("call to non-deterministic function %s", call.Name),
+		Detail: fmt.Sprintf("its value changes on every evaluation such that resources are updated forever; "+
+			"set %s = false on the enclosing group, resource or resources block to allow it", attrDeterministic),
+		Subject: call.NameRange.Ptr(),
+	}}
+}
+
+// checkDeterministic returns errors for calls to non-deterministic functions outside of blocks that allow them.
+func (a *analyzer) checkDeterministic(content *hcl.BodyContent) hcl.Diagnostics {
+	return newRuleWalker(deterministicRule{}).walkContent(nil, content)
+}
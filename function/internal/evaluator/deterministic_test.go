@@ -0,0 +1,217 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministic(t *testing.T) {
+	tests := []struct {
+		name string
+		hcl  string
+		err  string
+	}{
+		{
+			name: "allowed in resource",
+			hcl: `
+resource cm {
+  deterministic = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = timestamp() }
+  }
+}
+`,
+		},
+		{
+			name: "allowed in group",
+			hcl: `
+group {
+  deterministic = false
+  locals {
+    now = timestamp()
+  }
+  resource cm {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { created = now }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "not allowed",
+			hcl: `
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = timestamp() }
+  }
+}
+`,
+			err: "non-deterministic functions called: timestamp",
+		},
+		{
+			name: "not allowed after allowed block",
+			hcl: `
+resource allowed {
+  deterministic = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = timestamp() }
+  }
+}
+resources cms {
+  for_each = ["a"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { created = timestamp() }
+    }
+  }
+}
+`,
+			err: "non-deterministic functions called: timestamp",
+		},
+		{
+			name: "allowed in lambda",
+			hcl: `
+resource cm {
+  deterministic = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = apply(lambda(["x"], "timestamp()"), 1) }
+  }
+}
+`,
+		},
+		{
+			name: "not allowed in lambda",
+			hcl: `
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = apply(lambda(["x"], "timestamp()"), 1) }
+  }
+}
+`,
+			err: "non-deterministic functions called: timestamp",
+		},
+		{
+			name: "not allowed in user function",
+			hcl: `
+function stamp {
+  body = timestamp()
+}
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = invoke("stamp", {}) }
+  }
+}
+`,
+			err: "non-deterministic functions called: timestamp",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: test.hcl})
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, res.GetDesired().GetResources())
+		})
+	}
+}
+
+func TestDeterministicProgram(t *testing.T) {
+	p, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: "main.hcl", Content: `
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = apply(lambda(["x"], "timestamp()"), 1) }
+  }
+}
+`})
+	require.NoError(t, err)
+	for range 2 {
+		e, err := p.NewEvaluator()
+		require.NoError(t, err)
+		_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "non-deterministic functions called: timestamp")
+	}
+}
+
+func TestDeterministicAnalyze(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+locals {
+  now = timestamp()
+}
+group {
+  deterministic = false
+  resource allowed {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { created = timestamp() }
+    }
+  }
+}
+resource cm {
+  deterministic = req.composite.spec.allowed
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = now }
+  }
+}
+`})
+	var errors []string
+	for _, d := range diags {
+		if d.Severity == hcl.DiagError {
+			errors = append(errors, d.Error())
+		}
+	}
+	assert.Equal(t, []string{
+		"main.hcl:16,19-45: deterministic in resource block is not a constant bool; ",
+	}, errors)
+
+	diags = e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+function stamp {
+  body = timestamp()
+}
+`})
+	require.Len(t, diags, 1)
+	assert.Equal(t, "main.hcl:3,10-19: call to non-deterministic function timestamp in user function stamp; user functions may only call deterministic functions", diags[0].Error())
+
+	diags = e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+locals {
+  now = timestamp()
+}
+`})
+	require.Len(t, diags, 1)
+	assert.Equal(t, "main.hcl:3,9-18: call to non-deterministic function timestamp; its value changes on every evaluation such that resources are updated forever; set deterministic = false on the enclosing group, resource or resources block to allow it", diags[0].Error())
+}
@@ -0,0 +1,44 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func docSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrTitle},
+			{Name: attrDescription},
+		},
+	}
+}
+
+// docBlock returns the title and description of the composition declared in the supplied doc block, which must be
+// constant strings. The block does not affect evaluation.
+func docBlock(block *hcl.Block) (title, description string, diags hcl.Diagnostics) {
+	content, diags := block.Body.Content(docSchema())
+	if diags.HasErrors() {
+		return "", "", diags
+	}
+	title, ds := constantString(content, attrTitle, blockDoc)
+	diags = diags.Extend(ds)
+	description, ds = constantString(content, attrDescription, blockDoc)
+	return title, description, diags.Extend(ds)
+}
+
+// addDoc checks the supplied doc block and records that the composition has one.
+func (a *analyzer) addDoc(block *hcl.Block) hcl.Diagnostics {
+	_, _, diags := docBlock(block)
+	if diags.HasErrors() {
+		return diags
+	}
+	if a.docRange != nil {
+		return hclutils.ToErrorDiag(fmt.Sprintf("duplicate %s block", blockDoc),
+			fmt.Sprintf("the composition is already documented at %s", a.docRange), block.DefRange)
+	}
+	a.docRange = &block.DefRange
+	return nil
+}
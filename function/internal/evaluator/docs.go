@@ -0,0 +1,238 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ArgDoc documents an argument of a user function.
+type ArgDoc struct {
+	Name        string // argument name
+	Description string // optional description
+	Default     string // default value in HCL syntax, empty when the argument is required
+}
+
+// FunctionDoc documents a user function.
+type FunctionDoc struct {
+	Name        string    // function name
+	Description string    // optional description
+	Args        []ArgDoc  // arguments sorted by name
+	Range       hcl.Range // source range of the declaration
+}
+
+// ObjectDoc documents a resource, resource collection or requirement.
+type ObjectDoc struct {
+	Name        string    // name of the object, including the name prefixes of enclosing groups
+	APIVersion  string    // API version, when it is a constant
+	Kind        string    // kind, when it is a constant
+	Conditional bool      // whether the object, or a group that contains it, has a condition
+	Range       hcl.Range // source range of the declaration
+}
+
+// Documentation describes a composition along with its user functions, parameters and objects.
+type Documentation struct {
+	Title        string        // title from the doc block, if any
+	Description  string        // description from the doc block, if any
+	Functions    []FunctionDoc // user functions sorted by name
+	Parameters   []string      // paths under the composite spec that are referenced, like spec.parameters.region
+	Resources    []ObjectDoc   // resources in source order
+	Collections  []ObjectDoc   // resource collections in source order
+	Requirements []ObjectDoc   // requirements in source order
+}
+
+// Document returns documentation for the composition implemented by the supplied files. Blocks that are
+// not enabled for the feature flags of the evaluator are not documented.
+func (e *Evaluator) Document(files ...File) (*Documentation, hcl.Diagnostics) {
+	content, diags := e.toContent(files)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	p := functions.NewProcessor()
+	if ds := p.Process(content); ds.HasErrors() {
+		return nil, diags.Extend(ds)
+	}
+	aliases, ds := collectAliases(content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return nil, diags
+	}
+
+	ret := &Documentation{}
+	for _, fn := range p.Functions {
+		ret.Functions = append(ret.Functions, functionDoc(fn))
+	}
+	sort.Slice(ret.Functions, func(i, j int) bool {
+		return ret.Functions[i].Name < ret.Functions[j].Name
+	})
+
+	w := &docWalker{doc: ret, aliases: aliases, params: map[string]bool{}}
+	diags = diags.Extend(w.walk(content, "", false))
+	for param := range w.params {
+		ret.Parameters = append(ret.Parameters, param)
+	}
+	sort.Strings(ret.Parameters)
+	return ret, diags
+}
+
+func functionDoc(fn *functions.UserFunction) FunctionDoc {
+	ret := FunctionDoc{Name: fn.Name, Description: fn.Description, Range: fn.DefRange}
+	for _, arg := range fn.Args {
+		a := ArgDoc{Name: arg.Name, Description: arg.Description}
+		if arg.HasDefault {
+			a.Default = strings.TrimSpace(string(hclwrite.TokensForValue(arg.Default).Bytes()))
+		}
+		ret.Args = append(ret.Args, a)
+	}
+	sort.Slice(ret.Args, func(i, j int) bool {
+		return ret.Args[i].Name < ret.Args[j].Name
+	})
+	return ret
+}
+
+// docWalker walks content to find the objects and parameters to document.
+type docWalker struct {
+	doc     *Documentation
+	aliases map[string]hcl.Traversal
+	params  map[string]bool
+}
+
+func (w *docWalker) walk(content *hcl.BodyContent, prefix string, conditional bool) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if _, ok := content.Attributes[attrCondition]; ok {
+		conditional = true
+	}
+	for _, attr := range content.Attributes {
+		w.addParams(attr.Expr)
+	}
+	for _, block := range content.Blocks {
+		schema := schemasByBlockType[block.Type]
+		switch block.Type {
+		case blockFunction, blockAlias:
+			continue // alias targets are recorded where the aliases are used
+		case blockDoc:
+			title, description, ds := docBlock(block)
+			diags = diags.Extend(ds)
+			w.doc.Title, w.doc.Description = title, description
+			continue
+		case blockLocals, blockFileLocals:
+			attrs, _ := block.Body.JustAttributes()
+			for _, attr := range attrs {
+				w.addParams(attr.Expr)
+			}
+			continue
+		}
+		childContent, ds := block.Body.Content(schema)
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			continue
+		}
+		childPrefix := prefix
+		if block.Type == blockGroup {
+			p, ds := groupNamePrefix(childContent)
+			diags = diags.Extend(ds)
+			childPrefix += p
+		}
+		_, hasCondition := childContent.Attributes[attrCondition]
+		switch block.Type {
+		case blockResource:
+			name, ok := staticResourceName(prefix, block, childContent)
+			if !ok {
+				name = prefix + block.Labels[0]
+			}
+			w.doc.Resources = append(w.doc.Resources, resourceDoc(name, block, childContent, conditional || hasCondition))
+		case blockResources:
+			var body *hcl.BodyContent
+			for _, b := range childContent.Blocks {
+				if b.Type == blockTemplate {
+					body, _ = b.Body.Content(templateSchema())
+				}
+			}
+			w.doc.Collections = append(w.doc.Collections, resourceDoc(prefix+block.Labels[0], block, body, conditional || hasCondition))
+		case blockRequirement:
+			d := ObjectDoc{Name: block.Labels[0], Conditional: conditional || hasCondition, Range: block.DefRange}
+			for _, b := range childContent.Blocks {
+				if b.Type == blockSelect {
+					sel, _ := b.Body.Content(selectSchema())
+					if sel != nil {
+						d.APIVersion, _ = constantString(sel, attrAPIVersion, blockSelect)
+						d.Kind, _ = constantString(sel, attrKind, blockSelect)
+					}
+				}
+			}
+			w.doc.Requirements = append(w.doc.Requirements, d)
+		case blockObserve:
+			d := ObjectDoc{Name: block.Labels[0], Conditional: conditional || hasCondition, Range: block.DefRange}
+			d.APIVersion, _ = constantString(childContent, attrAPIVersion, blockObserve)
+			d.Kind, _ = constantString(childContent, attrKind, blockObserve)
+			w.doc.Requirements = append(w.doc.Requirements, d)
+		}
+		diags = diags.Extend(w.walk(childContent, childPrefix, conditional))
+	}
+	return diags
+}
+
+// addParams records the paths under the composite spec that are referenced by the supplied expression.
+func (w *docWalker) addParams(expr hcl.Expression) {
+	for _, t := range expr.Variables() {
+		t = hclexpr.NormalizeTraversal(expandAlias(t, w.aliases))
+		if t.RootName() != reservedReq || len(t) < 3 {
+			continue
+		}
+		if step, ok := t[1].(hcl.TraverseAttr); !ok || step.Name != reqComposite {
+			continue
+		}
+		var parts []string
+		for _, step := range t[2:] {
+			attr, ok := step.(hcl.TraverseAttr)
+			if !ok {
+				break
+			}
+			parts = append(parts, attr.Name)
+		}
+		if len(parts) < 2 || parts[0] != "spec" {
+			continue
+		}
+		w.params[strings.Join(parts, ".")] = true
+	}
+}
+
+// resourceDoc returns the documentation for a resource whose body is in the supplied content.
+func resourceDoc(name string, block *hcl.Block, content *hcl.BodyContent, conditional bool) ObjectDoc {
+	ret := ObjectDoc{Name: name, Conditional: conditional, Range: block.DefRange}
+	if content == nil {
+		return ret
+	}
+	if body, ok := content.Attributes[attrBody]; ok {
+		ret.APIVersion = staticObjectString(body.Expr, attrAPIVersion)
+		ret.Kind = staticObjectString(body.Expr, attrKind)
+	}
+	return ret
+}
+
+// staticObjectString returns the value of the supplied key in an object constructor expression when the
+// value is a constant string, or an empty string otherwise.
+func staticObjectString(expr hcl.Expression, key string) string {
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return ""
+	}
+	for _, item := range obj.Items {
+		k, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || !k.IsKnown() || k.IsNull() || k.Type() != cty.String || k.AsString() != key {
+			continue
+		}
+		v, diags := item.ValueExpr.Value(nil)
+		if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() || v.Type() != cty.String {
+			return ""
+		}
+		return v.AsString()
+	}
+	return ""
+}
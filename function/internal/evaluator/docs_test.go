@@ -0,0 +1,180 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const docsHCL = `
+doc {
+  title       = "S3 Buckets"
+  description = "Creates buckets in every zone."
+}
+
+alias {
+  params = req.composite.spec.parameters
+}
+
+function truncate {
+  description = "truncates a string"
+  arg s {
+    description = "the string"
+  }
+  arg length {
+    default = 8
+  }
+  body = substr(s, 0, length)
+}
+
+locals {
+  region = params.region
+}
+
+resource bucket {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "Bucket"
+    spec = {
+      forProvider = {
+        region = region
+        size   = req.composite.spec.size
+      }
+    }
+  }
+}
+
+group {
+  name_prefix = "db-"
+  condition   = params.database
+
+  resource instance {
+    body = {
+      apiVersion = "db.aws.com/v1"
+      kind       = req.composite.spec.parameters.dbKind
+    }
+  }
+}
+
+resources zones {
+  for_each = params.zones
+  template {
+    body = {
+      apiVersion = "aws.com/v1"
+      kind       = "Zone"
+    }
+  }
+}
+
+requirement cm {
+  select {
+    apiVersion  = "v1"
+    kind        = "ConfigMap"
+    matchName   = "config"
+  }
+}
+
+resource gpu-pool {
+  when = flag("gpu")
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "NodePool"
+  }
+}
+`
+
+func TestDocument(t *testing.T) {
+	e, err := New(Options{})
+	require.NoError(t, err)
+	doc, diags := e.Document(File{Name: "test.hcl", Content: docsHCL})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, "S3 Buckets", doc.Title)
+	assert.Equal(t, "Creates buckets in every zone.", doc.Description)
+
+	require.Len(t, doc.Functions, 1)
+	fn := doc.Functions[0]
+	assert.Equal(t, "truncate", fn.Name)
+	assert.Equal(t, "truncates a string", fn.Description)
+	assert.Equal(t, []ArgDoc{
+		{Name: "length", Default: "8"},
+		{Name: "s", Description: "the string"},
+	}, fn.Args)
+
+	assert.Equal(t, []string{
+		"spec.parameters.database",
+		"spec.parameters.dbKind",
+		"spec.parameters.region",
+		"spec.parameters.zones",
+		"spec.size",
+	}, doc.Parameters)
+
+	strip := func(objects []ObjectDoc) []ObjectDoc {
+		for i := range objects {
+			objects[i].Range = hcl.Range{}
+		}
+		return objects
+	}
+	assert.Equal(t, []ObjectDoc{
+		{Name: "bucket", APIVersion: "aws.com/v1", Kind: "Bucket"},
+		{Name: "db-instance", APIVersion: "db.aws.com/v1", Conditional: true},
+	}, strip(doc.Resources))
+	assert.Equal(t, []ObjectDoc{{Name: "zones", APIVersion: "aws.com/v1", Kind: "Zone"}}, strip(doc.Collections))
+	assert.Equal(t, []ObjectDoc{{Name: "cm", APIVersion: "v1", Kind: "ConfigMap"}}, strip(doc.Requirements))
+}
+
+func TestDocumentFlags(t *testing.T) {
+	e, err := New(Options{Flags: []string{"gpu"}})
+	require.NoError(t, err)
+	doc, diags := e.Document(File{Name: "test.hcl", Content: docsHCL})
+	require.False(t, diags.HasErrors(), diags.Error())
+	var names []string
+	for _, r := range doc.Resources {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"bucket", "db-instance", "gpu-pool"}, names)
+}
+
+func TestDocumentErrors(t *testing.T) {
+	e, err := New(Options{})
+	require.NoError(t, err)
+	_, diags := e.Document(File{Name: "test.hcl", Content: `resource foo {`})
+	require.True(t, diags.HasErrors())
+}
+
+func TestDocBlockAnalyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name:   "dynamic title",
+			hcl:    `doc { title = req.composite.metadata.name }`,
+			errMsg: "test.hcl:1,15-42: title in doc block is not a constant string",
+		},
+		{
+			name: "duplicate",
+			hcl: `
+doc { title = "one" }
+doc { description = "two" }
+`,
+			errMsg: "test.hcl:3,1-4: duplicate doc block; the composition is already documented at test.hcl:2,1-4",
+		},
+		{
+			name:   "unknown attribute",
+			hcl:    `doc { summary = "one" }`,
+			errMsg: `An argument named "summary" is not expected here`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
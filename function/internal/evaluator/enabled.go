@@ -0,0 +1,34 @@
+package evaluator
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// attrEnabled is the attribute that parks a resource or resource collection without removing its code. Unlike
+// a condition, a disabled block is skipped silently by the evaluator and its existing resources are kept as they
+// are observed, but it is still fully checked by the analyzer.
+const attrEnabled = "enabled"
+
+// isEnabled returns the value of the enabled attribute in the supplied content, or true if it is not set. The
+// value must be a constant boolean such that whether a block is processed never depends on the request.
+func isEnabled(content *hcl.BodyContent, blockType string) (bool, hcl.Diagnostics) {
+	return constantBoolAttr(content, attrEnabled, blockType, true)
+}
+
+// processDisabledResource handles a resource block that is disabled. When the resource exists, its observed state
+// is copied to the desired state such that it is parked as it is instead of being deleted.
+func (e *Evaluator) processDisabledResource(ctx *hcl.EvalContext, block *hcl.Block, content *hcl.BodyContent) hcl.Diagnostics {
+	resourceName, diags := e.resourceName(ctx, block, content)
+	if diags.HasErrors() {
+		return diags
+	}
+	_, ds := e.keepObserved(block, []string{resourceName})
+	return diags.Extend(ds)
+}
+
+// processDisabledResources handles a resource collection that is disabled, keeping the observed state of its
+// existing resources.
+func (e *Evaluator) processDisabledResources(block *hcl.Block, baseName string) hcl.Diagnostics {
+	_, ds := e.keepObserved(block, e.collectionResourceNames[baseName])
+	return ds
+}
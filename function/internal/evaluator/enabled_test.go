@@ -0,0 +1,130 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnabled(t *testing.T) {
+	hcl := `
+resource parked {
+  enabled = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { size = req.resource.missing.data.size }
+  }
+}
+resources parked-list {
+  enabled  = false
+  for_each = ["a", "b"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+    }
+  }
+}
+resource active {
+  enabled = true
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{DiscardsInContext: true})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.False(t, e.Incomplete())
+	assert.Len(t, res.GetDesired().GetResources(), 1)
+	assert.Contains(t, res.GetDesired().GetResources(), "active")
+	assert.Equal(t, []any{}, res.GetContext().AsMap()["hcl.fn.crossplane.io/discards"])
+	for _, r := range res.GetResults() {
+		assert.NotEqual(t, "incomplete", r.GetReason())
+	}
+}
+
+func TestEnabledKeepsExisting(t *testing.T) {
+	hcl := `
+resource parked {
+  enabled = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { version = "2" }
+  }
+}
+resources parked-list {
+  enabled  = false
+  for_each = ["a", "b"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { version = "2" }
+    }
+  }
+}
+`
+	observed := map[string]map[string]any{}
+	for name, base := range map[string]string{"parked": "", "parked-list-0": "parked-list"} {
+		observed[name] = map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "xr-" + name},
+			"data":       map[string]any{"version": "1"},
+			"status":     map[string]any{"id": "id-" + name},
+		}
+		if base != "" {
+			collectionMember(observed[name], base, "s000000")
+		}
+	}
+	req := makeRequest(t, baseRequestJSON, withObserved(t, observed))
+
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	desired := res.GetDesired().GetResources()
+	require.Len(t, desired, 2)
+	for _, name := range []string{"parked", "parked-list-0"} {
+		body := desired[name].GetResource().AsMap()
+		assert.Equal(t, map[string]any{"version": "1"}, body["data"], name)
+		assert.NotContains(t, body, "status", name)
+	}
+}
+
+func TestEnabledAnalyze(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+resource parked {
+  enabled = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { size = local.missing }
+  }
+}
+resource dynamic {
+  enabled = req.composite.spec.enabled
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+`})
+	require.True(t, diags.HasErrors())
+	var summaries []string
+	for _, d := range diags {
+		summaries = append(summaries, d.Summary)
+	}
+	assert.Contains(t, summaries, "enabled in resource block is not a constant bool")
+	assert.Len(t, summaries, 2)
+}
@@ -1,7 +1,10 @@
 package evaluator
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -17,7 +20,7 @@ import (
 )
 
 const (
-	maxDiscardsToDisplay = 3
+	defaultMaxDiscardsToDisplay = 3
 )
 
 func (e *Evaluator) doEval(in *fnv1.RunFunctionRequest, files ...File) (_ *fnv1.RunFunctionResponse, finalErr error) {
@@ -32,30 +35,55 @@ func (e *Evaluator) doEval(in *fnv1.RunFunctionRequest, files ...File) (_ *fnv1.
 		}
 	}()
 
-	// parse all files
-	mergedBody, diags := e.toContent(files)
-	if diags.HasErrors() {
-		return nil, diags
-	}
-
-	ctx, ds := e.processFunctions(mergedBody)
-	diags = diags.Extend(ds)
-	if diags.HasErrors() {
-		return nil, diags
+	c := e.program
+	if c == nil {
+		var diags hcl.Diagnostics
+		c, diags = e.compile(files)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+	} else if len(files) > 0 {
+		return nil, fmt.Errorf("evaluators created for a program cannot evaluate additional files")
 	}
+	// programs are evaluated in sequence by different evaluators, each of which records its own calls
+	c.funcs.SetCallObserver(e.recordNondeterministicCall)
+	diags := c.diags
 
 	// make vars in cty format and set up the initial eval context
-	ctx, err := e.makeVars(ctx, in)
+	e.namespaceContext = namespaceFromContext(in.GetContext(), e.contextNamespace)
+	ctx, err := e.makeVars(c.funcCtx, in)
 	if err != nil {
 		return nil, diags.Append(hclutils.Err2Diag(err))
 	}
 
+	// add values from values files and shorthand roots for deep references
+	ctx = argContext(ctx, c.values)
+	ctx = aliasContext(ctx, c.aliases)
+	e.aliases = c.aliases
+	e.stableRounds = stableRoundsFromRequest(in, e.requestContext(in.GetContext()))
+	ctx = e.stableContext(ctx)
+	ctx = e.changedContext(ctx)
+	ctx = e.sandboxContext(ctx)
+	e.credentials = credentialsFromRequest(in)
+
 	// process top-level blocks as a group
-	ds = e.processGroup(ctx, mergedBody)
+	ds := e.processGroup(ctx, c.content)
 	diags = diags.Extend(ds)
 	if ds.HasErrors() {
 		return nil, diags
 	}
+	if ds := e.checkDeterminism(); ds.HasErrors() {
+		return nil, diags.Extend(ds)
+	}
+
+	if ds := e.advance("resource hooks", hcl.Range{}); ds.HasErrors() {
+		return nil, diags.Extend(ds)
+	}
+
+	// let hooks check, annotate, and veto desired resources
+	if err := e.runHooks(in); err != nil {
+		return nil, err
+	}
 
 	// create the response from internal state.
 	res, err := e.toResponse(diags)
@@ -66,15 +94,124 @@ func (e *Evaluator) doEval(in *fnv1.RunFunctionRequest, files ...File) (_ *fnv1.
 	return res, nil
 }
 
-// processFunctions processes all function blocks at the top-level and returns an evaluation
-// context that includes all supported functions with an `invoke` function in addition.
-func (e *Evaluator) processFunctions(content *hcl.BodyContent) (*hcl.EvalContext, hcl.Diagnostics) {
+// compile parses the supplied files and values files, processes user functions and collects aliases. The result only depends
+// on the files and the options of the evaluator, and not on any request.
+func (e *Evaluator) compile(files []File) (*Program, hcl.Diagnostics) {
+	files, valuesFiles := splitValuesFiles(files)
+	values, diags := e.parseValues(valuesFiles)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// parse all files
+	mergedBody, ds := e.toContent(files)
+	diags = diags.Extend(ds)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	p, ds := e.processFunctions(mergedBody)
+	diags = diags.Extend(ds)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	aliases, ds := collectAliases(mergedBody)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return nil, diags
+	}
+	return &Program{
+		files:   e.files,
+		content: mergedBody,
+		funcs:   p,
+		funcCtx: p.RootContext(nil),
+		aliases: aliases,
+		values:  values,
+		diags:   diags,
+	}, nil
+}
+
+// advance records the item that is about to be processed, which is reported when evaluation stops or panics,
+// and returns an error diagnostic when the context of the evaluation is done.
+func (e *Evaluator) advance(what string, r hcl.Range) hcl.Diagnostics {
+	e.position, e.positionRange = what, r
+	if e.runCtx == nil {
+		return nil
+	}
+	err := e.runCtx.Err()
+	if err == nil {
+		return nil
+	}
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("evaluation stopped: %v", err),
+		Detail:   fmt.Sprintf("evaluation stopped before processing %s, %d desired resources were produced until then", what, len(e.desiredResources)),
+	}
+	if r.Filename != "" {
+		diag.Subject = r.Ptr()
+	}
+	return hcl.Diagnostics{diag}
+}
+
+// maxStackFrames is the maximum number of stack frames reported for a panic.
+const maxStackFrames = 8
+
+// StackSummary returns a one-line summary of the innermost frames of the current goroutine that are not in
+// the Go runtime. When called while recovering from a panic, the summary starts at the function that panicked.
+func StackSummary() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	var ret []string
+	for {
+		f, more := frames.Next()
+		switch {
+		case f.Function == "runtime.gopanic":
+			ret = nil // drop the frames of the recovery code
+		case !strings.HasPrefix(f.Function, "runtime."):
+			ret = append(ret, fmt.Sprintf("%s (%s:%d)", f.Function, filepath.Base(f.File), f.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	if len(ret) > maxStackFrames {
+		ret = ret[:maxStackFrames]
+	}
+	return strings.Join(ret, " <- ")
+}
+
+// panicDiags returns an error diagnostic for the supplied value recovered from a panic during evaluation.
+func (e *Evaluator) panicDiags(v any) hcl.Diagnostics {
+	detail := "stack: " + StackSummary()
+	if e.position != "" {
+		detail = fmt.Sprintf("panic while processing %s, %s", e.position, detail)
+	}
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("internal error: %v", v),
+		Detail:   detail,
+	}
+	if e.positionRange.Filename != "" {
+		diag.Subject = e.positionRange.Ptr()
+	}
+	return hclutils.Categorize(hcl.Diagnostics{diag}, hclutils.CategoryInternal)
+}
+
+// processFunctions processes all function blocks at the top-level and returns a processor whose root context
+// includes all supported functions with an `invoke` function in addition. Calls to non-deterministic functions
+// are reported to the evaluator, including the ones in the bodies of user functions and lambdas.
+func (e *Evaluator) processFunctions(content *hcl.BodyContent) (*functions.Processor, hcl.Diagnostics) {
 	p := functions.NewProcessor()
+	p.SetMaxDepth(e.maxInvokeDepth)
+	e.sandbox.restrict(p)
+	p.ObserveCalls(sortedKeys(nondeterministicFunctions)...)
+	p.SetCallObserver(e.recordNondeterministicCall)
 	diags := p.Process(content)
 	if diags.HasErrors() {
 		return nil, diags
 	}
-	return p.RootContext(nil), nil
+	return p, nil
 }
 
 func (e *Evaluator) toBodies(files []File) ([]hcl.Body, hcl.Diagnostics) {
@@ -90,7 +227,7 @@ func (e *Evaluator) toBodies(files []File) ([]hcl.Body, hcl.Diagnostics) {
 		if !ok {
 			panic(fmt.Errorf("internal error: unable to convert HCL body to desired type"))
 		}
-		bodies = append(bodies, b)
+		bodies = append(bodies, e.withFlags(b))
 	}
 	return bodies, nil
 }
@@ -100,7 +237,7 @@ func (e *Evaluator) makeContent(bodies []hcl.Body) (*hcl.BodyContent, hcl.Diagno
 	ret := &hcl.BodyContent{}
 	for _, body := range bodies {
 		content, diags := body.Content(topLevelSchema())
-		d = d.Extend(diags)
+		d = d.Extend(explainUnsupportedAttributes(body, topLevelSchema(), diags))
 		if content != nil {
 			ret.Blocks = append(ret.Blocks, content.Blocks...)
 		}
@@ -116,6 +253,10 @@ func (e *Evaluator) toContent(files []File) (*hcl.BodyContent, hcl.Diagnostics)
 	if diags.HasErrors() {
 		return nil, diags
 	}
+	diags = diags.Extend(e.checkRequires(bodies))
+	if diags.HasErrors() {
+		return nil, diags
+	}
 	return e.makeContent(bodies)
 }
 
@@ -135,8 +276,14 @@ func (e *Evaluator) evaluateCondition(ctx *hcl.EvalContext, content *hcl.BodyCon
 			// map unknown ready value errors to warnings as we'll handle them later
 			return false, diags.Extend(hclutils.DowngradeDiags(diags))
 		}
-		if val.Type() != cty.Bool {
-			return false, diags.Append(hclutils.Err2Diag(fmt.Errorf("got type %s, expected %s", val.Type(), cty.Bool)))
+		if val.Type() != cty.Bool || val.IsNull() {
+			r := condAttr.Expr.Range()
+			return false, diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("condition must be a bool, got %s", valueTypeName(val)),
+				Detail:   fmt.Sprintf("%s evaluated to %s", e.sourceCode(r), previewValue(val)),
+				Subject:  r.Ptr(),
+			})
 		}
 		if !val.True() {
 			e.discard(DiscardItem{
@@ -171,17 +318,25 @@ func (e *Evaluator) toResponse(diags hcl.Diagnostics) (*fnv1.RunFunctionResponse
 		}
 	}
 
-	if len(e.compositeStatuses) > 0 {
-		st, err := unify(e.compositeStatuses...)
-		if err != nil {
-			return nil, errors.Wrap(err, "unify composite status")
+	if len(e.compositeStatuses) > 0 || len(e.compositeSpecs) > 0 {
+		obj := Object{}
+		if len(e.compositeStatuses) > 0 {
+			st, err := unify(e.compositeStatuses...)
+			if err != nil {
+				return nil, errors.Wrap(err, "unify composite status")
+			}
+			obj["status"] = st
 		}
-		obj := Object{
-			"status": st,
+		if len(e.compositeSpecs) > 0 {
+			sp, err := unify(e.compositeSpecs...)
+			if err != nil {
+				return nil, errors.Wrap(err, "unify composite spec")
+			}
+			obj["spec"] = sp
 		}
 		s, err := structpb.NewStruct(obj)
 		if err != nil {
-			return nil, fmt.Errorf("unexpected error converting composite status: %v", err)
+			return nil, fmt.Errorf("unexpected error converting composite: %v", err)
 		}
 		ensureDesiredComposite()
 		ret.Desired.Composite.Resource = s
@@ -214,7 +369,8 @@ func (e *Evaluator) toResponse(diags hcl.Diagnostics) (*fnv1.RunFunctionResponse
 		}
 	}
 
-	for name, val := range e.ready {
+	for _, name := range sortedKeys(e.ready) {
+		val := e.ready[name]
 		desired := ret.Desired.Resources[name]
 		if desired == nil {
 			panic(fmt.Sprintf("internal error: no desired resource found for %s when readiness set", name))
@@ -226,7 +382,7 @@ func (e *Evaluator) toResponse(diags hcl.Diagnostics) (*fnv1.RunFunctionResponse
 	var discarded []string
 	msg := ""
 	for _, di := range e.discards {
-		if di.Reason == discardReasonUserCondition {
+		if di.expected() {
 			continue
 		}
 		resultReason := string(di.Reason)
@@ -237,19 +393,22 @@ func (e *Evaluator) toResponse(diags hcl.Diagnostics) (*fnv1.RunFunctionResponse
 			Reason:   &resultReason,
 		}
 		ret.Results = append(ret.Results, r)
-		if len(discarded) < maxDiscardsToDisplay {
+		if len(discarded) < e.maxDiscardsToDisplay {
 			discarded = append(discarded, fmt.Sprintf("%s %s", di.Type, di.Name))
 		}
 	}
 
-	if len(discarded) > 0 {
+	switch {
+	case len(discarded) > 0 && e.discardCountsOnly:
+		msg = fmt.Sprintf("%d items incomplete", len(ret.Results))
+	case len(discarded) > 0:
 		msg = strings.Join(discarded, ", ")
-		if len(ret.Results) > maxDiscardsToDisplay {
-			msg += fmt.Sprintf(" and %d more items incomplete", len(ret.Results)-maxDiscardsToDisplay)
+		if len(ret.Results) > e.maxDiscardsToDisplay {
+			msg += fmt.Sprintf(" and %d more items incomplete", len(ret.Results)-e.maxDiscardsToDisplay)
 		} else {
 			msg += " incomplete"
 		}
-	} else {
+	default:
 		msg = "all items complete"
 	}
 	c := fnv1.Status_STATUS_CONDITION_TRUE
@@ -268,12 +427,55 @@ func (e *Evaluator) toResponse(diags hcl.Diagnostics) (*fnv1.RunFunctionResponse
 	}
 	ret.Conditions = append(ret.Conditions, &cond)
 
+	if err := e.addDiscardsToContext(&ret); err != nil {
+		return nil, err
+	}
+	if err := e.addStableRounds(&ret); err != nil {
+		return nil, err
+	}
+	if err := e.addSandboxToContext(&ret); err != nil {
+		return nil, err
+	}
+	e.namespaceResponseContext(&ret)
+
+	// add policy results after discards such that they do not affect the resolution status
+	e.addPolicyInfo(&ret)
+
+	// add warnings from hooks after discards such that they do not affect the resolution status
+	e.addHookResults(&ret)
+
 	// Add diagnostics info
 	e.addDiagnosticsInfo(&ret, diags)
 
 	return &ret, nil
 }
 
+// addDiscardsToContext adds all discarded items, including the ones discarded by user conditions, to the
+// response context when requested. The list is emitted even when empty such that consumers can tell that
+// nothing was discarded.
+func (e *Evaluator) addDiscardsToContext(ret *fnv1.RunFunctionResponse) error {
+	if !e.discardsInContext {
+		return nil
+	}
+	b, err := json.Marshal(append([]DiscardItem{}, e.discards...))
+	if err != nil {
+		return errors.Wrap(err, "marshal discards")
+	}
+	var items []any
+	if err := json.Unmarshal(b, &items); err != nil {
+		return errors.Wrap(err, "unmarshal discards")
+	}
+	list, err := structpb.NewList(items)
+	if err != nil {
+		return errors.Wrap(err, "convert discards")
+	}
+	if ret.Context == nil {
+		ret.Context = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	ret.Context.Fields[discardsContextKey] = structpb.NewListValue(list)
+	return nil
+}
+
 type diagKey struct {
 	Sev     hcl.DiagnosticSeverity
 	Range   hcl.Range
@@ -301,10 +503,41 @@ var sevOrderMap = map[hcl.DiagnosticSeverity]int{
 	hcl.DiagInvalid: 2,
 }
 
+// diagnosticCategories are the categories of warnings in the order in which they are reported, with warnings that
+// do not have a category last.
+var diagnosticCategories = []hclutils.DiagCategory{
+	hclutils.CategoryInternal,
+	hclutils.CategoryUserError,
+	hclutils.CategoryIncompleteValue,
+	"",
+}
+
+// diagnosticsReasons are the reasons of the results that report warnings, by the category of the warnings.
+var diagnosticsReasons = map[hclutils.DiagCategory]string{
+	hclutils.CategoryInternal:        "HclInternal",
+	hclutils.CategoryUserError:       "HclUserError",
+	hclutils.CategoryIncompleteValue: "HclIncompleteValue",
+	"":                               diagnosticsReason,
+}
+
+// diagnosticsReason is the reason of the result that reports warnings without a category, or that there are none.
+const diagnosticsReason = "HclDiagnostics"
+
+// IsDiagnosticsResult returns true if the supplied result reports the diagnostics of an evaluation, as opposed to
+// a discarded item or a policy violation.
+func IsDiagnosticsResult(r *fnv1.Result) bool {
+	for _, reason := range diagnosticsReasons {
+		if r.GetReason() == reason {
+			return true
+		}
+	}
+	return false
+}
+
 // addDiagnosticsInfo adds diagnostics information to the response.
 func (e *Evaluator) addDiagnosticsInfo(ret *fnv1.RunFunctionResponse, diags hcl.Diagnostics) {
 	target := ptr(fnv1.Target_TARGET_COMPOSITE)
-	resultReason := ptr("HclDiagnostics")
+	resultReason := ptr(diagnosticsReason)
 	condition := &fnv1.Condition{
 		Type:   "HclDiagnostics",
 		Target: target,
@@ -335,7 +568,13 @@ func (e *Evaluator) addDiagnosticsInfo(ret *fnv1.RunFunctionResponse, diags hcl.
 		if lKey.Range.Filename != rKey.Range.Filename {
 			return lKey.Range.Filename < rKey.Range.Filename
 		}
-		return lKey.Range.Start.Byte < rKey.Range.Start.Byte
+		if lKey.Range.Start.Byte != rKey.Range.Start.Byte {
+			return lKey.Range.Start.Byte < rKey.Range.Start.Byte
+		}
+		if lKey.Range.End.Byte != rKey.Range.End.Byte {
+			return lKey.Range.End.Byte < rKey.Range.End.Byte
+		}
+		return lKey.Message < rKey.Message
 	})
 
 	var finalDiags []*hcl.Diagnostic
@@ -343,22 +582,42 @@ func (e *Evaluator) addDiagnosticsInfo(ret *fnv1.RunFunctionResponse, diags hcl.
 		finalDiags = append(finalDiags, d.item)
 	}
 	summaries := make([]string, 0, len(finalDiags))
+	summariesByCategory := map[hclutils.DiagCategory][]string{}
 	for _, diag := range finalDiags {
 		if diag.Severity == hcl.DiagWarning {
-			summaries = append(summaries, fmt.Sprintf("%s: %s", diag.Subject, diag.Summary))
+			summary := fmt.Sprintf("%s: %s", diag.Subject, diag.Summary)
+			summaries = append(summaries, summary)
+			category := hclutils.Category(diag)
+			summariesByCategory[category] = append(summariesByCategory[category], summary)
 			condition.Status = fnv1.Status_STATUS_CONDITION_FALSE
 		}
 	}
 
 	if len(summaries) > 0 {
-		r := &fnv1.Result{
-			Severity: fnv1.Severity_SEVERITY_WARNING,
-			Message:  fmt.Sprintf("warnings: [%s]", strings.Join(summaries, "; ")),
-			Target:   target,
-			Reason:   resultReason,
+		// warnings are reported in a result per category such that they can be told apart by reason
+		var counts []string
+		for _, category := range diagnosticCategories {
+			categorySummaries := summariesByCategory[category]
+			if len(categorySummaries) == 0 {
+				continue
+			}
+			message := fmt.Sprintf("warnings: [%s]", strings.Join(categorySummaries, "; "))
+			if category != "" {
+				message = fmt.Sprintf("%s %s", category, message)
+				counts = append(counts, fmt.Sprintf("%d %s", len(categorySummaries), category))
+			}
+			ret.Results = append(ret.Results, &fnv1.Result{
+				Severity: fnv1.Severity_SEVERITY_WARNING,
+				Message:  message,
+				Target:   target,
+				Reason:   ptr(diagnosticsReasons[category]),
+			})
 		}
-		ret.Results = append(ret.Results, r)
-		condition.Message = ptr(fmt.Sprintf("hcl.Diagnostics contains %d warnings; %s", len(summaries), strings.Join(summaries, "; ")))
+		breakdown := ""
+		if len(counts) > 0 {
+			breakdown = fmt.Sprintf(" (%s)", strings.Join(counts, ", "))
+		}
+		condition.Message = ptr(fmt.Sprintf("hcl.Diagnostics contains %d warnings%s; %s", len(summaries), breakdown, strings.Join(summaries, "; ")))
 	} else {
 		r := &fnv1.Result{
 			Severity: fnv1.Severity_SEVERITY_NORMAL,
@@ -379,14 +638,22 @@ func (e *Evaluator) discard(el DiscardItem) {
 }
 
 // getObservedResource returns the resource body of the observed
-// resource with the supplied name or any empty object.
+// resource with the supplied name or any empty object. Renamed
+// resources have the body of the resource they were renamed from.
 func (e *Evaluator) getObservedResource(name string) cty.Value {
+	if oldName, ok := e.renames[name]; ok {
+		return e.observedResources[oldName]
+	}
 	return e.existingResourceMap[name]
 }
 
 // getObservedConnection returns the connection details of the observed
-// resource with the supplied name or any empty object.
+// resource with the supplied name or any empty object. Renamed
+// resources have the details of the resource they were renamed from.
 func (e *Evaluator) getObservedConnection(name string) cty.Value {
+	if oldName, ok := e.renames[name]; ok {
+		return e.observedConnections[oldName]
+	}
 	return e.existingConnectionMap[name]
 }
 
@@ -1,16 +1,20 @@
 package evaluator_test
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 //go:embed testdata/simple.json
@@ -37,6 +41,33 @@ func makeRequest(t *testing.T, data string, fns ...func(request *fnv1.RunFunctio
 	return req
 }
 
+// withObserved returns a request modifier that sets the observed resources of the request to the supplied bodies.
+func withObserved(t *testing.T, bodies map[string]map[string]any) func(*fnv1.RunFunctionRequest) {
+	return func(req *fnv1.RunFunctionRequest) {
+		req.Observed.Resources = map[string]*fnv1.Resource{}
+		for name, body := range bodies {
+			obj, err := structpb.NewStruct(body)
+			require.NoError(t, err)
+			req.Observed.Resources[name] = &fnv1.Resource{Resource: obj}
+		}
+	}
+}
+
+// collectionMember annotates the supplied body as the member of the collection with the supplied base name at the
+// supplied index and returns it.
+func collectionMember(body map[string]any, base, index string) map[string]any {
+	meta, _ := body["metadata"].(map[string]any)
+	if meta == nil {
+		meta = map[string]any{}
+		body["metadata"] = meta
+	}
+	meta["annotations"] = map[string]any{
+		"hcl.fn.crossplane.io/collection-base-name": base,
+		"hcl.fn.crossplane.io/collection-index":     index,
+	}
+	return body
+}
+
 type testCase struct {
 	name     string
 	hcl      string
@@ -112,7 +143,7 @@ func TestPositiveEval(t *testing.T) {
 			e, err := evaluator.New(evaluator.Options{})
 			require.NoError(t, err)
 
-			res, err := e.Eval(req, evaluator.File{
+			res, err := e.Eval(context.Background(), req, evaluator.File{
 				Name:    "main.hcl",
 				Content: test.hcl,
 			})
@@ -212,7 +243,7 @@ func TestNegativeEval(t *testing.T) {
 			e, err := evaluator.New(evaluator.Options{})
 			require.NoError(t, err)
 
-			res, err := e.Eval(req, evaluator.File{
+			res, err := e.Eval(context.Background(), req, evaluator.File{
 				Name:    "main.hcl",
 				Content: test.hcl,
 			})
@@ -224,3 +255,256 @@ func TestNegativeEval(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalCancelled(t *testing.T) {
+	hcl := `
+resource foo {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+`
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	_, err = e.Eval(ctx, makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "main.hcl:2,1-13: evaluation stopped: context canceled; evaluation stopped before processing the resource block")
+}
+
+func TestDiscardsInContext(t *testing.T) {
+	hcl := `
+resource skipped {
+  condition = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+resource incomplete {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data = {
+      foo = req.resource.missing.data.foo
+    }
+  }
+}
+`
+	for _, enabled := range []bool{false, true} {
+		e, err := evaluator.New(evaluator.Options{DiscardsInContext: enabled})
+		require.NoError(t, err)
+		res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+		require.NoError(t, err)
+		assert.True(t, e.Incomplete())
+		discards, ok := res.GetContext().AsMap()["hcl.fn.crossplane.io/discards"]
+		if !enabled {
+			assert.False(t, ok)
+			continue
+		}
+		require.True(t, ok)
+		items := discards.([]any)
+		require.Len(t, items, 2)
+		var reasons []any
+		for _, item := range items {
+			reasons = append(reasons, item.(map[string]any)["reason"])
+		}
+		assert.ElementsMatch(t, []any{"user-condition", "incomplete"}, reasons)
+	}
+}
+
+func TestDiscardsInCondition(t *testing.T) {
+	hcl := `
+resources incomplete {
+  for_each = ["a", "b", "c", "d", "e"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { foo = req.resource.missing.data.foo }
+    }
+  }
+}
+`
+	tests := []struct {
+		name     string
+		opts     evaluator.Options
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "resource incomplete-0, resource incomplete-1, resource incomplete-2 and 2 more items incomplete",
+		},
+		{
+			name:     "more items",
+			opts:     evaluator.Options{MaxDiscardsToDisplay: 5},
+			expected: "resource incomplete-0, resource incomplete-1, resource incomplete-2, resource incomplete-3, resource incomplete-4 incomplete",
+		},
+		{
+			name:     "counts only",
+			opts:     evaluator.Options{DiscardCountsOnly: true},
+			expected: "5 items incomplete",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(test.opts)
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+			require.NoError(t, err)
+			var message string
+			for _, c := range res.GetConditions() {
+				if c.GetType() == "FullyResolved" {
+					message = c.GetMessage()
+				}
+			}
+			assert.Equal(t, test.expected, message)
+		})
+	}
+}
+
+func TestDeterministicResponse(t *testing.T) {
+	hcl := `
+resources buckets {
+  for_each = req.composite.spec.parameters.azs
+  template {
+    body = {
+      apiVersion = "aws.com/v1"
+      kind       = "S3Bucket"
+      spec = {
+        forProvider = { az = each.value, size = req.resource.primary-bucket.status.missing }
+      }
+    }
+  }
+}
+resources queues {
+  for_each = { alpha = 1, bravo = 2, charlie = 3, delta = 4, echo = 5, foxtrot = 6 }
+  template {
+    body = {
+      apiVersion = "aws.com/v1"
+      kind       = "Queue"
+      spec = {
+        forProvider = { index = each.value, arn = req.resource.primary-bucket.status.missing }
+      }
+    }
+  }
+}
+resource primary-bucket {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "S3Bucket"
+  }
+  ready {
+    value = "READY_TRUE"
+  }
+  composite status {
+    body = { primary = { region = self.resource.spec.forProvider.region } }
+  }
+}
+resource secondary-bucket {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "S3Bucket"
+  }
+  ready {
+    value = "READY_FALSE"
+  }
+}
+composite status {
+  body = { primary = { size = req.resource.primary-bucket.status.bucket_size }, ready = true }
+}
+composite connection {
+  body = { a = "not base64!", b = "also not base64!", c = "bm90IGJhc2U2NAo=", d = "still not base64!" }
+}
+context {
+  key   = "example.com/a"
+  value = { b = 1, a = 2 }
+}
+context {
+  key   = "example.com/a"
+  value = { c = 3 }
+}
+`
+	var first []byte
+	for i := 0; i < 20; i++ {
+		e, err := evaluator.New(evaluator.Options{})
+		require.NoError(t, err)
+		res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+		require.NoError(t, err)
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(res)
+		require.NoError(t, err)
+		if first == nil {
+			first = b
+			continue
+		}
+		require.Equal(t, first, b, "response differs on run %d", i)
+	}
+}
+
+func TestDiagnosticCategories(t *testing.T) {
+	hcl := `
+composite status {
+  body = { size = req.resource.missing.status.size }
+}
+group {
+  resources alerts {
+    for_each = 42
+    template {
+      body = {
+        apiVersion = "v1"
+        kind       = "ConfigMap"
+      }
+    }
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{IsolateGroupErrors: true})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+
+	reasons := map[string]string{}
+	for _, r := range res.GetResults() {
+		if evaluator.IsDiagnosticsResult(r) {
+			reasons[r.GetReason()] = r.GetMessage()
+		}
+	}
+	require.Len(t, reasons, 2)
+	assert.True(t, strings.HasPrefix(reasons["HclIncompleteValue"], "incomplete-value warnings: [main.hcl:3,"), reasons["HclIncompleteValue"])
+	assert.True(t, strings.HasPrefix(reasons["HclUserError"], "user-error warnings: [main.hcl:7,"), reasons["HclUserError"])
+
+	for _, c := range res.GetConditions() {
+		if c.GetType() == "HclDiagnostics" {
+			assert.Contains(t, c.GetMessage(), "hcl.Diagnostics contains 2 warnings (1 user-error, 1 incomplete-value); ")
+		}
+	}
+}
+
+func TestExportConnectionCollectionMember(t *testing.T) {
+	hcl := `
+resources db {
+  for_each = ["primary"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "Database"
+    }
+  }
+}
+export_connection {
+  from = "db-0"
+  map  = { endpoint = "DB_HOST" }
+}
+`
+	req := makeRequest(t, baseRequestJSON, withObserved(t, map[string]map[string]any{
+		"db-0": collectionMember(map[string]any{"apiVersion": "v1", "kind": "Database"}, "db", "s000000"),
+	}))
+	req.Observed.Resources["db-0"].ConnectionDetails = map[string][]byte{"endpoint": []byte("db.example.com")}
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"DB_HOST": []byte("db.example.com")}, res.GetDesired().GetComposite().GetConnectionDetails())
+}
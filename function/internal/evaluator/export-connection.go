@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// processExportConnection processes an export_connection block. The block publishes connection details of an
+// observed resource as composite connection details, optionally under different keys.
+func (e *Evaluator) processExportConnection(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
+	content, diags := block.Body.Content(exportConnectionSchema())
+	if diags.HasErrors() {
+		return diags
+	}
+
+	ctx, ds := e.processLocals(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+
+	cond, ds := e.evaluateCondition(ctx, content, discardTypeConnection, "")
+	diags = diags.Extend(ds)
+	if ds.HasErrors() || !cond {
+		return diags
+	}
+
+	discardIncomplete := func(name string, r hcl.Range, messages []string) {
+		e.discard(DiscardItem{
+			Type:        discardTypeConnection,
+			Reason:      discardReasonIncomplete,
+			Name:        name,
+			SourceRange: r.String(),
+			Context:     messages,
+		})
+	}
+
+	fromExpr := content.Attributes[attrFrom].Expr
+	from, ds := fromExpr.Value(ctx)
+	if ds.HasErrors() || !from.IsWhollyKnown() {
+		discardIncomplete("", fromExpr.Range(), e.messagesFromDiags(ds))
+		return diags.Extend(hclutils.DowngradeDiags(ds))
+	}
+	if from.IsNull() || from.Type() != cty.String {
+		return diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("%s in %s block was not a string", attrFrom, blockExportConn), "", fromExpr.Range()))
+	}
+	resourceName := from.AsString()
+
+	mapExpr := content.Attributes[attrMap].Expr
+	mapping, ds := mapExpr.Value(ctx)
+	if ds.HasErrors() || !mapping.IsWhollyKnown() {
+		discardIncomplete(resourceName, mapExpr.Range(), e.messagesFromDiags(ds))
+		return diags.Extend(hclutils.DowngradeDiags(ds))
+	}
+	if mapping.IsNull() || !(mapping.Type().IsObjectType() || mapping.Type().IsMapType()) {
+		return diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("%s in %s block was not an object", attrMap, blockExportConn), resourceName, mapExpr.Range()))
+	}
+	keyMap := map[string]string{}
+	for k, v := range mapping.AsValueMap() {
+		if v.IsNull() || v.Type() != cty.String {
+			return diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("target key for %q in %s block was not a string", k, blockExportConn), resourceName, mapExpr.Range()))
+		}
+		keyMap[k] = v.AsString()
+	}
+
+	// the connection details are not available until the resource has been created and has published them.
+	// Unlike references, the resource may be a member of a collection.
+	observedName := resourceName
+	if oldName, ok := e.renames[resourceName]; ok {
+		observedName = oldName
+	}
+	conn := e.observedConnections[observedName]
+	if conn == cty.NilVal || conn.IsNull() || !conn.Type().IsObjectType() {
+		discardIncomplete(resourceName, block.DefRange, []string{fmt.Sprintf("no connection details for resource %q", resourceName)})
+		return diags
+	}
+	sourceKeys := make([]string, 0, len(keyMap))
+	for k := range keyMap {
+		sourceKeys = append(sourceKeys, k)
+	}
+	sort.Strings(sourceKeys)
+
+	values := map[string][]byte{}
+	for _, k := range sourceKeys {
+		if !conn.Type().HasAttribute(k) {
+			discardIncomplete(resourceName, mapExpr.Range(), []string{fmt.Sprintf("connection key %q not found for resource %q", k, resourceName)})
+			return diags
+		}
+		v := conn.GetAttr(k)
+		if v.IsNull() || v.Type() != cty.String {
+			return diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("connection key %q for resource %q was not a string", k, resourceName), "", mapExpr.Range()))
+		}
+		b, err := base64.StdEncoding.DecodeString(v.AsString())
+		if err != nil {
+			return diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("connection key %q for resource %q not in base64 format", k, resourceName), "", mapExpr.Range()))
+		}
+		values[keyMap[k]] = b
+	}
+	e.compositeConnections = append(e.compositeConnections, values)
+	return diags
+}
@@ -0,0 +1,144 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestExportConnection(t *testing.T) {
+	hclContent := `
+export_connection {
+  from = "db"
+  map = {
+    endpoint = "DB_HOST"
+    port     = "DB_PORT"
+  }
+}
+`
+	e := createTestEvaluator(t)
+	e.observedConnections = DynamicObject{
+		"db": cty.ObjectVal(map[string]cty.Value{
+			"endpoint": cty.StringVal("ZGIuZXhhbXBsZS5jb20="), // db.example.com
+			"port":     cty.StringVal("NTQzMg=="),             // 5432
+			"password": cty.StringVal("c2VjcmV0"),
+		}),
+	}
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, hclContent, "test.hcl")
+
+	diags := e.processGroup(ctx, content)
+	require.Empty(t, diags)
+	require.Len(t, e.compositeConnections, 1)
+	assert.Equal(t, map[string][]byte{
+		"DB_HOST": []byte("db.example.com"),
+		"DB_PORT": []byte("5432"),
+	}, e.compositeConnections[0])
+}
+
+func TestExportConnectionIncomplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		hcl     string
+		message string
+	}{
+		{
+			name: "no connection details",
+			hcl: `
+export_connection {
+  from = "cache"
+  map  = { endpoint = "CACHE_HOST" }
+}
+`,
+			message: `no connection details for resource "cache"`,
+		},
+		{
+			name: "missing key",
+			hcl: `
+export_connection {
+  from = "db"
+  map  = { username = "DB_USER" }
+}
+`,
+			message: `connection key "username" not found for resource "db"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := createTestEvaluator(t)
+			e.observedConnections = DynamicObject{
+				"db": cty.ObjectVal(map[string]cty.Value{
+					"endpoint": cty.StringVal("ZGIuZXhhbXBsZS5jb20="),
+				}),
+			}
+			ctx := createTestEvalContext()
+			content := parseHCL(t, e, test.hcl, "test.hcl")
+
+			diags := e.processGroup(ctx, content)
+			require.False(t, diags.HasErrors())
+			assert.Empty(t, e.compositeConnections)
+			require.Len(t, e.discards, 1)
+			assert.Equal(t, discardTypeConnection, e.discards[0].Type)
+			assert.Equal(t, discardReasonIncomplete, e.discards[0].Reason)
+			assert.Equal(t, []string{test.message}, e.discards[0].Context)
+		})
+	}
+}
+
+func TestExportConnectionErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name: "from not a string",
+			hcl: `
+export_connection {
+  from = true
+  map  = {}
+}
+`,
+			errMsg: "from in export_connection block was not a string",
+		},
+		{
+			name: "target not a string",
+			hcl: `
+export_connection {
+  from = "db"
+  map  = { endpoint = 10 }
+}
+`,
+			errMsg: `target key for "endpoint" in export_connection block was not a string`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := createTestEvaluator(t)
+			ctx := createTestEvalContext()
+			content := parseHCL(t, e, test.hcl, "test.hcl")
+			diags := e.processGroup(ctx, content)
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
+
+func TestExportConnectionAnalyze(t *testing.T) {
+	e, err := New(Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(File{Name: "test.hcl", Content: `
+group {
+  export_connection {
+    locals {
+      prefix = "DB"
+    }
+    from = "db"
+    map  = { endpoint = "${prefix}_HOST" }
+  }
+}
+`})
+	require.False(t, diags.HasErrors(), diags.Error())
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// annotationExternalName is the annotation that Crossplane providers use for the name of the external resource.
+const annotationExternalName = "crossplane.io/external-name"
+
+// annotationValue returns the value of the supplied annotation in the resource body, if it is a known string.
+func annotationValue(body cty.Value, name string) (string, bool) {
+	v := body
+	for _, key := range []string{"metadata", "annotations", name} {
+		if v.IsNull() || !v.IsKnown() || !(v.Type().IsObjectType() || v.Type().IsMapType()) {
+			return "", false
+		}
+		var diags hcl.Diagnostics
+		v, diags = hcl.Index(v, cty.StringVal(key), nil)
+		if diags.HasErrors() {
+			return "", false
+		}
+	}
+	if v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return "", false
+	}
+	return v.AsString(), true
+}
+
+// evaluateExternalName evaluates the external_name attribute of a resource block, if present, and adds the
+// external name annotation to the supplied annotations. It returns false, without any errors, when the external
+// name of a new resource cannot be evaluated yet, in which case the resource is discarded.
+func (e *Evaluator) evaluateExternalName(ctx *hcl.EvalContext, resourceName string, content *hcl.BodyContent,
+	body cty.Value, annotations map[string]string,
+) (map[string]string, bool, hcl.Diagnostics) {
+	attr, ok := content.Attributes[attrExternalName]
+	if !ok {
+		return annotations, true, nil
+	}
+	existing := e.getObservedResource(resourceName) != cty.NilVal
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		if existing {
+			return nil, false, diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Subject:  ptr(attr.Expr.Range()),
+				Summary:  fmt.Sprintf("external name of existing resource %s could not be evaluated, abort", resourceName),
+			})
+		}
+		e.discard(DiscardItem{
+			Type:        discardTypeResource,
+			Reason:      discardReasonIncomplete,
+			Name:        resourceName,
+			SourceRange: attr.Expr.Range().String(),
+			Context:     append(e.messagesFromDiags(diags), "unknown external name"),
+		})
+		return nil, false, hclutils.DowngradeDiags(diags)
+	}
+	if val.IsNull() || val.Type() != cty.String || val.AsString() == "" {
+		return nil, false, diags.Extend(hclutils.ToErrorDiag(
+			fmt.Sprintf("%s of resource %s must be a non-empty string", attrExternalName, resourceName), "", attr.Expr.Range()))
+	}
+	externalName := val.AsString()
+	if _, ok := annotationValue(body, annotationExternalName); ok {
+		return nil, false, diags.Extend(hclutils.ToErrorDiag(
+			fmt.Sprintf("resource %s sets the %s annotation in its body as well as using %s", resourceName, annotationExternalName, attrExternalName),
+			"remove the annotation from the body", attr.Expr.Range()))
+	}
+
+	if existing {
+		current, ok := annotationValue(e.getObservedResource(resourceName), annotationExternalName)
+		if ok && current != externalName {
+			allow, ds := e.allowExternalNameChange(ctx, content)
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				return nil, false, diags
+			}
+			if !allow {
+				return nil, false, diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Subject:  ptr(attr.Expr.Range()),
+					Summary: fmt.Sprintf("external name of existing resource %s would change from %q to %q",
+						resourceName, current, externalName),
+					Detail: fmt.Sprintf("set %s = true to change it", attrAllowExternalNameChange),
+				})
+			}
+		}
+	}
+
+	ret := map[string]string{annotationExternalName: externalName}
+	for k, v := range annotations {
+		ret[k] = v
+	}
+	return ret, true, diags
+}
+
+// allowExternalNameChange returns the value of the allow_external_name_change attribute, or false if not set.
+func (e *Evaluator) allowExternalNameChange(ctx *hcl.EvalContext, content *hcl.BodyContent) (bool, hcl.Diagnostics) {
+	attr, ok := content.Attributes[attrAllowExternalNameChange]
+	if !ok {
+		return false, nil
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if !val.IsWhollyKnown() || val.IsNull() || val.Type() != cty.Bool {
+		return false, diags.Extend(hclutils.ToErrorDiag(
+			fmt.Sprintf("%s must be a known boolean", attrAllowExternalNameChange), "", attr.Expr.Range()))
+	}
+	return val.True(), diags
+}
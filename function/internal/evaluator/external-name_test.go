@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func observedWithExternalName(name string) cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"metadata": cty.ObjectVal(map[string]cty.Value{
+			"annotations": cty.ObjectVal(map[string]cty.Value{
+				annotationExternalName: cty.StringVal(name),
+			}),
+		}),
+	})
+}
+
+func TestEvaluator_ExternalName(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		observed string // external name of the observed resource, if any
+		expected string // expected external name annotation, empty if an error is expected
+		err      string
+	}{
+		{
+			name: "new resource",
+			hcl: `resource bucket {
+  external_name = "${req.composite.spec.environment}-bucket"
+  body = { apiVersion = "v1", kind = "Bucket" }
+}`,
+			expected: "production-bucket",
+		},
+		{
+			name: "unchanged",
+			hcl: `resource bucket {
+  external_name = "my-bucket"
+  body = { apiVersion = "v1", kind = "Bucket" }
+}`,
+			observed: "my-bucket",
+			expected: "my-bucket",
+		},
+		{
+			name: "changed",
+			hcl: `resource bucket {
+  external_name = "new-bucket"
+  body = { apiVersion = "v1", kind = "Bucket" }
+}`,
+			observed: "my-bucket",
+			err:      `external name of existing resource bucket would change from "my-bucket" to "new-bucket"`,
+		},
+		{
+			name: "changed with permission",
+			hcl: `resource bucket {
+  external_name              = "new-bucket"
+  allow_external_name_change = true
+  body                       = { apiVersion = "v1", kind = "Bucket" }
+}`,
+			observed: "my-bucket",
+			expected: "new-bucket",
+		},
+		{
+			name: "annotation in body",
+			hcl: `resource bucket {
+  external_name = "my-bucket"
+  body = {
+    apiVersion = "v1"
+    kind       = "Bucket"
+    metadata   = { annotations = { "crossplane.io/external-name" = "other" } }
+  }
+}`,
+			err: "resource bucket sets the crossplane.io/external-name annotation in its body as well as using external_name",
+		},
+		{
+			name: "not a string",
+			hcl: `resource bucket {
+  external_name = req.composite.spec.replicas > 1
+  body = { apiVersion = "v1", kind = "Bucket" }
+}`,
+			err: "external_name of resource bucket must be a non-empty string",
+		},
+		{
+			name: "unknown for existing resource",
+			hcl: `resource bucket {
+  external_name = req.composite.status.name
+  body = { apiVersion = "v1", kind = "Bucket" }
+}`,
+			observed: "my-bucket",
+			err:      "external name of existing resource bucket could not be evaluated, abort",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := createTestEvaluator(t)
+			if test.observed != "" {
+				e.existingResourceMap = DynamicObject{"bucket": observedWithExternalName(test.observed)}
+			}
+			content := parseHCL(t, e, test.hcl, "main.hcl")
+			diags := e.processGroup(createTestEvalContext(), content)
+			if test.err != "" {
+				require.True(t, diags.HasErrors())
+				var summaries []string
+				for _, d := range diags.Errs() {
+					summaries = append(summaries, d.Error())
+				}
+				assert.Contains(t, strings.Join(summaries, "\n"), test.err)
+				assert.NotContains(t, e.desiredResources, "bucket")
+				return
+			}
+			require.False(t, diags.HasErrors(), diags.Error())
+			require.Contains(t, e.desiredResources, "bucket")
+			metadata := e.desiredResources["bucket"].AsMap()["metadata"].(map[string]any)
+			assert.Equal(t, map[string]any{annotationExternalName: test.expected}, metadata["annotations"])
+		})
+	}
+}
+
+func TestEvaluator_ExternalNameUnknownForNewResource(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, `resource bucket {
+  external_name = req.composite.status.name
+  body          = { apiVersion = "v1", kind = "Bucket" }
+}`, "main.hcl")
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.NotContains(t, e.desiredResources, "bucket")
+	require.Len(t, e.discards, 1)
+	assert.Equal(t, discardTypeResource, e.discards[0].Type)
+	assert.Equal(t, discardReasonIncomplete, e.discards[0].Reason)
+	assert.Contains(t, e.discards[0].Context, "unknown external name")
+}
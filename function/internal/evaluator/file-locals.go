@@ -0,0 +1,55 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/locals"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// fileScopes are eval contexts that have the file locals of a file, keyed by file name.
+type fileScopes map[string]*hcl.EvalContext
+
+// context returns the context for the file in which the supplied block is declared, or the supplied
+// context if the file has no file locals.
+func (s fileScopes) context(ctx *hcl.EvalContext, block *hcl.Block) *hcl.EvalContext {
+	if fileCtx, ok := s[block.DefRange.Filename]; ok {
+		return fileCtx
+	}
+	return ctx
+}
+
+// fileLocalBlocks returns the file_locals blocks in the supplied content keyed by the file they are declared in.
+func fileLocalBlocks(content *hcl.BodyContent) map[string][]*hcl.Block {
+	ret := map[string][]*hcl.Block{}
+	for _, block := range content.Blocks {
+		if block.Type == blockFileLocals {
+			ret[block.DefRange.Filename] = append(ret[block.DefRange.Filename], block)
+		}
+	}
+	return ret
+}
+
+// processFileLocals evaluates the file locals of every file in a child of the supplied context, which already
+// has the values of the top-level locals. File locals are only visible to blocks declared in the same file,
+// such that different files can use the same names without colliding.
+func processFileLocals(ctx *hcl.EvalContext, content *hcl.BodyContent) (fileScopes, hcl.Diagnostics) {
+	blocksByFile := fileLocalBlocks(content)
+	var files []string
+	for file := range blocksByFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var diags hcl.Diagnostics
+	ret := fileScopes{}
+	for _, file := range files {
+		fileCtx, ds := locals.NewProcessor().ProcessBlocks(ctx, blocksByFile[file])
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			return nil, diags
+		}
+		ret[file] = fileCtx
+	}
+	return ret, diags
+}
@@ -0,0 +1,186 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLocalsEval(t *testing.T) {
+	files := []evaluator.File{
+		{
+			Name: "common.hcl",
+			Content: `
+locals {
+  region = req.composite.spec.parameters.region
+}
+`,
+		},
+		{
+			Name: "primary.hcl",
+			Content: `
+file_locals {
+  suffix = "primary"
+  name   = "${req.composite.metadata.name}-${suffix}"
+}
+resource primary {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "S3Bucket"
+    metadata   = { name = name }
+    spec       = { forProvider = { region = region } }
+  }
+}
+`,
+		},
+		{
+			Name: "replica.hcl",
+			Content: `
+file_locals {
+  suffix = "replica"
+}
+file_locals {
+  name = "${req.composite.metadata.name}-${suffix}"
+}
+group {
+  resource replica {
+    body = {
+      apiVersion = "aws.com/v1"
+      kind       = "S3Bucket"
+      metadata   = { name = name }
+      spec       = { forProvider = { region = region } }
+    }
+  }
+}
+`,
+		},
+	}
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), files...)
+	require.NoError(t, err)
+	for name, expected := range map[string]string{
+		"primary": "comp-a7df3-primary",
+		"replica": "comp-a7df3-replica",
+	} {
+		body := res.Desired.Resources[name].Resource.AsMap()
+		assert.Equal(t, expected, body["metadata"].(map[string]any)["name"])
+		assert.Equal(t, "us-east-1", body["spec"].(map[string]any)["forProvider"].(map[string]any)["region"])
+	}
+}
+
+func TestFileLocalsAnalyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []evaluator.File
+		errMsg string
+	}{
+		{
+			name: "good",
+			files: []evaluator.File{
+				{Name: "a.hcl", Content: `
+file_locals {
+  foo = "a"
+}
+resource a {
+  body = { name = foo }
+}
+`},
+				{Name: "b.hcl", Content: `
+file_locals {
+  foo = req.resource.a.metadata.name
+}
+resource b {
+  body = { name = foo }
+}
+`},
+			},
+		},
+		{
+			name: "not visible in other files",
+			files: []evaluator.File{
+				{Name: "a.hcl", Content: `
+file_locals {
+  foo = "a"
+}
+`},
+				{Name: "b.hcl", Content: `
+resource b {
+  body = { name = foo }
+}
+`},
+			},
+			errMsg: `b.hcl:3,19-22: invalid local variable reference; foo`,
+		},
+		{
+			name: "not visible in top-level locals",
+			files: []evaluator.File{
+				{Name: "a.hcl", Content: `
+file_locals {
+  foo = "a"
+}
+locals {
+  bar = foo
+}
+`},
+			},
+			errMsg: `a.hcl:6,9-12: reference to non-existent variable; foo`,
+		},
+		{
+			name: "bad reference",
+			files: []evaluator.File{
+				{Name: "a.hcl", Content: `
+file_locals {
+  foo = req.resource.b
+}
+`},
+			},
+			errMsg: `a.hcl:3,9-23: invalid resource name reference; b`,
+		},
+		{
+			name: "shadows local",
+			files: []evaluator.File{
+				{Name: "a.hcl", Content: `
+locals {
+  foo = "a"
+}
+`},
+				{Name: "b.hcl", Content: `
+file_locals {
+  foo = "b"
+}
+`},
+			},
+			errMsg: `b.hcl:3,3-12: attempt to shadow variable; foo`,
+		},
+		{
+			name: "not allowed in groups",
+			files: []evaluator.File{
+				{Name: "a.hcl", Content: `
+group {
+  file_locals {
+    foo = "a"
+  }
+}
+`},
+			},
+			errMsg: `Unsupported block type; Blocks of type "file_locals" are not expected here`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(test.files...)
+			if test.errMsg == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
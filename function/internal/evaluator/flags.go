@@ -0,0 +1,217 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const (
+	attrWhen     = "when"
+	flagFunction = "flag"
+
+	// maxAnalyzedFlags is the maximum number of flags for which all combinations are analyzed.
+	maxAnalyzedFlags = 6
+)
+
+// whenBlocks are the block types that support a when attribute.
+var whenBlocks = map[string]bool{
+	blockGroup:     true,
+	blockResource:  true,
+	blockResources: true,
+}
+
+// flagBody wraps an HCL body such that blocks with a when attribute that evaluates to false
+// are removed from its content. This makes blocks that are not enabled for the current feature flags
+// invisible to both evaluation and analysis. The when attribute itself is also removed from the content.
+type flagBody struct {
+	hcl.Body
+	e *Evaluator
+}
+
+func (e *Evaluator) withFlags(body hcl.Body) hcl.Body {
+	return &flagBody{Body: body, e: e}
+}
+
+func (f *flagBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, diags := f.Body.Content(schema)
+	if content == nil {
+		return nil, diags
+	}
+	content, ds := f.filter(content)
+	return content, diags.Extend(ds)
+}
+
+func (f *flagBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := f.Body.PartialContent(schema)
+	if content == nil {
+		return nil, remain, diags
+	}
+	content, ds := f.filter(content)
+	return content, f.e.withFlags(remain), diags.Extend(ds)
+}
+
+// filter returns a copy of the supplied content without the when attribute and without blocks that are not enabled.
+// Bodies of all remaining blocks are wrapped such that their content is filtered in turn.
+func (f *flagBody) filter(content *hcl.BodyContent) (*hcl.BodyContent, hcl.Diagnostics) {
+	ret := &hcl.BodyContent{
+		Attributes:       content.Attributes,
+		MissingItemRange: content.MissingItemRange,
+	}
+	if _, ok := content.Attributes[attrWhen]; ok {
+		ret.Attributes = hcl.Attributes{}
+		for name, attr := range content.Attributes {
+			if name != attrWhen {
+				ret.Attributes[name] = attr
+			}
+		}
+	}
+	var diags hcl.Diagnostics
+	for _, block := range content.Blocks {
+		if whenBlocks[block.Type] {
+			enabled, ds := f.e.evaluateWhen(block)
+			diags = diags.Extend(ds)
+			if !enabled {
+				continue
+			}
+		}
+		b := *block
+		b.Body = f.e.withFlags(block.Body)
+		ret.Blocks = append(ret.Blocks, &b)
+	}
+	return ret, diags
+}
+
+// whenContext returns the evaluation context for when attributes. It only has the flag function
+// such that when expressions are guaranteed to be static.
+func (e *Evaluator) whenContext() *hcl.EvalContext {
+	return &hcl.EvalContext{
+		Functions: map[string]function.Function{
+			flagFunction: function.New(&function.Spec{
+				Params: []function.Parameter{
+					{Name: "name", Type: cty.String},
+				},
+				Type: function.StaticReturnType(cty.Bool),
+				Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+					return cty.BoolVal(e.flags[args[0].AsString()]), nil
+				},
+			}),
+		},
+	}
+}
+
+// evaluateWhen returns true if the supplied block is enabled for the current set of flags.
+// Blocks without a when attribute are always enabled.
+func (e *Evaluator) evaluateWhen(block *hcl.Block) (bool, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: attrWhen}},
+	})
+	if diags.HasErrors() {
+		return false, diags
+	}
+	attr, ok := content.Attributes[attrWhen]
+	if !ok {
+		return true, nil
+	}
+	val, diags := attr.Expr.Value(e.whenContext())
+	if diags.HasErrors() {
+		return false, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "invalid when expression",
+			Detail:   fmt.Sprintf("when expressions may only use flag() calls with constant names and boolean operators: %s", diags.Error()),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	if !val.IsWhollyKnown() || val.IsNull() || val.Type() != cty.Bool {
+		return false, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "invalid when expression",
+			Detail:   fmt.Sprintf("got type %s, expected %s", val.Type().FriendlyName(), cty.Bool.FriendlyName()),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	return val.True(), nil
+}
+
+// usedFlags returns the sorted names of all flags referenced in when attributes of the parsed files.
+func (e *Evaluator) usedFlags() []string {
+	seen := map[string]bool{}
+	var walk func(body *hclsyntax.Body)
+	walk = func(body *hclsyntax.Body) {
+		if attr, ok := body.Attributes[attrWhen]; ok {
+			_ = hclsyntax.VisitAll(attr.Expr, func(n hclsyntax.Node) hcl.Diagnostics {
+				call, ok := n.(*hclsyntax.FunctionCallExpr)
+				if !ok || call.Name != flagFunction || len(call.Args) != 1 {
+					return nil
+				}
+				v, diags := call.Args[0].Value(nil)
+				if !diags.HasErrors() && v.IsWhollyKnown() && !v.IsNull() && v.Type() == cty.String {
+					seen[v.AsString()] = true
+				}
+				return nil
+			})
+		}
+		for _, block := range body.Blocks {
+			walk(block.Body)
+		}
+	}
+	for _, f := range e.files {
+		if body, ok := f.Body.(*hclsyntax.Body); ok {
+			walk(body)
+		}
+	}
+	var ret []string
+	for name := range seen {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// flagCombinations returns the sets of flags to analyze for the supplied flag names, along with a diagnostic
+// if there were too many flags to analyze all combinations.
+func flagCombinations(names []string) ([][]string, hcl.Diagnostics) {
+	if len(names) > maxAnalyzedFlags {
+		return [][]string{{}, names}, hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("too many flags (%d) to analyze all combinations", len(names)),
+			Detail:   "only analyzed with no flags and all flags set",
+		}}
+	}
+	var ret [][]string
+	for mask := 0; mask < 1<<len(names); mask++ {
+		var set []string
+		for i, name := range names {
+			if mask&(1<<i) != 0 {
+				set = append(set, name)
+			}
+		}
+		ret = append(ret, set)
+	}
+	return ret, nil
+}
+
+// toFlagSet converts a list of flags to a set. A nil list returns a nil set.
+func toFlagSet(flags []string) map[string]bool {
+	if flags == nil {
+		return nil
+	}
+	ret := map[string]bool{}
+	for _, f := range flags {
+		ret[f] = true
+	}
+	return ret
+}
+
+// describeFlags returns a display string for the supplied flags.
+func describeFlags(flags []string) string {
+	if len(flags) == 0 {
+		return "no flags"
+	}
+	return "flags " + strings.Join(flags, ", ")
+}
@@ -0,0 +1,188 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flagsHCL = `
+resource base {
+  body = {
+    kind = "Base"
+  }
+}
+
+resource gpu-pool {
+  when = flag("gpu")
+  body = {
+    kind = "NodePool"
+  }
+}
+
+group {
+  when = flag("multi-az") && !flag("gpu")
+  resource replica {
+    body = {
+      kind = "Replica"
+    }
+  }
+}
+
+resources zones {
+  when     = flag("multi-az")
+  for_each = ["a", "b"]
+  template {
+    body = {
+      kind = "Zone"
+    }
+  }
+}
+`
+
+func TestEvaluator_Flags(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    []string
+		expected []string
+	}{
+		{
+			name:     "no flags",
+			expected: []string{"base"},
+		},
+		{
+			name:     "gpu",
+			flags:    []string{"gpu"},
+			expected: []string{"base", "gpu-pool"},
+		},
+		{
+			name:     "multi-az",
+			flags:    []string{"multi-az"},
+			expected: []string{"base", "replica", "zones-0", "zones-1"},
+		},
+		{
+			name:     "all",
+			flags:    []string{"gpu", "multi-az"},
+			expected: []string{"base", "gpu-pool", "zones-0", "zones-1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{Flags: test.flags})
+			require.NoError(t, err)
+			content, diags := e.toContent([]File{{Name: "test.hcl", Content: flagsHCL}})
+			require.False(t, diags.HasErrors(), diags.Error())
+			diags = e.processGroup(createTestEvalContext(), content)
+			require.False(t, diags.HasErrors(), diags.Error())
+			var names []string
+			for name := range e.desiredResources {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, test.expected, names)
+		})
+	}
+}
+
+func TestAnalyzeFlags(t *testing.T) {
+	// the same resource name is defined for different variants
+	hcl := `
+resource pool {
+  when = flag("gpu")
+  body = {
+    apiVersion = "example.org/v1"
+    kind       = "GPUPool"
+  }
+}
+resource pool {
+  when = !flag("gpu")
+  body = {
+    apiVersion = "example.org/v1"
+    kind       = "Pool"
+  }
+}
+`
+	e, err := New(Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(File{Name: "test.hcl", Content: hcl})
+	require.Empty(t, diags)
+
+	// errors that only show up for a variant are reported with the flags used
+	hcl = `
+resource pool {
+  when = flag("gpu") || flag("multi-az")
+  body = {
+    apiVersion = "example.org/v1"
+    kind       = "Pool"
+  }
+}
+resource pool {
+  when = flag("gpu")
+  body = {
+    apiVersion = "example.org/v1"
+    kind       = "GPUPool"
+  }
+}
+`
+	e, err = New(Options{})
+	require.NoError(t, err)
+	diags = e.Analyze(File{Name: "test.hcl", Content: hcl})
+	require.Len(t, diags, 1)
+	assert.Contains(t, diags.Error(), "test.hcl:9,10-14: resource defined more than once; pool (with flags gpu)")
+
+	// explicit flags only analyze one variant
+	e, err = New(Options{Flags: []string{"multi-az"}})
+	require.NoError(t, err)
+	diags = e.Analyze(File{Name: "test.hcl", Content: hcl})
+	require.Empty(t, diags)
+}
+
+func TestAnalyzeFlagsNegative(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name: "variable reference",
+			hcl: `
+resource pool {
+  when = req.composite.spec.gpu
+  body = {}
+}
+`,
+			errMsg: "test.hcl:3,10-32: invalid when expression; when expressions may only use flag() calls with constant names and boolean operators",
+		},
+		{
+			name: "non-boolean",
+			hcl: `
+group {
+  when = "gpu"
+}
+`,
+			errMsg: "test.hcl:3,10-15: invalid when expression; got type string, expected bool",
+		},
+		{
+			name: "unsupported block",
+			hcl: `
+resource pool {
+  body = {}
+  ready {
+    when  = flag("gpu")
+    value = "READY_TRUE"
+  }
+}
+`,
+			errMsg: `An argument named "when" is not expected here`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
@@ -16,11 +16,19 @@ type Arg struct {
 	Default     cty.Value // the default value
 }
 
+// validation is a condition that the arguments of a user function must satisfy.
+type validation struct {
+	condition hcl.Expression // must evaluate to true
+	message   hcl.Expression // message reported when the condition is false
+}
+
 // UserFunction represents a user-defined function.
 type UserFunction struct {
-	Name         string           // user function name
+	Name         string           // user function name, optionally qualified by namespaces separated by dots
 	Description  string           // optional description
+	DefRange     hcl.Range        // source range of the function declaration
 	Args         map[string]*Arg  // named arguments
+	validations  []*validation    // conditions checked before the result is evaluated
 	body         hcl.Expression   // result expression
 	blockContent *hcl.BodyContent // function block in which to find locals blocks
 }
@@ -28,16 +36,35 @@ type UserFunction struct {
 // Processor loads user functions and provides mechanisms to provide a root context.
 // capable of invoking these functions.
 type Processor struct {
-	Functions map[string]*UserFunction
-	invoker   *invoker
+	Functions         map[string]*UserFunction
+	invoker           *invoker
+	maxDepth          int
+	maxCollectionSize int               // maximum number of elements of collections returned by functions
+	disabled          map[string]string // reasons for which functions are disabled, by function name
+	observed          map[string]bool   // names of functions whose calls are reported to the observer
+	observer          func(name string) // called with the name of observed functions before they are called
 }
 
 // NewProcessor creates a processor.
 func NewProcessor() *Processor {
 	return &Processor{
 		Functions: map[string]*UserFunction{},
-		invoker:   newInvoker(nil),
+		invoker:   newInvoker(nil, DefaultMaxDepth),
+		maxDepth:  DefaultMaxDepth,
+		disabled:  map[string]string{},
+		observed:  map[string]bool{},
+	}
+}
+
+// SetMaxDepth sets the maximum depth of nested user function and lambda calls. DefaultMaxDepth is used when
+// the supplied depth is not positive, and MaxDepthLimit when it is larger than that.
+func (e *Processor) SetMaxDepth(depth int) {
+	if depth <= 0 {
+		depth = DefaultMaxDepth
 	}
+	depth = min(depth, MaxDepthLimit)
+	e.maxDepth = depth
+	e.invoker.maxDepth = depth
 }
 
 // Process processes the supplied body for function definitions.
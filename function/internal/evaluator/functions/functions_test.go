@@ -116,7 +116,86 @@ function factorial {
 	expr = parseExpression(t, `invoke("factorial", { n: 101 })`)
 	_, diags = expr.Value(ctx)
 	require.True(t, diags.HasErrors())
-	assert.Contains(t, diags.Error(), "user function calls: max depth 100 exceeded")
+	assert.Contains(t, diags.Error(), "user function calls: max depth 100 exceeded, call chain: factorial (x100)")
+}
+
+func TestFunctionCallsMaxDepth(t *testing.T) {
+	defs := parseFunctionsHCL(t, `
+function even {
+	arg n {}
+	body = n == 0 ? true : invoke("odd", { n: n - 1 })
+}
+function odd {
+	arg n {}
+	body = n == 0 ? false : apply(lambda(["x"], "invoke(\"even\", { n: x })"), n - 1)
+}
+`)
+	p := functions.NewProcessor()
+	p.SetMaxDepth(15)
+	diags := p.Process(defs)
+	require.False(t, diags.HasErrors(), diags.Error())
+	ctx := p.RootContext(nil)
+
+	v, diags := parseExpression(t, `invoke("even", { n: 2 })`).Value(ctx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.True, v)
+
+	_, diags = parseExpression(t, `invoke("even", { n: 20 })`).Value(ctx)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(),
+		"user function calls: max depth 15 exceeded, call chain: ... -> lambda -> even -> odd -> lambda -> even -> odd -> lambda -> even -> odd -> lambda")
+}
+
+func TestFunctionCallsMaxDepthLimit(t *testing.T) {
+	defs := parseFunctionsHCL(t, `
+function countdown {
+	arg n {}
+	body = n == 0 ? 0 : invoke("countdown", { n: n - 1 })
+}
+`)
+	p := functions.NewProcessor()
+	p.SetMaxDepth(5000)
+	diags := p.Process(defs)
+	require.False(t, diags.HasErrors(), diags.Error())
+	_, diags = parseExpression(t, `invoke("countdown", { n: 2000 })`).Value(p.RootContext(nil))
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "user function calls: max depth 1000 exceeded")
+}
+
+func TestFunctionLimits(t *testing.T) {
+	defs := parseFunctionsHCL(t, `
+function items {
+	arg n {}
+	body = range(n)
+}
+function upper {
+	arg s {}
+	body = upper(s)
+}
+`)
+
+	p := functions.NewProcessor()
+	p.DisableFunctions("not allowed here", "upper")
+	p.SetMaxCollectionSize(3)
+	diags := p.Process(defs)
+	require.False(t, diags.HasErrors(), diags.Error())
+	ctx := p.RootContext(nil)
+
+	v, diags := parseExpression(t, `invoke("items", { n: 3 })`).Value(ctx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, 3, v.LengthInt())
+
+	_, diags = parseExpression(t, `invoke("items", { n: 4 })`).Value(ctx)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "result has 4 elements, more than the maximum of 3")
+
+	_, diags = parseExpression(t, `upper("a")`).Value(ctx)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "function upper is disabled: not allowed here")
+
+	_, diags = parseExpression(t, `invoke("upper", { s: "a" })`).Value(ctx)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "function upper is disabled: not allowed here")
 }
 
 func TestFunctionCallsNegative(t *testing.T) {
@@ -198,7 +277,7 @@ function x {
 		},
 		{
 			name: "function name not identifier",
-			msg:  `test.hcl:2,10-20: function "x plus y" : name must be an identifier`,
+			msg:  `test.hcl:2,10-20: function "x plus y" : name must be an identifier, optionally qualified by namespaces separated by dots`,
 			hcl: `
 function "x plus y" { 
 	arg y {} 
@@ -228,7 +307,7 @@ function x {
 		},
 		{
 			name: "duplicate function declaration",
-			msg:  `test.hcl:6,1-11: duplicate function declaration; x`,
+			msg:  `test.hcl:6,1-11: duplicate function declaration; x, also declared at test.hcl:2,1-11`,
 			hcl: `
 function x { 
 	arg y {} 
@@ -237,6 +316,60 @@ function x {
 function x { 
 	arg z {} 
 	body = z
+}
+			`,
+		},
+		{
+			name: "function name with empty namespace",
+			msg:  `test.hcl:2,10-22: function "strings..x" : name must be an identifier`,
+			hcl: `
+function "strings..x" {
+	body = 1
+}
+			`,
+		},
+		{
+			name: "all duplicates reported",
+			msg:  `test.hcl:8,1-28: duplicate function declaration; strings.truncate, also declared at test.hcl:2,1-28`,
+			hcl: `
+function "strings.truncate" {
+	body = 1
+}
+function "lists.first" {
+	body = 1
+}
+function "strings.truncate" {
+	body = 2
+}
+function "lists.first" {
+	body = 2
+}
+			`,
+		},
+		{
+			name: "validate without message",
+			msg:  `test.hcl:4,11-11: Missing required argument; The argument "message" is required`,
+			hcl: `
+function x {
+	arg y {}
+	validate {
+		condition = y > 0
+	}
+	body = y
+}
+			`,
+		},
+		{
+			name: "validate with bad reference",
+			msg:  `test.hcl:5,15-19: function x: reference to non-existent variable; cont, did you mean "count"?`,
+			hcl: `
+function x {
+	arg count {}
+	validate {
+		condition = cont > 0
+		message   = "bad"
+	}
+	body = count
 }
 			`,
 		},
@@ -386,3 +519,303 @@ function plus10 {
 	assert.True(t, diags.HasErrors())
 	assert.Contains(t, diags.Error(), `expr.hcl:1,8-16: invoke called on unknown function: "plus20"`)
 }
+
+func TestProcessorCheckInvokeArgs(t *testing.T) {
+	p := functions.NewProcessor()
+	diags := p.Process(parseFunctionsHCL(t, `
+function add {
+	arg left {}
+	arg right {}
+	arg scale {
+		default = 1
+	}
+	body = (left + right) * scale
+}
+`))
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	tests := []struct {
+		name string
+		expr string
+		msg  string
+	}{
+		{name: "all args", expr: `invoke("add", { left: 1, right: 2, scale: 3 })`},
+		{name: "defaults", expr: `invoke("add", { "left": 1, right = 2 })`},
+		{name: "dynamic keys", expr: `invoke("add", { (local.name): 1 })`},
+		{name: "not a literal", expr: `invoke("add", local.args)`},
+		{
+			name: "unknown arg",
+			expr: `invoke("add", { left: 1, rigth: 2 })`,
+			msg:  `expr.hcl:1,26-31: function add, invalid argument "rigth"; did you mean "right"?`,
+		},
+		{
+			name: "missing arg",
+			expr: `invoke("add", { left: 1 })`,
+			msg:  `expr.hcl:1,15-26: function add, argument "right" expected but not supplied`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diags := p.CheckUserFunctionRefs(parseExpression(t, test.expr))
+			if test.msg == "" {
+				assert.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.msg)
+		})
+	}
+}
+
+func TestNamespacedFunctions(t *testing.T) {
+	p := functions.NewProcessor()
+	diags := p.Process(parseFunctionsHCL(t, `
+function "strings.truncate" {
+	arg s {}
+	arg n {
+		default = 3
+	}
+	body = substr(s, 0, n)
+}
+function truncate {
+	arg s {}
+	body = invoke("strings.truncate", { s: s, n: 1 })
+}
+`))
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, "strings.truncate", p.Functions["strings.truncate"].Name)
+
+	ctx := p.RootContext(nil)
+	v, diags := parseExpression(t, `invoke("strings.truncate", { s: "abcdef" })`).Value(ctx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("abc"), v)
+	v, diags = parseExpression(t, `invoke("truncate", { s: "abcdef" })`).Value(ctx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("a"), v)
+
+	diags = p.CheckUserFunctionRefs(parseExpression(t, `invoke("strings.trunc", { s: "abcdef" })`))
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), `invoke called on unknown function: "strings.trunc"`)
+}
+
+func TestLambda(t *testing.T) {
+	p := functions.NewProcessor()
+	diags := p.Process(parseFunctionsHCL(t, `
+function "strings.truncate" {
+	arg s {}
+	body = substr(s, 0, 2)
+}
+`))
+	require.False(t, diags.HasErrors(), diags.Error())
+	ctx := p.RootContext(functions.DynamicObject{
+		"names": cty.TupleVal([]cty.Value{cty.StringVal("alpha"), cty.StringVal("beta")}),
+	})
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected cty.Value
+		msg      string
+	}{
+		{
+			name:     "in a for expression",
+			expr:     `[for n in names : apply(lambda(["x"], "upper(x)"), n)]`,
+			expected: cty.TupleVal([]cty.Value{cty.StringVal("ALPHA"), cty.StringVal("BETA")}),
+		},
+		{
+			name:     "multiple parameters",
+			expr:     `apply(lambda(["a", "b"], "a * b + 1"), 3, 4)`,
+			expected: cty.NumberIntVal(13),
+		},
+		{
+			name:     "no parameters",
+			expr:     `apply(lambda([], "\"constant\""))`,
+			expected: cty.StringVal("constant"),
+		},
+		{
+			name:     "invokes user functions",
+			expr:     `apply(lambda(["x"], "invoke(\"strings.truncate\", { s: x })"), "alpha")`,
+			expected: cty.StringVal("al"),
+		},
+		{
+			name: "wrong number of arguments",
+			expr: `apply(lambda(["a", "b"], "a + b"), 1)`,
+			msg:  `lambda(a, b): expected 2 arguments, got 1`,
+		},
+		{
+			name: "no access to outer variables",
+			expr: `apply(lambda(["x"], "names"), 1)`,
+			msg:  `Unknown variable; There is no variable named "names"`,
+		},
+		{
+			name: "bad parameter",
+			expr: `lambda(["a b"], "1")`,
+			msg:  `lambda parameter cty.StringVal("a b") is not an identifier`,
+		},
+		{
+			name: "duplicate parameter",
+			expr: `lambda(["a", "a"], "a")`,
+			msg:  `lambda parameter "a" declared more than once`,
+		},
+		{
+			name: "bad body",
+			expr: `lambda(["a"], "a +")`,
+			msg:  `lambda body:`,
+		},
+		{
+			name: "not a lambda",
+			expr: `apply("upper", "a")`,
+			msg:  `Invalid value for "fn" parameter: lambda required`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, diags := parseExpression(t, test.expr).Value(ctx)
+			if test.msg != "" {
+				require.True(t, diags.HasErrors())
+				assert.Contains(t, diags.Error(), test.msg)
+				return
+			}
+			require.False(t, diags.HasErrors(), diags.Error())
+			assert.True(t, test.expected.RawEquals(v), v.GoString())
+		})
+	}
+}
+
+func TestLambdaCheckRefs(t *testing.T) {
+	p := functions.NewProcessor()
+	diags := p.Process(parseFunctionsHCL(t, `
+function double {
+	arg n {}
+	body = apply(lambda(["x"], "x * 2"), n)
+}
+`))
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	tests := []struct {
+		name string
+		expr string
+		msg  string
+	}{
+		{
+			name: "good",
+			expr: `lambda(["x"], "invoke(\"double\", { n: x })")`,
+		},
+		{
+			name: "not constant",
+			expr: `lambda(params, body)`,
+		},
+		{
+			name: "unknown variable",
+			expr: `lambda(["item"], "upper(iten)")`,
+			msg:  `expr.hcl:1,25-29: lambda: reference to non-existent variable; iten, did you mean "item"?`,
+		},
+		{
+			name: "unknown user function",
+			expr: `lambda(["x"], "invoke(\"triple\", { n: x })")`,
+			msg:  `invoke called on unknown function: "triple"`,
+		},
+		{
+			name: "bad parameters",
+			expr: `lambda("x", "x")`,
+			msg:  `expr.hcl:1,8-11: lambda parameters must be a list of names, found string`,
+		},
+		{
+			name: "wrong number of arguments",
+			expr: `lambda(["x"])`,
+			msg:  `lambda has incorrect number of arguments; want 2, got 1`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diags := p.CheckUserFunctionRefs(parseExpression(t, test.expr))
+			if test.msg == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.msg)
+		})
+	}
+}
+
+func TestFunctionValidations(t *testing.T) {
+	p := functions.NewProcessor()
+	diags := p.Process(parseFunctionsHCL(t, `
+function truncate {
+	arg s {}
+	arg n {
+		default = 3
+	}
+	locals {
+		max = 10
+	}
+	validate {
+		condition = n > 0
+		message   = "n must be positive, got ${n}"
+	}
+	validate {
+		condition = n <= max
+		message   = "n must be at most ${max}"
+	}
+	body = substr(s, 0, n)
+}
+function badCondition {
+	arg n {}
+	validate {
+		condition = n
+		message   = "n must be true"
+	}
+	body = n
+}
+`))
+	require.False(t, diags.HasErrors(), diags.Error())
+	ctx := p.RootContext(functions.DynamicObject{
+		"unknown": cty.UnknownVal(cty.Number),
+	})
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected cty.Value
+		msg      string
+	}{
+		{
+			name:     "valid",
+			expr:     `invoke("truncate", { s: "abcdef", n: 2 })`,
+			expected: cty.StringVal("ab"),
+		},
+		{
+			name: "first validation fails",
+			expr: `invoke("truncate", { s: "abcdef", n: 0 })`,
+			msg:  `expr.hcl:1,1-8: Error in function call; Call to function "invoke" failed: function truncate: n must be positive, got 0.`,
+		},
+		{
+			name: "second validation fails",
+			expr: `invoke("truncate", { s: "abcdef", n: 11 })`,
+			msg:  `function truncate: n must be at most 10`,
+		},
+		{
+			name:     "unknown argument",
+			expr:     `invoke("truncate", { s: "abcdef", n: unknown })`,
+			expected: cty.DynamicVal,
+		},
+		{
+			name: "condition not a bool",
+			expr: `invoke("badCondition", { n: "yes" })`,
+			msg:  `function badCondition: validate condition: got type string, expected bool`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, diags := parseExpression(t, test.expr).Value(ctx)
+			if test.msg != "" {
+				require.True(t, diags.HasErrors())
+				assert.Contains(t, diags.Error(), test.msg)
+				return
+			}
+			require.False(t, diags.HasErrors(), diags.Error())
+			assert.True(t, test.expected.RawEquals(v), v.GoString())
+		})
+	}
+}
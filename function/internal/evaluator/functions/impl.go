@@ -2,6 +2,8 @@ package functions
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions/internal/funcs"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
@@ -14,7 +16,12 @@ import (
 
 const (
 	InvokeFunctionName = "invoke"
-	maxDepth           = 100
+	// DefaultMaxDepth is the default maximum depth of nested user function and lambda calls.
+	DefaultMaxDepth = 100
+	// MaxDepthLimit is the largest maximum depth of nested user function and lambda calls that can be set.
+	MaxDepthLimit = 1000
+	// maxChainLength is the maximum number of calls shown in the call chain when the maximum depth is exceeded.
+	maxChainLength = 10
 )
 
 func (f *UserFunction) checkRefs(i *invoker) hcl.Diagnostics {
@@ -28,20 +35,52 @@ func (f *UserFunction) checkRefs(i *invoker) hcl.Diagnostics {
 	if diags.HasErrors() {
 		return diags
 	}
-	vars := f.body.Variables()
-	for _, v := range vars {
-		ref := v.RootName()
-		if !hclutils.HasVariable(ctx, ref) {
-			diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %s: reference to non-existent variable", f.Name), ref, v.SourceRange()))
-		}
+	exprs := []hcl.Expression{f.body}
+	for _, v := range f.validations {
+		exprs = append(exprs, v.condition, v.message)
 	}
-	n, ok := f.body.(hclsyntax.Node)
-	if ok {
-		diags = diags.Extend(i.checkUserFunctionRefs(n))
+	for _, expr := range exprs {
+		for _, v := range expr.Variables() {
+			ref := v.RootName()
+			if !hclutils.HasVariable(ctx, ref) {
+				diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %s: reference to non-existent variable", f.Name),
+					hclutils.DidYouMean(ref, ref, hclutils.VariableNames(ctx)), v.SourceRange()))
+			}
+		}
+		n, ok := expr.(hclsyntax.Node)
+		if ok {
+			diags = diags.Extend(i.checkUserFunctionRefs(n))
+		}
 	}
 	return diags
 }
 
+// validate checks the validations of the function in the supplied context. It returns false without an error
+// when a condition cannot be evaluated yet because it depends on unknown values.
+func (f *UserFunction) validate(ctx *hcl.EvalContext) (bool, error) {
+	for _, v := range f.validations {
+		cond, diags := v.condition.Value(ctx)
+		if diags.HasErrors() {
+			return false, fmt.Errorf("function %s: validate condition: %s", f.Name, diags.Error())
+		}
+		if !cond.IsKnown() {
+			return false, nil
+		}
+		if cond.IsNull() || !cond.Type().Equals(cty.Bool) {
+			return false, fmt.Errorf("function %s: validate condition: got type %s, expected %s", f.Name, cond.Type().FriendlyName(), cty.Bool.FriendlyName())
+		}
+		if cond.True() {
+			continue
+		}
+		msg, diags := v.message.Value(ctx)
+		if diags.HasErrors() || !msg.IsWhollyKnown() || msg.IsNull() || !msg.Type().Equals(cty.String) {
+			return false, fmt.Errorf("function %s: validation failed at %s", f.Name, v.condition.Range())
+		}
+		return false, fmt.Errorf("function %s: %s", f.Name, msg.AsString())
+	}
+	return true, nil
+}
+
 func (f *UserFunction) invoke(i *invoker, params DynamicObject) (cty.Value, error) {
 	for pName := range params {
 		if _, ok := f.Args[pName]; !ok {
@@ -65,6 +104,13 @@ func (f *UserFunction) invoke(i *invoker, params DynamicObject) (cty.Value, erro
 	if diags.HasErrors() {
 		return cty.NilVal, diags
 	}
+	ok, err := f.validate(ctx)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	if !ok {
+		return cty.DynamicVal, nil
+	}
 	ret, diags := f.body.Value(ctx)
 	if diags.HasErrors() {
 		return cty.NilVal, diags
@@ -73,17 +119,19 @@ func (f *UserFunction) invoke(i *invoker, params DynamicObject) (cty.Value, erro
 }
 
 type invoker struct {
-	fns     map[string]*UserFunction
-	depth   int
-	funcMap map[string]function.Function
+	fns      map[string]*UserFunction
+	calls    []string // names of the functions being called, outermost first
+	maxDepth int
+	funcMap  map[string]function.Function
 }
 
-func newInvoker(fns map[string]*UserFunction) *invoker {
+func newInvoker(fns map[string]*UserFunction, maxDepth int) *invoker {
 	if fns == nil {
 		fns = map[string]*UserFunction{}
 	}
 	ret := &invoker{
-		fns: fns,
+		fns:      fns,
+		maxDepth: maxDepth,
 	}
 	all := funcs.All()
 	f := function.New(&function.Spec{
@@ -106,6 +154,8 @@ func newInvoker(fns map[string]*UserFunction) *invoker {
 		Impl: ret.invoke,
 	})
 	all[InvokeFunctionName] = f
+	all[LambdaFunctionName] = ret.lambdaFunction()
+	all[ApplyFunctionName] = ret.applyFunction()
 	ret.funcMap = all
 	return ret
 }
@@ -117,16 +167,51 @@ func (i *invoker) rootContext(values DynamicObject) *hcl.EvalContext {
 	}
 }
 
-func (i *invoker) invoke(args []cty.Value, _ cty.Type) (cty.Value, error) {
-	i.depth++
-	if i.depth >= maxDepth {
-		return cty.NilVal, fmt.Errorf("user function calls: max depth %d exceeded", maxDepth)
+// enter records a call to the named function. It returns an error that shows the chain of calls when the
+// maximum depth is exceeded.
+func (i *invoker) enter(name string) error {
+	if len(i.calls)+1 >= i.maxDepth {
+		return fmt.Errorf("user function calls: max depth %d exceeded, call chain: %s", i.maxDepth,
+			callChain(append(i.calls[:len(i.calls):len(i.calls)], name)))
 	}
-	defer func() {
-		i.depth--
-	}()
+	i.calls = append(i.calls, name)
+	return nil
+}
 
+// leave records the return from the innermost call.
+func (i *invoker) leave() {
+	i.calls = i.calls[:len(i.calls)-1]
+}
+
+// callChain formats the supplied function names for display. Consecutive calls to the same function are
+// collapsed and only the innermost calls are shown for long chains.
+func callChain(names []string) string {
+	var parts []string
+	for n := 0; n < len(names); {
+		count := 1
+		for n+count < len(names) && names[n+count] == names[n] {
+			count++
+		}
+		part := names[n]
+		if count > 1 {
+			part = fmt.Sprintf("%s (x%d)", part, count)
+		}
+		parts = append(parts, part)
+		n += count
+	}
+	if len(parts) > maxChainLength {
+		parts = append([]string{"..."}, parts[len(parts)-maxChainLength:]...)
+	}
+	return strings.Join(parts, " -> ")
+}
+
+func (i *invoker) invoke(args []cty.Value, _ cty.Type) (cty.Value, error) {
 	name := args[0].AsString()
+	if err := i.enter(name); err != nil {
+		return cty.NilVal, err
+	}
+	defer i.leave()
+
 	fn, ok := i.fns[name]
 	if !ok {
 		return cty.NilVal, fmt.Errorf("user function '%s' not found", name)
@@ -146,6 +231,10 @@ func (i *invoker) checkUserFunctionRefs(expr hclsyntax.Node) hcl.Diagnostics {
 		if !ok {
 			return nil
 		}
+		if fnCall.Name == LambdaFunctionName {
+			diags = diags.Extend(i.checkLambda(fnCall))
+			return nil
+		}
 		if fnCall.Name != InvokeFunctionName {
 			return nil
 		}
@@ -160,11 +249,50 @@ func (i *invoker) checkUserFunctionRefs(expr hclsyntax.Node) hcl.Diagnostics {
 			diags = diags.Extend(hclutils.ToErrorDiag("user function invocation is not via a static string", "", fnCall.Args[0].Range()))
 			return nil
 		}
-		if _, ok := i.fns[v.AsString()]; !ok {
+		fn, ok := i.fns[v.AsString()]
+		if !ok {
 			diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("invoke called on unknown function: %q", v.AsString()), "", fnCall.Args[0].Range()))
 			return nil
 		}
+		if obj, ok := fnCall.Args[1].(*hclsyntax.ObjectConsExpr); ok {
+			diags = diags.Extend(fn.checkArgs(obj))
+		}
 		return nil
 	})
 	return diags
 }
+
+// checkArgs checks the keys of an object literal passed to invoke against the arguments of the function. Nothing
+// is checked when a key is not a constant string since the names of the supplied arguments are not known.
+func (f *UserFunction) checkArgs(obj *hclsyntax.ObjectConsExpr) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	supplied := map[string]bool{}
+	for _, item := range obj.Items {
+		k, ds := item.KeyExpr.Value(nil)
+		if ds.HasErrors() || !k.IsWhollyKnown() || k.IsNull() || k.Type() != cty.String {
+			return nil
+		}
+		name := k.AsString()
+		supplied[name] = true
+		if _, ok := f.Args[name]; !ok {
+			var names []string
+			for n := range f.Args {
+				names = append(names, n)
+			}
+			diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %s, invalid argument %q", f.Name, name),
+				hclutils.DidYouMean("", name, names), item.KeyExpr.Range()))
+		}
+	}
+	var missing []string
+	for name, arg := range f.Args {
+		if !arg.HasDefault && !supplied[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %s, argument %q expected but not supplied", f.Name, name),
+			"", obj.Range()))
+	}
+	return diags
+}
@@ -29,14 +29,17 @@ func All() map[string]function.Function {
 		"cidrsubnet":       CidrSubnetFunc,
 		"cidrsubnets":      CidrSubnetsFunc,
 		"coalesce":         CoalesceFunc,
+		"collect":          CollectFunc,
 		"coalescelist":     stdlib.CoalesceListFunc,
 		"compact":          stdlib.CompactFunc,
 		"concat":           stdlib.ConcatFunc,
 		"contains":         stdlib.ContainsFunc,
 		"csvdecode":        stdlib.CSVDecodeFunc,
 		"distinct":         stdlib.DistinctFunc,
+		"dns1123":          DNS1123Func,
 		"element":          stdlib.ElementFunc,
 		"endswith":         EndsWithFunc,
+		"equal":            EqualFunc,
 		"chunklist":        stdlib.ChunklistFunc,
 		"flatten":          stdlib.FlattenFunc,
 		"floor":            stdlib.FloorFunc,
@@ -49,6 +52,7 @@ func All() map[string]function.Function {
 		"jsondecode":       stdlib.JSONDecodeFunc,
 		"jsonencode":       stdlib.JSONEncodeFunc,
 		"keys":             stdlib.KeysFunc,
+		"kubernetes_name":  KubernetesNameFunc,
 		"length":           LengthFunc,
 		"list":             ListFunc,
 		"log":              stdlib.LogFunc,
@@ -59,9 +63,11 @@ func All() map[string]function.Function {
 		"max":              stdlib.MaxFunc,
 		"md5":              Md5Func,
 		"merge":            stdlib.MergeFunc,
+		"merge_resources":  MergeResourcesFunc,
 		"min":              stdlib.MinFunc,
 		"one":              OneFunc,
 		"parseint":         stdlib.ParseIntFunc,
+		"pemdecode":        PemDecodeFunc,
 		"pow":              stdlib.PowFunc,
 		"range":            stdlib.RangeFunc,
 		"regex":            stdlib.RegexFunc,
@@ -79,6 +85,8 @@ func All() map[string]function.Function {
 		"signum":           stdlib.SignumFunc,
 		"slice":            stdlib.SliceFunc,
 		"sort":             stdlib.SortFunc,
+		"sshfingerprint":   SSHFingerprintFunc,
+		"sshpublickey":     SSHPublicKeyFunc,
 		"split":            stdlib.SplitFunc,
 		"startswith":       StartsWithFunc,
 		"strcontains":      StrContainsFunc,
@@ -106,6 +114,7 @@ func All() map[string]function.Function {
 		"upper":            stdlib.UpperFunc,
 		"urlencode":        URLEncodeFunc,
 		"values":           stdlib.ValuesFunc,
+		"x509decode":       X509DecodeFunc,
 		"yamldecode":       ctyyaml.YAMLDecodeFunc,
 		"yamlencode":       ctyyaml.YAMLEncodeFunc,
 		"zipmap":           stdlib.ZipmapFunc,
@@ -0,0 +1,106 @@
+package funcs
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// EqualFunc constructs a function that compares two values deeply without regard to their types, the way values
+// compare once they are rendered into a resource. Numbers and booleans are equal to strings that convert to them,
+// and attributes with null values are equal to missing attributes. The result is unknown when either value is not
+// wholly known.
+var EqualFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "a",
+			Type:             cty.DynamicPseudoType,
+			AllowUnknown:     true,
+			AllowNull:        true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:             "b",
+			Type:             cty.DynamicPseudoType,
+			AllowUnknown:     true,
+			AllowNull:        true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if !args[0].IsWhollyKnown() || !args[1].IsWhollyKnown() {
+			return cty.UnknownVal(cty.Bool), nil
+		}
+		return cty.BoolVal(looselyEqual(args[0], args[1])), nil
+	},
+})
+
+// Equal returns true if the supplied values are deeply equal without regard to their types.
+func Equal(a, b cty.Value) (cty.Value, error) {
+	return EqualFunc.Call([]cty.Value{a, b})
+}
+
+// isSequence returns true if the supplied type is a list, tuple or set.
+func isSequence(ty cty.Type) bool {
+	return isListLike(ty) || ty.IsSetType()
+}
+
+// looselyEqual returns true if the supplied wholly known values are deeply equal without regard to their types.
+func looselyEqual(a, b cty.Value) bool {
+	if a.IsNull() || b.IsNull() {
+		return a.IsNull() && b.IsNull()
+	}
+	at, bt := a.Type(), b.Type()
+	switch {
+	case isObjectLike(at) && isObjectLike(bt):
+		am, bm := a.AsValueMap(), b.AsValueMap()
+		for k, av := range am {
+			bv, ok := bm[k]
+			if !ok {
+				bv = cty.NullVal(cty.DynamicPseudoType)
+			}
+			if !looselyEqual(av, bv) {
+				return false
+			}
+		}
+		for k, bv := range bm {
+			if _, ok := am[k]; !ok && !bv.IsNull() {
+				return false
+			}
+		}
+		return true
+	case isSequence(at) && isSequence(bt):
+		as, bs := a.AsValueSlice(), b.AsValueSlice()
+		if len(as) != len(bs) {
+			return false
+		}
+		for i := range as {
+			if !looselyEqual(as[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	case at.IsPrimitiveType() && bt.IsPrimitiveType():
+		return primitiveEqual(a, b)
+	default:
+		return false
+	}
+}
+
+// primitiveEqual compares two non-null primitive values, converting strings to the type of the other value.
+func primitiveEqual(a, b cty.Value) bool {
+	target := a.Type()
+	if target == cty.String {
+		target = b.Type()
+	}
+	ac, err := convert.Convert(a, target)
+	if err != nil {
+		return false
+	}
+	bc, err := convert.Convert(b, target)
+	if err != nil {
+		return false
+	}
+	return ac.Equals(bc).True()
+}
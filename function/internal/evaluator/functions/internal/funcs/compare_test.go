@@ -0,0 +1,109 @@
+package funcs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		A    cty.Value
+		B    cty.Value
+		Want cty.Value
+	}{
+		{
+			cty.StringVal("a"),
+			cty.StringVal("a"),
+			cty.True,
+		},
+		{
+			cty.StringVal("a"),
+			cty.StringVal("b"),
+			cty.False,
+		},
+		{
+			cty.NumberIntVal(1),
+			cty.StringVal("1.0"),
+			cty.True,
+		},
+		{
+			cty.StringVal("2"),
+			cty.NumberIntVal(1),
+			cty.False,
+		},
+		{
+			cty.StringVal("one"),
+			cty.NumberIntVal(1),
+			cty.False,
+		},
+		{
+			cty.True,
+			cty.StringVal("true"),
+			cty.True,
+		},
+		{
+			cty.NullVal(cty.String),
+			cty.NullVal(cty.Number),
+			cty.True,
+		},
+		{
+			cty.NullVal(cty.String),
+			cty.StringVal(""),
+			cty.False,
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.StringVal("1"),
+				"b": cty.NullVal(cty.String),
+			}),
+			cty.MapVal(map[string]cty.Value{
+				"a": cty.NumberIntVal(1),
+			}),
+			cty.True,
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.StringVal("1"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.StringVal("1"),
+				"b": cty.StringVal("2"),
+			}),
+			cty.False,
+		},
+		{
+			cty.TupleVal([]cty.Value{cty.StringVal("1"), cty.True}),
+			cty.ListVal([]cty.Value{cty.StringVal("1"), cty.StringVal("true")}),
+			cty.True,
+		},
+		{
+			cty.TupleVal([]cty.Value{cty.StringVal("1")}),
+			cty.ListVal([]cty.Value{cty.StringVal("1"), cty.StringVal("2")}),
+			cty.False,
+		},
+		{
+			cty.ListValEmpty(cty.String),
+			cty.EmptyObjectVal,
+			cty.False,
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{"a": cty.UnknownVal(cty.String)}),
+			cty.EmptyObjectVal,
+			cty.UnknownVal(cty.Bool),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("equal(%#v, %#v)", test.A, test.B), func(t *testing.T) {
+			got, err := Equal(test.A, test.B)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -129,6 +129,10 @@ var DescriptionList = map[string]descriptionEntry{
 			"List or tuple values to test in the given order.",
 		},
 	},
+	"collect": {
+		Description:      "`collect` walks a collection of observed resources, such as `self.resources`, and returns a map of the values at the given dot-separated path keyed by resource name. Values that do not exist yet are unknown.",
+		ParamDescription: []string{"", ""},
+	},
 	"compact": {
 		Description:      "`compact` takes a list of strings and returns a new list with any empty string elements removed.",
 		ParamDescription: []string{""},
@@ -153,6 +157,10 @@ var DescriptionList = map[string]descriptionEntry{
 		Description:      "`distinct` takes a list and returns a new list with any duplicate elements removed.",
 		ParamDescription: []string{""},
 	},
+	"dns1123": {
+		Description:      "`dns1123` converts a string into a valid DNS-1123 label, suitable for names of services and namespaces as well as label values. Invalid characters are replaced with dashes and strings longer than 63 characters are truncated and suffixed with a stable hash of the input.",
+		ParamDescription: []string{""},
+	},
 	"element": {
 		Description:      "`element` retrieves a single element from a list.",
 		ParamDescription: []string{"", ""},
@@ -161,6 +169,10 @@ var DescriptionList = map[string]descriptionEntry{
 		Description:      "`endswith` takes two values: a string to check and a suffix string. The function returns true if the first string ends with that exact suffix.",
 		ParamDescription: []string{"", ""},
 	},
+	"equal": {
+		Description:      "`equal` compares two values deeply without regard to their types. Numbers and booleans are equal to strings that convert to them, and attributes with null values are equal to missing attributes.",
+		ParamDescription: []string{"", ""},
+	},
 	"file": {
 		Description:      "`file` reads the contents of a file at the given path and returns them as a string.",
 		ParamDescription: []string{""},
@@ -253,6 +265,10 @@ var DescriptionList = map[string]descriptionEntry{
 			"The map to extract keys from. May instead be an object-typed value, in which case the result is a tuple of the object attributes.",
 		},
 	},
+	"kubernetes_name": {
+		Description:      "`kubernetes_name` converts a string into a valid kubernetes object name (a DNS-1123 subdomain). Invalid characters are replaced with dashes and strings longer than 253 characters are truncated and suffixed with a stable hash of the input.",
+		ParamDescription: []string{""},
+	},
 	"length": {
 		Description:      "`length` determines the length of a given list, map, or string.",
 		ParamDescription: []string{""},
@@ -293,6 +309,10 @@ var DescriptionList = map[string]descriptionEntry{
 		Description:      "`merge` takes an arbitrary number of maps or objects, and returns a single map or object that contains a merged set of elements from all arguments.",
 		ParamDescription: []string{""},
 	},
+	"merge_resources": {
+		Description:      "`merge_resources` merges one or more overlays into a kubernetes object like `kubectl` does with a strategic merge patch. Lists of containers, environment variables, volumes, ports and the like are merged by their keys, other lists are replaced, and objects are merged recursively. `null` values remove attributes, and a `$patch` attribute set to `delete` or `replace` deletes or replaces an object or list element.",
+		ParamDescription: []string{"", ""},
+	},
 	"min": {
 		Description:      "`min` takes one or more numbers and returns the smallest number from the set.",
 		ParamDescription: []string{""},
@@ -313,6 +333,10 @@ var DescriptionList = map[string]descriptionEntry{
 		Description:      "`pathexpand` takes a filesystem path that might begin with a `~` segment, and if so it replaces that segment with the current user's home directory path.",
 		ParamDescription: []string{""},
 	},
+	"pemdecode": {
+		Description:      "`pemdecode` decodes all PEM blocks of a string into a list of objects with the `type`, `headers` and Base64 encoded `bytes` of each block.",
+		ParamDescription: []string{""},
+	},
 	"pow": {
 		Description:      "`pow` calculates an exponent, by raising its first argument to the power of the second argument.",
 		ParamDescription: []string{"", ""},
@@ -391,6 +415,14 @@ var DescriptionList = map[string]descriptionEntry{
 		Description:      "`split` produces a list by dividing a given string at all occurrences of a given separator.",
 		ParamDescription: []string{"", ""},
 	},
+	"sshfingerprint": {
+		Description:      "`sshfingerprint` computes the SHA256 fingerprint of an SSH public key in the authorized_keys format, as printed by `ssh-keygen -l`.",
+		ParamDescription: []string{""},
+	},
+	"sshpublickey": {
+		Description:      "`sshpublickey` returns the SSH public key of a PEM encoded private key in the authorized_keys format.",
+		ParamDescription: []string{""},
+	},
 	"startswith": {
 		Description:      "`startswith` takes two values: a string to check and a prefix string. The function returns true if the string begins with that exact prefix.",
 		ParamDescription: []string{"", ""},
@@ -518,6 +550,10 @@ var DescriptionList = map[string]descriptionEntry{
 		Description:      "`values` takes a map and returns a list containing the values of the elements in that map.",
 		ParamDescription: []string{""},
 	},
+	"x509decode": {
+		Description:      "`x509decode` decodes the first certificate of a PEM string into an object with its subject, issuer, subject alternative names, validity period as RFC 3339 timestamps and SHA256 fingerprint.",
+		ParamDescription: []string{""},
+	},
 	"yamldecode": {
 		Description:      "`yamldecode` parses a string as a subset of YAML, and produces a representation of its value.",
 		ParamDescription: []string{""},
@@ -0,0 +1,509 @@
+package funcs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const (
+	// maxSubdomainLength is the maximum length of a DNS-1123 subdomain, used for most kubernetes object names.
+	maxSubdomainLength = 253
+	// maxLabelLength is the maximum length of a DNS-1123 label, also the maximum length of a label value.
+	maxLabelLength = 63
+	// hashSuffixLength is the number of hex characters of the hash appended to truncated names.
+	hashSuffixLength = 8
+)
+
+var (
+	invalidSubdomainChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+	invalidLabelChars     = regexp.MustCompile(`[^a-z0-9-]+`)
+	repeatedDashes        = regexp.MustCompile(`-{2,}`)
+	dotRuns               = regexp.MustCompile(`[-.]*\.[-.]*`)
+)
+
+// isAlphaNumeric returns true if the supplied byte is a lowercase letter or a digit.
+func isAlphaNumeric(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+}
+
+// trimNonAlphaNumeric removes leading and trailing characters that are not lowercase alphanumeric.
+func trimNonAlphaNumeric(s string) string {
+	start, end := 0, len(s)
+	for start < end && !isAlphaNumeric(s[start]) {
+		start++
+	}
+	for end > start && !isAlphaNumeric(s[end-1]) {
+		end--
+	}
+	return s[start:end]
+}
+
+// sanitizeName converts the supplied string to a lowercase name where characters matched by the supplied
+// invalid expression are replaced by dashes and that starts and ends with an alphanumeric character. Names longer than
+// max length are truncated and suffixed with a stable hash of the original input so that distinct long inputs
+// produce distinct names.
+func sanitizeName(input string, invalid *regexp.Regexp, maxLength int) (string, error) {
+	s := strings.ToLower(input)
+	s = invalid.ReplaceAllString(s, "-")
+	s = repeatedDashes.ReplaceAllString(s, "-")
+	s = dotRuns.ReplaceAllString(s, ".") // every dot-separated segment must start and end with an alphanumeric
+	s = trimNonAlphaNumeric(s)
+	if s == "" {
+		return "", fmt.Errorf("unable to derive a valid name from %q", input)
+	}
+	if len(s) <= maxLength {
+		return s, nil
+	}
+	sum := sha256.Sum256([]byte(input))
+	suffix := hex.EncodeToString(sum[:])[:hashSuffixLength]
+	prefix := trimNonAlphaNumeric(s[:maxLength-hashSuffixLength-1])
+	if prefix == "" {
+		return suffix, nil
+	}
+	return prefix + "-" + suffix, nil
+}
+
+func makeNameFunc(invalid *regexp.Regexp, maxLength int) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "str",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			out, err := sanitizeName(args[0].AsString(), invalid, maxLength)
+			if err != nil {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, err)
+			}
+			return cty.StringVal(out), nil
+		},
+	})
+}
+
+// KubernetesNameFunc constructs a function that converts a string into a valid kubernetes object name
+// (a DNS-1123 subdomain), truncating it with a hash suffix when it is too long.
+var KubernetesNameFunc = makeNameFunc(invalidSubdomainChars, maxSubdomainLength)
+
+// DNS1123Func constructs a function that converts a string into a valid DNS-1123 label that can be
+// used for names of services, namespaces and for label values, truncating it with a hash suffix
+// when it is too long.
+var DNS1123Func = makeNameFunc(invalidLabelChars, maxLabelLength)
+
+// KubernetesName converts a string into a valid kubernetes object name.
+func KubernetesName(str cty.Value) (cty.Value, error) {
+	return KubernetesNameFunc.Call([]cty.Value{str})
+}
+
+// DNS1123 converts a string into a valid DNS-1123 label.
+func DNS1123(str cty.Value) (cty.Value, error) {
+	return DNS1123Func.Call([]cty.Value{str})
+}
+
+// annotationCompositionResourceName is the annotation that Crossplane sets on composed resources to record the name
+// of the resource in the composition.
+const annotationCompositionResourceName = "crossplane.io/composition-resource-name"
+
+// lookupPath returns the value at the supplied dot-separated path, where numeric segments index into lists. An unknown
+// value is returned when any part of the path does not exist.
+func lookupPath(v cty.Value, path []string) cty.Value {
+	for _, seg := range path {
+		if !v.IsKnown() || v.IsNull() {
+			return cty.DynamicVal
+		}
+		ty := v.Type()
+		switch {
+		case ty.IsObjectType():
+			if !ty.HasAttribute(seg) {
+				return cty.DynamicVal
+			}
+			v = v.GetAttr(seg)
+		case ty.IsMapType():
+			key := cty.StringVal(seg)
+			if !v.HasIndex(key).True() {
+				return cty.DynamicVal
+			}
+			v = v.Index(key)
+		case ty.IsListType() || ty.IsTupleType():
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= v.LengthInt() {
+				return cty.DynamicVal
+			}
+			v = v.Index(cty.NumberIntVal(int64(i)))
+		default:
+			return cty.DynamicVal
+		}
+	}
+	if v.IsNull() {
+		return cty.DynamicVal
+	}
+	return v
+}
+
+// resourceName returns the name of the supplied observed resource in the composition, falling back to its object name.
+func resourceName(v cty.Value) (string, bool) {
+	for _, path := range [][]string{
+		{"metadata", "annotations", annotationCompositionResourceName},
+		{"metadata", "name"},
+	} {
+		name := lookupPath(v, path)
+		if name.IsKnown() && name.Type().Equals(cty.String) {
+			return name.AsString(), true
+		}
+	}
+	return "", false
+}
+
+// CollectFunc constructs a function that returns a map of the values at a path of a collection of observed resources,
+// keyed by resource name. Values that do not exist are unknown such that the result is incomplete until all
+// resources have them.
+var CollectFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "resources",
+			Type:             cty.DynamicPseudoType,
+			AllowUnknown:     true,
+			AllowNull:        true,
+			AllowDynamicType: true,
+		},
+		{
+			Name: "path",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		resources := args[0]
+		path := strings.Split(args[1].AsString(), ".")
+		for _, seg := range path {
+			if seg == "" {
+				return cty.DynamicVal, function.NewArgErrorf(1, "invalid path %q", args[1].AsString())
+			}
+		}
+		if !resources.IsKnown() {
+			return cty.DynamicVal, nil
+		}
+		if resources.IsNull() {
+			return cty.EmptyObjectVal, nil
+		}
+		ret := map[string]cty.Value{}
+		ty := resources.Type()
+		switch {
+		case ty.IsObjectType() || ty.IsMapType():
+			for name, r := range resources.AsValueMap() {
+				ret[name] = lookupPath(r, path)
+			}
+		case ty.IsListType() || ty.IsTupleType():
+			for i, r := range resources.AsValueSlice() {
+				if !r.IsKnown() {
+					return cty.DynamicVal, nil
+				}
+				name, ok := resourceName(r)
+				if !ok {
+					return cty.DynamicVal, function.NewArgErrorf(0, "resource at index %d has no name", i)
+				}
+				if _, ok := ret[name]; ok {
+					return cty.DynamicVal, function.NewArgErrorf(0, "duplicate resource name %q", name)
+				}
+				ret[name] = lookupPath(r, path)
+			}
+		default:
+			return cty.DynamicVal, function.NewArgErrorf(0, "resources must be a list or a map, got %s", ty.FriendlyName())
+		}
+		return cty.ObjectVal(ret), nil
+	},
+})
+
+// Collect returns a map of the values at the supplied path of a collection of observed resources.
+func Collect(resources, path cty.Value) (cty.Value, error) {
+	return CollectFunc.Call([]cty.Value{resources, path})
+}
+
+// patchDirective is the key of the strategic merge patch directive in objects of an overlay.
+const patchDirective = "$patch"
+
+// mergeKeys are the keys that identify the elements of lists that are merged by merge_resources, by field name.
+// This covers the lists of the core workload types. When there is more than one candidate, the first one that is
+// present in all the elements of both lists is used, such that container ports and service ports are both handled.
+// Lists without merge keys are replaced by the overlay, like in kubernetes.
+var mergeKeys = map[string][]string{
+	"containers":          {"name"},
+	"initContainers":      {"name"},
+	"ephemeralContainers": {"name"},
+	"env":                 {"name"},
+	"imagePullSecrets":    {"name"},
+	"volumes":             {"name"},
+	"volumeMounts":        {"mountPath"},
+	"volumeDevices":       {"devicePath"},
+	"ports":               {"containerPort", "port"},
+	"hostAliases":         {"ip"},
+	"resourceClaims":      {"name"},
+	"conditions":          {"type"},
+	"ownerReferences":     {"uid"},
+}
+
+// isObjectLike returns true if the supplied type is an object or a map.
+func isObjectLike(ty cty.Type) bool {
+	return ty.IsObjectType() || ty.IsMapType()
+}
+
+// isListLike returns true if the supplied type is a list or a tuple.
+func isListLike(ty cty.Type) bool {
+	return ty.IsListType() || ty.IsTupleType()
+}
+
+// patchOf returns the patch directive of the supplied overlay value, if any.
+func patchOf(v cty.Value) (string, error) {
+	if !v.IsKnown() || v.IsNull() || !isObjectLike(v.Type()) {
+		return "", nil
+	}
+	d := lookupPath(v, []string{patchDirective})
+	if !d.IsKnown() {
+		return "", nil
+	}
+	if !d.Type().Equals(cty.String) {
+		return "", fmt.Errorf("%s must be a string", patchDirective)
+	}
+	switch s := d.AsString(); s {
+	case "replace", "delete":
+		return s, nil
+	default:
+		return "", fmt.Errorf("unsupported %s directive %q", patchDirective, s)
+	}
+}
+
+// mergeObjects merges the overlay into the base object. Attributes with null values in the overlay are removed,
+// as are objects with a "delete" patch directive. An overlay with a "replace" patch directive replaces the base.
+func mergeObjects(base, overlay cty.Value) (cty.Value, error) {
+	ret := map[string]cty.Value{}
+	if !base.IsNull() {
+		if !isObjectLike(base.Type()) {
+			base = cty.EmptyObjectVal
+		}
+		for k, v := range base.AsValueMap() {
+			ret[k] = v
+		}
+	}
+	directive, err := patchOf(overlay)
+	if err != nil {
+		return cty.DynamicVal, err
+	}
+	if directive == "replace" {
+		ret = map[string]cty.Value{}
+	}
+	for k, v := range overlay.AsValueMap() {
+		if k == patchDirective {
+			continue
+		}
+		if v.IsNull() {
+			delete(ret, k)
+			continue
+		}
+		d, err := patchOf(v)
+		if err != nil {
+			return cty.DynamicVal, fmt.Errorf("%s: %w", k, err)
+		}
+		if d == "delete" {
+			delete(ret, k)
+			continue
+		}
+		existing, ok := ret[k]
+		if !ok {
+			existing = cty.NullVal(cty.DynamicPseudoType)
+		}
+		merged, err := mergeValues(k, existing, v)
+		if err != nil {
+			return cty.DynamicVal, fmt.Errorf("%s: %w", k, err)
+		}
+		ret[k] = merged
+	}
+	return cty.ObjectVal(ret), nil
+}
+
+// keyString returns a string form of the merge key of the supplied list element. It returns false if
+// the element does not have a known, primitive value for the key.
+func keyString(v cty.Value, key string) (string, bool) {
+	if !v.IsKnown() || v.IsNull() || !isObjectLike(v.Type()) {
+		return "", false
+	}
+	k := lookupPath(v, []string{key})
+	if !k.IsKnown() {
+		return "", false
+	}
+	switch k.Type() {
+	case cty.String:
+		return "s:" + k.AsString(), true
+	case cty.Number:
+		return "n:" + k.AsBigFloat().String(), true
+	default:
+		return "", false
+	}
+}
+
+// listMergeKey returns the first of the merge keys for the supplied field that is present in all the supplied
+// elements, or false if there is none.
+func listMergeKey(field string, elements []cty.Value) (string, bool) {
+	for _, key := range mergeKeys[field] {
+		found := true
+		for _, e := range elements {
+			if _, ok := keyString(e, key); !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// mergeLists merges the elements of the overlay into the base list by the merge key of the supplied field.
+// Elements that exist in the base are merged in place, new elements are appended, and elements of the overlay
+// with a "delete" patch directive are removed. The overlay replaces the base when the elements have no merge key.
+func mergeLists(field string, base, overlay cty.Value) (cty.Value, error) {
+	var baseElements []cty.Value
+	if !base.IsNull() && isListLike(base.Type()) {
+		baseElements = base.AsValueSlice()
+	}
+	if _, ok := mergeKeys[field]; !ok {
+		return overlay, nil
+	}
+	overlayElements := overlay.AsValueSlice()
+	all := append(append([]cty.Value{}, baseElements...), overlayElements...)
+	for _, e := range all {
+		if !e.IsWhollyKnown() {
+			return cty.DynamicVal, nil // elements cannot be matched until they are known
+		}
+	}
+	key, ok := listMergeKey(field, all)
+	if !ok {
+		return overlay, nil
+	}
+	ret := append([]cty.Value{}, baseElements...)
+	index := map[string]int{}
+	for i, e := range ret {
+		k, _ := keyString(e, key)
+		index[k] = i
+	}
+	var deleted []int
+	for _, e := range overlayElements {
+		k, _ := keyString(e, key)
+		directive, err := patchOf(e)
+		if err != nil {
+			return cty.DynamicVal, err
+		}
+		pos, exists := index[k]
+		switch {
+		case directive == "delete":
+			if exists {
+				deleted = append(deleted, pos)
+			}
+		case exists:
+			merged, err := mergeValues(field, ret[pos], e)
+			if err != nil {
+				return cty.DynamicVal, err
+			}
+			ret[pos] = merged
+		default:
+			merged, err := mergeValues(field, cty.NullVal(cty.DynamicPseudoType), e)
+			if err != nil {
+				return cty.DynamicVal, err
+			}
+			index[k] = len(ret)
+			ret = append(ret, merged)
+		}
+	}
+	if len(deleted) > 0 {
+		skip := map[int]bool{}
+		for _, pos := range deleted {
+			skip[pos] = true
+		}
+		var kept []cty.Value
+		for i, e := range ret {
+			if !skip[i] {
+				kept = append(kept, e)
+			}
+		}
+		ret = kept
+	}
+	if len(ret) == 0 {
+		return cty.EmptyTupleVal, nil
+	}
+	return cty.TupleVal(ret), nil
+}
+
+// mergeValues merges the overlay value into the base value of the supplied field using strategic merge semantics.
+func mergeValues(field string, base, overlay cty.Value) (cty.Value, error) {
+	if !overlay.IsKnown() {
+		return overlay, nil
+	}
+	ty := overlay.Type()
+	if !isObjectLike(ty) && !isListLike(ty) {
+		return overlay, nil
+	}
+	if !base.IsKnown() {
+		return cty.DynamicVal, nil
+	}
+	if isObjectLike(ty) {
+		return mergeObjects(base, overlay)
+	}
+	return mergeLists(field, base, overlay)
+}
+
+// MergeResourcesFunc constructs a function that merges one or more overlays into a kubernetes object using
+// strategic merge patch semantics for the common core types. Lists of containers, environment variables, volumes
+// and the like are merged by their keys, other lists are replaced and objects are merged recursively.
+var MergeResourcesFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "base",
+			Type:             cty.DynamicPseudoType,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	VarParam: &function.Parameter{
+		Name:             "overlays",
+		Type:             cty.DynamicPseudoType,
+		AllowUnknown:     true,
+		AllowNull:        true,
+		AllowDynamicType: true,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		for i, arg := range args {
+			if arg.IsKnown() && !arg.IsNull() && !isObjectLike(arg.Type()) {
+				return cty.DynamicVal, function.NewArgErrorf(i, "must be an object, got %s", arg.Type().FriendlyName())
+			}
+		}
+		ret := args[0]
+		for i, overlay := range args[1:] {
+			if overlay.IsNull() {
+				continue
+			}
+			if !ret.IsKnown() || !overlay.IsKnown() {
+				return cty.DynamicVal, nil
+			}
+			merged, err := mergeObjects(ret, overlay)
+			if err != nil {
+				return cty.DynamicVal, function.NewArgError(i+1, err)
+			}
+			ret = merged
+		}
+		return ret, nil
+	},
+})
+
+// MergeResources merges the supplied overlays into a base kubernetes object.
+func MergeResources(base cty.Value, overlays ...cty.Value) (cty.Value, error) {
+	return MergeResourcesFunc.Call(append([]cty.Value{base}, overlays...))
+}
@@ -0,0 +1,381 @@
+package funcs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestKubernetesName(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	tests := []struct {
+		String cty.Value
+		Want   cty.Value
+		Err    bool
+	}{
+		{
+			cty.StringVal("my-bucket"),
+			cty.StringVal("my-bucket"),
+			false,
+		},
+		{
+			cty.StringVal("My_Bucket Name!"),
+			cty.StringVal("my-bucket-name"),
+			false,
+		},
+		{
+			cty.StringVal("--foo..bar.-baz__"),
+			cty.StringVal("foo.bar.baz"),
+			false,
+		},
+		{
+			cty.StringVal(long),
+			cty.StringVal(strings.Repeat("a", 244) + "-9835fa6b"),
+			false,
+		},
+		{
+			cty.StringVal("___"),
+			cty.UnknownVal(cty.String),
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("kubernetes_name(%#v)", test.String), func(t *testing.T) {
+			got, err := KubernetesName(test.String)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestDNS1123(t *testing.T) {
+	long := strings.Repeat("ab", 40)
+	tests := []struct {
+		String cty.Value
+		Want   cty.Value
+		Err    bool
+	}{
+		{
+			cty.StringVal("frontend"),
+			cty.StringVal("frontend"),
+			false,
+		},
+		{
+			cty.StringVal("team.payments/API"),
+			cty.StringVal("team-payments-api"),
+			false,
+		},
+		{
+			cty.StringVal(long),
+			cty.StringVal(long[:54] + "-a8a0b75e"),
+			false,
+		},
+		{
+			cty.StringVal(""),
+			cty.UnknownVal(cty.String),
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("dns1123(%#v)", test.String), func(t *testing.T) {
+			got, err := DNS1123(test.String)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	observed := func(name, annotation string, status cty.Value) cty.Value {
+		metadata := map[string]cty.Value{"name": cty.StringVal(name)}
+		if annotation != "" {
+			metadata["annotations"] = cty.MapVal(map[string]cty.Value{
+				"crossplane.io/composition-resource-name": cty.StringVal(annotation),
+			})
+		}
+		return cty.ObjectVal(map[string]cty.Value{
+			"metadata": cty.ObjectVal(metadata),
+			"status":   status,
+		})
+	}
+	withArn := func(arn string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"atProvider": cty.ObjectVal(map[string]cty.Value{"arn": cty.StringVal(arn)}),
+		})
+	}
+	tests := []struct {
+		Resources cty.Value
+		Path      string
+		Want      cty.Value
+		Err       string
+	}{
+		{
+			cty.TupleVal([]cty.Value{
+				observed("xr-abc12", "bucket-0", withArn("arn:a")),
+				observed("xr-def34", "", withArn("arn:b")),
+			}),
+			"status.atProvider.arn",
+			cty.ObjectVal(map[string]cty.Value{
+				"bucket-0": cty.StringVal("arn:a"),
+				"xr-def34": cty.StringVal("arn:b"),
+			}),
+			"",
+		},
+		{
+			cty.TupleVal([]cty.Value{
+				observed("xr-abc12", "bucket-0", withArn("arn:a")),
+				observed("xr-def34", "bucket-1", cty.EmptyObjectVal),
+			}),
+			"status.atProvider.arn",
+			cty.ObjectVal(map[string]cty.Value{
+				"bucket-0": cty.StringVal("arn:a"),
+				"bucket-1": cty.DynamicVal,
+			}),
+			"",
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"vpc": observed("xr-abc12", "vpc", withArn("arn:vpc")),
+			}),
+			"status.atProvider.arn",
+			cty.ObjectVal(map[string]cty.Value{
+				"vpc": cty.StringVal("arn:vpc"),
+			}),
+			"",
+		},
+		{
+			cty.TupleVal([]cty.Value{
+				observed("xr-abc12", "bucket-0", cty.ObjectVal(map[string]cty.Value{
+					"conditions": cty.TupleVal([]cty.Value{
+						cty.ObjectVal(map[string]cty.Value{"type": cty.StringVal("Ready")}),
+					}),
+				})),
+			}),
+			"status.conditions.0.type",
+			cty.ObjectVal(map[string]cty.Value{
+				"bucket-0": cty.StringVal("Ready"),
+			}),
+			"",
+		},
+		{
+			cty.DynamicVal,
+			"status.atProvider.arn",
+			cty.DynamicVal,
+			"",
+		},
+		{
+			cty.NullVal(cty.DynamicPseudoType),
+			"status",
+			cty.EmptyObjectVal,
+			"",
+		},
+		{
+			cty.TupleVal([]cty.Value{
+				observed("a", "bucket", cty.EmptyObjectVal),
+				observed("b", "bucket", cty.EmptyObjectVal),
+			}),
+			"status",
+			cty.DynamicVal,
+			`duplicate resource name "bucket"`,
+		},
+		{
+			cty.StringVal("foo"),
+			"status",
+			cty.DynamicVal,
+			"resources must be a list or a map, got string",
+		},
+		{
+			cty.EmptyTupleVal,
+			"status..arn",
+			cty.DynamicVal,
+			`invalid path "status..arn"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("collect(%#v, %q)", test.Resources, test.Path), func(t *testing.T) {
+			got, err := Collect(test.Resources, cty.StringVal(test.Path))
+			if test.Err != "" {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				if err.Error() != test.Err {
+					t.Errorf("wrong error\ngot:  %s\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestMergeResources(t *testing.T) {
+	decode := func(s string) cty.Value {
+		v, err := stdlib.JSONDecode(cty.StringVal(s))
+		if err != nil {
+			t.Fatalf("decode %s: %v", s, err)
+		}
+		return v
+	}
+	deployment := `{
+		"metadata": {"name": "app", "labels": {"app": "web", "tier": "frontend"}},
+		"spec": {"template": {"spec": {
+			"containers": [
+				{"name": "app", "image": "app:1", "env": [{"name": "A", "value": "1"}, {"name": "B", "value": "2"}],
+				 "ports": [{"containerPort": 8080, "protocol": "TCP"}]},
+				{"name": "sidecar", "image": "proxy:1"}
+			],
+			"tolerations": [{"key": "a"}]
+		}}}
+	}`
+	tests := []struct {
+		name     string
+		base     cty.Value
+		overlays []cty.Value
+		want     cty.Value
+		err      string
+	}{
+		{
+			name: "merge by key",
+			base: decode(deployment),
+			overlays: []cty.Value{decode(`{
+				"metadata": {"labels": {"tier": null, "team": "x"}},
+				"spec": {"template": {"spec": {
+					"containers": [
+						{"name": "app", "image": "app:2", "env": [{"name": "B", "value": "3"}, {"name": "C", "value": "4"}],
+						 "ports": [{"containerPort": 9090}]},
+						{"name": "sidecar", "$patch": "delete"},
+						{"name": "logger", "image": "logger:1"}
+					],
+					"tolerations": [{"key": "b"}]
+				}}}
+			}`)},
+			want: decode(`{
+				"metadata": {"name": "app", "labels": {"app": "web", "team": "x"}},
+				"spec": {"template": {"spec": {
+					"containers": [
+						{"name": "app", "image": "app:2",
+						 "env": [{"name": "A", "value": "1"}, {"name": "B", "value": "3"}, {"name": "C", "value": "4"}],
+						 "ports": [{"containerPort": 8080, "protocol": "TCP"}, {"containerPort": 9090}]},
+						{"name": "logger", "image": "logger:1"}
+					],
+					"tolerations": [{"key": "b"}]
+				}}}
+			}`),
+		},
+		{
+			name: "service ports and multiple overlays",
+			base: decode(`{"spec": {"ports": [{"port": 80, "targetPort": 8080}]}}`),
+			overlays: []cty.Value{
+				decode(`{"spec": {"ports": [{"port": 80, "targetPort": 9090}]}}`),
+				cty.NullVal(cty.DynamicPseudoType),
+				decode(`{"spec": {"ports": [{"port": 443}]}}`),
+			},
+			want: decode(`{"spec": {"ports": [{"port": 80, "targetPort": 9090}, {"port": 443}]}}`),
+		},
+		{
+			name:     "replace directive",
+			base:     decode(`{"spec": {"selector": {"a": "1", "b": "2"}}}`),
+			overlays: []cty.Value{decode(`{"spec": {"selector": {"$patch": "replace", "c": "3"}}}`)},
+			want:     decode(`{"spec": {"selector": {"c": "3"}}}`),
+		},
+		{
+			name:     "delete directive on object",
+			base:     decode(`{"spec": {"selector": {"a": "1"}, "replicas": 1}}`),
+			overlays: []cty.Value{decode(`{"spec": {"selector": {"$patch": "delete"}}}`)},
+			want:     decode(`{"spec": {"replicas": 1}}`),
+		},
+		{
+			name: "unknown elements",
+			base: decode(deployment),
+			overlays: []cty.Value{cty.ObjectVal(map[string]cty.Value{
+				"spec": cty.ObjectVal(map[string]cty.Value{
+					"template": cty.ObjectVal(map[string]cty.Value{
+						"spec": cty.ObjectVal(map[string]cty.Value{
+							"containers": cty.TupleVal([]cty.Value{cty.DynamicVal}),
+						}),
+					}),
+				}),
+			})},
+			want: func() cty.Value {
+				v := decode(deployment).AsValueMap()
+				v["spec"] = cty.ObjectVal(map[string]cty.Value{
+					"template": cty.ObjectVal(map[string]cty.Value{
+						"spec": cty.ObjectVal(map[string]cty.Value{
+							"containers":  cty.DynamicVal,
+							"tolerations": decode(`[{"key": "a"}]`),
+						}),
+					}),
+				})
+				return cty.ObjectVal(v)
+			}(),
+		},
+		{
+			name:     "unknown overlay",
+			base:     decode(deployment),
+			overlays: []cty.Value{cty.DynamicVal},
+			want:     cty.DynamicVal,
+		},
+		{
+			name:     "bad directive",
+			base:     decode(deployment),
+			overlays: []cty.Value{decode(`{"metadata": {"$patch": "merge"}}`)},
+			err:      `metadata: unsupported $patch directive "merge"`,
+		},
+		{
+			name: "not an object",
+			base: cty.StringVal("foo"),
+			err:  "must be an object, got string",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergeResources(test.base, test.overlays...)
+			if test.err != "" {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				if err.Error() != test.err {
+					t.Errorf("wrong error\ngot:  %s\nwant: %s", err, test.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
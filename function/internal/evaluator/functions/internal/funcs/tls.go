@@ -0,0 +1,226 @@
+package funcs
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"golang.org/x/crypto/ssh"
+)
+
+// pemBlockType is the type of the objects returned by pemdecode.
+var pemBlockType = cty.Object(map[string]cty.Type{
+	"type":    cty.String,
+	"headers": cty.Map(cty.String),
+	"bytes":   cty.String,
+})
+
+// certificateType is the type of the object returned by x509decode.
+var certificateType = cty.Object(map[string]cty.Type{
+	"subject":              cty.String,
+	"common_name":          cty.String,
+	"issuer":               cty.String,
+	"issuer_common_name":   cty.String,
+	"serial_number":        cty.String,
+	"dns_names":            cty.List(cty.String),
+	"ip_addresses":         cty.List(cty.String),
+	"email_addresses":      cty.List(cty.String),
+	"uris":                 cty.List(cty.String),
+	"not_before":           cty.String,
+	"not_after":            cty.String,
+	"is_ca":                cty.Bool,
+	"public_key_algorithm": cty.String,
+	"sha256_fingerprint":   cty.String,
+})
+
+// PemDecodeFunc constructs a function that decodes all PEM blocks of a string.
+var PemDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "str",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.List(pemBlockType)),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return PemDecode(args[0])
+	},
+})
+
+// X509DecodeFunc constructs a function that decodes the first certificate of a PEM string.
+var X509DecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "pem",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(certificateType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return X509Decode(args[0])
+	},
+})
+
+// SSHPublicKeyFunc constructs a function that derives the public key of a PEM encoded private key in the
+// authorized_keys format.
+var SSHPublicKeyFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "privatekey",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return SSHPublicKey(args[0])
+	},
+})
+
+// SSHFingerprintFunc constructs a function that computes the SHA256 fingerprint of an SSH public key in the
+// authorized_keys format.
+var SSHFingerprintFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "publickey",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return SSHFingerprint(args[0])
+	},
+})
+
+// PemDecode decodes all PEM blocks of the supplied string into a list of objects that have the type, headers and
+// base64 encoded bytes of each block. Text outside the blocks is ignored. It is an error if there are no blocks.
+func PemDecode(str cty.Value) (cty.Value, error) {
+	var blocks []cty.Value
+	rest := []byte(str.AsString())
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		headers := map[string]cty.Value{}
+		for k, v := range block.Headers {
+			headers[k] = cty.StringVal(v)
+		}
+		headerVal := cty.MapValEmpty(cty.String)
+		if len(headers) > 0 {
+			headerVal = cty.MapVal(headers)
+		}
+		blocks = append(blocks, cty.ObjectVal(map[string]cty.Value{
+			"type":    cty.StringVal(block.Type),
+			"headers": headerVal,
+			"bytes":   cty.StringVal(base64.StdEncoding.EncodeToString(block.Bytes)),
+		}))
+	}
+	if len(blocks) == 0 {
+		return cty.UnknownVal(cty.List(pemBlockType)), function.NewArgErrorf(0, "no PEM data found")
+	}
+	return cty.ListVal(blocks), nil
+}
+
+// X509Decode decodes the first certificate of the supplied PEM string, which is usually the leaf certificate of a
+// chain. Validity times are returned as RFC 3339 timestamps, such that expiry can be checked with timecmp.
+func X509Decode(str cty.Value) (cty.Value, error) {
+	rest := []byte(str.AsString())
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return cty.UnknownVal(certificateType), function.NewArgErrorf(0, "no PEM encoded certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return cty.UnknownVal(certificateType), function.NewArgErrorf(0, "invalid certificate: %s", err)
+		}
+		return certificateValue(cert), nil
+	}
+}
+
+func certificateValue(cert *x509.Certificate) cty.Value {
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+	fingerprint := sha256.Sum256(cert.Raw)
+	return cty.ObjectVal(map[string]cty.Value{
+		"subject":              cty.StringVal(cert.Subject.String()),
+		"common_name":          cty.StringVal(cert.Subject.CommonName),
+		"issuer":               cty.StringVal(cert.Issuer.String()),
+		"issuer_common_name":   cty.StringVal(cert.Issuer.CommonName),
+		"serial_number":        cty.StringVal(cert.SerialNumber.String()),
+		"dns_names":            stringList(cert.DNSNames),
+		"ip_addresses":         stringList(ips),
+		"email_addresses":      stringList(cert.EmailAddresses),
+		"uris":                 stringList(uris),
+		"not_before":           cty.StringVal(cert.NotBefore.UTC().Format(time.RFC3339)),
+		"not_after":            cty.StringVal(cert.NotAfter.UTC().Format(time.RFC3339)),
+		"is_ca":                cty.BoolVal(cert.IsCA),
+		"public_key_algorithm": cty.StringVal(publicKeyAlgorithm(cert.PublicKey)),
+		"sha256_fingerprint":   cty.StringVal(hex.EncodeToString(fingerprint[:])),
+	})
+}
+
+func stringList(list []string) cty.Value {
+	if len(list) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+	vals := make([]cty.Value, 0, len(list))
+	for _, s := range list {
+		vals = append(vals, cty.StringVal(s))
+	}
+	return cty.ListVal(vals)
+}
+
+func publicKeyAlgorithm(key any) string {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return "RSA"
+	case *ecdsa.PublicKey:
+		return "ECDSA"
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return "unknown"
+	}
+}
+
+// SSHPublicKey returns the public key of the supplied PEM encoded private key in the authorized_keys format,
+// without a trailing newline. RSA, ECDSA and Ed25519 keys in PKCS#1, PKCS#8, SEC 1 and OpenSSH formats are
+// supported. Encrypted keys are not.
+func SSHPublicKey(privateKey cty.Value) (cty.Value, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey.AsString()))
+	if err != nil {
+		return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "invalid private key: %s", err)
+	}
+	return cty.StringVal(strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "\n")), nil
+}
+
+// SSHFingerprint returns the SHA256 fingerprint of the supplied public key in the authorized_keys format, in the
+// form that ssh-keygen -l prints.
+func SSHFingerprint(publicKey cty.Value) (cty.Value, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey.AsString()))
+	if err != nil {
+		return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "invalid public key: %s", err)
+	}
+	return cty.StringVal(ssh.FingerprintSHA256(key)), nil
+}
@@ -0,0 +1,183 @@
+package funcs
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const rsaPublicKey = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQCBQSVXmbCqSWgiszxk1nvaBIJydIm3v79Sxrkb4PXlhBQB1/1zXMR6RN8eAM/7TifD+4U0VoTm/VFsdo/GFlhWDlkSs0Jr+HOf7HXTHNx6l5Lco9VdzFp7gxHQER6C+pmonM32Whew0v9zcf8H7YaV7eFPGOVYVvcXmouBH7gx/iu6ERHW/p4dBr+dyGLTcwGlPhR4nsysv3aFMlgt2lLIKqavzKPGQokNULa5Guv6xNLF+Huvq/bi7++n9BOYEgCU0+Bp1UBnDXuI01vu+NXsbCX/mAdeicJQpRFpX750E0uschmmpwAzzAdGmHyYPUR8v2GzPvukd7DGiUEBNX7t"
+
+// testCertificate returns a PEM encoded self-signed certificate. Ed25519 signatures are deterministic, so the
+// certificate is the same for every run.
+func testCertificate(t *testing.T) string {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(42),
+		Subject:               pkix.Name{CommonName: "api.example.com", Organization: []string{"Example"}},
+		DNSNames:              []string{"api.example.com", "www.example.com"},
+		IPAddresses:           []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:             time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(nil, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestPemDecode(t *testing.T) {
+	got, err := PemDecode(cty.StringVal("bundle\n" + testCertificate(t) + PrivateKey))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := got.LengthInt(); n != 2 {
+		t.Fatalf("wrong number of blocks: %d", n)
+	}
+	for i, want := range []string{"CERTIFICATE", "RSA PRIVATE KEY"} {
+		if typ := got.Index(cty.NumberIntVal(int64(i))).GetAttr("type"); !typ.RawEquals(cty.StringVal(want)) {
+			t.Errorf("wrong type of block %d\ngot:  %#v\nwant: %s", i, typ, want)
+		}
+	}
+
+	_, err = PemDecode(cty.StringVal("not pem"))
+	if err == nil || err.Error() != "no PEM data found" {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
+
+func TestX509Decode(t *testing.T) {
+	cert := testCertificate(t)
+	got, err := X509Decode(cty.StringVal(PrivateKey + cert))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := map[string]cty.Value{
+		"subject":            cty.StringVal("CN=api.example.com,O=Example"),
+		"common_name":        cty.StringVal("api.example.com"),
+		"issuer_common_name": cty.StringVal("api.example.com"),
+		"serial_number":      cty.StringVal("42"),
+		"dns_names": cty.ListVal([]cty.Value{
+			cty.StringVal("api.example.com"),
+			cty.StringVal("www.example.com"),
+		}),
+		"ip_addresses":         cty.ListVal([]cty.Value{cty.StringVal("10.0.0.1")}),
+		"uris":                 cty.ListValEmpty(cty.String),
+		"not_before":           cty.StringVal("2025-01-01T00:00:00Z"),
+		"not_after":            cty.StringVal("2026-01-01T00:00:00Z"),
+		"is_ca":                cty.False,
+		"public_key_algorithm": cty.StringVal("Ed25519"),
+	}
+	for name, value := range want {
+		if attr := got.GetAttr(name); !attr.RawEquals(value) {
+			t.Errorf("wrong %s\ngot:  %#v\nwant: %#v", name, attr, value)
+		}
+	}
+	block, _ := pem.Decode([]byte(cert))
+	fingerprint, _ := Sha256(cty.StringVal(string(block.Bytes)))
+	if attr := got.GetAttr("sha256_fingerprint"); !attr.RawEquals(fingerprint) {
+		t.Errorf("wrong fingerprint\ngot:  %#v\nwant: %#v", attr, fingerprint)
+	}
+
+	for _, test := range []struct {
+		Input string
+		Err   string
+	}{
+		{PrivateKey, "no PEM encoded certificate found"},
+		{"-----BEGIN CERTIFICATE-----\nYWJj\n-----END CERTIFICATE-----\n", "invalid certificate: x509: malformed certificate"},
+	} {
+		t.Run(fmt.Sprintf("X509Decode(%q)", test.Input), func(t *testing.T) {
+			_, err := X509Decode(cty.StringVal(test.Input))
+			if err == nil || err.Error() != test.Err {
+				t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+			}
+		})
+	}
+}
+
+func TestSSHPublicKey(t *testing.T) {
+	tests := []struct {
+		Key  cty.Value
+		Want cty.Value
+		Err  string
+	}{
+		{
+			cty.StringVal(PrivateKey),
+			cty.StringVal(rsaPublicKey),
+			"",
+		},
+		{
+			cty.StringVal(OpenSSHPrivateKey),
+			cty.StringVal(rsaPublicKey),
+			"",
+		},
+		{
+			cty.StringVal(""),
+			cty.UnknownVal(cty.String),
+			"invalid private key: ssh: no key found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("SSHPublicKey(%#v)", test.Key), func(t *testing.T) {
+			got, err := SSHPublicKey(test.Key)
+			if test.Err != "" {
+				if err == nil || err.Error() != test.Err {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSSHFingerprint(t *testing.T) {
+	tests := []struct {
+		Key  cty.Value
+		Want cty.Value
+		Err  string
+	}{
+		{
+			cty.StringVal(rsaPublicKey + " user@host\n"),
+			cty.StringVal("SHA256:R3/XPFhh9fyhIfnp2NIbkVQ5DOZDg4JX3on5TsxmdnQ"),
+			"",
+		},
+		{
+			cty.StringVal(""),
+			cty.UnknownVal(cty.String),
+			"invalid public key: ssh: no key found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("SSHFingerprint(%#v)", test.Key), func(t *testing.T) {
+			got, err := SSHFingerprint(test.Key)
+			if test.Err != "" {
+				if err == nil || err.Error() != test.Err {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.Err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
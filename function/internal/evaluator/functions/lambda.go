@@ -0,0 +1,170 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const (
+	LambdaFunctionName = "lambda"
+	ApplyFunctionName  = "apply"
+)
+
+// lambda is an anonymous function created from an expression that is only able to access its parameters.
+type lambda struct {
+	params []string
+	body   hclsyntax.Expression
+}
+
+// lambdaType is the type of values returned by the lambda function.
+var lambdaType = cty.Capsule("lambda", reflect.TypeOf(lambda{}))
+
+// lambdaParams returns the parameter names from the supplied value, which must be a list of strings.
+func lambdaParams(v cty.Value) ([]string, error) {
+	if !v.CanIterateElements() {
+		return nil, fmt.Errorf("lambda parameters must be a list of names, found %s", v.Type().FriendlyName())
+	}
+	var ret []string
+	seen := map[string]bool{}
+	for it := v.ElementIterator(); it.Next(); {
+		_, p := it.Element()
+		if p.IsNull() || !p.Type().Equals(cty.String) || !hclexpr.IsIdentifier(p.AsString()) {
+			return nil, fmt.Errorf("lambda parameter %s is not an identifier", p.GoString())
+		}
+		name := p.AsString()
+		if seen[name] {
+			return nil, fmt.Errorf("lambda parameter %q declared more than once", name)
+		}
+		seen[name] = true
+		ret = append(ret, name)
+	}
+	return ret, nil
+}
+
+// parseLambdaBody parses the body of a lambda that starts at the supplied position.
+func parseLambdaBody(body string, filename string, pos hcl.Pos) (hclsyntax.Expression, hcl.Diagnostics) {
+	return hclsyntax.ParseExpression([]byte(body), filename, pos)
+}
+
+// lambdaFunction returns the lambda function that creates an anonymous function from a list of parameter
+// names and a string containing the expression that is its result.
+func (i *invoker) lambdaFunction() function.Function {
+	return function.New(&function.Spec{
+		Description: "creates an anonymous function from parameter names and an expression, to be called using apply",
+		Params: []function.Parameter{
+			{
+				Name:        "params",
+				Description: "a list of parameter names",
+				Type:        cty.DynamicPseudoType,
+			},
+			{
+				Name:        "body",
+				Description: "an expression that can refer to the parameters, whose value is the result of the function",
+				Type:        cty.String,
+			},
+		},
+		Type: function.StaticReturnType(lambdaType),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			params, err := lambdaParams(args[0])
+			if err != nil {
+				return cty.NilVal, err
+			}
+			body, diags := parseLambdaBody(args[1].AsString(), "<lambda>", hcl.InitialPos)
+			if diags.HasErrors() {
+				return cty.NilVal, fmt.Errorf("lambda body: %s", diags.Error())
+			}
+			return cty.CapsuleVal(lambdaType, &lambda{params: params, body: body}), nil
+		},
+	})
+}
+
+// applyFunction returns the apply function that calls an anonymous function with positional arguments.
+func (i *invoker) applyFunction() function.Function {
+	return function.New(&function.Spec{
+		Description: "calls an anonymous function created using lambda with the supplied arguments",
+		Params: []function.Parameter{
+			{
+				Name:        "fn",
+				Description: "the function to call",
+				Type:        lambdaType,
+			},
+		},
+		VarParam: &function.Parameter{
+			Name:         "args",
+			Description:  "arguments to the function, in the order of its parameters",
+			Type:         cty.DynamicPseudoType,
+			AllowNull:    true,
+			AllowUnknown: true,
+		},
+		Type: func([]cty.Value) (cty.Type, error) {
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: i.apply,
+	})
+}
+
+func (i *invoker) apply(args []cty.Value, _ cty.Type) (cty.Value, error) {
+	if err := i.enter(LambdaFunctionName); err != nil {
+		return cty.NilVal, err
+	}
+	defer i.leave()
+
+	fn := args[0].EncapsulatedValue().(*lambda)
+	params := args[1:]
+	if len(params) != len(fn.params) {
+		return cty.NilVal, fmt.Errorf("lambda(%s): expected %d arguments, got %d", strings.Join(fn.params, ", "), len(fn.params), len(params))
+	}
+	values := DynamicObject{}
+	for index, name := range fn.params {
+		values[name] = params[index]
+	}
+	ret, diags := fn.body.Value(i.rootContext(values))
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	return ret, nil
+}
+
+// checkLambda statically checks a call to the lambda function whose parameters and body are constants,
+// such that the body only refers to the parameters and to user functions that exist.
+func (i *invoker) checkLambda(fnCall *hclsyntax.FunctionCallExpr) hcl.Diagnostics {
+	if len(fnCall.Args) != 2 {
+		return hclutils.ToErrorDiag("lambda has incorrect number of arguments", fmt.Sprintf("want 2, got %d", len(fnCall.Args)), fnCall.Range())
+	}
+	paramsVal, ds1 := fnCall.Args[0].Value(&hcl.EvalContext{})
+	bodyVal, ds2 := fnCall.Args[1].Value(&hcl.EvalContext{})
+	if ds1.HasErrors() || ds2.HasErrors() || !paramsVal.IsWhollyKnown() || !bodyVal.IsWhollyKnown() || bodyVal.IsNull() || !bodyVal.Type().Equals(cty.String) {
+		return nil // not a constant, checked at runtime
+	}
+	params, err := lambdaParams(paramsVal)
+	if err != nil {
+		return hclutils.ToErrorDiag(err.Error(), "", fnCall.Args[0].Range())
+	}
+	// for plain strings, the body starts after the opening quote.
+	r := fnCall.Args[1].Range()
+	start := hcl.Pos{Line: r.Start.Line, Column: r.Start.Column + 1, Byte: r.Start.Byte + 1}
+	body, diags := parseLambdaBody(bodyVal.AsString(), r.Filename, start)
+	if diags.HasErrors() {
+		return diags
+	}
+	known := map[string]bool{}
+	for _, p := range params {
+		known[p] = true
+	}
+	for _, v := range body.Variables() {
+		ref := v.RootName()
+		if !known[ref] {
+			diags = diags.Extend(hclutils.ToErrorDiag("lambda: reference to non-existent variable",
+				hclutils.DidYouMean(ref, ref, params), v.SourceRange()))
+		}
+	}
+	return diags.Extend(i.checkUserFunctionRefs(body))
+}
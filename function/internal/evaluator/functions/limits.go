@@ -0,0 +1,138 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// DisableFunctions replaces the named functions with ones that fail with the supplied reason when they are called,
+// both in expressions evaluated in the root context and in the bodies of user functions and lambdas.
+func (e *Processor) DisableFunctions(reason string, names ...string) {
+	for _, name := range names {
+		e.disabled[name] = reason
+	}
+	e.invoker = e.newInvoker(e.Functions)
+}
+
+// SetMaxCollectionSize sets the maximum number of elements of lists, sets, tuples, maps and objects returned by
+// functions. Collections are not limited when the size is not positive.
+func (e *Processor) SetMaxCollectionSize(size int) {
+	e.maxCollectionSize = size
+	e.invoker = e.newInvoker(e.Functions)
+}
+
+// ObserveCalls makes the named functions report their calls to the observer set by SetCallObserver before they
+// are called, both in expressions evaluated in the root context and in the bodies of user functions and lambdas.
+func (e *Processor) ObserveCalls(names ...string) {
+	for _, name := range names {
+		e.observed[name] = true
+	}
+	e.invoker = e.newInvoker(e.Functions)
+}
+
+// SetCallObserver sets the function that is called with the name of an observed function whenever it is called.
+// Since a processor can be used for many evaluations in sequence, the observer can be replaced for each of them.
+func (e *Processor) SetCallObserver(observer func(name string)) {
+	e.observer = observer
+}
+
+// newInvoker returns an invoker for the supplied user functions that enforces the limits of the processor.
+func (e *Processor) newInvoker(fns map[string]*UserFunction) *invoker {
+	i := newInvoker(fns, e.maxDepth)
+	for name, fn := range i.funcMap {
+		if reason, ok := e.disabled[name]; ok {
+			i.funcMap[name] = DisabledFunction(name, reason)
+			continue
+		}
+		if e.maxCollectionSize > 0 {
+			fn = limitCollectionSize(fn, e.maxCollectionSize)
+			i.funcMap[name] = fn
+		}
+		if e.observed[name] {
+			i.funcMap[name] = e.observeCalls(name, fn)
+		}
+	}
+	return i
+}
+
+// observeCalls returns a function that behaves like the supplied one but reports its calls to the observer of the
+// processor.
+func (e *Processor) observeCalls(name string, fn function.Function) function.Function {
+	return function.New(&function.Spec{
+		Description: fn.Description(),
+		Params:      fn.Params(),
+		VarParam:    fn.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return fn.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			if e.observer != nil {
+				e.observer(name)
+			}
+			return fn.Call(args)
+		},
+	})
+}
+
+// DisabledFunction returns a function that accepts any arguments and fails with the supplied reason when called.
+func DisabledFunction(name, reason string) function.Function {
+	return function.New(&function.Spec{
+		Description: fmt.Sprintf("%s is disabled: %s", name, reason),
+		VarParam: &function.Parameter{
+			Name:             "args",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+			AllowMarked:      true,
+		},
+		Type: func([]cty.Value) (cty.Type, error) {
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func([]cty.Value, cty.Type) (cty.Value, error) {
+			return cty.NilVal, fmt.Errorf("function %s is disabled: %s", name, reason)
+		},
+	})
+}
+
+// limitCollectionSize returns a function that behaves like the supplied one but fails when it returns a
+// collection with more than the supplied number of elements.
+func limitCollectionSize(fn function.Function, size int) function.Function {
+	return function.New(&function.Spec{
+		Description: fn.Description(),
+		Params:      fn.Params(),
+		VarParam:    fn.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return fn.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			ret, err := fn.Call(args)
+			if err != nil {
+				return ret, err
+			}
+			if n := CollectionSize(ret); n > size {
+				return cty.NilVal, fmt.Errorf("result has %d elements, more than the maximum of %d", n, size)
+			}
+			return ret, nil
+		},
+	})
+}
+
+// CollectionSize returns the number of elements of the supplied value when it is a known list, set, tuple, map
+// or object, and zero otherwise.
+func CollectionSize(v cty.Value) int {
+	v, _ = v.Unmark()
+	if !v.IsKnown() || v.IsNull() {
+		return 0
+	}
+	t := v.Type()
+	switch {
+	case t.IsObjectType():
+		return len(t.AttributeTypes())
+	case t.IsListType() || t.IsSetType() || t.IsTupleType() || t.IsMapType():
+		return v.LengthInt()
+	}
+	return 0
+}
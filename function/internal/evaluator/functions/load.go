@@ -2,9 +2,11 @@ package functions
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/locals"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -12,16 +14,20 @@ import (
 const (
 	BlockFunction   = "function"
 	BlockArg        = "arg"
+	BlockValidate   = "validate"
 	attrDescription = "description"
 	attrDefault     = "default"
 	attrBody        = "body"
+	attrCondition   = "condition"
+	attrMessage     = "message"
 	blockLocals     = locals.BlockLocals
 )
 
 // processFunctions processes all function blocks at the top-level and returns error
-// diagnostics in case of function definition issues.
+// diagnostics in case of function definition issues. Every function that is declared more than once
+// is reported along with the location of its first declaration.
 func (e *Processor) processFunctions(content *hcl.BodyContent) hcl.Diagnostics {
-	var curDiags, emptyDiags hcl.Diagnostics
+	var curDiags, collisions hcl.Diagnostics
 	funcs := map[string]*UserFunction{}
 	for _, b := range content.Blocks {
 		if b.Type != BlockFunction {
@@ -32,19 +38,35 @@ func (e *Processor) processFunctions(content *hcl.BodyContent) hcl.Diagnostics {
 		if diags.HasErrors() {
 			return diags
 		}
-		if _, ok := funcs[fn.Name]; ok {
-			return emptyDiags.Extend(hclutils.ToErrorDiag("duplicate function declaration", fn.Name, b.DefRange))
+		if existing, ok := funcs[fn.Name]; ok {
+			collisions = collisions.Extend(hclutils.ToErrorDiag("duplicate function declaration",
+				fmt.Sprintf("%s, also declared at %s", fn.Name, existing.DefRange), b.DefRange))
+			continue
 		}
 		funcs[fn.Name] = fn
 	}
+	if collisions.HasErrors() {
+		return collisions
+	}
 	e.Functions = funcs
-	e.invoker = newInvoker(funcs)
+	e.invoker = e.newInvoker(funcs)
 	for _, f := range funcs {
 		curDiags = curDiags.Extend(f.checkRefs(e.invoker))
 	}
 	return curDiags
 }
 
+// isFunctionName returns true if the supplied name is an identifier, optionally qualified by one or more
+// namespaces separated by dots, like "strings.truncate".
+func isFunctionName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !hclexpr.IsIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
 // processFunction processes a single function block and returns an equivalent UserFunction.
 func (e *Processor) processFunction(block *hcl.Block) (*UserFunction, hcl.Diagnostics) {
 	var curDiags, emptyDiags hcl.Diagnostics
@@ -55,8 +77,8 @@ func (e *Processor) processFunction(block *hcl.Block) (*UserFunction, hcl.Diagno
 	curDiags = curDiags.Extend(diags)
 	fnName := block.Labels[0]
 
-	if !hclutils.IsIdentifier(fnName) {
-		return nil, emptyDiags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %q : name must be an identifier", fnName), "", block.LabelRanges[0]))
+	if !isFunctionName(fnName) {
+		return nil, emptyDiags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %q : name must be an identifier, optionally qualified by namespaces separated by dots", fnName), "", block.LabelRanges[0]))
 	}
 
 	desc := ""
@@ -84,11 +106,24 @@ func (e *Processor) processFunction(block *hcl.Block) (*UserFunction, hcl.Diagno
 			args[arg.Name] = arg
 		}
 	}
+	var validations []*validation
+	for _, b := range content.Blocks {
+		if b.Type == BlockValidate {
+			v, diags := b.Body.Content(ValidateSchema())
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			validations = append(validations, &validation{
+				condition: v.Attributes[attrCondition].Expr,
+				message:   v.Attributes[attrMessage].Expr,
+			})
+		}
+	}
 	vals := map[string]cty.Value{}
 	for _, a := range args {
 		vals[a.Name] = a.Default // doesn't matter if there is no default
 	}
-	ctx := newInvoker(nil).rootContext(vals)
+	ctx := newInvoker(nil, e.maxDepth).rootContext(vals)
 	lp := locals.NewProcessor()
 	_, diags = lp.Process(ctx, content)
 	if diags.HasErrors() {
@@ -99,7 +134,9 @@ func (e *Processor) processFunction(block *hcl.Block) (*UserFunction, hcl.Diagno
 	return &UserFunction{
 		Name:         fnName,
 		Description:  desc,
+		DefRange:     block.DefRange,
 		Args:         args,
+		validations:  validations,
 		body:         bodyAttr.Expr,
 		blockContent: content,
 	}, curDiags
@@ -115,7 +152,7 @@ func (e *Processor) processArg(fn string, block *hcl.Block) (*Arg, hcl.Diagnosti
 	}
 
 	argName := block.Labels[0]
-	if !hclutils.IsIdentifier(argName) {
+	if !hclexpr.IsIdentifier(argName) {
 		return nil, emptyDiags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("function %q, arg %q : name must be an identifier", fn, argName), "", block.LabelRanges[0]))
 	}
 
@@ -154,6 +191,7 @@ func FunctionSchema() *hcl.BodySchema {
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: BlockArg, LabelNames: []string{"name"}},
 			{Type: blockLocals},
+			{Type: BlockValidate},
 		},
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrDescription},
@@ -171,3 +209,13 @@ func ArgSchema() *hcl.BodySchema {
 		},
 	}
 }
+
+// ValidateSchema is the schema for validate blocks.
+func ValidateSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrCondition, Required: true},
+			{Name: attrMessage, Required: true},
+		},
+	}
+}
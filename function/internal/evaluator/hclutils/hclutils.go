@@ -2,54 +2,67 @@ package hclutils
 
 import (
 	"fmt"
-	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/agext/levenshtein"
 	"github.com/hashicorp/hcl/v2"
-	"github.com/zclconf/go-cty/cty"
 )
 
-// reIdent is a regular expression that can test for HCL identifiers that are allowed to contain dashes.
-var reIdent = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+// DiagCategory is the category of a diagnostic, which tells who needs to act on it.
+type DiagCategory string
 
-// IsIdentifier returns true if the supplied string can be interpreted as an HCL identifier.
-func IsIdentifier(s string) bool {
-	return reIdent.MatchString(s)
+// diagnostic categories.
+const (
+	// CategoryIncompleteValue is the category of diagnostics caused by values that are not known yet, which are
+	// usually resolved by later reconciles.
+	CategoryIncompleteValue DiagCategory = "incomplete-value"
+	// CategoryUserError is the category of diagnostics caused by mistakes in the HCL source or its inputs.
+	CategoryUserError DiagCategory = "user-error"
+	// CategoryInternal is the category of diagnostics caused by failures of the function itself.
+	CategoryInternal DiagCategory = "internal"
+)
+
+// categoryExtra is the extra information of a categorized diagnostic. It wraps any extra information that the
+// diagnostic already had, which is still available using hcl.DiagnosticExtra.
+type categoryExtra struct {
+	category DiagCategory
+	wrapped  any
 }
 
-// NormalizeTraversal normalizes an index traversal to an attribute traversal for known cases.
-// (i.e. x["foo"] is effectively turned to x.foo).
-func NormalizeTraversal(t hcl.Traversal) hcl.Traversal {
-	var ret hcl.Traversal
-loop:
-	for _, item := range t {
-		switch item := item.(type) {
-		case hcl.TraverseRoot:
-			ret = append(ret, item)
-		case hcl.TraverseAttr:
-			ret = append(ret, item)
-		case hcl.TraverseIndex:
-			k := item.Key
-			if k.Type() == cty.String && IsIdentifier(k.AsString()) {
-				ret = append(ret, hcl.TraverseAttr{
-					Name:     k.AsString(),
-					SrcRange: item.SrcRange,
-				})
-				continue loop
-			}
-			ret = append(ret, item)
-		default:
-			panic(fmt.Errorf("unexpected traversal type: %T", item))
+func (c *categoryExtra) UnwrapDiagnosticExtra() any {
+	return c.wrapped
+}
+
+// Categorize sets the supplied category on all diagnostics that do not have one yet and returns them.
+// This is a destructive operation, clone the diags before calling this function if you need the original.
+func Categorize(diags hcl.Diagnostics, category DiagCategory) hcl.Diagnostics {
+	for _, d := range diags {
+		if Category(d) == "" {
+			d.Extra = &categoryExtra{category: category, wrapped: d.Extra}
 		}
 	}
-	return ret
+	return diags
 }
 
-// DowngradeDiags downgrades all errors in the supplied diags to warnings and returns it.
+// Category returns the category of the supplied diagnostic, or an empty string if it does not have one.
+func Category(d *hcl.Diagnostic) DiagCategory {
+	extra, ok := hcl.DiagnosticExtra[*categoryExtra](d)
+	if !ok {
+		return ""
+	}
+	return extra.category
+}
+
+// DowngradeDiags downgrades all errors in the supplied diags to warnings and returns it. Errors are only
+// downgraded when they are caused by values that are not known yet, so downgraded errors that do not have a
+// category are categorized as incomplete values.
 // This is a destructive operation, clone the diags before calling this function if you need the original.
 func DowngradeDiags(diags hcl.Diagnostics) hcl.Diagnostics {
 	for i := range diags {
 		if diags[i].Severity == hcl.DiagError {
 			diags[i].Severity = hcl.DiagWarning
+			Categorize(diags[i:i+1], CategoryIncompleteValue)
 		}
 	}
 	return diags
@@ -86,3 +99,63 @@ func HasVariable(ctx *hcl.EvalContext, name string) bool {
 	}
 	return false
 }
+
+// VariableNames returns the names of all variables defined in the current or any ancestor context.
+func VariableNames(ctx *hcl.EvalContext) []string {
+	seen := map[string]bool{}
+	var ret []string
+	for c := ctx; c != nil; c = c.Parent() {
+		for name := range c.Variables {
+			if !seen[name] {
+				seen[name] = true
+				ret = append(ret, name)
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// maxSuggestionDistance is the maximum edit distance between a name and a suggestion for it.
+const maxSuggestionDistance = 3
+
+// Suggest returns the candidate that is closest to the supplied name when it is close enough to
+// be a likely typo, or an empty string otherwise. Very short names never have suggestions since
+// almost any other short name would be close enough to them. A candidate that only differs in case
+// and separators, like compositeConnection for composite_connection, is always suggested.
+func Suggest(name string, candidates []string) string {
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+	for _, c := range sorted {
+		if c != name && foldName(c) == foldName(name) {
+			return c
+		}
+	}
+	best, bestDistance := "", maxSuggestionDistance
+	for _, c := range sorted {
+		if c == name {
+			continue
+		}
+		if d := levenshtein.Distance(name, c, nil); d < bestDistance && d < len(name) {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// foldName returns the supplied name in lower case without underscores and dashes.
+func foldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// DidYouMean appends a suggestion for the supplied name to the message, if there is one.
+func DidYouMean(message string, name string, candidates []string) string {
+	s := Suggest(name, candidates)
+	if s == "" {
+		return message
+	}
+	if message == "" {
+		return fmt.Sprintf("did you mean %q?", s)
+	}
+	return fmt.Sprintf("%s, did you mean %q?", message, s)
+}
@@ -5,24 +5,52 @@ import (
 	"testing"
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestIdentifier(t *testing.T) {
+func TestDidYouMean(t *testing.T) {
+	candidates := []string{"resource", "resources", "group", "composite", "composite_connection"}
 	tests := []struct {
-		ident string
-		want  bool
+		message string
+		name    string
+		want    string
 	}{
-		{"foo", true},
-		{"fooBar", true},
-		{"_fooBar", true},
-		{"foo-bar", true},
-		{"-foo-bar", false},
-		{"a b", false},
+		{"", "ressource", `did you mean "resource"?`},
+		{"bad", "compsite", `bad, did you mean "composite"?`},
+		{"bad", "grop", `bad, did you mean "group"?`},
+		{"bad", "something", "bad"},
+		{"bad", "x", "bad"},
+		{"bad", "compositeConnection", `bad, did you mean "composite_connection"?`},
+		{"bad", "Composite-Connection", `bad, did you mean "composite_connection"?`},
 	}
 	for i, test := range tests {
 		t.Run(fmt.Sprintf("index-%d", i), func(t *testing.T) {
-			assert.Equal(t, test.want, hclutils.IsIdentifier(test.ident))
+			assert.Equal(t, test.want, hclutils.DidYouMean(test.message, test.name, candidates))
 		})
 	}
 }
+
+func TestCategories(t *testing.T) {
+	diags := hcl.Diagnostics{
+		{Severity: hcl.DiagError, Summary: "unknown", Extra: "original"},
+		{Severity: hcl.DiagError, Summary: "bad"},
+		{Severity: hcl.DiagWarning, Summary: "warning"},
+	}
+	hclutils.Categorize(diags[1:2], hclutils.CategoryUserError)
+	hclutils.DowngradeDiags(diags)
+
+	assert.Equal(t, hclutils.CategoryIncompleteValue, hclutils.Category(diags[0]))
+	assert.Equal(t, hclutils.CategoryUserError, hclutils.Category(diags[1]))
+	assert.Equal(t, hclutils.DiagCategory(""), hclutils.Category(diags[2]))
+	for _, d := range diags {
+		assert.Equal(t, hcl.DiagWarning, d.Severity)
+	}
+	extra, ok := hcl.DiagnosticExtra[string](diags[0])
+	assert.True(t, ok)
+	assert.Equal(t, "original", extra)
+
+	hclutils.Categorize(diags, hclutils.CategoryInternal)
+	assert.Equal(t, hclutils.CategoryIncompleteValue, hclutils.Category(diags[0]))
+	assert.Equal(t, hclutils.CategoryInternal, hclutils.Category(diags[2]))
+}
@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// HookSeverity is the severity of a finding reported by a resource hook.
+type HookSeverity string
+
+const (
+	// HookSeverityWarning findings are reported as warning results and never remove resources.
+	HookSeverityWarning HookSeverity = "Warning"
+	// HookSeverityFatal findings fail the evaluation, unless they veto a resource that does not exist yet.
+	HookSeverityFatal HookSeverity = "Fatal"
+)
+
+// HookFinding is a finding reported by a resource hook.
+type HookFinding struct {
+	Resource string       // name of the desired resource, empty for findings about the resource set as a whole
+	Severity HookSeverity // severity of the finding
+	Message  string       // message to report
+	Veto     bool         // when true for a fatal finding, the named resource is removed instead of failing
+}
+
+// ResourceHook is called with the desired resources after the HCL has been evaluated and before a response
+// is created. Hooks provide an integration point for governance, for example to enforce a maximum number of
+// resources of some kind per composite. Hooks may annotate resources by modifying the supplied bodies in place
+// and can reject resources by returning findings.
+type ResourceHook interface {
+	CheckResources(in *fnv1.RunFunctionRequest, desired map[string]*structpb.Struct) []HookFinding
+}
+
+const hookResultReason = "resource-hook"
+
+// runHooks runs all hooks in order against the desired resources, removing vetoed resources and recording
+// warnings. Resources that already exist are never removed, since Crossplane would delete them, and vetoes of
+// them fail the evaluation instead. It returns an error that lists all fatal findings, if any.
+func (e *Evaluator) runHooks(in *fnv1.RunFunctionRequest) error {
+	var fatal []string
+	for _, hook := range e.hooks {
+		for _, f := range hook.CheckResources(in, e.desiredResources) {
+			msg := f.Message
+			if f.Resource != "" {
+				msg = fmt.Sprintf("resource %s: %s", f.Resource, f.Message)
+			}
+			if f.Severity != HookSeverityFatal {
+				e.hookWarnings = append(e.hookWarnings, msg)
+				continue
+			}
+			if _, exists := e.observedResources[f.Resource]; f.Veto && f.Resource != "" && !exists {
+				delete(e.desiredResources, f.Resource)
+				delete(e.ready, f.Resource)
+				e.hookWarnings = append(e.hookWarnings, msg+", not rendered")
+				continue
+			}
+			fatal = append(fatal, msg)
+		}
+	}
+	if len(fatal) > 0 {
+		sort.Strings(fatal)
+		return fmt.Errorf("rejected by resource hook: %s", strings.Join(fatal, "; "))
+	}
+	return nil
+}
+
+// addHookResults adds warning results for hook findings to the response.
+func (e *Evaluator) addHookResults(ret *fnv1.RunFunctionResponse) {
+	tg := fnv1.Target_TARGET_COMPOSITE
+	for _, msg := range e.hookWarnings {
+		reason := hookResultReason
+		ret.Results = append(ret.Results, &fnv1.Result{
+			Severity: fnv1.Severity_SEVERITY_WARNING,
+			Message:  msg,
+			Target:   &tg,
+			Reason:   &reason,
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package evaluator_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxPools is a hook that allows a maximum number of node pools, annotating the ones that are allowed.
+type maxPools struct {
+	max      int
+	severity evaluator.HookSeverity
+	veto     bool
+}
+
+func (m maxPools) CheckResources(_ *fnv1.RunFunctionRequest, desired map[string]*structpb.Struct) []evaluator.HookFinding {
+	var ret []evaluator.HookFinding
+	for _, name := range []string{"pool-a", "pool-b", "pool-c"} {
+		res, ok := desired[name]
+		if !ok {
+			continue
+		}
+		if m.max == 0 {
+			ret = append(ret, evaluator.HookFinding{
+				Resource: name,
+				Severity: m.severity,
+				Message:  "too many node pools",
+				Veto:     m.veto,
+			})
+			continue
+		}
+		m.max--
+		res.Fields["metadata"] = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"annotations": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"example.com/quota": structpb.NewStringValue("ok"),
+			}}),
+		}})
+	}
+	return ret
+}
+
+const poolsHCL = `
+resource pool-a {
+  body = {
+    apiVersion = "example.com/v1"
+    kind       = "NodePool"
+  }
+}
+resource pool-b {
+  body = {
+    apiVersion = "example.com/v1"
+    kind       = "NodePool"
+  }
+  ready {
+    value = "READY_TRUE"
+  }
+}
+resource pool-c {
+  body = {
+    apiVersion = "example.com/v1"
+    kind       = "NodePool"
+  }
+}
+`
+
+// hookMessages returns the messages of the results reported for hook findings.
+func hookMessages(t *testing.T, res *fnv1.RunFunctionResponse) []string {
+	var messages []string
+	for _, r := range res.Results {
+		if r.GetReason() != "resource-hook" {
+			continue
+		}
+		assert.Equal(t, fnv1.Severity_SEVERITY_WARNING, r.Severity)
+		messages = append(messages, r.Message)
+	}
+	return messages
+}
+
+func TestHooks(t *testing.T) {
+	// warnings never remove resources, even when they veto them
+	e, err := evaluator.New(evaluator.Options{Hooks: []evaluator.ResourceHook{maxPools{max: 1, severity: evaluator.HookSeverityWarning, veto: true}}})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: poolsHCL})
+	require.NoError(t, err)
+	require.Len(t, res.Desired.Resources, 3)
+	pool := res.Desired.Resources["pool-a"].Resource.AsMap()
+	assert.Equal(t, "ok", pool["metadata"].(map[string]any)["annotations"].(map[string]any)["example.com/quota"])
+	assert.Equal(t, []string{"resource pool-b: too many node pools", "resource pool-c: too many node pools"}, hookMessages(t, res))
+}
+
+func TestHooksVeto(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{Hooks: []evaluator.ResourceHook{maxPools{max: 1, severity: evaluator.HookSeverityFatal, veto: true}}})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: poolsHCL})
+	require.NoError(t, err)
+	require.Len(t, res.Desired.Resources, 1)
+	assert.Contains(t, res.Desired.Resources, "pool-a")
+	assert.Equal(t, []string{
+		"resource pool-b: too many node pools, not rendered",
+		"resource pool-c: too many node pools, not rendered",
+	}, hookMessages(t, res))
+	for _, c := range res.Conditions {
+		if c.Type == "FullyResolved" {
+			assert.Equal(t, fnv1.Status_STATUS_CONDITION_TRUE, c.Status)
+		}
+	}
+}
+
+func TestHooksVetoExisting(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		observed map[string]any
+	}{
+		{
+			name:     "resource",
+			hcl:      poolsHCL,
+			observed: map[string]any{"apiVersion": "example.com/v1", "kind": "NodePool"},
+		},
+		{
+			name: "collection",
+			hcl: `
+resources pool {
+  for_each = { a = 1, b = 2, c = 3 }
+  template {
+    body = {
+      apiVersion = "example.com/v1"
+      kind       = "NodePool"
+    }
+  }
+}
+`,
+			observed: collectionMember(map[string]any{"apiVersion": "example.com/v1", "kind": "NodePool"}, "pool", "s000002"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := makeRequest(t, baseRequestJSON, withObserved(t, map[string]map[string]any{"pool-c": test.observed}))
+			e, err := evaluator.New(evaluator.Options{Hooks: []evaluator.ResourceHook{maxPools{max: 1, severity: evaluator.HookSeverityFatal, veto: true}}})
+			require.NoError(t, err)
+			_, err = e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: test.hcl})
+			require.Error(t, err)
+			assert.Equal(t, "rejected by resource hook: resource pool-c: too many node pools", err.Error())
+		})
+	}
+}
+
+type panicHook struct{}
+
+func (panicHook) CheckResources(*fnv1.RunFunctionRequest, map[string]*structpb.Struct) []evaluator.HookFinding {
+	panic("boom")
+}
+
+func TestHooksPanic(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{Hooks: []evaluator.ResourceHook{panicHook{}}})
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: poolsHCL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "internal error: boom; panic while processing resource hooks, stack: ")
+	assert.Contains(t, err.Error(), "evaluator_test.panicHook.CheckResources (hooks_test.go:")
+}
+
+func TestHooksFatal(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{Hooks: []evaluator.ResourceHook{maxPools{max: 1, severity: evaluator.HookSeverityFatal}}})
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: poolsHCL})
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf("rejected by resource hook: %s; %s",
+		"resource pool-b: too many node pools", "resource pool-c: too many node pools"), err.Error())
+}
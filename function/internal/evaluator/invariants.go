@@ -0,0 +1,257 @@
+package evaluator
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// functions whose arguments must not be replaced by their values, either because the function needs the
+// expressions themselves or because evaluating them once changes the result. Non-deterministic functions return
+// a different value for every iteration, and stable records unstable rounds for branches that are never taken.
+var noHoistFunctions = func() map[string]bool {
+	ret := map[string]bool{
+		"try":          true,
+		"can":          true,
+		"lambda":       true,
+		stableFunction: true,
+	}
+	for name := range nondeterministicFunctions {
+		ret[name] = true
+	}
+	return ret
+}()
+
+// invariantHoister evaluates subexpressions that do not depend on the current iteration of a resource collection
+// once, such that they are not evaluated again for every iteration.
+type invariantHoister struct {
+	ctx     *hcl.EvalContext                   // context of the collection, without any iteration variables
+	varying map[string]bool                    // root names of variables whose values change between iterations
+	values  map[hclsyntax.Expression]cty.Value // values of hoisted expressions, by expression
+}
+
+// hoistInvariants hoists the invariant subexpressions of all attributes in the supplied template content, including
+// its locals. Names of template locals are treated as varying. It returns a copy of the content in which these
+// subexpressions are replaced by their values. The supplied content is not changed, since the same parsed files are
+// shared by all evaluations of a program.
+func hoistInvariants(ctx *hcl.EvalContext, content *hcl.BodyContent) *hcl.BodyContent {
+	h := &invariantHoister{
+		ctx:     ctx,
+		varying: map[string]bool{iteratorName: true, reservedSelf: true},
+		values:  map[hclsyntax.Expression]cty.Value{},
+	}
+	for _, b := range content.Blocks {
+		if b.Type != blockLocals {
+			continue
+		}
+		localAttrs, _ := b.Body.JustAttributes()
+		for name := range localAttrs {
+			h.varying[name] = true
+		}
+	}
+
+	ret := *content
+	ret.Attributes = hcl.Attributes{}
+	for name, attr := range content.Attributes {
+		ret.Attributes[name] = h.hoistAttribute(attr)
+	}
+	ret.Blocks = make(hcl.Blocks, len(content.Blocks))
+	for i, b := range content.Blocks {
+		ret.Blocks[i] = b
+		body, ok := b.Body.(*hclsyntax.Body)
+		if b.Type != blockLocals || !ok {
+			continue
+		}
+		hoisted := *body
+		hoisted.Attributes = hclsyntax.Attributes{}
+		for name, attr := range body.Attributes {
+			a := *attr
+			a.Expr = h.hoistChildren(attr.Expr)
+			hoisted.Attributes[name] = &a
+		}
+		block := *b
+		block.Body = &hoisted
+		ret.Blocks[i] = &block
+	}
+	return &ret
+}
+
+// hoistAttribute returns a copy of the supplied attribute with its invariant subexpressions replaced by their values.
+func (h *invariantHoister) hoistAttribute(attr *hcl.Attribute) *hcl.Attribute {
+	expr, ok := attr.Expr.(hclsyntax.Expression)
+	if !ok {
+		return attr
+	}
+	ret := *attr
+	ret.Expr = h.hoistChildren(expr)
+	return &ret
+}
+
+// isInvariant returns true if the supplied expression does not refer to any varying names or call functions that
+// should not be hoisted.
+func (h *invariantHoister) isInvariant(expr hclsyntax.Expression) bool {
+	for _, t := range expr.Variables() {
+		if h.varying[t.RootName()] {
+			return false
+		}
+	}
+	invariant := true
+	_ = hclsyntax.VisitAll(expr, func(n hclsyntax.Node) hcl.Diagnostics {
+		if call, ok := n.(*hclsyntax.FunctionCallExpr); ok && noHoistFunctions[call.Name] {
+			invariant = false
+		}
+		return nil
+	})
+	return invariant
+}
+
+// value returns the value of the supplied expression if it is invariant and can be fully evaluated. Values are
+// cached by expression, such that every invariant expression is evaluated once.
+func (h *invariantHoister) value(expr hclsyntax.Expression) (cty.Value, bool) {
+	if v, ok := h.values[expr]; ok {
+		return v, true
+	}
+	if !h.isInvariant(expr) {
+		return cty.NilVal, false
+	}
+	// leave expressions that cannot be fully evaluated alone, such that they produce the usual diagnostics
+	v, diags := expr.Value(h.ctx)
+	if len(diags) > 0 || !v.IsWhollyKnown() || v.Type() == cty.DynamicPseudoType {
+		return cty.NilVal, false
+	}
+	h.values[expr] = v
+	return v, true
+}
+
+// hoist returns the value of the supplied expression as a literal if it is invariant, or the expression with its
+// invariant children hoisted otherwise.
+func (h *invariantHoister) hoist(expr hclsyntax.Expression) hclsyntax.Expression {
+	if _, ok := expr.(*hclsyntax.LiteralValueExpr); ok {
+		return expr
+	}
+	if v, ok := h.value(expr); ok {
+		return &hclsyntax.LiteralValueExpr{Val: v, SrcRange: expr.Range()}
+	}
+	return h.hoistChildren(expr)
+}
+
+// hoistAll hoists the supplied expressions. It returns a new slice and true if any of them changed.
+func (h *invariantHoister) hoistAll(exprs []hclsyntax.Expression) ([]hclsyntax.Expression, bool) {
+	ret := make([]hclsyntax.Expression, len(exprs))
+	changed := false
+	for i, expr := range exprs {
+		ret[i] = h.hoist(expr)
+		changed = changed || ret[i] != expr
+	}
+	return ret, changed
+}
+
+// hoistChildren returns a copy of the supplied expression whose child expressions are hoisted, or the expression
+// itself when none of them changed. Expressions that introduce their own variables, like for expressions and
+// splats, are not descended into.
+func (h *invariantHoister) hoistChildren(expr hclsyntax.Expression) hclsyntax.Expression {
+	switch x := expr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		var items []hclsyntax.ObjectConsItem
+		for i, item := range x.Items {
+			hoisted := h.hoist(item.ValueExpr)
+			if hoisted == item.ValueExpr {
+				continue
+			}
+			if items == nil {
+				items = append([]hclsyntax.ObjectConsItem{}, x.Items...)
+			}
+			items[i].ValueExpr = hoisted
+		}
+		if items == nil {
+			return expr
+		}
+		ret := *x
+		ret.Items = items
+		return &ret
+	case *hclsyntax.TupleConsExpr:
+		exprs, changed := h.hoistAll(x.Exprs)
+		if !changed {
+			return expr
+		}
+		ret := *x
+		ret.Exprs = exprs
+		return &ret
+	case *hclsyntax.TemplateExpr:
+		parts, changed := h.hoistAll(x.Parts)
+		if !changed {
+			return expr
+		}
+		ret := *x
+		ret.Parts = parts
+		return &ret
+	case *hclsyntax.TemplateWrapExpr:
+		wrapped := h.hoist(x.Wrapped)
+		if wrapped == x.Wrapped {
+			return expr
+		}
+		ret := *x
+		ret.Wrapped = wrapped
+		return &ret
+	case *hclsyntax.ConditionalExpr:
+		cond, t, f := h.hoist(x.Condition), h.hoist(x.TrueResult), h.hoist(x.FalseResult)
+		if cond == x.Condition && t == x.TrueResult && f == x.FalseResult {
+			return expr
+		}
+		ret := *x
+		ret.Condition, ret.TrueResult, ret.FalseResult = cond, t, f
+		return &ret
+	case *hclsyntax.BinaryOpExpr:
+		lhs, rhs := h.hoist(x.LHS), h.hoist(x.RHS)
+		if lhs == x.LHS && rhs == x.RHS {
+			return expr
+		}
+		ret := *x
+		ret.LHS, ret.RHS = lhs, rhs
+		return &ret
+	case *hclsyntax.UnaryOpExpr:
+		val := h.hoist(x.Val)
+		if val == x.Val {
+			return expr
+		}
+		ret := *x
+		ret.Val = val
+		return &ret
+	case *hclsyntax.ParenthesesExpr:
+		inner := h.hoist(x.Expression)
+		if inner == x.Expression {
+			return expr
+		}
+		ret := *x
+		ret.Expression = inner
+		return &ret
+	case *hclsyntax.IndexExpr:
+		coll, key := h.hoist(x.Collection), h.hoist(x.Key)
+		if coll == x.Collection && key == x.Key {
+			return expr
+		}
+		ret := *x
+		ret.Collection, ret.Key = coll, key
+		return &ret
+	case *hclsyntax.RelativeTraversalExpr:
+		source := h.hoist(x.Source)
+		if source == x.Source {
+			return expr
+		}
+		ret := *x
+		ret.Source = source
+		return &ret
+	case *hclsyntax.FunctionCallExpr:
+		if noHoistFunctions[x.Name] {
+			return expr
+		}
+		args, changed := h.hoistAll(x.Args)
+		if !changed {
+			return expr
+		}
+		ret := *x
+		ret.Args = args
+		return &ret
+	}
+	return expr
+}
@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestHoistInvariants(t *testing.T) {
+	calls := 0
+	ctx := createTestEvalContext()
+	ctx.Functions = map[string]function.Function{
+		"expensive": function.New(&function.Spec{
+			Params: []function.Parameter{{Name: "s", Type: cty.String}},
+			Type:   function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+				calls++
+				return cty.StringVal(args[0].AsString() + "!"), nil
+			},
+		}),
+	}
+	hclContent := `
+template {
+	locals {
+		suffix = each.value
+	}
+	body = {
+		region = expensive(req.composite.spec.region)
+		name   = "${expensive(req.composite.metadata.name)}-${suffix}"
+		index  = expensive(each.key)
+	}
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(hclContent), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	top, diags := file.Body.Content(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: blockTemplate}}})
+	require.False(t, diags.HasErrors())
+	content, diags := top.Blocks[0].Body.Content(templateSchema())
+	require.False(t, diags.HasErrors())
+
+	hoisted := hoistInvariants(ctx, content)
+	assert.Equal(t, 2, calls)
+
+	var results []cty.Value
+	for _, key := range []string{"a", "b", "c"} {
+		iterCtx := ctx.NewChild()
+		iterCtx.Variables = map[string]cty.Value{
+			iteratorName: cty.ObjectVal(map[string]cty.Value{
+				attrKey:   cty.StringVal(key),
+				attrValue: cty.StringVal(key + key),
+			}),
+			"suffix": cty.StringVal(key + key),
+		}
+		v, diags := hoisted.Attributes[attrBody].Expr.Value(iterCtx)
+		require.False(t, diags.HasErrors())
+		results = append(results, v)
+	}
+	// only the expression that depends on each is evaluated for every iteration
+	assert.Equal(t, 5, calls)
+	assert.Equal(t, "us-west-2!", results[2].GetAttr("region").AsString())
+	assert.Equal(t, "my-composite!-cc", results[2].GetAttr("name").AsString())
+	assert.Equal(t, "c!", results[2].GetAttr("index").AsString())
+
+	// the original expressions are not changed
+	v, diags := content.Attributes[attrBody].Expr.Value(ctx.NewChild())
+	assert.True(t, diags.HasErrors())
+	assert.False(t, v.IsWhollyKnown())
+	assert.Equal(t, 7, calls)
+}
+
+func TestHoistInvariantsCollectionResults(t *testing.T) {
+	hclContent := `
+resources buckets {
+	for_each = ["a", "b"]
+	template {
+		locals {
+			env = req.composite.spec.environment
+		}
+		body = {
+			apiVersion = "s3.aws.upbound.io/v1beta1"
+			kind       = "Bucket"
+			spec = {
+				region = "${req.composite.spec.region}-${req.composite.spec.environment}"
+				tags   = { env = env, index = self.index, name = self.name }
+			}
+		}
+	}
+}
+`
+	e := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, hclContent, "test.hcl")
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, e.desiredResources, 2, "%v", e.discards)
+	spec := e.desiredResources["buckets-1"].AsMap()["spec"].(map[string]any)
+	assert.Equal(t, "us-west-2-production", spec["region"])
+	assert.Equal(t, map[string]any{"env": "production", "index": float64(1), "name": "buckets-1"}, spec["tags"])
+}
+
+func BenchmarkHoistInvariants(b *testing.B) {
+	hclContent := `
+resources buckets {
+	for_each = range(100)
+	template {
+		locals {
+			env = req.composite.spec.environment
+		}
+		body = {
+			apiVersion = "s3.aws.upbound.io/v1beta1"
+			kind       = "Bucket"
+			metadata   = { annotations = { owner = upper(req.composite.metadata.name) } }
+			spec = {
+				forProvider = {
+					region = "${req.composite.spec.region}-${lower(req.composite.spec.environment)}"
+					tags   = merge({ env = env }, { for k in ["a", "b", "c"] : k => sha256(req.composite.metadata.name) })
+				}
+				index = each.value
+			}
+		}
+	}
+}
+`
+	e, err := New(Options{})
+	require.NoError(b, err)
+	file, diags := hclsyntax.ParseConfig([]byte(hclContent), "test.hcl", hcl.InitialPos)
+	require.False(b, diags.HasErrors())
+	content, diags := file.Body.Content(topLevelSchema())
+	require.False(b, diags.HasErrors())
+	p, diags := e.processFunctions(&hcl.BodyContent{})
+	require.False(b, diags.HasErrors())
+	ctx := p.RootContext(nil).NewChild()
+	ctx.Variables = createTestEvalContext().Variables
+	b.ResetTimer()
+	for range b.N {
+		e, err := New(Options{})
+		require.NoError(b, err)
+		if diags := e.processGroup(ctx, content); diags.HasErrors() {
+			b.Fatal(diags.Error())
+		}
+		if len(e.desiredResources) != 100 {
+			b.Fatalf("expected 100 resources, got %d", len(e.desiredResources))
+		}
+	}
+}
+
+func TestHoistInvariantsSkipsFunctions(t *testing.T) {
+	calls := 0
+	counter := function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.DynamicPseudoType},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(_ []cty.Value, _ cty.Type) (cty.Value, error) {
+			calls++
+			return cty.StringVal("x"), nil
+		},
+	})
+	ctx := createTestEvalContext()
+	ctx.Functions = map[string]function.Function{stableFunction: counter}
+	for name := range nondeterministicFunctions {
+		ctx.Functions[name] = counter
+	}
+	hclContent := `
+template {
+	body = {
+		hash    = bcrypt(req.composite.metadata.name)
+		id      = uuid()
+		created = timestamp()
+		arn     = stable(req.composite.spec.region, "pending", 3)
+	}
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(hclContent), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	top, diags := file.Body.Content(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: blockTemplate}}})
+	require.False(t, diags.HasErrors())
+	content, diags := top.Blocks[0].Body.Content(templateSchema())
+	require.False(t, diags.HasErrors())
+
+	hoisted := hoistInvariants(ctx, content)
+	assert.Equal(t, 0, calls)
+	assert.Same(t, content.Attributes[attrBody].Expr, hoisted.Attributes[attrBody].Expr)
+}
@@ -0,0 +1,141 @@
+package evaluator
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// groupState is the part of the state of the evaluator that the blocks of a group add to.
+type groupState struct {
+	desiredResources      map[string]*structpb.Struct
+	requirements          map[string]*fnv1.ResourceSelector
+	ready                 map[string]int32
+	sensitiveContextKeys  map[string]bool
+	unstableRounds        map[string]int
+	nondeterministicCalls map[string]bool
+	renames               map[string]string
+	hookWarnings          int
+	policyViolations      int
+	compositeStatuses     int
+	compositeConnections  int
+	compositeSpecs        int
+	contexts              int
+	discards              int
+}
+
+// saveGroupState returns the state that the blocks of a group add to. Maps are copied, and for lists, only their
+// lengths are recorded since blocks only ever append to them.
+func (e *Evaluator) saveGroupState() *groupState {
+	return &groupState{
+		desiredResources:      maps.Clone(e.desiredResources),
+		requirements:          maps.Clone(e.requirements),
+		ready:                 maps.Clone(e.ready),
+		sensitiveContextKeys:  maps.Clone(e.sensitiveContextKeys),
+		unstableRounds:        maps.Clone(e.unstableRounds),
+		nondeterministicCalls: maps.Clone(e.nondeterministicCalls),
+		renames:               maps.Clone(e.renames),
+		hookWarnings:          len(e.hookWarnings),
+		policyViolations:      len(e.policyViolations),
+		compositeStatuses:     len(e.compositeStatuses),
+		compositeConnections:  len(e.compositeConnections),
+		compositeSpecs:        len(e.compositeSpecs),
+		contexts:              len(e.contexts),
+		discards:              len(e.discards),
+	}
+}
+
+// restoreGroupState removes everything that was added since the supplied state was saved.
+func (e *Evaluator) restoreGroupState(s *groupState) {
+	e.desiredResources = s.desiredResources
+	e.requirements = s.requirements
+	e.ready = s.ready
+	e.sensitiveContextKeys = s.sensitiveContextKeys
+	e.unstableRounds = s.unstableRounds
+	e.nondeterministicCalls = s.nondeterministicCalls
+	e.renames = s.renames
+	e.hookWarnings = e.hookWarnings[:s.hookWarnings]
+	e.policyViolations = e.policyViolations[:s.policyViolations]
+	e.compositeStatuses = e.compositeStatuses[:s.compositeStatuses]
+	e.compositeStatusRanges = e.compositeStatusRanges[:s.compositeStatuses]
+	e.compositeConnections = e.compositeConnections[:s.compositeConnections]
+	e.compositeSpecs = e.compositeSpecs[:s.compositeSpecs]
+	e.compositeSpecRanges = e.compositeSpecRanges[:s.compositeSpecs]
+	e.contexts = e.contexts[:s.contexts]
+	e.discards = e.discards[:s.discards]
+}
+
+// isolateGroup calls the supplied function to process a group. When group errors are isolated and processing fails,
+// everything that the group added is removed and the failure is reported as a discarded group instead of an error,
+// such that the objects of other groups are still rendered. The observed state of the existing resources of the
+// group is kept as their desired state, such that an error never deletes them. Errors are not isolated when the
+// names of the resources of the group cannot be determined without evaluating them, since its existing resources
+// would be deleted, and once the evaluation is cancelled, since the remaining blocks would not be processed either.
+func (e *Evaluator) isolateGroup(block *hcl.Block, content *hcl.BodyContent, name string, process func() hcl.Diagnostics) hcl.Diagnostics {
+	if !e.isolateGroupErrors {
+		return process()
+	}
+	s := e.saveGroupState()
+	diags := process()
+	if !diags.HasErrors() || (e.runCtx != nil && e.runCtx.Err() != nil) {
+		return diags
+	}
+	resourceNames, ok := e.groupResourceNames(name, content)
+	if !ok {
+		return diags
+	}
+	e.restoreGroupState(s)
+	kept, ds := e.keepObserved(block, resourceNames)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	context := e.messagesFromDiags(diags)
+	if len(kept) > 0 {
+		context = append(context, fmt.Sprintf("kept observed state of %s", strings.Join(kept, ", ")))
+	}
+	e.discard(DiscardItem{
+		Type:        discardTypeGroup,
+		Reason:      discardReasonFailed,
+		Name:        name,
+		SourceRange: block.DefRange.String(),
+		Context:     context,
+	})
+	return hclutils.DowngradeDiags(hclutils.Categorize(diags, hclutils.CategoryUserError))
+}
+
+// groupResourceNames returns the names of the resources that the group with the supplied content and name prefix
+// may declare, including the observed resources of its collections and the resources of nested groups. The boolean
+// result is false when the name of a resource cannot be determined without evaluating it.
+func (e *Evaluator) groupResourceNames(prefix string, content *hcl.BodyContent) ([]string, bool) {
+	var ret []string
+	for _, b := range content.Blocks {
+		switch b.Type {
+		case blockResource:
+			c, _ := b.Body.Content(resourceSchema())
+			name, ok := staticResourceName(prefix, b, c)
+			if !ok {
+				return nil, false
+			}
+			ret = append(ret, name)
+		case blockResources:
+			ret = append(ret, e.collectionResourceNames[prefix+b.Labels[0]]...)
+		case blockGroup:
+			c, _ := b.Body.Content(groupSchema())
+			p, ds := groupNamePrefix(c)
+			if ds.HasErrors() {
+				return nil, false
+			}
+			names, ok := e.groupResourceNames(prefix+p, c)
+			if !ok {
+				return nil, false
+			}
+			ret = append(ret, names...)
+		}
+	}
+	return ret, true
+}
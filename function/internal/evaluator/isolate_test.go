@@ -0,0 +1,148 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const isolateHCL = `
+resource core {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+group {
+  name_prefix = "monitoring-"
+  resource dashboard {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+    }
+  }
+  composite status {
+    body = { monitoring = "on" }
+  }
+  resources alerts {
+    for_each = 42
+    template {
+      body = {
+        apiVersion = "v1"
+        kind       = "ConfigMap"
+      }
+    }
+  }
+}
+composite status {
+  body = { core = "on" }
+}
+`
+
+func TestIsolateGroupErrors(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: isolateHCL})
+	require.Error(t, err)
+
+	e, err = evaluator.New(evaluator.Options{IsolateGroupErrors: true, DiscardsInContext: true})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: isolateHCL})
+	require.NoError(t, err)
+	assert.True(t, e.Incomplete())
+
+	var names []string
+	for name := range res.GetDesired().GetResources() {
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"core"}, names)
+	assert.Equal(t, map[string]any{"core": "on"}, res.GetDesired().GetComposite().GetResource().AsMap()["status"])
+
+	discards := res.GetContext().AsMap()["hcl.fn.crossplane.io/discards"].([]any)
+	require.Len(t, discards, 1)
+	discard := discards[0].(map[string]any)
+	assert.Equal(t, "group", discard["type"])
+	assert.Equal(t, "failed", discard["reason"])
+	assert.Equal(t, "monitoring-", discard["name"])
+
+	for _, c := range res.GetConditions() {
+		if c.GetType() == "FullyResolved" {
+			assert.Equal(t, "group monitoring- incomplete", c.GetMessage())
+		}
+	}
+}
+
+func TestIsolateGroupErrorsKeepExisting(t *testing.T) {
+	observed := map[string]map[string]any{}
+	for name, base := range map[string]string{"monitoring-dashboard": "", "monitoring-alerts-0": "monitoring-alerts"} {
+		observed[name] = map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "xr-" + name, "uid": "1234"},
+			"data":       map[string]any{"version": "1"},
+			"status":     map[string]any{"id": "id-" + name},
+		}
+		if base != "" {
+			collectionMember(observed[name], base, "s000000")
+		}
+	}
+	req := makeRequest(t, baseRequestJSON, withObserved(t, observed))
+
+	e, err := evaluator.New(evaluator.Options{IsolateGroupErrors: true, DiscardsInContext: true})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), req, evaluator.File{Name: "main.hcl", Content: isolateHCL})
+	require.NoError(t, err)
+
+	desired := res.GetDesired().GetResources()
+	require.Len(t, desired, 3)
+	for _, name := range []string{"monitoring-dashboard", "monitoring-alerts-0"} {
+		body := desired[name].GetResource().AsMap()
+		assert.Equal(t, map[string]any{"version": "1"}, body["data"], name)
+		assert.NotContains(t, body, "status", name)
+	}
+	discards := res.GetContext().AsMap()["hcl.fn.crossplane.io/discards"].([]any)
+	require.Len(t, discards, 1)
+	assert.Contains(t, discards[0].(map[string]any)["context"], "kept observed state of monitoring-dashboard, monitoring-alerts-0")
+}
+
+func TestIsolateGroupErrorsDynamicNames(t *testing.T) {
+	hcl := `
+group {
+  locals {
+    suffix = "a"
+  }
+  resource dashboard {
+    resource_name = "dashboard-${suffix}"
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+    }
+  }
+  resources alerts {
+    for_each = 42
+    template {
+      body = {
+        apiVersion = "v1"
+        kind       = "ConfigMap"
+      }
+    }
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{IsolateGroupErrors: true})
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.Error(t, err)
+}
+
+func TestIsolateGroupErrorsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e, err := evaluator.New(evaluator.Options{IsolateGroupErrors: true})
+	require.NoError(t, err)
+	_, err = e.Eval(ctx, makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: isolateHCL})
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package evaluator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/secrets"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// redactedValue replaces the values of sensitive locals in snapshots.
+const redactedValue = "(sensitive value redacted)"
+
+// sensitiveNames matches names of locals that are likely to hold credentials.
+var sensitiveNames = regexp.MustCompile(`(?i)passw(or)?d|secret|token|credential|private_?key|api_?key|access_?key`)
+
+// isSensitiveLocal returns true if the value of the supplied local should not be displayed. This is the case
+// when its name suggests a credential, when it has been marked, or when its value looks like a credential.
+func isSensitiveLocal(name string, v cty.Value) bool {
+	if sensitiveNames.MatchString(name) || v.ContainsMarked() {
+		return true
+	}
+	if !v.IsWhollyKnown() {
+		return false
+	}
+	b, err := ctyjson.Marshal(v, v.Type())
+	return err != nil || len(secrets.Scan(b)) > 0
+}
+
+// describeLocal returns a preview of the value of a local for a snapshot. For values that are not wholly known,
+// it lists the paths of the unknown parts.
+func describeLocal(name string, v cty.Value) string {
+	if isSensitiveLocal(name, v) {
+		return redactedValue
+	}
+	if v.IsWhollyKnown() {
+		return previewValue(v)
+	}
+	paths, err := hclexpr.FindUnknownPaths(v)
+	if err != nil || len(paths) == 0 || (len(paths) == 1 && paths[0] == "") {
+		return "(unknown)"
+	}
+	return fmt.Sprintf("(unknown at %s)", strings.Join(paths, ", "))
+}
+
+// localsSnapshot returns a description of the values of all locals in scope of the supplied context, sorted by name.
+// Reserved namespaces and aliases are not included.
+func (e *Evaluator) localsSnapshot(ctx *hcl.EvalContext) []string {
+	values := DynamicObject{}
+	for c := ctx; c != nil; c = c.Parent() {
+		for name, v := range c.Variables {
+			if reservedNames[name] || e.aliases[name] != nil {
+				continue
+			}
+			if _, ok := values[name]; !ok {
+				values[name] = v
+			}
+		}
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ret := make([]string, 0, len(names))
+	for _, name := range names {
+		ret = append(ret, fmt.Sprintf("local %s = %s", name, describeLocal(name, values[name])))
+	}
+	return ret
+}
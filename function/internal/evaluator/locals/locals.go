@@ -36,17 +36,27 @@ type exprDeps struct {
 // Process processes all local blocks found in the supplied body contents as a single unit and returns a child
 // context which has values for all locals.
 func (l *Processor) Process(ctx *hcl.EvalContext, content *hcl.BodyContent) (*hcl.EvalContext, hcl.Diagnostics) {
-	var diags hcl.Diagnostics
-	var attrsList []hcl.Attributes
+	var blocks []*hcl.Block
 	for _, block := range content.Blocks {
 		if block.Type == BlockLocals {
-			attrs, ds := block.Body.JustAttributes()
-			diags = diags.Extend(ds)
-			if ds.HasErrors() {
-				return nil, diags
-			}
-			attrsList = append(attrsList, attrs)
+			blocks = append(blocks, block)
+		}
+	}
+	return l.ProcessBlocks(ctx, blocks)
+}
+
+// ProcessBlocks processes the attributes of all supplied blocks as locals in a single unit and returns a child
+// context which has values for all of them.
+func (l *Processor) ProcessBlocks(ctx *hcl.EvalContext, blocks []*hcl.Block) (*hcl.EvalContext, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var attrsList []hcl.Attributes
+	for _, block := range blocks {
+		attrs, ds := block.Body.JustAttributes()
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			return nil, diags
 		}
+		attrsList = append(attrsList, attrs)
 	}
 	childCtx, ds := l.evaluate(ctx, attrsList)
 	return childCtx, diags.Extend(ds)
@@ -112,7 +122,12 @@ func (l *Processor) computeDeps(ctx *hcl.EvalContext, locals map[string]*exprDep
 			if _, ok := locals[reference]; ok {
 				locals[name].deps = append(locals[name].deps, reference)
 			} else if !hclutils.HasVariable(ctx, reference) {
-				return hclutils.ToErrorDiag("reference to non-existent variable", reference, dep.SourceRange())
+				candidates := hclutils.VariableNames(ctx)
+				for local := range locals {
+					candidates = append(candidates, local)
+				}
+				return hclutils.ToErrorDiag("reference to non-existent variable",
+					hclutils.DidYouMean(reference, reference, candidates), dep.SourceRange())
 			}
 		}
 	}
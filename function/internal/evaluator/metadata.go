@@ -0,0 +1,29 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
+)
+
+// BlockTypes returns the sorted names of the blocks supported at the top-level of a composition.
+func BlockTypes() []string {
+	var ret []string
+	for _, b := range topLevelBlocks {
+		ret = append(ret, b.Type)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// BuiltinFunctions returns the sorted names of the functions that can be called from a composition,
+// not including user functions.
+func BuiltinFunctions() []string {
+	var ret []string
+	for name := range functions.NewProcessor().RootContext(nil).Functions {
+		ret = append(ret, name)
+	}
+	ret = append(ret, stableFunction, changedFunction)
+	sort.Strings(ret)
+	return ret
+}
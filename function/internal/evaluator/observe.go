@@ -0,0 +1,84 @@
+package evaluator
+
+import (
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// observedExtraResource returns the first extra resource returned for the supplied requirement name, or an
+// unknown value if none have been returned yet.
+func observedExtraResource(ctx *hcl.EvalContext, name string) cty.Value {
+	extra, ok := extractSymbolTable(ctx, reservedReq)[reqExtraResources]
+	if !ok || !extra.IsKnown() || extra.IsNull() || !extra.Type().IsObjectType() || !extra.Type().HasAttribute(name) {
+		return cty.DynamicVal
+	}
+	v := extra.GetAttr(name)
+	if !v.IsKnown() || v.IsNull() || !v.CanIterateElements() || v.LengthInt() == 0 {
+		return cty.DynamicVal
+	}
+	return v.Index(cty.NumberIntVal(0))
+}
+
+// processObserve processes an observe block. The block adds a requirement for the object it selects and
+// makes the first object returned for it available as self.observed to its composite and context blocks.
+func (e *Evaluator) processObserve(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
+	name := block.Labels[0]
+	if _, ok := e.requirements[name]; ok {
+		return hclutils.ToErrorDiag("multiple requirements with name", name, block.DefRange)
+	}
+
+	content, diags := block.Body.Content(observeSchema())
+	if diags.HasErrors() {
+		return diags
+	}
+	sel, ds := contentToSelection(name, content, block.DefRange)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+
+	ctx, ds = e.processLocals(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+
+	cond, ds := e.evaluateCondition(ctx, content, discardTypeRequirement, name)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+	if !cond {
+		return diags
+	}
+
+	selector, ds := e.selectionToSelector(name, ctx, sel)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+	if selector != nil {
+		e.requirements[name] = selector
+	}
+
+	ctx = createSelfChildContext(ctx, DynamicObject{
+		selfObserved: observedExtraResource(ctx, name),
+	})
+	for _, b := range content.Blocks {
+		var curDiags hcl.Diagnostics
+		switch b.Type {
+		case blockComposite:
+			curDiags = e.processComposite(ctx, b)
+		case blockContext:
+			curDiags = e.processContext(ctx, b)
+		case blockContexts:
+			curDiags = e.processContexts(ctx, b)
+		}
+		diags = diags.Extend(curDiags)
+		if curDiags.HasErrors() {
+			return diags
+		}
+	}
+	return diags
+}
@@ -0,0 +1,145 @@
+package evaluator
+
+import (
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const observeHCL = `
+observe vpc {
+	locals {
+		vpcName = "${req.composite.metadata.name}-vpc"
+	}
+	apiVersion = "ec2.aws.upbound.io/v1beta1"
+	kind = "VPC"
+	matchName = vpcName
+
+	composite status {
+		body = {
+			vpcId = self.observed.status.atProvider.id
+		}
+	}
+}
+`
+
+func withExtraResources(ctx *hcl.EvalContext, extra cty.Value) *hcl.EvalContext {
+	req := ctx.Variables[reservedReq].AsValueMap()
+	req[reqExtraResources] = extra
+	ctx.Variables[reservedReq] = cty.ObjectVal(req)
+	return ctx
+}
+
+func TestObserveAddsRequirement(t *testing.T) {
+	e := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, observeHCL, "test.hcl")
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.NotNil(t, e.requirements["vpc"])
+	assert.Equal(t, "ec2.aws.upbound.io/v1beta1", e.requirements["vpc"].ApiVersion)
+	assert.Equal(t, "VPC", e.requirements["vpc"].Kind)
+	mn, ok := e.requirements["vpc"].Match.(*fnv1.ResourceSelector_MatchName)
+	require.True(t, ok)
+	assert.Equal(t, "my-composite-vpc", mn.MatchName)
+
+	// the status is incomplete since the object has not been observed yet
+	assert.Empty(t, e.compositeStatuses)
+}
+
+func TestObserveExposesObservedObject(t *testing.T) {
+	e := createTestEvaluator(t)
+	ctx := withExtraResources(createTestEvalContext(), cty.ObjectVal(map[string]cty.Value{
+		"vpc": cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"status": cty.ObjectVal(map[string]cty.Value{
+					"atProvider": cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal("vpc-1234"),
+					}),
+				}),
+			}),
+		}),
+	}))
+	content := parseHCL(t, e, observeHCL, "test.hcl")
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, e.compositeStatuses, 1)
+	assert.Equal(t, "vpc-1234", e.compositeStatuses[0]["vpcId"])
+}
+
+func TestObserveSkipCondition(t *testing.T) {
+	e := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	hclContent := `
+observe vpc {
+	condition = false
+	apiVersion = "ec2.aws.upbound.io/v1beta1"
+	kind = "VPC"
+	matchName = "foo"
+}
+`
+	content := parseHCL(t, e, hclContent, "test.hcl")
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors())
+	assert.Empty(t, e.requirements)
+	require.Len(t, e.discards, 1)
+	assert.Equal(t, discardReasonUserCondition, e.discards[0].Reason)
+}
+
+func TestObserveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		hcl  string
+		msg  string
+	}{
+		{
+			name: "no match",
+			hcl: `
+observe vpc {
+	apiVersion = "v1"
+	kind = "ConfigMap"
+}
+`,
+			msg: "requirement selector has neither matchName nor matchLabels",
+		},
+		{
+			name: "duplicate requirement",
+			hcl: `
+requirement vpc {
+	select {
+		apiVersion = "v1"
+		kind = "ConfigMap"
+		matchName = "foo"
+	}
+}
+observe vpc {
+	apiVersion = "v1"
+	kind = "ConfigMap"
+	matchName = "bar"
+}
+`,
+			msg: "multiple requirements with name",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := createTestEvaluator(t)
+			ctx := createTestEvalContext()
+			content := parseHCL(t, e, test.hcl, "test.hcl")
+			diags := e.processGroup(ctx, content)
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.msg)
+		})
+	}
+}
+
+func TestObserveAnalyze(t *testing.T) {
+	e, err := New(Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(File{Name: "test.hcl", Content: observeHCL})
+	require.False(t, diags.HasErrors(), diags.Error())
+}
@@ -0,0 +1,255 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// policy severities and targets.
+const (
+	policySeverityError   = "error"
+	policySeverityWarning = "warning"
+
+	policyTargetResources = "resources"
+	policyTargetComposite = "composite"
+
+	// policySubjectComposite is the value of self.name when checking the composite.
+	policySubjectComposite = "composite"
+)
+
+// policy is an assertion that is checked against desired resources or the desired composite after
+// all resources have been processed.
+type policy struct {
+	name       string
+	target     string           // one of resources or composite
+	severity   string           // one of error or warning
+	apiVersion string           // empty matches any API version
+	kind       string           // empty matches any kind
+	content    *hcl.BodyContent // content of the block that has the assertion, message and locals
+}
+
+// policyViolation is a failed policy assertion.
+type policyViolation struct {
+	policy   string
+	subject  string
+	severity string
+	message  string
+}
+
+func (v policyViolation) String() string {
+	return fmt.Sprintf("policy %s failed for %s: %s", v.policy, v.subject, v.message)
+}
+
+// checkPolicyBlock checks the structure of a policy block and returns the policy it represents.
+func (e *Evaluator) checkPolicyBlock(block *hcl.Block) (*policy, hcl.Diagnostics) {
+	content, diags := block.Body.Content(policySchema())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	ret := &policy{name: block.Labels[0], content: content}
+	oneOf := func(name, def string, allowed ...string) string {
+		v, ds := constantString(content, name, blockPolicy)
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			return ""
+		}
+		if v == "" {
+			return def
+		}
+		for _, a := range allowed {
+			if v == a {
+				return v
+			}
+		}
+		diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("invalid %s in %s block, must be one of %q", name, blockPolicy, allowed), v,
+			content.Attributes[name].Expr.Range()))
+		return ""
+	}
+	ret.target = oneOf(attrTarget, policyTargetResources, policyTargetResources, policyTargetComposite)
+	ret.severity = oneOf(attrSeverity, policySeverityError, policySeverityError, policySeverityWarning)
+	var ds hcl.Diagnostics
+	ret.apiVersion, ds = constantString(content, attrAPIVersion, blockPolicy)
+	diags = diags.Extend(ds)
+	ret.kind, ds = constantString(content, attrKind, blockPolicy)
+	diags = diags.Extend(ds)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if ret.target == policyTargetComposite && (ret.apiVersion != "" || ret.kind != "") {
+		r := content.Attributes[attrTarget].Expr.Range()
+		return nil, hclutils.ToErrorDiag(fmt.Sprintf("%s and %s cannot be specified for composite policies", attrAPIVersion, attrKind), ret.name, r)
+	}
+	return ret, diags
+}
+
+// policySubject is a named body that a policy is checked against.
+type policySubject struct {
+	name string
+	body cty.Value
+}
+
+// policySubjects returns the subjects that the supplied policy applies to.
+func (e *Evaluator) policySubjects(p *policy) ([]policySubject, error) {
+	if p.target == policyTargetComposite {
+		composite := Object{}
+		if len(e.compositeStatuses) > 0 {
+			st, err := unify(e.compositeStatuses...)
+			if err != nil {
+				return nil, errors.Wrap(err, "unify composite status")
+			}
+			composite["status"] = st
+		}
+		body, err := objectToValue(composite)
+		if err != nil {
+			return nil, err
+		}
+		return []policySubject{{name: policySubjectComposite, body: body}}, nil
+	}
+	var names []string
+	for name := range e.desiredResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ret []policySubject
+	for _, name := range names {
+		body, err := objectToValue(e.desiredResources[name].AsMap())
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert resource %s", name)
+		}
+		apiVersion, kind := typeOf(body)
+		if (p.apiVersion != "" && p.apiVersion != apiVersion) || (p.kind != "" && p.kind != kind) {
+			continue
+		}
+		ret = append(ret, policySubject{name: name, body: body})
+	}
+	return ret, nil
+}
+
+// checkPolicy checks the supplied policy against a single subject, returning a violation if the assertion fails.
+func (e *Evaluator) checkPolicy(ctx *hcl.EvalContext, p *policy, subject policySubject) (*policyViolation, hcl.Diagnostics) {
+	ctx = createSelfChildContext(ctx, DynamicObject{
+		selfName: cty.StringVal(subject.name),
+		selfBody: subject.body,
+	})
+	ctx, diags := e.processLocals(ctx, p.content)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	attr := p.content.Attributes[attrAssert]
+	val, ds := attr.Expr.Value(ctx)
+	if ds.HasErrors() || !val.IsWhollyKnown() {
+		e.discard(DiscardItem{
+			Type:        discardTypePolicy,
+			Reason:      discardReasonIncomplete,
+			Name:        fmt.Sprintf("%s for %s", p.name, subject.name),
+			SourceRange: attr.Expr.Range().String(),
+			Context:     e.messagesFromDiags(ds),
+		})
+		return nil, diags.Extend(hclutils.DowngradeDiags(ds))
+	}
+	diags = diags.Extend(ds)
+	if val.IsNull() || val.Type() != cty.Bool {
+		return nil, diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("attribute %q not a boolean in policy %s", attrAssert, p.name),
+			Subject:  ptr(attr.Expr.Range()),
+		})
+	}
+	if val.True() {
+		return nil, diags
+	}
+	message := "assertion failed"
+	if msgAttr, ok := p.content.Attributes[attrMessage]; ok {
+		m, ds := msgAttr.Expr.Value(ctx)
+		if !ds.HasErrors() && m.IsWhollyKnown() && !m.IsNull() && m.Type() == cty.String {
+			message = m.AsString()
+		}
+	}
+	return &policyViolation{policy: p.name, subject: subject.name, severity: p.severity, message: message}, diags
+}
+
+// processPolicies checks all policies in the supplied blocks against the desired state. Violations of policies with
+// error severity fail the evaluation, other violations are reported as warnings.
+func (e *Evaluator) processPolicies(ctx *hcl.EvalContext, blocks []*hcl.Block) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, block := range blocks {
+		p, ds := e.checkPolicyBlock(block)
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			continue
+		}
+		e.policyCount++
+		subjects, err := e.policySubjects(p)
+		if err != nil {
+			diags = diags.Append(hclutils.Err2Diag(err))
+			continue
+		}
+		for _, subject := range subjects {
+			v, ds := e.checkPolicy(ctx, p, subject)
+			diags = diags.Extend(ds)
+			if v == nil {
+				continue
+			}
+			if v.severity == policySeverityError {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("policy %s failed for %s", v.policy, v.subject),
+					Detail:   v.message,
+					Subject:  ptr(p.content.Attributes[attrAssert].Expr.Range()),
+				})
+				continue
+			}
+			e.policyViolations = append(e.policyViolations, *v)
+		}
+	}
+	return diags
+}
+
+// addPolicyInfo adds warnings for policy violations and a condition that summarizes policy compliance to the response.
+// Nothing is added if there are no policies.
+func (e *Evaluator) addPolicyInfo(ret *fnv1.RunFunctionResponse) {
+	if e.policyCount == 0 {
+		return
+	}
+	tg := fnv1.Target_TARGET_COMPOSITE
+	var failed []string
+	for _, v := range e.policyViolations {
+		reason := "policy-violation"
+		ret.Results = append(ret.Results, &fnv1.Result{
+			Severity: fnv1.Severity_SEVERITY_WARNING,
+			Message:  v.String(),
+			Target:   &tg,
+			Reason:   &reason,
+		})
+		if len(failed) < e.maxDiscardsToDisplay {
+			failed = append(failed, fmt.Sprintf("%s for %s", v.policy, v.subject))
+		}
+	}
+	cond := &fnv1.Condition{
+		Type:    "PolicyCompliant",
+		Target:  &tg,
+		Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+		Reason:  "AllPoliciesPassed",
+		Message: ptr(fmt.Sprintf("%d policies passed", e.policyCount)),
+	}
+	if len(e.policyViolations) > 0 {
+		msg := fmt.Sprintf("%d policies failed", len(e.policyViolations))
+		if !e.discardCountsOnly {
+			msg = "failed policies: " + strings.Join(failed, ", ")
+			if len(e.policyViolations) > e.maxDiscardsToDisplay {
+				msg += fmt.Sprintf(" and %d more", len(e.policyViolations)-e.maxDiscardsToDisplay)
+			}
+		}
+		cond.Status = fnv1.Status_STATUS_CONDITION_FALSE
+		cond.Reason = "PolicyViolations"
+		cond.Message = &msg
+	}
+	ret.Conditions = append(ret.Conditions, cond)
+}
@@ -0,0 +1,216 @@
+package evaluator
+
+import (
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policyResourcesHCL = `
+resource small {
+  body = {
+    apiVersion = "example.com/v1"
+    kind       = "NodePool"
+    spec = {
+      nodes = 2
+    }
+  }
+}
+
+resource large {
+  body = {
+    apiVersion = "example.com/v1"
+    kind       = "NodePool"
+    spec = {
+      nodes = 20
+    }
+  }
+}
+
+resource config {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+  composite status {
+    body = {
+      ready = true
+    }
+  }
+}
+`
+
+func findCondition(res *fnv1.RunFunctionResponse, conditionType string) *fnv1.Condition {
+	for _, c := range res.Conditions {
+		if c.Type == conditionType {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestEvaluator_PolicyWarnings(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, policyResourcesHCL+`
+locals {
+  max_nodes = 10
+}
+
+policy max-nodes {
+  kind     = "NodePool"
+  severity = "warning"
+  assert   = self.body.spec.nodes <= max_nodes
+  message  = "${self.name} has ${self.body.spec.nodes} nodes, max is ${max_nodes}"
+}
+
+policy status {
+  target = "composite"
+  assert = self.body.status.ready
+}
+`, "test.hcl")
+
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, e.policyViolations, 1)
+	assert.Equal(t, "policy max-nodes failed for large: large has 20 nodes, max is 10", e.policyViolations[0].String())
+
+	res, err := e.toResponse(diags)
+	require.NoError(t, err)
+	cond := findCondition(res, "PolicyCompliant")
+	require.NotNil(t, cond)
+	assert.Equal(t, fnv1.Status_STATUS_CONDITION_FALSE, cond.Status)
+	assert.Equal(t, "failed policies: max-nodes for large", cond.GetMessage())
+	assert.Len(t, res.Desired.Resources, 3)
+
+	var found bool
+	for _, r := range res.Results {
+		if r.GetReason() == "policy-violation" {
+			found = true
+			assert.Equal(t, fnv1.Severity_SEVERITY_WARNING, r.Severity)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestEvaluator_PolicyPass(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, policyResourcesHCL+`
+policy has-kind {
+  assert = self.body.kind != ""
+}
+`, "test.hcl")
+
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	res, err := e.toResponse(diags)
+	require.NoError(t, err)
+	cond := findCondition(res, "PolicyCompliant")
+	require.NotNil(t, cond)
+	assert.Equal(t, fnv1.Status_STATUS_CONDITION_TRUE, cond.Status)
+	assert.Equal(t, "1 policies passed", cond.GetMessage())
+}
+
+func TestEvaluator_PolicyError(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, policyResourcesHCL+`
+policy max-nodes {
+  apiVersion = "example.com/v1"
+  kind       = "NodePool"
+  assert     = self.body.spec.nodes <= 10
+  message    = "too many nodes"
+}
+`, "test.hcl")
+
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "policy max-nodes failed for large; too many nodes")
+}
+
+func TestEvaluator_PolicyIncomplete(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, policyResourcesHCL+`
+policy observed {
+  kind   = "ConfigMap"
+  assert = req.resource.config.status.ok
+}
+`, "test.hcl")
+
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, e.discards, 1)
+	assert.Equal(t, discardTypePolicy, e.discards[0].Type)
+	assert.Equal(t, "observed for config", e.discards[0].Name)
+}
+
+func TestAnalyzePoliciesNegative(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name: "duplicate",
+			hcl: `
+policy foo {
+  assert = true
+}
+policy foo {
+  assert = true
+}
+`,
+			errMsg: "test.hcl:5,8-11: policy defined more than once; foo",
+		},
+		{
+			name: "bad severity",
+			hcl: `
+policy foo {
+  severity = "info"
+  assert   = true
+}
+`,
+			errMsg: `test.hcl:3,14-20: invalid severity in policy block, must be one of ["error" "warning"]; info`,
+		},
+		{
+			name: "kind for composite",
+			hcl: `
+policy foo {
+  target = "composite"
+  kind   = "Foo"
+  assert = true
+}
+`,
+			errMsg: "test.hcl:3,12-23: apiVersion and kind cannot be specified for composite policies; foo",
+		},
+		{
+			name: "bad self reference",
+			hcl: `
+policy foo {
+  assert = self.resource.status.ok
+}
+`,
+			errMsg: `test.hcl:3,12-35: no such attribute "resource"; self.resource.status.ok`,
+		},
+		{
+			name: "in group",
+			hcl: `
+group {
+  policy foo {
+    assert = true
+  }
+}
+`,
+			errMsg: `Blocks of type "policy" are not expected here`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Program is a set of HCL files that have been parsed and whose user functions have been processed, such that
+// the same composition can be evaluated against many requests without repeating this work. A program may be
+// evaluated any number of times in sequence, but evaluations must not run concurrently.
+type Program struct {
+	opts    Options                  // options used to create evaluators
+	files   map[string]*hcl.File     // parsed files keyed by name
+	content *hcl.BodyContent         // merged top-level content
+	funcs   *functions.Processor     // processor of the user functions
+	funcCtx *hcl.EvalContext         // root context with built-in and user functions
+	aliases map[string]hcl.Traversal // aliases declared in the files
+	values  DynamicObject            // values from values files, available under arg
+	diags   hcl.Diagnostics          // warnings produced while compiling
+}
+
+// Compile parses the supplied files using the supplied options and returns a program for them.
+func Compile(opts Options, files ...File) (*Program, error) {
+	e, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	p, diags := e.compile(files)
+	if diags.HasErrors() {
+		return nil, sortDiagsBySeverity(diags)
+	}
+	p.opts = opts
+	p.opts.Logger = e.log // avoid creating a logger for every evaluation
+	return p, nil
+}
+
+// NewEvaluator returns an evaluator for a single evaluation of the program. The evaluator must be called
+// without any files.
+func (p *Program) NewEvaluator() (*Evaluator, error) {
+	e, err := New(p.opts)
+	if err != nil {
+		return nil, err
+	}
+	e.program = p
+	e.files = p.files
+	return e, nil
+}
+
+// NewEvaluatorWithLogger returns an evaluator like NewEvaluator that uses the supplied logger and debug setting
+// instead of the ones the program was compiled with, such that a program can be shared by requests that are
+// logged and debugged differently.
+func (p *Program) NewEvaluatorWithLogger(logger logging.Logger, debug bool) (*Evaluator, error) {
+	opts := p.opts
+	opts.Logger, opts.Debug = logger, debug
+	e, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	e.program = p
+	e.files = p.files
+	return e, nil
+}
@@ -0,0 +1,62 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProgram(t *testing.T) {
+	hcl := `
+function bucketName {
+  arg prefix {}
+  arg region {}
+  body = "${prefix}-${region}"
+}
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    metadata = {
+      name = invoke("bucketName", {
+        prefix = req.composite.metadata.name
+        region = req.composite.spec.parameters.region
+      })
+    }
+  }
+}
+`
+	p, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+
+	withRegion := func(region string) func(*fnv1.RunFunctionRequest) {
+		return func(req *fnv1.RunFunctionRequest) {
+			params := req.Observed.Composite.Resource.Fields["spec"].GetStructValue().Fields["parameters"].GetStructValue()
+			params.Fields["region"] = structpb.NewStringValue(region)
+		}
+	}
+	for _, region := range []string{"us-east-1", "eu-west-1"} {
+		e, err := p.NewEvaluator()
+		require.NoError(t, err)
+		res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withRegion(region)))
+		require.NoError(t, err)
+		bucket := res.Desired.Resources["bucket"].Resource.AsMap()
+		assert.Equal(t, "comp-a7df3-"+region, bucket["metadata"].(map[string]any)["name"])
+	}
+
+	e, err := p.NewEvaluator()
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "other.hcl"})
+	require.Error(t, err)
+}
+
+func TestProgramCompileError(t *testing.T) {
+	_, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: "main.hcl", Content: "resource foo {"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unclosed configuration block")
+}
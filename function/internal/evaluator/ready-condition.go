@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// defaults for the attributes of a ready_from_condition block.
+const (
+	defaultReadyConditionType   = "Ready"
+	defaultReadyConditionStatus = "True"
+	conditionStatusUnknown      = "Unknown"
+)
+
+// readyFromCondition returns the readiness of an observed resource from the condition of the supplied type in its
+// status. The resource is ready when the condition has the expected status, and not ready when it has any other
+// status. The readiness is unspecified when the resource has not been observed, has no such condition, or the
+// status of the condition is unknown.
+func readyFromCondition(observed cty.Value, conditionType, status string) fnv1.Ready {
+	conditions := lookupField(observed, []string{"status", "conditions"})
+	if conditions.IsNull() || !conditions.IsKnown() || !conditions.CanIterateElements() {
+		return fnv1.Ready_READY_UNSPECIFIED
+	}
+	for it := conditions.ElementIterator(); it.Next(); {
+		_, cond := it.Element()
+		if stringField(cond, "type") != conditionType {
+			continue
+		}
+		switch actual := stringField(cond, "status"); {
+		case actual == status:
+			return fnv1.Ready_READY_TRUE
+		case actual == "" || actual == conditionStatusUnknown:
+			return fnv1.Ready_READY_UNSPECIFIED
+		default:
+			return fnv1.Ready_READY_FALSE
+		}
+	}
+	return fnv1.Ready_READY_UNSPECIFIED
+}
+
+// stringField returns the named string attribute of the supplied object, or an empty string if there is none.
+func stringField(v cty.Value, name string) string {
+	f := lookupField(v, []string{name})
+	if f.IsNull() || !f.IsKnown() || f.Type() != cty.String {
+		return ""
+	}
+	return f.AsString()
+}
+
+// processReadyFromCondition sets the readiness of a resource from a condition of its observed status.
+func (e *Evaluator) processReadyFromCondition(ctx *hcl.EvalContext, resourceName string, block *hcl.Block) hcl.Diagnostics {
+	content, diags := block.Body.Content(readyFromConditionSchema())
+	if diags.HasErrors() {
+		return diags
+	}
+	values := map[string]string{
+		attrType:   defaultReadyConditionType,
+		attrStatus: defaultReadyConditionStatus,
+	}
+	for _, name := range []string{attrType, attrStatus} {
+		attr, ok := content.Attributes[name]
+		if !ok {
+			continue
+		}
+		value, ds := attr.Expr.Value(ctx)
+		if ds.HasErrors() || !value.IsWhollyKnown() {
+			e.discard(DiscardItem{
+				Type:        discardTypeReady,
+				Reason:      discardReasonIncomplete,
+				Name:        resourceName,
+				SourceRange: attr.Expr.Range().String(),
+				Context:     e.messagesFromDiags(ds),
+			})
+			return diags.Extend(hclutils.DowngradeDiags(ds))
+		}
+		diags = diags.Extend(ds)
+		if value.IsNull() || value.Type() != cty.String {
+			return diags.Extend(hclutils.ToErrorDiag(
+				fmt.Sprintf("attribute %q not a string in %s block for %s", name, blockReadyFromCondition, resourceName),
+				"", attr.Expr.Range()))
+		}
+		values[name] = value.AsString()
+	}
+	e.ready[resourceName] = int32(readyFromCondition(e.getObservedResource(resourceName), values[attrType], values[attrStatus]))
+	return diags
+}
@@ -0,0 +1,180 @@
+package evaluator
+
+import (
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func conditionsResource(conditions ...cty.Value) cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"status": cty.ObjectVal(map[string]cty.Value{
+			"conditions": cty.TupleVal(conditions),
+		}),
+	})
+}
+
+func condition(conditionType, status string) cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"type":   cty.StringVal(conditionType),
+		"status": cty.StringVal(status),
+	})
+}
+
+func TestReadyFromCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		observed cty.Value
+		status   string
+		want     fnv1.Ready
+	}{
+		{name: "not observed", observed: cty.NilVal, status: "True", want: fnv1.Ready_READY_UNSPECIFIED},
+		{name: "no status", observed: cty.EmptyObjectVal, status: "True", want: fnv1.Ready_READY_UNSPECIFIED},
+		{name: "no conditions", observed: conditionsResource(), status: "True", want: fnv1.Ready_READY_UNSPECIFIED},
+		{name: "other condition", observed: conditionsResource(condition("Synced", "True")), status: "True", want: fnv1.Ready_READY_UNSPECIFIED},
+		{name: "match", observed: conditionsResource(condition("Synced", "False"), condition("Ready", "True")), status: "True", want: fnv1.Ready_READY_TRUE},
+		{name: "mismatch", observed: conditionsResource(condition("Ready", "False")), status: "True", want: fnv1.Ready_READY_FALSE},
+		{name: "unknown", observed: conditionsResource(condition("Ready", "Unknown")), status: "True", want: fnv1.Ready_READY_UNSPECIFIED},
+		{name: "expected false", observed: conditionsResource(condition("Ready", "False")), status: "False", want: fnv1.Ready_READY_TRUE},
+		{
+			name: "no condition status",
+			observed: conditionsResource(cty.ObjectVal(map[string]cty.Value{
+				"type": cty.StringVal("Ready"),
+			})),
+			status: "True",
+			want:   fnv1.Ready_READY_UNSPECIFIED,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, readyFromCondition(test.observed, "Ready", test.status))
+		})
+	}
+}
+
+func TestEvaluator_ReadyFromCondition(t *testing.T) {
+	hclContent := `
+resource "bucket" {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+  ready_from_condition {}
+}
+
+resource "database" {
+  body = {
+    apiVersion = "rds.aws.upbound.io/v1beta1"
+    kind       = "Instance"
+  }
+  ready {
+    condition = self.name == "database"
+    value     = "READY_FALSE"
+  }
+  ready_from_condition {}
+}
+
+resource "cluster" {
+  body = {
+    apiVersion = "eks.aws.upbound.io/v1beta1"
+    kind       = "Cluster"
+  }
+  ready_from_condition {
+    type   = "Available"
+    status = req.composite.spec.enabled ? "True" : "False"
+  }
+}
+
+resource "new" {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+  ready_from_condition {}
+}
+`
+	e := createTestEvaluator(t)
+	e.existingResourceMap = DynamicObject{
+		"bucket":   conditionsResource(condition("Ready", "True")),
+		"database": conditionsResource(condition("Ready", "True")),
+		"cluster":  conditionsResource(condition("Ready", "True"), condition("Available", "False")),
+	}
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, hclContent, "test.hcl")
+
+	diags := e.processGroup(ctx, content)
+	require.Empty(t, diags)
+
+	assert.Equal(t, fnv1.Ready_READY_TRUE, fnv1.Ready(e.ready["bucket"]))
+	assert.Equal(t, fnv1.Ready_READY_FALSE, fnv1.Ready(e.ready["database"]))
+	assert.Equal(t, fnv1.Ready_READY_FALSE, fnv1.Ready(e.ready["cluster"]))
+	assert.Equal(t, fnv1.Ready_READY_UNSPECIFIED, fnv1.Ready(e.ready["new"]))
+}
+
+func TestEvaluator_ReadyFromConditionNegative(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name: "after unconditional ready",
+			hcl: `
+resource "bucket" {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+  ready {
+    value = "READY_TRUE"
+  }
+  ready_from_condition {}
+}
+`,
+			errMsg: "test.hcl:10,3-23: unreachable ready block; the ready block at test.hcl:7,3-8 has no condition",
+		},
+		{
+			name: "followed by ready",
+			hcl: `
+resource "bucket" {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+  ready_from_condition {}
+  ready {
+    value = "READY_TRUE"
+  }
+}
+`,
+			errMsg: "test.hcl:8,3-8: unreachable ready block; the ready_from_condition block at test.hcl:7,3-23 has no condition",
+		},
+		{
+			name: "unsupported attribute",
+			hcl: `
+resource "bucket" {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+  ready_from_condition {
+    value = "READY_TRUE"
+  }
+}
+`,
+			errMsg: `Unsupported argument; An argument named "value" is not expected here`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
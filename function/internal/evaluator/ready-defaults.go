@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// readyDefault is a readiness expression that applies to resources of a specific API version and kind
+// when the resource does not have an explicit ready block.
+type readyDefault struct {
+	apiVersion string           // empty matches any API version
+	kind       string           // empty matches any kind
+	defRange   hcl.Range        // definition range of the block
+	content    *hcl.BodyContent // content of the block that has the value and locals
+}
+
+// specificity returns a rank for the default such that more specific defaults win over less specific ones.
+// A default that specifies both API version and kind is the most specific, followed by one that only specifies
+// the kind, followed by one that only specifies the API version, followed by a catch-all.
+func (r *readyDefault) specificity() int {
+	ret := 0
+	if r.kind != "" {
+		ret += 2
+	}
+	if r.apiVersion != "" {
+		ret++
+	}
+	return ret
+}
+
+// key returns a string that uniquely identifies the selection criteria of the default.
+func (r *readyDefault) key() string {
+	return fmt.Sprintf("%s/%s", r.apiVersion, r.kind)
+}
+
+// matches returns true if the default applies to the supplied API version and kind.
+func (r *readyDefault) matches(apiVersion, kind string) bool {
+	if r.apiVersion != "" && r.apiVersion != apiVersion {
+		return false
+	}
+	if r.kind != "" && r.kind != kind {
+		return false
+	}
+	return true
+}
+
+// checkReadyDefaultBlock checks the structure of a default ready block and returns the default it represents.
+// The API version and kind attributes, when present, must be constant strings.
+func (e *Evaluator) checkReadyDefaultBlock(block *hcl.Block) (*readyDefault, hcl.Diagnostics) {
+	content, diags := block.Body.Content(readyDefaultSchema())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	ret := &readyDefault{defRange: block.DefRange, content: content}
+	var ds hcl.Diagnostics
+	ret.apiVersion, ds = constantString(content, attrAPIVersion, blockReadyDefault)
+	diags = diags.Extend(ds)
+	ret.kind, ds = constantString(content, attrKind, blockReadyDefault)
+	diags = diags.Extend(ds)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return ret, diags
+}
+
+// addReadyDefault records the default ready block for use by resources without explicit ready blocks.
+func (e *Evaluator) addReadyDefault(block *hcl.Block) hcl.Diagnostics {
+	rd, diags := e.checkReadyDefaultBlock(block)
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, existing := range e.readyDefaults {
+		if existing.key() == rd.key() {
+			return diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("duplicate %s block", blockReadyDefault), rd.key(), block.DefRange))
+		}
+	}
+	e.readyDefaults = append(e.readyDefaults, rd)
+	return diags
+}
+
+// constantString returns the value of the named attribute in the supplied content, which must be a constant
+// string when present. It returns an empty string if the attribute is not present.
+func constantString(content *hcl.BodyContent, name, blockType string) (string, hcl.Diagnostics) {
+	attr, ok := content.Attributes[name]
+	if !ok {
+		return "", nil
+	}
+	v, _ := attr.Expr.Value(nil)
+	//nolint:staticcheck // using De Morgan's law makes code unreadable
+	if !(v.IsWhollyKnown() && !v.IsNull() && v.Type() == cty.String) {
+		return "", hclutils.ToErrorDiag(fmt.Sprintf("%s in %s block is not a constant string", name, blockType), "", attr.Expr.Range())
+	}
+	return v.AsString(), nil
+}
+
+// constantBoolAttr returns the value of the named attribute, which must be a constant boolean, or the supplied
+// default if the attribute is not set.
+func constantBoolAttr(content *hcl.BodyContent, name, blockType string, def bool) (bool, hcl.Diagnostics) {
+	attr, ok := content.Attributes[name]
+	if !ok {
+		return def, nil
+	}
+	v, _ := attr.Expr.Value(nil)
+	//nolint:staticcheck // using De Morgan's law makes code unreadable
+	if !(v.IsWhollyKnown() && !v.IsNull() && v.Type() == cty.Bool) {
+		return def, hclutils.ToErrorDiag(fmt.Sprintf("%s in %s block is not a constant bool", name, blockType), "", attr.Expr.Range())
+	}
+	return v.True(), nil
+}
+
+// typeOf returns the API version and kind of the supplied resource body, using empty strings for values that
+// are not known.
+func typeOf(body cty.Value) (apiVersion, kind string) {
+	getString := func(name string) string {
+		if body.IsNull() || !body.IsKnown() || !body.Type().IsObjectType() || !body.Type().HasAttribute(name) {
+			return ""
+		}
+		v := body.GetAttr(name)
+		if v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+			return ""
+		}
+		return v.AsString()
+	}
+	return getString(attrAPIVersion), getString(attrKind)
+}
+
+// findReadyDefault returns the most specific default that applies to the supplied resource body, or nil if
+// none apply.
+func (e *Evaluator) findReadyDefault(body cty.Value) *readyDefault {
+	if len(e.readyDefaults) == 0 {
+		return nil
+	}
+	apiVersion, kind := typeOf(body)
+	var ret *readyDefault
+	for _, rd := range e.readyDefaults {
+		if !rd.matches(apiVersion, kind) {
+			continue
+		}
+		if ret == nil || rd.specificity() > ret.specificity() {
+			ret = rd
+		}
+	}
+	return ret
+}
@@ -0,0 +1,155 @@
+package evaluator
+
+import (
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_ReadyDefaults(t *testing.T) {
+	hclContent := `
+resource "pod" {
+  body = {
+    apiVersion = "v1"
+    kind       = "Pod"
+  }
+}
+
+resource "config" {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+
+resource "explicit" {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+  ready {
+    value = "READY_FALSE"
+  }
+}
+
+resource "bucket" {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+}
+
+default_ready {
+  value = "READY_UNSPECIFIED"
+}
+
+default_ready {
+  apiVersion = "v1"
+  value      = "READY_FALSE"
+}
+
+default_ready {
+  apiVersion = "v1"
+  kind       = "ConfigMap"
+  locals {
+    v = self.name == "config" ? "READY_TRUE" : "READY_FALSE"
+  }
+  value = v
+}
+`
+	e := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, hclContent, "test.hcl")
+
+	diags := e.processGroup(ctx, content)
+	require.Empty(t, diags)
+
+	assert.Equal(t, fnv1.Ready_READY_FALSE, fnv1.Ready(e.ready["pod"]))
+	assert.Equal(t, fnv1.Ready_READY_TRUE, fnv1.Ready(e.ready["config"]))
+	assert.Equal(t, fnv1.Ready_READY_FALSE, fnv1.Ready(e.ready["explicit"]))
+	assert.Equal(t, fnv1.Ready_READY_UNSPECIFIED, fnv1.Ready(e.ready["bucket"]))
+}
+
+func TestEvaluator_ReadyDefaultsIncomplete(t *testing.T) {
+	hclContent := `
+resource "pod" {
+  body = {
+    apiVersion = "v1"
+    kind       = "Pod"
+  }
+}
+
+default_ready {
+  kind  = "Pod"
+  value = self.resource.status.ready ? "READY_TRUE" : "READY_FALSE"
+}
+`
+	e := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, hclContent, "test.hcl")
+
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors())
+	assert.Contains(t, e.desiredResources, "pod")
+	assert.NotContains(t, e.ready, "pod")
+	require.Len(t, e.discards, 1)
+	assert.Equal(t, discardTypeReady, e.discards[0].Type)
+}
+
+func TestEvaluator_ReadyDefaultsNegative(t *testing.T) {
+	tests := []struct {
+		name   string
+		hcl    string
+		errMsg string
+	}{
+		{
+			name: "duplicate",
+			hcl: `
+default_ready {
+  kind  = "Pod"
+  value = "READY_TRUE"
+}
+default_ready {
+  kind  = "Pod"
+  value = "READY_FALSE"
+}
+`,
+			errMsg: "test.hcl:6,1-14: duplicate default_ready block; /Pod",
+		},
+		{
+			name: "non-constant kind",
+			hcl: `
+locals {
+  k = "Pod"
+}
+default_ready {
+  kind  = k
+  value = "READY_TRUE"
+}
+`,
+			errMsg: "test.hcl:6,11-12: kind in default_ready block is not a constant string",
+		},
+		{
+			name: "in group",
+			hcl: `
+group {
+  default_ready {
+    value = "READY_TRUE"
+  }
+}
+`,
+			errMsg: `Blocks of type "default_ready" are not expected here`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(Options{})
+			require.NoError(t, err)
+			diags := e.Analyze(File{Name: "test.hcl", Content: test.hcl})
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
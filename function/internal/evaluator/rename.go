@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// renameFrom evaluates the rename_from attribute of a resource collection for the resource with the supplied name.
+// When the attribute names an observed resource and no resource has been observed under the new name yet, the
+// observed resource is treated as the observed state of the renamed resource.
+func (e *Evaluator) renameFrom(ctx *hcl.EvalContext, baseName, name string, attr *hcl.Attribute) hcl.Diagnostics {
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return diags
+	}
+	if !val.IsWhollyKnown() || (!val.IsNull() && val.Type() != cty.String) {
+		return diags.Extend(hclutils.ToErrorDiag(
+			fmt.Sprintf("%s of resource collection %s must be a known string or null", attrRenameFrom, baseName),
+			e.sourceCode(attr.Expr.Range()), attr.Expr.Range()))
+	}
+	if val.IsNull() {
+		return diags
+	}
+	oldName := val.AsString()
+	if oldName == name {
+		return diags
+	}
+	if _, ok := e.observedResources[name]; ok {
+		return diags
+	}
+	if _, ok := e.observedResources[oldName]; !ok {
+		return diags
+	}
+	for newName, from := range e.renames {
+		if from == oldName {
+			return diags.Extend(hclutils.ToErrorDiag(
+				fmt.Sprintf("observed resource %s cannot be renamed to both %s and %s", oldName, newName, name),
+				e.sourceCode(attr.Expr.Range()), attr.Expr.Range()))
+		}
+	}
+	e.renames[name] = oldName
+	return diags
+}
+
+// adoptRenamed sets the name of the object of a renamed resource to the name of its observed object, unless its
+// body sets a name, such that the desired resource refers to the existing object instead of a new one.
+func (e *Evaluator) adoptRenamed(resourceName string, body *structpb.Struct) {
+	oldName, ok := e.renames[resourceName]
+	if !ok {
+		return
+	}
+	observed := e.observedResources[oldName]
+	if !observed.Type().IsObjectType() || !observed.Type().HasAttribute("metadata") {
+		return
+	}
+	metadata := observed.GetAttr("metadata")
+	if !metadata.Type().IsObjectType() || !metadata.Type().HasAttribute("name") {
+		return
+	}
+	objectName := metadata.GetAttr("name")
+	if objectName.IsNull() || objectName.Type() != cty.String {
+		return
+	}
+	meta := body.GetFields()["metadata"].GetStructValue()
+	if meta == nil {
+		meta = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		body.Fields["metadata"] = structpb.NewStructValue(meta)
+	}
+	if _, ok := meta.GetFields()["name"]; ok {
+		return
+	}
+	meta.Fields["name"] = structpb.NewStringValue(objectName.AsString())
+}
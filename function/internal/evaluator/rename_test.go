@@ -0,0 +1,102 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renameObserved() map[string]map[string]any {
+	ret := map[string]map[string]any{}
+	for i, name := range []string{"workers-0", "workers-1"} {
+		ret[name] = collectionMember(map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "xr-" + name},
+			"status":     map[string]any{"id": "id-" + name},
+		}, "workers", []string{"0", "1"}[i])
+	}
+	return ret
+}
+
+func TestRenameFrom(t *testing.T) {
+	hcl := `
+resources workers {
+  for_each    = { east = "workers-0", west = "workers-1", north = null }
+  rename_from = each.value
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { id = try(self.resource.status.id, "new") }
+    }
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, renameObserved())), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+
+	resources := res.GetDesired().GetResources()
+	require.Len(t, resources, 3)
+	for name, want := range map[string]struct{ objectName, id string }{
+		"workers-east":  {"xr-workers-0", "id-workers-0"},
+		"workers-west":  {"xr-workers-1", "id-workers-1"},
+		"workers-north": {"", "new"},
+	} {
+		body := resources[name].GetResource().AsMap()
+		metadata := body["metadata"].(map[string]any)
+		if want.objectName == "" {
+			assert.NotContains(t, metadata, "name", name)
+		} else {
+			assert.Equal(t, want.objectName, metadata["name"], name)
+		}
+		assert.Equal(t, map[string]any{"id": want.id}, body["data"], name)
+	}
+}
+
+func TestRenameFromErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		contains string
+	}{
+		{
+			name: "renamed twice",
+			hcl: `
+resources workers {
+  for_each    = { east = 1, west = 2 }
+  rename_from = "workers-0"
+  template {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+}`,
+			contains: "observed resource workers-0 cannot be renamed to both workers-east and workers-west",
+		},
+		{
+			name: "not a string",
+			hcl: `
+resources workers {
+  for_each    = ["east"]
+  rename_from = 0
+  template {
+    body = { apiVersion = "v1", kind = "ConfigMap" }
+  }
+}`,
+			contains: "rename_from of resource collection workers must be a known string or null",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, renameObserved())), evaluator.File{Name: "main.hcl", Content: test.hcl})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.contains)
+		})
+	}
+}
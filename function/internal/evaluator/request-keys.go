@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+)
+
+// RequestKeys returns the sorted names of the attributes of the req variable.
+func RequestKeys() []string {
+	ret := []string{
+		reqContext,
+		reqComposite,
+		reqCompositeConnection,
+		reqObservedResource,
+		reqObservedConnection,
+		reqObservedResources,
+		reqObservedConnections,
+		reqExtraResources,
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// reqAliases map names that authors commonly use for attributes of the req variable, from other tools or from the
+// fields of the function request, to the attribute they mean.
+var reqAliases = map[string]string{
+	"xr":                           reqComposite,
+	"oxr":                          reqComposite,
+	"observed_composite":           reqComposite,
+	"xr_connection":                reqCompositeConnection,
+	"connection_details":           reqCompositeConnection,
+	"composite_connection_details": reqCompositeConnection,
+	"composite_connections":        reqCompositeConnection,
+	"extra":                        reqExtraResources,
+	"required_resources":           reqExtraResources,
+}
+
+// suggestRequestKey appends a suggestion for an unknown attribute of the req variable to the supplied message. Known
+// aliases are suggested before names that are merely close to the unknown one.
+func suggestRequestKey(message, name string, names []string) string {
+	if alias, ok := reqAliases[name]; ok {
+		return fmt.Sprintf("%s, did you mean %q?", message, alias)
+	}
+	return hclutils.DidYouMean(message, name, names)
+}
+
+// checkCompositeField returns an error message for a reference to a top-level field of the composite that cannot
+// exist because it names its connection details, which are only available as req.composite_connection.
+func checkCompositeField(name string) string {
+	if strings.Contains(strings.ToLower(name), "connection") {
+		return fmt.Sprintf("the composite has no field %q, its connection details are available as req.%s", name, reqCompositeConnection)
+	}
+	return ""
+}
@@ -99,6 +99,31 @@ func (e *Evaluator) processRequirement(ctx *hcl.EvalContext, block *hcl.Block) h
 	return curDiags
 }
 
+// scopedExtraResources returns the value of self.extra for the scope with the supplied content. It contains the
+// extra resources of the requirement and observe blocks declared directly in the scope, in addition to the ones
+// visible in the enclosing scope. Requirements for which no resources have been returned yet are unknown.
+func scopedExtraResources(ctx *hcl.EvalContext, content *hcl.BodyContent) cty.Value {
+	extra := DynamicObject{}
+	if v, ok := extractSymbolTable(ctx, reservedSelf)[selfExtra]; ok && v.IsKnown() && !v.IsNull() && v.Type().IsObjectType() {
+		for name, value := range v.AsValueMap() {
+			extra[name] = value
+		}
+	}
+	all := extractSymbolTable(ctx, reservedReq)[reqExtraResources]
+	for _, b := range content.Blocks {
+		if b.Type != blockRequirement && b.Type != blockObserve {
+			continue
+		}
+		name := b.Labels[0]
+		if all.IsKnown() && !all.IsNull() && all.Type().IsObjectType() && all.Type().HasAttribute(name) {
+			extra[name] = all.GetAttr(name)
+		} else {
+			extra[name] = cty.DynamicVal
+		}
+	}
+	return cty.ObjectVal(extra)
+}
+
 // selectBlockToSelection checks for overall correctness of the supplied select block without regard to actual values.
 func (e *Evaluator) selectBlockToSelection(requirementName string, block *hcl.Block) (*selection, hcl.Diagnostics) {
 	var curDiags hcl.Diagnostics
@@ -107,20 +132,27 @@ func (e *Evaluator) selectBlockToSelection(requirementName string, block *hcl.Bl
 	if diags.HasErrors() {
 		return nil, diags
 	}
+	sel, diags := contentToSelection(requirementName, content, block.DefRange)
+	return sel, curDiags.Extend(diags)
+}
 
+// contentToSelection checks the selector attributes in the supplied content and returns a selection for them.
+// The range is that of the block that contains the attributes.
+func contentToSelection(requirementName string, content *hcl.BodyContent, blockRange hcl.Range) (*selection, hcl.Diagnostics) {
+	var curDiags hcl.Diagnostics
 	_, hasName := content.Attributes[attrMatchName]
 	_, hasLabels := content.Attributes[attrMatchLabels]
 
 	switch {
 	case hasName && hasLabels:
-		return nil, hclutils.ToErrorDiag("requirement selector has both matchName and matchLabels", requirementName, block.DefRange)
+		return nil, hclutils.ToErrorDiag("requirement selector has both matchName and matchLabels", requirementName, blockRange)
 	//nolint:staticcheck // using De Morgan's law makes code unreadable
 	case !(hasName || hasLabels):
-		return nil, hclutils.ToErrorDiag("requirement selector has neither matchName nor matchLabels", requirementName, block.DefRange)
+		return nil, hclutils.ToErrorDiag("requirement selector has neither matchName nor matchLabels", requirementName, blockRange)
 	}
 
 	sel := &selection{
-		sourceRange: block.DefRange,
+		sourceRange: blockRange,
 		apiVersion:  content.Attributes[attrAPIVersion].Expr,
 		kind:        content.Attributes[attrKind].Expr,
 		hasName:     hasName,
@@ -151,8 +183,8 @@ func (e *Evaluator) selectBlockToSelection(requirementName string, block *hcl.Bl
 				curDiags = curDiags.Extend(hclutils.ToErrorDiag("matchLabels in requirement selector was not an object", requirementName, sel.matchLabels.Range()))
 			} else {
 				val := labelsVal.AsValueMap()
-				for k, v := range val {
-					if v.Type() != cty.String {
+				for _, k := range sortedKeys(val) {
+					if val[k].Type() != cty.String {
 						curDiags = curDiags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("match label %q in requirement selector was not an string", k), requirementName, sel.matchLabels.Range()))
 					}
 				}
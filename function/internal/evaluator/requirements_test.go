@@ -6,6 +6,7 @@ import (
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestReqBasicMatchLabels(t *testing.T) {
@@ -177,6 +178,61 @@ requirement cm {
 	}
 }
 
+const scopedExtraHCL = `
+group {
+	name_prefix = "net-"
+	requirement config {
+		select {
+			apiVersion = "v1"
+			kind = "ConfigMap"
+			matchName = "network"
+		}
+	}
+	group {
+		resource vpc {
+			body = {
+				apiVersion = "v1"
+				kind = "ConfigMap"
+				data = {
+					cidr = self.extra.config[0].data.cidr
+				}
+			}
+		}
+	}
+}
+`
+
+func TestReqScopedExtraResources(t *testing.T) {
+	e := createTestEvaluator(t)
+	ctx := withExtraResources(createTestEvalContext(), cty.ObjectVal(map[string]cty.Value{
+		"config": cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"data": cty.ObjectVal(map[string]cty.Value{
+					"cidr": cty.StringVal("10.0.0.0/16"),
+				}),
+			}),
+		}),
+	}))
+	content := parseHCL(t, e, scopedExtraHCL, "test.hcl")
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Contains(t, e.desiredResources, "net-vpc")
+	data := e.desiredResources["net-vpc"].AsMap()["data"].(map[string]any)
+	assert.Equal(t, "10.0.0.0/16", data["cidr"])
+}
+
+func TestReqScopedExtraResourcesIncomplete(t *testing.T) {
+	e := createTestEvaluator(t)
+	ctx := createTestEvalContext()
+	content := parseHCL(t, e, scopedExtraHCL, "test.hcl")
+	diags := e.processGroup(ctx, content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.NotNil(t, e.requirements["config"])
+	assert.NotContains(t, e.desiredResources, "net-vpc")
+	require.Len(t, e.discards, 1)
+	assert.Equal(t, discardReasonIncomplete, e.discards[0].Reason)
+}
+
 func TestReqNegative(t *testing.T) {
 	tests := []struct {
 		name string
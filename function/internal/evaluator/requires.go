@@ -0,0 +1,68 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// requiresOnlySchema extracts requires blocks from a body that may contain blocks that this version
+// of the function does not know about.
+var requiresOnlySchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: blockRequires}},
+}
+
+// releaseVersion returns the semantic version of the function build, or nil for development builds
+// that are not tagged with a release version. Pre-release information is dropped such that release
+// candidates satisfy the constraints of the release they precede.
+func releaseVersion(v string) *semver.Version {
+	ret, err := semver.StrictNewVersion(strings.TrimPrefix(v, "v"))
+	if err != nil {
+		return nil
+	}
+	noPre, err := ret.SetPrerelease("")
+	if err != nil {
+		return nil
+	}
+	return &noPre
+}
+
+// checkRequires checks the version constraints declared in requires blocks against the version of the function.
+// This is done before the bodies are decoded using the full schema such that compositions that use features of a
+// newer version fail with a clear message rather than errors about unsupported blocks and attributes. Constraints
+// are not checked for development builds, but they must still be valid.
+func (e *Evaluator) checkRequires(bodies []hcl.Body) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	current := releaseVersion(e.version)
+	for _, body := range bodies {
+		content, _, _ := body.PartialContent(requiresOnlySchema)
+		for _, block := range content.Blocks {
+			c, ds := block.Body.Content(requiresSchema())
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				continue
+			}
+			constraint, ds := constantString(c, attrFunctionHCL, blockRequires)
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				continue
+			}
+			r := c.Attributes[attrFunctionHCL].Expr.Range()
+			constraints, err := semver.NewConstraint(constraint)
+			if err != nil {
+				diags = diags.Extend(hclutils.ToErrorDiag("invalid version constraint", err.Error(), r))
+				continue
+			}
+			if current == nil || constraints.Check(current) {
+				continue
+			}
+			diags = diags.Extend(hclutils.ToErrorDiag(
+				fmt.Sprintf("this composition requires function-hcl %s but the function version is %s", constraint, e.version),
+				"upgrade the function to a version that satisfies the constraint", r))
+		}
+	}
+	return diags
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{version: "v0.5.1", expected: "0.5.1"},
+		{version: "0.5.1", expected: "0.5.1"},
+		{version: "0.6.0-rc3", expected: "0.6.0"},
+		{version: "dev", expected: ""},
+		{version: "20260101120000-0123456789ab", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			v := releaseVersion(test.version)
+			if test.expected == "" {
+				assert.Nil(t, v)
+				return
+			}
+			require.NotNil(t, v)
+			assert.Equal(t, test.expected, v.String())
+		})
+	}
+}
+
+func TestCheckRequires(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		hcl     string
+		errMsg  string
+	}{
+		{
+			name:    "satisfied",
+			version: "v0.5.2",
+			hcl: `
+requires {
+  function_hcl = ">= 0.5"
+}
+`,
+		},
+		{
+			name:    "pre-release satisfies",
+			version: "0.5.0-rc1",
+			hcl: `
+requires {
+  function_hcl = ">= 0.5"
+}
+`,
+		},
+		{
+			name:    "development build",
+			version: "dev",
+			hcl: `
+requires {
+  function_hcl = ">= 100"
+}
+`,
+		},
+		{
+			name:    "too old",
+			version: "v0.4.3",
+			hcl: `
+requires {
+  function_hcl = ">= 0.5"
+}
+future_block {
+  foo = "bar"
+}
+`,
+			errMsg: `test.hcl:3,18-26: this composition requires function-hcl >= 0.5 but the function version is v0.4.3; upgrade the function to a version that satisfies the constraint`,
+		},
+		{
+			name:    "invalid constraint",
+			version: "dev",
+			hcl: `
+requires {
+  function_hcl = "newest"
+}
+`,
+			errMsg: `test.hcl:3,18-26: invalid version constraint`,
+		},
+		{
+			name:    "not a constant",
+			version: "dev",
+			hcl: `
+requires {
+  function_hcl = "${req.composite}"
+}
+`,
+			errMsg: `function_hcl in requires block is not a constant string`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := createTestEvaluator(t)
+			e.version = test.version
+			_, diags := e.toContent([]File{{Name: "test.hcl", Content: test.hcl}})
+			if test.errMsg == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.errMsg)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// resourceName returns the name under which the resource declared by the supplied block is added to the desired
+// resources. This is the value of the resource_name attribute when one is present, and the label of the block
+// otherwise, prefixed by the name prefix of the enclosing groups. The attribute is evaluated before the self
+// variables and locals of the resource are set up, since they depend on the name.
+func (e *Evaluator) resourceName(ctx *hcl.EvalContext, block *hcl.Block, content *hcl.BodyContent) (string, hcl.Diagnostics) {
+	attr, ok := content.Attributes[attrResourceName]
+	if !ok {
+		return e.namePrefix + block.Labels[0], nil
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if !val.IsWhollyKnown() || val.IsNull() || val.Type() != cty.String || val.AsString() == "" {
+		return "", diags.Extend(hclutils.ToErrorDiag(
+			fmt.Sprintf("%s of resource %s must be a known, non-empty string", attrResourceName, block.Labels[0]),
+			e.sourceCode(attr.Expr.Range()), attr.Expr.Range()))
+	}
+	return e.namePrefix + val.AsString(), diags
+}
+
+// staticResourceName returns the name of the resource declared by the supplied block, prefixed by the supplied
+// prefix, when it can be determined without evaluating any expressions.
+func staticResourceName(prefix string, block *hcl.Block, content *hcl.BodyContent) (string, bool) {
+	attr, ok := content.Attributes[attrResourceName]
+	if !ok {
+		return prefix + block.Labels[0], true
+	}
+	if len(attr.Expr.Variables()) > 0 {
+		return "", false
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() || val.Type() != cty.String || val.AsString() == "" {
+		return "", false
+	}
+	return prefix + val.AsString(), true
+}
+
+// namePattern matches the names of resources whose names are only known at evaluation time.
+type namePattern struct {
+	prefix string // constant text at the start of the name
+	suffix string // constant text at the end of the name
+}
+
+// matches returns true if the supplied name could be a name that matches the pattern.
+func (p namePattern) matches(name string) bool {
+	return len(name) >= len(p.prefix)+len(p.suffix) && strings.HasPrefix(name, p.prefix) && strings.HasSuffix(name, p.suffix)
+}
+
+// dynamicNamePattern returns the pattern of the names produced by the supplied resource_name expression of a
+// resource in groups with the supplied name prefix, from the constant text at the start and end of templates.
+func dynamicNamePattern(prefix string, expr hcl.Expression) namePattern {
+	ret := namePattern{prefix: prefix}
+	tmpl, ok := expr.(*hclsyntax.TemplateExpr)
+	if !ok {
+		return ret
+	}
+	literal := func(part hclsyntax.Expression) (string, bool) {
+		lit, ok := part.(*hclsyntax.LiteralValueExpr)
+		if !ok || lit.Val.Type() != cty.String || !lit.Val.IsKnown() || lit.Val.IsNull() {
+			return "", false
+		}
+		return lit.Val.AsString(), true
+	}
+	start := 0
+	for ; start < len(tmpl.Parts); start++ {
+		s, ok := literal(tmpl.Parts[start])
+		if !ok {
+			break
+		}
+		ret.prefix += s
+	}
+	for end := len(tmpl.Parts) - 1; end > start; end-- {
+		s, ok := literal(tmpl.Parts[end])
+		if !ok {
+			break
+		}
+		ret.suffix = s + ret.suffix
+	}
+	return ret
+}
+
+// maybeDynamicName returns true if the supplied name could be the name of a resource whose name is only known at
+// evaluation time.
+func (a *analyzer) maybeDynamicName(name string) bool {
+	for _, p := range a.dynamicNames {
+		if p.matches(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_ResourceName(t *testing.T) {
+	e := createTestEvaluator(t)
+	content := parseHCL(t, e, `
+group {
+  name_prefix = "net-"
+
+  resource db {
+    resource_name = "${req.composite.metadata.name}-db"
+    body = {
+      apiVersion = "v1"
+      kind       = "Database"
+      metadata   = { name = self.name }
+    }
+  }
+}
+`, "main.hcl")
+	diags := e.processGroup(createTestEvalContext(), content)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.NotContains(t, e.desiredResources, "net-db")
+	require.Contains(t, e.desiredResources, "net-my-composite-db")
+	metadata := e.desiredResources["net-my-composite-db"].AsMap()["metadata"].(map[string]any)
+	assert.Equal(t, "net-my-composite-db", metadata["name"])
+}
+
+func TestEvaluator_ResourceNameErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{name: "not a string", expr: `["db"]`, err: "resource_name of resource db must be a known, non-empty string"},
+		{name: "empty", expr: `""`, err: "resource_name of resource db must be a known, non-empty string"},
+		{name: "self reference", expr: `self.resource.metadata.name`, err: "Unsupported attribute"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := createTestEvaluator(t)
+			content := parseHCL(t, e, `
+resource db {
+  resource_name = `+test.expr+`
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+`, "main.hcl")
+			diags := e.processGroup(createTestEvalContext(), content)
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags.Error(), test.err)
+			assert.Empty(t, e.desiredResources)
+		})
+	}
+}
+
+func TestAnalyzeResourceName(t *testing.T) {
+	analyze := func(t *testing.T, hcl string) string {
+		e, err := New(Options{})
+		require.NoError(t, err)
+		var messages []string
+		for _, d := range e.Analyze(File{Name: "test.hcl", Content: hcl}).Errs() {
+			messages = append(messages, d.Error())
+		}
+		return strings.Join(messages, ", ")
+	}
+
+	t.Run("constant name", func(t *testing.T) {
+		assert.Empty(t, analyze(t, `
+resource db {
+  resource_name = "primary-db"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+resource user {
+  body = { apiVersion = "v1", kind = "User", spec = { db = req.resource.primary-db.metadata.name } }
+}
+`))
+		assert.Contains(t, analyze(t, `
+resource db {
+  resource_name = "primary-db"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+resource user {
+  body = { apiVersion = "v1", kind = "User", spec = { db = req.resource.db.metadata.name } }
+}
+`), "invalid resource name reference")
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		assert.Contains(t, analyze(t, `
+resource db {
+  resource_name = "user"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+resource user {
+  body = { apiVersion = "v1", kind = "User" }
+}
+`), "resource defined more than once")
+	})
+
+	t.Run("dynamic name", func(t *testing.T) {
+		assert.Empty(t, analyze(t, `
+resource db {
+  resource_name = "${req.composite.metadata.name}-db"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+resource user {
+  body = { apiVersion = "v1", kind = "User", spec = { db = req.resource.xr-db.metadata.name } }
+}
+`))
+	})
+
+	t.Run("typo with dynamic name", func(t *testing.T) {
+		assert.Contains(t, analyze(t, `
+resource db {
+  resource_name = "${req.composite.metadata.name}-db"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+resource cache {
+  body = { apiVersion = "v1", kind = "Cache" }
+}
+resource user {
+  body = { apiVersion = "v1", kind = "User", spec = { cache = req.resource.cahce.metadata.name } }
+}
+`), "invalid resource name reference")
+	})
+
+	t.Run("dynamic name in prefixed group", func(t *testing.T) {
+		hcl := `
+group {
+  name_prefix = "app-"
+  resource db {
+    resource_name = req.composite.spec.dbName
+    body          = { apiVersion = "v1", kind = "Database" }
+  }
+}
+resource user {
+  body = { apiVersion = "v1", kind = "User", spec = { db = req.resource.%s.metadata.name } }
+}
+`
+		assert.Empty(t, analyze(t, fmt.Sprintf(hcl, "app-main")))
+		assert.Contains(t, analyze(t, fmt.Sprintf(hcl, "main")), "invalid resource name reference")
+	})
+
+	t.Run("self reference", func(t *testing.T) {
+		assert.Contains(t, analyze(t, `
+resource db {
+  resource_name = "${self.name}-db"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+`), `no such attribute "name"`)
+	})
+
+	t.Run("resource local reference", func(t *testing.T) {
+		assert.Contains(t, analyze(t, `
+resource db {
+  locals {
+    suffix = "db"
+  }
+  resource_name = "xr-${suffix}"
+  body          = { apiVersion = "v1", kind = "Database" }
+}
+`), "invalid local variable reference")
+	})
+}
@@ -0,0 +1,44 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// typeFields are the fields that every resource body must set to a string.
+var typeFields = []string{attrAPIVersion, attrKind}
+
+// checkResourceType returns warnings when the supplied rendered body of a resource does not have the API version
+// and kind strings that Crossplane needs to apply it.
+func checkResourceType(resourceName string, body cty.Value, r hcl.Range) hcl.Diagnostics {
+	ty := body.Type()
+	if !ty.IsObjectType() && !ty.IsMapType() {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("body of resource %s must be an object, got %s", resourceName, ty.FriendlyName()),
+			Subject:  ptr(r),
+		}}
+	}
+	values := body.AsValueMap()
+	var diags hcl.Diagnostics
+	for _, field := range typeFields {
+		v, ok := values[field]
+		switch {
+		case !ok || v.IsNull():
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("body of resource %s has no %s", resourceName, field),
+				Subject:  ptr(r),
+			})
+		case v.Type() != cty.String || v.AsString() == "":
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("%s of resource %s must be a non-empty string", field, resourceName),
+				Subject:  ptr(r),
+			})
+		}
+	}
+	return diags
+}
@@ -7,9 +7,9 @@ import (
 
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/locals"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -17,51 +17,148 @@ func (e *Evaluator) processLocals(ctx *hcl.EvalContext, content *hcl.BodyContent
 	return locals.NewProcessor().Process(ctx, content)
 }
 
+// conditionNeedsLocals returns true if the condition of the supplied content refers to one of its locals, such
+// that it can only be evaluated after them. Contents whose locals cannot be read also return true, such that the
+// errors are reported when the locals are processed.
+func conditionNeedsLocals(content *hcl.BodyContent) bool {
+	attr, ok := content.Attributes[attrCondition]
+	if !ok {
+		return false
+	}
+	names, diags := locals.NewProcessor().Expressions(content)
+	if diags.HasErrors() {
+		return true
+	}
+	for _, v := range attr.Expr.Variables() {
+		if _, ok := names[v.RootName()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // processGroup processes all blocks at the top-level or at the level of a single group.
 func (e *Evaluator) processGroup(ctx *hcl.EvalContext, content *hcl.BodyContent) hcl.Diagnostics {
-	ctx, diags := e.processLocals(ctx, content)
+	// default ready blocks are only allowed at the top-level and must be known before any resource is processed.
+	for _, b := range content.Blocks {
+		if b.Type == blockReadyDefault {
+			if ds := e.addReadyDefault(b); ds.HasErrors() {
+				return ds
+			}
+		}
+	}
+
+	leave, diags := e.enterDeterminism(content, blockGroup)
 	if diags.HasErrors() {
 		return diags
 	}
+	defer leave()
+
+	ctx = createSelfChildContext(ctx, DynamicObject{
+		selfExtra: scopedExtraResources(ctx, content),
+	})
 
-	cond, ds := e.evaluateCondition(ctx, content, discardTypeGroup, "")
+	// a condition that does not refer to the locals of the group is evaluated before them, such that the locals of
+	// groups that are skipped are never evaluated.
+	checkCondition := func(ctx *hcl.EvalContext) (bool, hcl.Diagnostics) {
+		cond, ds := e.evaluateCondition(ctx, content, discardTypeGroup, "")
+		if ds.HasErrors() {
+			return false, ds.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "unable to evaluate condition",
+			})
+		}
+		return cond, ds
+	}
+	early := !conditionNeedsLocals(content)
+	if early {
+		cond, ds := checkCondition(ctx)
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			return diags
+		}
+		if !cond {
+			return nil
+		}
+	}
+
+	ctx, ds := e.processLocals(ctx, content)
 	diags = diags.Extend(ds)
 	if ds.HasErrors() {
-		return diags.Append(&hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Summary:  "unable to evaluate condition",
-		})
+		return diags
 	}
-	if !cond {
-		return nil
+	scopes, ds := processFileLocals(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
 	}
+
+	if !early {
+		cond, ds := checkCondition(ctx)
+		diags = diags.Extend(ds)
+		if ds.HasErrors() {
+			return diags
+		}
+		if !cond {
+			return nil
+		}
+	}
+	var policies []*hcl.Block
 	for _, b := range content.Blocks {
+		if ds := e.advance(fmt.Sprintf("the %s block", b.Type), b.DefRange); ds.HasErrors() {
+			return diags.Extend(ds)
+		}
 		var curDiags hcl.Diagnostics
+		blockCtx := scopes.context(ctx, b)
 		switch b.Type {
 		case blockGroup:
 			content, ds := b.Body.Content(groupSchema())
+			if ds.HasErrors() {
+				return diags.Extend(explainUnsupportedAttributes(b.Body, groupSchema(), ds))
+			}
+			prefix, ds := groupNamePrefix(content)
 			if ds.HasErrors() {
 				return diags.Extend(ds)
 			}
-			curDiags = ds.Extend(e.processGroup(ctx, content))
+			leaveTarget, ds := e.enterTarget(content)
+			if ds.HasErrors() {
+				return diags.Extend(ds)
+			}
+			parentPrefix := e.namePrefix
+			e.namePrefix += prefix
+			curDiags = e.isolateGroup(b, content, e.namePrefix, func() hcl.Diagnostics {
+				return e.processGroup(blockCtx, content)
+			})
+			e.namePrefix = parentPrefix
+			leaveTarget()
 		case blockResource:
-			curDiags = e.processResource(ctx, b)
+			curDiags = e.processResource(blockCtx, b)
 		case blockResources:
-			curDiags = e.processResources(ctx, b)
+			curDiags = e.processResources(blockCtx, b)
 		case blockContext:
-			curDiags = e.processContext(ctx, b)
+			curDiags = e.processContext(blockCtx, b)
+		case blockContexts:
+			curDiags = e.processContexts(blockCtx, b)
 		case blockComposite:
-			curDiags = e.processComposite(ctx, b)
+			curDiags = e.processComposite(blockCtx, b)
 		case blockRequirement:
-			curDiags = e.processRequirement(ctx, b)
+			curDiags = e.processRequirement(blockCtx, b)
+		case blockObserve:
+			curDiags = e.processObserve(blockCtx, b)
+		case blockExportConn:
+			curDiags = e.processExportConnection(blockCtx, b)
 		case blockLocals:
 			// already processed
-		case blockFunction:
+		case blockFunction, blockReadyDefault, blockRequires, blockAlias, blockFileLocals, blockDoc:
 			// ditto
+		case blockPolicy:
+			// processed after all other blocks
+			policies = append(policies, b)
 		default:
 			curDiags = curDiags.Append(&hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  fmt.Sprintf("unsupported block type %s", b.Type),
+				Detail:   hclutils.DidYouMean("", b.Type, blockTypes(topLevelSchema())),
 				Subject:  ptr(b.DefRange),
 			})
 		}
@@ -70,30 +167,80 @@ func (e *Evaluator) processGroup(ctx *hcl.EvalContext, content *hcl.BodyContent)
 			return diags
 		}
 	}
+	// policies are only allowed at the top-level and are checked once all desired resources are known.
+	for _, p := range policies {
+		diags = diags.Extend(e.processPolicies(scopes.context(ctx, p), []*hcl.Block{p}))
+	}
 	return diags
 }
 
-func (e *Evaluator) processResource(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
-	resourceName := block.Labels[0]
+// groupNamePrefix returns the name prefix declared for the group with the supplied content.
+func groupNamePrefix(content *hcl.BodyContent) (string, hcl.Diagnostics) {
+	return constantString(content, attrNamePrefix, blockGroup)
+}
 
+func (e *Evaluator) processResource(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
 	content, diags := block.Body.Content(resourceSchema())
 	if diags.HasErrors() {
 		return diags
 	}
+	enabled, ds := isEnabled(content, blockResource)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	if !enabled {
+		return diags.Extend(e.processDisabledResource(ctx, block, content))
+	}
+	targeted, ds := e.isTargeted(content, blockResource)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	if !targeted {
+		return diags.Extend(e.processUntargetedResource(ctx, block, content))
+	}
+	leave, ds := e.enterDeterminism(content, blockResource)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	defer leave()
+	resourceName, ds := e.resourceName(ctx, block, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
 
 	// add the resource to our stash
-	ds := e.addResource(ctx, resourceName, content, nil)
+	ds = e.addResource(ctx, resourceName, content, nil)
 	return diags.Extend(ds)
 }
 
 func (e *Evaluator) processResources(ctx *hcl.EvalContext, block *hcl.Block) hcl.Diagnostics {
-	baseName := block.Labels[0]
+	baseName := e.namePrefix + block.Labels[0]
 
 	// parse with strict schema
 	content, diags := block.Body.Content(resourcesSchema())
 	if diags.HasErrors() {
 		return diags
 	}
+	enabled, ds := isEnabled(content, blockResources)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	if !enabled {
+		return diags.Extend(e.processDisabledResources(block, baseName))
+	}
+	targeted, ds := e.isTargeted(content, blockResources)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	if !targeted {
+		return diags.Extend(e.processUntargetedResources(block, baseName))
+	}
+	leave, ds := e.enterDeterminism(content, blockResources)
+	if ds.HasErrors() {
+		return diags.Extend(ds)
+	}
+	defer leave()
 
 	var templateBlock *hcl.Block
 	for _, b := range content.Blocks {
@@ -116,8 +263,8 @@ func (e *Evaluator) processResources(ctx *hcl.EvalContext, block *hcl.Block) hcl
 		})
 	}
 
-	templateContent, ds := templateBlock.Body.Content(templateSchema())
-	diags = diags.Extend(ds)
+	templateContent, ds := templateBlock.Body.Content(schemasByBlockType[blockTemplate])
+	diags = diags.Extend(explainUnsupportedAttributes(templateBlock.Body, schemasByBlockType[blockTemplate], ds))
 	if ds.HasErrors() {
 		return diags
 	}
@@ -170,62 +317,105 @@ func (e *Evaluator) processResources(ctx *hcl.EvalContext, block *hcl.Block) hcl
 			Subject:  ptr(forEachExpr.Range()),
 		})
 	}
+	if err := e.checkCollectionSize(len(iters)); err != nil {
+		return diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("too many iterations for resource collection %s", baseName),
+			Detail:   err.Error(),
+			Subject:  ptr(forEachExpr.Range()),
+		})
+	}
 
-	// get the name as an expression.
+	// get the name as an expression, the default name is derived from the key of each iteration instead.
 	var nameExpr hcl.Expression
 	if npAttr, ok := content.Attributes[attrName]; ok {
 		nameExpr = npAttr.Expr
-	} else {
-		nameExpr, ds = hclsyntax.ParseTemplate([]byte(`${self.basename}-${each.key}`), "default-name.hcl", hcl.Pos{Line: 1, Column: 1})
-		diags = diags.Extend(ds)
-		if ds.HasErrors() {
-			return diags.Append(&hcl.Diagnostic{
-				Severity: hcl.DiagError,
-				Summary:  fmt.Sprintf("unable to evaluate default name expression for resource collection %s", baseName),
-				Subject:  ptr(nameExpr.Range()),
-			})
-		}
 	}
+	keysByName := map[string]string{}
+	renameAttr := content.Attributes[attrRenameFrom]
 
-	// actually process resources
+	// evaluate parts of the template that are the same for all iterations only once
+	if len(iters) > 1 {
+		templateContent = hoistInvariants(ctx, templateContent)
+	}
+
+	// actually process resources, recording the rendered items for composite and context blocks
+	items := []cty.Value{}
 	for i, iter := range iters {
-		iterContext := ctx.NewChild()
-		iterContext.Variables = DynamicObject{
-			iteratorName: cty.ObjectVal(DynamicObject{
-				attrKey:   iter.key,
-				attrValue: iter.value,
-			}),
+		if ds := e.advance(fmt.Sprintf("iteration %d of resource collection %s", i, baseName), templateBlock.DefRange); ds.HasErrors() {
+			return diags.Extend(ds)
 		}
+		iterContext := createSelfChildContext(ctx, DynamicObject{
+			selfIndex: cty.NumberIntVal(int64(i)),
+		})
+		iterContext.Variables[iteratorName] = cty.ObjectVal(DynamicObject{
+			attrKey:       iter.key,
+			attrValue:     iter.value,
+			iteratorIndex: cty.NumberIntVal(int64(i)),
+			iteratorFirst: cty.BoolVal(i == 0),
+			iteratorLast:  cty.BoolVal(i == len(iters)-1),
+			iteratorCount: cty.NumberIntVal(int64(len(iters))),
+		})
 
-		resourceExpr, ds := nameExpr.Value(iterContext)
-		diags = diags.Extend(ds)
-		if ds.HasErrors() {
-			return diags.Append(&hcl.Diagnostic{
-				Severity: hcl.DiagError,
-				Summary:  fmt.Sprintf("unable to evaluate name expression for resource collection %s", baseName),
-				Subject:  ptr(nameExpr.Range()),
-			})
+		var name string
+		if nameExpr == nil {
+			defaultName, ds := e.defaultCollectionName(baseName, iter.key, keysByName, forEachExpr.Range())
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				return diags
+			}
+			name = defaultName
+		} else {
+			resourceExpr, ds := nameExpr.Value(iterContext)
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				return diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("unable to evaluate name expression for resource collection %s", baseName),
+					Subject:  ptr(nameExpr.Range()),
+				})
+			}
+			if resourceExpr.Type() != cty.String {
+				return diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("name produced from evaluating the name expression for collection %s was not a string", baseName),
+					Subject:  ptr(nameExpr.Range()),
+				})
+			}
+			name = resourceExpr.AsString()
 		}
-		if resourceExpr.Type() != cty.String {
-			return diags.Append(&hcl.Diagnostic{
-				Severity: hcl.DiagError,
-				Summary:  fmt.Sprintf("name produced from evaluating the name expression for collection %s was not a string", baseName),
-				Subject:  ptr(nameExpr.Range()),
-			})
+		if renameAttr != nil {
+			ds := e.renameFrom(iterContext, baseName, name, renameAttr)
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				return diags
+			}
 		}
-		name := resourceExpr.AsString()
 		annotations := map[string]string{
 			annotationBaseName: baseName,
-			annotationIndex:    fmt.Sprintf("s%06d", i),
+			annotationIndex:    e.indexFormat.format(i),
 		}
 		ds = e.addResource(iterContext, name, templateContent, annotations)
 		diags = diags.Extend(ds)
 		if ds.HasErrors() {
 			return diags
 		}
+		item, err := e.collectionItem(name, iter)
+		if err != nil {
+			return diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("unable to convert body of resource %s", name),
+				Detail:   err.Error(),
+				Subject:  ptr(templateBlock.DefRange),
+			})
+		}
+		items = append(items, item)
 	}
 
-	// process any composite and context blocks
+	// process any composite and context blocks, which have access to the rendered items
+	ctx = createSelfChildContext(ctx, DynamicObject{
+		selfItems: cty.TupleVal(items),
+	})
 	for _, b := range content.Blocks {
 		var currentDiags hcl.Diagnostics
 		if b.Type == blockComposite {
@@ -234,6 +424,9 @@ func (e *Evaluator) processResources(ctx *hcl.EvalContext, block *hcl.Block) hcl
 		if b.Type == blockContext {
 			currentDiags = e.processContext(ctx, b)
 		}
+		if b.Type == blockContexts {
+			currentDiags = e.processContexts(ctx, b)
+		}
 		diags = diags.Extend(currentDiags)
 		if currentDiags.HasErrors() {
 			return diags
@@ -242,6 +435,25 @@ func (e *Evaluator) processResources(ctx *hcl.EvalContext, block *hcl.Block) hcl
 	return diags
 }
 
+// collectionItem returns the name, iterator values and rendered body for an iteration of a resource collection.
+// The body is null when the resource was not produced because its condition was false or its values were incomplete.
+func (e *Evaluator) collectionItem(name string, iter iteration) (cty.Value, error) {
+	body := cty.NullVal(cty.DynamicPseudoType)
+	if res := e.desiredResources[name]; res != nil {
+		var err error
+		body, err = toCtyValue(res.AsMap())
+		if err != nil {
+			return cty.NilVal, err
+		}
+	}
+	return cty.ObjectVal(DynamicObject{
+		selfName:  cty.StringVal(name),
+		attrKey:   iter.key,
+		attrValue: iter.value,
+		selfBody:  body,
+	}), nil
+}
+
 func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, content *hcl.BodyContent, annotations map[string]string) hcl.Diagnostics {
 	// dup check
 	if e.desiredResources[resourceName] != nil {
@@ -282,6 +494,23 @@ func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, conte
 		return nil
 	}
 
+	// values the resource explicitly waits for are checked before the body, so that the discard lists them
+	pending, ds := e.pendingWaits(ctx, content)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return diags
+	}
+	if len(pending) > 0 {
+		if e.getObservedResource(resourceName) != cty.NilVal {
+			return diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Subject:  ptr(content.Attributes[attrWaitFor].Expr.Range()),
+				Summary:  fmt.Sprintf("existing resource %s could not be evaluated, abort (waiting for %s)", resourceName, strings.Join(pending, ", ")),
+			})
+		}
+		return diags.Extend(e.discardWaiting(content, discardTypeResource, resourceName, pending))
+	}
+
 	// process the body
 	out, ds := body.Expr.Value(ctx)
 
@@ -293,14 +522,16 @@ func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, conte
 		context := e.messagesFromDiags(ds)
 
 		var incompleteVars []string
+		incompleteSelf := 0 // number of incomplete values that are fields of the observed resource itself
 		for _, t := range body.Expr.Variables() {
 			v, tdiag := t.TraverseAbs(ctx)
 			ds = append(ds, tdiag...)
 
 			sourceName := e.sourceCode(t.SourceRange())
+			numIncomplete := len(incompleteVars)
 
 			// try to find the path to the actual unknown values to assist with debugging
-			unknownPaths, err := findUnknownPaths(v)
+			unknownPaths, err := hclexpr.FindUnknownPaths(v)
 			if err != nil {
 				// unexpected error while finding unknown paths, add to context instead of failing
 				ds = append(ds, &hcl.Diagnostic{
@@ -317,9 +548,12 @@ func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, conte
 			if len(unknownPaths) == 0 && !v.IsWhollyKnown() {
 				incompleteVars = append(incompleteVars, sourceName)
 			}
+			if isSelfResourceField(t) {
+				incompleteSelf += len(incompleteVars) - numIncomplete
+			}
 		}
 		unknown := strings.Join(incompleteVars, ", ")
-		if _, have := e.existingResourceMap[resourceName]; have {
+		if e.getObservedResource(resourceName) != cty.NilVal {
 			return diags.Extend(ds).Append(&hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Subject:  ptr(body.Expr.Range()),
@@ -327,17 +561,34 @@ func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, conte
 			})
 		}
 
+		if len(incompleteVars) > 0 && incompleteSelf == len(incompleteVars) {
+			// the resource can never be created since its body depends on its own observed state
+			context = append(context, fmt.Sprintf("waiting for first reconcile of this resource: %s cannot be read before it has been created, use try with a default value to create it", unknown))
+		} else {
+			context = append(context, fmt.Sprintf("unknown values: %s", unknown))
+		}
+		// the values of locals show which of them are unknown, but may be large and are only added when debugging
+		if e.debug {
+			context = append(context, e.localsSnapshot(ctx)...)
+		}
 		e.discard(DiscardItem{
 			Type:        discardTypeResource,
 			Reason:      discardReasonIncomplete,
 			Name:        resourceName,
 			SourceRange: body.Expr.Range().String(),
-			Context:     append(context, fmt.Sprintf("unknown values: %s", unknown)),
+			Context:     context,
 		})
 		// map unknown resource value errors to warnings as we'll handle them later
 		return diags.Extend(hclutils.DowngradeDiags(ds))
 	}
 	diags = diags.Extend(ds)
+	diags = diags.Extend(checkResourceType(resourceName, out, body.Expr.Range()))
+
+	annotations, complete, ds := e.evaluateExternalName(ctx, resourceName, content, out, annotations)
+	diags = diags.Extend(ds)
+	if !complete {
+		return diags
+	}
 
 	// convert body to a protobuf struct and add to desired state
 	bodyStruct, err := valueToStructWithAnnotations(out, annotations)
@@ -348,6 +599,7 @@ func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, conte
 			Subject:  ptr(body.Expr.Range()),
 		})
 	}
+	e.adoptRenamed(resourceName, bodyStruct)
 	e.desiredResources[resourceName] = bodyStruct
 
 	for _, b := range content.Blocks {
@@ -355,48 +607,126 @@ func (e *Evaluator) addResource(ctx *hcl.EvalContext, resourceName string, conte
 		if b.Type == blockComposite {
 			currentDiags = e.processComposite(ctx, b)
 		}
-		if b.Type == blockReady {
-			currentDiags = e.processReady(ctx, resourceName, b)
-		}
 		if b.Type == blockContext {
 			currentDiags = e.processContext(ctx, b)
 		}
+		if b.Type == blockContexts {
+			currentDiags = e.processContexts(ctx, b)
+		}
 		diags = diags.Extend(currentDiags)
 		if currentDiags.HasErrors() {
 			return diags
 		}
 	}
 
+	// process ready blocks in order, such that the first one that applies sets the readiness
+	diags = diags.Extend(checkReadyBlocks(content))
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, b := range content.Blocks {
+		switch b.Type {
+		case blockReady:
+			applied, currentDiags := e.processReady(ctx, resourceName, b)
+			diags = diags.Extend(currentDiags)
+			if applied {
+				return diags
+			}
+		case blockReadyFromCondition:
+			return diags.Extend(e.processReadyFromCondition(ctx, resourceName, b))
+		}
+	}
+
+	// apply a default readiness expression for the kind of resource if one exists
+	if rd := e.findReadyDefault(out); rd != nil {
+		diags = diags.Extend(e.evaluateReady(ctx, resourceName, rd.defRange, rd.content))
+	}
+
+	return diags
+}
+
+// checkReadyBlocks checks that every ready block in the supplied content, except for the last one, has a condition.
+// A ready block that follows one without a condition can never apply. A ready_from_condition block is treated as a
+// ready block without a condition.
+func checkReadyBlocks(content *hcl.BodyContent) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	var unconditional *hcl.Block
+	for _, b := range content.Blocks {
+		if b.Type != blockReady && b.Type != blockReadyFromCondition {
+			continue
+		}
+		if unconditional != nil {
+			diags = diags.Extend(hclutils.ToErrorDiag("unreachable ready block",
+				fmt.Sprintf("the %s block at %s has no condition", unconditional.Type, unconditional.DefRange), b.DefRange))
+			continue
+		}
+		attrs, _ := b.Body.JustAttributes()
+		if _, ok := attrs[attrCondition]; !ok || b.Type == blockReadyFromCondition {
+			unconditional = b
+		}
+	}
 	return diags
 }
 
-var validReadyValues string
+var (
+	readyValueNames  []string // sorted names of the ready values
+	validReadyValues string
+)
 
 func init() {
-	var keys []string
 	for k := range fnv1.Ready_value {
-		keys = append(keys, k)
+		readyValueNames = append(readyValueNames, k)
 	}
-	sort.Strings(keys)
-	validReadyValues = strings.Join(keys, ", ")
+	sort.Strings(readyValueNames)
+	validReadyValues = strings.Join(readyValueNames, ", ")
 }
 
-func (e *Evaluator) processReady(ctx *hcl.EvalContext, resourceName string, block *hcl.Block) hcl.Diagnostics {
+// processReady processes a ready block for a resource. It returns true if the block applies, which is the case
+// when it has no condition, the condition is true, or the condition cannot yet be evaluated. In the last case, the
+// readiness of the resource is left unset.
+func (e *Evaluator) processReady(ctx *hcl.EvalContext, resourceName string, block *hcl.Block) (bool, hcl.Diagnostics) {
 	content, diags := block.Body.Content(readySchema())
 	if diags.HasErrors() {
-		return diags
+		return true, diags
 	}
 	ctx, ds := e.processLocals(ctx, content)
 	diags = diags.Extend(ds)
 	if ds.HasErrors() {
+		return true, diags
+	}
+	numDiscards := len(e.discards)
+	cond, ds := e.evaluateCondition(ctx, content, discardTypeReady, resourceName)
+	diags = diags.Extend(ds)
+	if ds.HasErrors() {
+		return true, diags
+	}
+	if !cond {
+		// an incomplete condition is recorded as such, and later ready blocks must not be used in its place
+		incomplete := len(e.discards) > numDiscards && e.discards[numDiscards].Reason == discardReasonIncomplete
+		return incomplete, diags
+	}
+	return true, diags.Extend(e.setReady(ctx, resourceName, block.DefRange, content))
+}
+
+// evaluateReady evaluates the value attribute of the supplied ready content and sets the readiness of the resource.
+func (e *Evaluator) evaluateReady(ctx *hcl.EvalContext, resourceName string, defRange hcl.Range, content *hcl.BodyContent) hcl.Diagnostics {
+	ctx, diags := e.processLocals(ctx, content)
+	if diags.HasErrors() {
 		return diags
 	}
+	return diags.Extend(e.setReady(ctx, resourceName, defRange, content))
+}
+
+// setReady sets the readiness of the resource from the value attribute of the supplied ready content, evaluated
+// in a context that already has its locals.
+func (e *Evaluator) setReady(ctx *hcl.EvalContext, resourceName string, defRange hcl.Range, content *hcl.BodyContent) hcl.Diagnostics {
+	var diags hcl.Diagnostics
 	attr, ok := content.Attributes[attrValue]
 	if !ok {
 		return diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  fmt.Sprintf("attribute %q not found in ready block for %s", attrValue, resourceName),
-			Subject:  ptr(block.DefRange),
+			Subject:  ptr(defRange),
 		})
 	}
 
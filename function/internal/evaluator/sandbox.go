@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty/function"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// sandboxContextKey is the context key under which the enforced sandbox profile is emitted.
+const sandboxContextKey = "hcl.fn.crossplane.io/sandbox"
+
+// SandboxProfile restricts what the expressions of a composition may do, for platforms on which compositions are
+// written by users who are not trusted with the resources of the function.
+type SandboxProfile struct {
+	// Name identifies the profile in the response context.
+	Name string `json:"name"`
+	// DisabledFunctions are the names of built-in functions that fail when they are called.
+	DisabledFunctions []string `json:"disabledFunctions,omitempty"`
+	// MaxCollectionSize is the maximum number of elements of collections returned by functions and of the
+	// iterations of resource collections. Collections are not limited when it is not positive.
+	MaxCollectionSize int `json:"maxCollectionSize,omitempty"`
+	// MaxInvokeDepth is the maximum depth of nested user function and lambda calls. It lowers the depth set by
+	// the options of the evaluator, and does not change it when it is not positive.
+	MaxInvokeDepth int `json:"maxInvokeDepth,omitempty"`
+}
+
+// DefaultSandboxProfile is a profile for compositions that are not trusted, which disables functions whose values
+// depend on the time of the evaluation and limits the sizes of collections and the depth of user function calls.
+var DefaultSandboxProfile = SandboxProfile{
+	Name:              "restricted",
+	DisabledFunctions: []string{"formatdate", "timeadd", "timecmp", "timestamp"},
+	MaxCollectionSize: 10000,
+	MaxInvokeDepth:    20,
+}
+
+// Validate returns an error if the profile does not have a name, has negative limits, or disables functions that
+// do not exist.
+func (s *SandboxProfile) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("profile does not have a name")
+	}
+	if s.MaxCollectionSize < 0 {
+		return fmt.Errorf("maxCollectionSize must not be negative, got %d", s.MaxCollectionSize)
+	}
+	if s.MaxInvokeDepth < 0 {
+		return fmt.Errorf("maxInvokeDepth must not be negative, got %d", s.MaxInvokeDepth)
+	}
+	if s.MaxInvokeDepth > MaxInvokeDepthLimit {
+		return fmt.Errorf("maxInvokeDepth must not be larger than %d, got %d", MaxInvokeDepthLimit, s.MaxInvokeDepth)
+	}
+	builtins := BuiltinFunctions()
+	known := map[string]bool{}
+	for _, name := range builtins {
+		known[name] = true
+	}
+	for _, name := range s.DisabledFunctions {
+		if !known[name] {
+			return errors.New(hclutils.DidYouMean(fmt.Sprintf("cannot disable unknown function %q", name), name, builtins))
+		}
+	}
+	return nil
+}
+
+// disabledReason returns the reason reported for calls to functions disabled by the profile.
+func (s *SandboxProfile) disabledReason() string {
+	return fmt.Sprintf("not allowed by sandbox profile %q", s.Name)
+}
+
+// invokeDepth returns the maximum depth of nested user function calls when the supplied depth is configured.
+func (s *SandboxProfile) invokeDepth(depth int) int {
+	if s == nil || s.MaxInvokeDepth <= 0 {
+		return depth
+	}
+	if depth <= 0 {
+		depth = functions.DefaultMaxDepth
+	}
+	return min(depth, s.MaxInvokeDepth)
+}
+
+// restrict applies the limits of the profile to the supplied function processor.
+func (s *SandboxProfile) restrict(p *functions.Processor) {
+	if s == nil {
+		return
+	}
+	p.DisableFunctions(s.disabledReason(), s.DisabledFunctions...)
+	p.SetMaxCollectionSize(s.MaxCollectionSize)
+}
+
+// sandboxContext returns a child of the supplied context in which the functions disabled by the sandbox profile fail
+// when called, including the ones like stable and changed that are not provided by the function processor.
+func (e *Evaluator) sandboxContext(ctx *hcl.EvalContext) *hcl.EvalContext {
+	if e.sandbox == nil || len(e.sandbox.DisabledFunctions) == 0 {
+		return ctx
+	}
+	funcs := map[string]function.Function{}
+	for _, name := range e.sandbox.DisabledFunctions {
+		funcs[name] = functions.DisabledFunction(name, e.sandbox.disabledReason())
+	}
+	ctx = ctx.NewChild()
+	ctx.Functions = funcs
+	return ctx
+}
+
+// checkCollectionSize returns an error if the supplied number of elements is more than the sandbox profile allows.
+func (e *Evaluator) checkCollectionSize(size int) error {
+	if e.sandbox == nil || e.sandbox.MaxCollectionSize <= 0 || size <= e.sandbox.MaxCollectionSize {
+		return nil
+	}
+	return fmt.Errorf("%d elements, more than the maximum of %d allowed by sandbox profile %q",
+		size, e.sandbox.MaxCollectionSize, e.sandbox.Name)
+}
+
+// addSandboxToContext adds the enforced sandbox profile to the response context, such that consumers can tell
+// which restrictions applied to the evaluation.
+func (e *Evaluator) addSandboxToContext(ret *fnv1.RunFunctionResponse) error {
+	if e.sandbox == nil {
+		return nil
+	}
+	b, err := json.Marshal(e.sandbox)
+	if err != nil {
+		return errors.Wrap(err, "marshal sandbox profile")
+	}
+	var profile map[string]any
+	if err := json.Unmarshal(b, &profile); err != nil {
+		return errors.Wrap(err, "unmarshal sandbox profile")
+	}
+	s, err := structpb.NewStruct(profile)
+	if err != nil {
+		return errors.Wrap(err, "convert sandbox profile")
+	}
+	if ret.Context == nil {
+		ret.Context = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	ret.Context.Fields[sandboxContextKey] = structpb.NewStructValue(s)
+	return nil
+}
@@ -0,0 +1,203 @@
+package evaluator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox(t *testing.T) {
+	profile := &evaluator.SandboxProfile{
+		Name:              "tenant",
+		DisabledFunctions: []string{"timestamp", "changed"},
+		MaxCollectionSize: 3,
+		MaxInvokeDepth:    4,
+	}
+	tests := []struct {
+		name string
+		hcl  string
+		err  string
+	}{
+		{
+			name: "allowed",
+			hcl: `
+resources cms {
+  for_each = range(3)
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { index = tostring(each.value) }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "disabled function",
+			hcl: `
+resource cm {
+  deterministic = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = timestamp() }
+  }
+}
+`,
+			err: `function timestamp is disabled: not allowed by sandbox profile "tenant"`,
+		},
+		{
+			name: "disabled function in user function",
+			hcl: `
+function stamp {
+  body = timestamp()
+}
+resource cm {
+  deterministic = false
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { created = invoke("stamp", {}) }
+  }
+}
+`,
+			err: "function timestamp is disabled",
+		},
+		{
+			name: "disabled evaluator function",
+			hcl: `
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { changed = tostring(changed("x", 1)) }
+  }
+}
+`,
+			err: "function changed is disabled",
+		},
+		{
+			name: "large function result",
+			hcl: `
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { count = tostring(length(range(4))) }
+  }
+}
+`,
+			err: "result has 4 elements, more than the maximum of 3",
+		},
+		{
+			name: "too many iterations",
+			hcl: `
+resources cms {
+  for_each = ["a", "b", "c", "d"]
+  template {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+    }
+  }
+}
+`,
+			err: `too many iterations for resource collection cms; 4 elements, more than the maximum of 3 allowed by sandbox profile "tenant"`,
+		},
+		{
+			name: "deep recursion",
+			hcl: `
+function count {
+  arg n {}
+  body = n == 0 ? 0 : invoke("count", { n = n - 1 })
+}
+resource cm {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { count = tostring(invoke("count", { n = 10 })) }
+  }
+}
+`,
+			err: "max depth 4 exceeded",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{Sandbox: profile})
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: test.hcl})
+			if test.err != "" {
+				// errors in resource bodies discard the resource instead of failing the evaluation
+				var messages []string
+				if err != nil {
+					messages = append(messages, err.Error())
+				}
+				for _, r := range res.GetResults() {
+					messages = append(messages, r.GetMessage())
+				}
+				assert.Contains(t, strings.Join(messages, "\n"), test.err)
+				assert.Empty(t, res.GetDesired().GetResources())
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, res.GetDesired().GetResources(), 3)
+			sandbox := res.GetContext().GetFields()["hcl.fn.crossplane.io/sandbox"].GetStructValue().AsMap()
+			assert.Equal(t, map[string]any{
+				"name":              "tenant",
+				"disabledFunctions": []any{"timestamp", "changed"},
+				"maxCollectionSize": float64(3),
+				"maxInvokeDepth":    float64(4),
+			}, sandbox)
+		})
+	}
+}
+
+func TestSandboxValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile evaluator.SandboxProfile
+		err     string
+	}{
+		{
+			name:    "default",
+			profile: evaluator.DefaultSandboxProfile,
+		},
+		{
+			name:    "no name",
+			profile: evaluator.SandboxProfile{},
+			err:     "sandbox profile: profile does not have a name",
+		},
+		{
+			name:    "unknown function",
+			profile: evaluator.SandboxProfile{Name: "tenant", DisabledFunctions: []string{"timestamps"}},
+			err:     `sandbox profile: cannot disable unknown function "timestamps", did you mean "timestamp"?`,
+		},
+		{
+			name:    "negative size",
+			profile: evaluator.SandboxProfile{Name: "tenant", MaxCollectionSize: -1},
+			err:     "sandbox profile: maxCollectionSize must not be negative, got -1",
+		},
+		{
+			name:    "large depth",
+			profile: evaluator.SandboxProfile{Name: "tenant", MaxInvokeDepth: 5000},
+			err:     "sandbox profile: maxInvokeDepth must not be larger than 1000, got 5000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := evaluator.New(evaluator.Options{Sandbox: &test.profile})
+			if test.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, test.err, err.Error())
+		})
+	}
+}
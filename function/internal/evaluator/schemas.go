@@ -1,8 +1,13 @@
 package evaluator
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/functions"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
 // file that declares schemas for various blocks
@@ -16,34 +21,54 @@ var (
 		{Type: blockResources, LabelNames: []string{"baseName"}},
 		{Type: blockComposite, LabelNames: []string{"object"}},
 		{Type: blockContext},
+		{Type: blockContexts},
 		{Type: blockRequirement, LabelNames: []string{"name"}},
+		{Type: blockObserve, LabelNames: []string{"name"}},
+		{Type: blockExportConn},
 	}
 
 	topOnlyBlocks = []hcl.BlockHeaderSchema{
 		{Type: blockFunction, LabelNames: []string{"name"}},
+		{Type: blockReadyDefault},
+		{Type: blockPolicy, LabelNames: []string{"name"}},
+		{Type: blockRequires},
+		{Type: blockAlias},
+		{Type: blockFileLocals},
+		{Type: blockDoc},
 	}
 	topLevelBlocks = append(baseGroupBlocks, topOnlyBlocks...)
 	// applicable to resource and template blocks.
 	resourceBlocks = []hcl.BlockHeaderSchema{
 		{Type: blockLocals},
 		{Type: blockReady},
+		{Type: blockReadyFromCondition},
 		{Type: blockComposite, LabelNames: []string{"object"}},
 		{Type: blockContext},
+		{Type: blockContexts},
 	}
 )
 
 var schemasByBlockType = map[string]*hcl.BodySchema{
-	blockGroup:       groupSchema(),
-	blockResource:    resourceSchema(),
-	blockResources:   resourcesSchema(),
-	blockComposite:   compositeSchema(),
-	blockContext:     contextSchema(),
-	blockTemplate:    templateSchema(),
-	blockReady:       readySchema(),
-	blockFunction:    functions.FunctionSchema(),
-	blockArg:         functions.ArgSchema(),
-	blockRequirement: requirementSchema(),
-	blockSelect:      selectSchema(),
+	blockGroup:              groupSchema(),
+	blockResource:           resourceSchema(),
+	blockResources:          resourcesSchema(),
+	blockComposite:          compositeSchema(),
+	blockContext:            contextSchema(),
+	blockContexts:           contextsSchema(),
+	blockTemplate:           templateSchema(),
+	blockReady:              readySchema(),
+	blockReadyDefault:       readyDefaultSchema(),
+	blockReadyFromCondition: readyFromConditionSchema(),
+	blockFunction:           functions.FunctionSchema(),
+	blockArg:                functions.ArgSchema(),
+	blockValidate:           functions.ValidateSchema(),
+	blockRequirement:        requirementSchema(),
+	blockSelect:             selectSchema(),
+	blockObserve:            observeSchema(),
+	blockExportConn:         exportConnectionSchema(),
+	blockPolicy:             policySchema(),
+	blockRequires:           requiresSchema(),
+	blockDoc:                docSchema(),
 }
 
 func topLevelSchema() *hcl.BodySchema {
@@ -57,6 +82,10 @@ func groupSchema() *hcl.BodySchema {
 		Blocks: baseGroupBlocks,
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrCondition},
+			{Name: attrWhen},
+			{Name: attrLabels},
+			{Name: attrNamePrefix},
+			{Name: attrDeterministic},
 		},
 	}
 }
@@ -65,22 +94,47 @@ func resourcesSchema() *hcl.BodySchema {
 	return &hcl.BodySchema{
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrCondition},
+			{Name: attrWhen},
+			{Name: attrLabels},
+			{Name: attrEnabled},
+			{Name: attrDeterministic},
 			{Name: attrForEach, Required: true},
 			{Name: attrName},
+			{Name: attrRenameFrom},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: blockLocals},
 			{Type: blockComposite, LabelNames: []string{"object"}},
 			{Type: blockTemplate},
 			{Type: blockContext},
+			{Type: blockContexts},
 		},
 	}
 }
 
+// templateAttributeHints explain where the attributes of resource blocks that template blocks do not allow belong.
+// A template is evaluated once for every item of its collection, so everything that decides whether or how the
+// collection is rendered is set on the enclosing resources block instead.
+var templateAttributeHints = map[string]string{
+	attrCondition: "Template blocks cannot have a condition. Set the condition on the enclosing resources block to skip the " +
+		"whole collection, or filter the for_each collection to skip some items, such as " +
+		"for_each = { for k, v in coll : k => v if v.enabled }.",
+	attrEnabled:                 "Set it on the enclosing resources block, it applies to all resources of the collection.",
+	attrWhen:                    "Set it on the enclosing resources block, it applies to all resources of the collection.",
+	attrLabels:                  "Set it on the enclosing resources block, it applies to all resources of the collection.",
+	attrDeterministic:           "Set it on the enclosing resources block, it applies to all resources of the collection.",
+	attrResourceName:            "Use the name attribute of the enclosing resources block to name the resources of the collection.",
+	attrExternalName:            "External names are only supported for resource blocks.",
+	attrAllowExternalNameChange: "External names are only supported for resource blocks.",
+}
+
+// templateSchema returns the schema of template blocks, which have the body and child blocks of a resource block
+// but none of the attributes that control whether and under which name it is rendered.
 func templateSchema() *hcl.BodySchema {
 	return &hcl.BodySchema{
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrBody, Required: true},
+			{Name: attrWaitFor},
 		},
 		Blocks: resourceBlocks,
 	}
@@ -91,6 +145,14 @@ func resourceSchema() *hcl.BodySchema {
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrBody, Required: true},
 			{Name: attrCondition},
+			{Name: attrWhen},
+			{Name: attrLabels},
+			{Name: attrEnabled},
+			{Name: attrDeterministic},
+			{Name: attrResourceName},
+			{Name: attrExternalName},
+			{Name: attrAllowExternalNameChange},
+			{Name: attrWaitFor},
 		},
 		Blocks: resourceBlocks,
 	}
@@ -104,6 +166,21 @@ func contextSchema() *hcl.BodySchema {
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrKey, Required: true},
 			{Name: attrValue, Required: true},
+			{Name: attrSensitive},
+			{Name: attrWaitFor},
+		},
+	}
+}
+
+func contextsSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: blockLocals},
+		},
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrBody, Required: true},
+			{Name: attrSensitive},
+			{Name: attrWaitFor},
 		},
 	}
 }
@@ -114,11 +191,58 @@ func readySchema() *hcl.BodySchema {
 			{Type: blockLocals},
 		},
 		Attributes: []hcl.AttributeSchema{
+			{Name: attrCondition},
+			{Name: attrValue, Required: true},
+		},
+	}
+}
+
+func readyFromConditionSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrType},
+			{Name: attrStatus},
+		},
+	}
+}
+
+func readyDefaultSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: blockLocals},
+		},
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrAPIVersion},
+			{Name: attrKind},
 			{Name: attrValue, Required: true},
 		},
 	}
 }
 
+func policySchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: blockLocals},
+		},
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrTarget},
+			{Name: attrSeverity},
+			{Name: attrAPIVersion},
+			{Name: attrKind},
+			{Name: attrAssert, Required: true},
+			{Name: attrMessage},
+		},
+	}
+}
+
+func requiresSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrFunctionHCL, Required: true},
+		},
+	}
+}
+
 func compositeSchema() *hcl.BodySchema {
 	return &hcl.BodySchema{
 		Blocks: []hcl.BlockHeaderSchema{
@@ -126,6 +250,7 @@ func compositeSchema() *hcl.BodySchema {
 		},
 		Attributes: []hcl.AttributeSchema{
 			{Name: attrBody, Required: true},
+			{Name: attrWaitFor},
 		},
 	}
 }
@@ -152,3 +277,88 @@ func selectSchema() *hcl.BodySchema {
 		},
 	}
 }
+
+func observeSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: blockLocals},
+			{Type: blockComposite, LabelNames: []string{"object"}},
+			{Type: blockContext},
+			{Type: blockContexts},
+		},
+		Attributes: append([]hcl.AttributeSchema{
+			{Name: attrCondition},
+		}, selectSchema().Attributes...),
+	}
+}
+
+func exportConnectionSchema() *hcl.BodySchema {
+	return &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: blockLocals},
+		},
+		Attributes: []hcl.AttributeSchema{
+			{Name: attrCondition},
+			{Name: attrFrom, Required: true},
+			{Name: attrMap, Required: true},
+		},
+	}
+}
+
+// explainUnsupportedAttributes adds hints to the errors for attributes that the schema of the supplied body does not
+// allow. Such attributes usually belong to a nested block and end up at the wrong level because of an indentation
+// mistake or unbalanced braces.
+func explainUnsupportedAttributes(body hcl.Body, schema *hcl.BodySchema, diags hcl.Diagnostics) hcl.Diagnostics {
+	if f, ok := body.(*flagBody); ok {
+		body = f.Body
+	}
+	b, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return diags
+	}
+	for _, d := range diags {
+		if d.Summary != "Unsupported argument" || d.Subject == nil {
+			continue
+		}
+		for name, attr := range b.Attributes {
+			if attr.NameRange != *d.Subject {
+				continue
+			}
+			if hint := unsupportedAttributeHint(name, schema); hint != "" {
+				d.Detail += " " + hint
+			}
+		}
+	}
+	return diags
+}
+
+// unsupportedAttributeHint returns a hint for an attribute that the supplied schema does not allow, or an empty
+// string when there is none. HCL already has hints for typos and for attributes that are meant to be blocks.
+func unsupportedAttributeHint(name string, schema *hcl.BodySchema) string {
+	if hint, ok := templateAttributeHints[name]; ok && schema == schemasByBlockType[blockTemplate] {
+		return hint
+	}
+	for _, b := range schema.Blocks {
+		if b.Type == name {
+			return ""
+		}
+	}
+	var owners []string
+	for blockType, s := range schemasByBlockType {
+		for _, a := range s.Attributes {
+			if a.Name == name {
+				owners = append(owners, blockType)
+			}
+		}
+	}
+	sort.Strings(owners)
+	switch {
+	case len(owners) > 0:
+		return fmt.Sprintf("It is an attribute of %s blocks, check that it is inside the block that it belongs to "+
+			"and that the braces before it are balanced.", strings.Join(owners, ", "))
+	case len(schema.Attributes) == 0:
+		return "Only blocks are allowed here, use a locals block to declare values."
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,127 @@
+package evaluator
+
+import (
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/customdecode"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/gocty"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// stableFunction is the name of the function that waits a number of rounds for a value to become known
+// before falling back to a default.
+const stableFunction = "stable"
+
+// stableRoundsContextKey is the context key under which the number of consecutive rounds for which each stable
+// value was unknown is read from the request and written to the response.
+const stableRoundsContextKey = "hcl.fn.crossplane.io/stable-rounds"
+
+// stableRoundsStatusField is the field of the status of the composite under which the number of rounds is stored,
+// such that it is available to the next reconcile. Crossplane does not carry the pipeline context from one
+// reconcile to the next.
+const stableRoundsStatusField = "hclStableRounds"
+
+// stableRoundsFromRequest returns the number of rounds by name found in the status of the observed composite or,
+// failing that, in the supplied request context.
+func stableRoundsFromRequest(in *fnv1.RunFunctionRequest, c *structpb.Struct) map[string]int {
+	ret := map[string]int{}
+	status := in.GetObserved().GetComposite().GetResource().GetFields()["status"].GetStructValue()
+	rounds := status.GetFields()[stableRoundsStatusField].GetStructValue()
+	if rounds == nil {
+		rounds = c.GetFields()[stableRoundsContextKey].GetStructValue()
+	}
+	for name, v := range rounds.GetFields() {
+		if n, ok := v.GetKind().(*structpb.Value_NumberValue); ok {
+			ret[name] = int(n.NumberValue)
+		}
+	}
+	return ret
+}
+
+// stableValue returns the value of the supplied expression closure if it can be evaluated and is wholly known.
+// Otherwise, it records that the named value is unknown for one more round and returns the fallback once the
+// value was unknown for more than the supplied number of rounds, or an unknown value before that, such that
+// everything that depends on it is deferred.
+func (e *Evaluator) stableValue(name string, closure *customdecode.ExpressionClosure, fallback cty.Value, rounds int) cty.Value {
+	v, diags := closure.Value()
+	if !diags.HasErrors() && v.IsWhollyKnown() {
+		return v
+	}
+	n := e.stableRounds[name] + 1
+	e.unstableRounds[name] = n
+	if n > rounds {
+		return fallback
+	}
+	return cty.DynamicVal
+}
+
+// stableContext returns a child of the supplied context that has the stable function.
+func (e *Evaluator) stableContext(ctx *hcl.EvalContext) *hcl.EvalContext {
+	ctx = ctx.NewChild()
+	ctx.Functions = map[string]function.Function{
+		stableFunction: function.New(&function.Spec{
+			Description: "returns the value of an expression once it is known, or a fallback after it was unknown for a number of rounds",
+			Params: []function.Parameter{
+				{Name: "name", Type: cty.String},
+				{Name: "expression", Type: customdecode.ExpressionClosureType},
+				{Name: "fallback", Type: cty.DynamicPseudoType, AllowNull: true},
+				{Name: "rounds", Type: cty.Number},
+			},
+			Type: function.StaticReturnType(cty.DynamicPseudoType),
+			Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+				var rounds int
+				if err := gocty.FromCtyValue(args[3], &rounds); err != nil {
+					return cty.NilVal, function.NewArgErrorf(3, "rounds must be a whole number: %v", err)
+				}
+				if rounds < 0 {
+					return cty.NilVal, function.NewArgErrorf(3, "rounds must not be negative, got %d", rounds)
+				}
+				closure := customdecode.ExpressionClosureFromVal(args[1])
+				return e.stableValue(args[0].AsString(), closure, args[2], rounds), nil
+			},
+		}),
+	}
+	return ctx
+}
+
+// addStableRounds adds the number of rounds for which stable values were unknown to the status of the desired
+// composite and to the response context, such that they are available to the next round.
+func (e *Evaluator) addStableRounds(ret *fnv1.RunFunctionResponse) error {
+	if len(e.unstableRounds) == 0 {
+		return nil
+	}
+	rounds := map[string]any{}
+	for name, n := range e.unstableRounds {
+		rounds[name] = n
+	}
+	s, err := structpb.NewStruct(rounds)
+	if err != nil {
+		return errors.Wrap(err, "convert stable rounds")
+	}
+	if ret.Context == nil {
+		ret.Context = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	ret.Context.Fields[stableRoundsContextKey] = structpb.NewStructValue(s)
+
+	if ret.Desired == nil {
+		ret.Desired = &fnv1.State{}
+	}
+	if ret.Desired.Composite == nil {
+		ret.Desired.Composite = &fnv1.Resource{}
+	}
+	if ret.Desired.Composite.Resource == nil {
+		ret.Desired.Composite.Resource = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	xr := ret.Desired.Composite.Resource
+	status := xr.GetFields()["status"].GetStructValue()
+	if status == nil {
+		status = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		xr.Fields["status"] = structpb.NewStructValue(status)
+	}
+	status.Fields[stableRoundsStatusField] = structpb.NewStructValue(proto.Clone(s).(*structpb.Struct))
+	return nil
+}
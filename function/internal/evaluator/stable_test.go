@@ -0,0 +1,123 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStable(t *testing.T) {
+	hcl := `
+composite status {
+  body = {
+    arn  = stable("arn", req.resource.primary-bucket.status.arn, "none", 2)
+    size = stable("size", req.resource.primary-bucket.status.bucket_size, "unknown", 0)
+  }
+}
+`
+	withRounds := func(rounds map[string]any, inContext bool) func(*fnv1.RunFunctionRequest) {
+		return func(req *fnv1.RunFunctionRequest) {
+			if rounds == nil {
+				return
+			}
+			s, err := structpb.NewStruct(rounds)
+			require.NoError(t, err)
+			if inContext {
+				req.Context.Fields["hcl.fn.crossplane.io/stable-rounds"] = structpb.NewStructValue(s)
+				return
+			}
+			status := req.Observed.Composite.Resource.Fields["status"].GetStructValue()
+			status.Fields["hclStableRounds"] = structpb.NewStructValue(s)
+		}
+	}
+	tests := []struct {
+		name      string
+		rounds    map[string]any
+		inContext bool
+		status    map[string]any
+		next      map[string]any
+	}{
+		{
+			name: "first round",
+			next: map[string]any{"arn": float64(1)},
+		},
+		{
+			name:   "waiting",
+			rounds: map[string]any{"arn": 1},
+			next:   map[string]any{"arn": float64(2)},
+		},
+		{
+			name:   "fallback",
+			rounds: map[string]any{"arn": 2},
+			status: map[string]any{"arn": "none", "size": "1 litre"},
+			next:   map[string]any{"arn": float64(3)},
+		},
+		{
+			name:      "from context",
+			rounds:    map[string]any{"arn": 2},
+			inContext: true,
+			status:    map[string]any{"arn": "none", "size": "1 litre"},
+			next:      map[string]any{"arn": float64(3)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withRounds(test.rounds, test.inContext)), evaluator.File{Name: "main.hcl", Content: hcl})
+			require.NoError(t, err)
+			status := map[string]any{}
+			if test.status == nil {
+				assert.True(t, e.Incomplete())
+			}
+			for k, v := range test.status {
+				status[k] = v
+			}
+			// the rounds are kept in the status of the composite since Crossplane does not keep the context
+			status["hclStableRounds"] = test.next
+			assert.Equal(t, status, res.GetDesired().GetComposite().GetResource().AsMap()["status"])
+			assert.Equal(t, test.next, res.GetContext().AsMap()["hcl.fn.crossplane.io/stable-rounds"])
+		})
+	}
+}
+
+func TestStableKnownValue(t *testing.T) {
+	hcl := `
+composite status {
+  body = {
+    size = stable("size", req.resource.primary-bucket.status.bucket_size, "unknown", 0)
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"size": "1 litre"}, res.GetDesired().GetComposite().GetResource().AsMap()["status"])
+	_, ok := res.GetContext().AsMap()["hcl.fn.crossplane.io/stable-rounds"]
+	assert.False(t, ok)
+}
+
+func TestStableAnalyze(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+  }
+}
+composite status {
+  body = {
+    arn = stable("arn", req.resource.bucket.status.arn, "none", 2)
+  }
+}
+`})
+	assert.Empty(t, diags)
+}
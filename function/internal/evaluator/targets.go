@@ -0,0 +1,144 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// attrLabels is the attribute that labels a group, resource or resource collection such that evaluation can be
+// restricted to the blocks with some labels.
+const attrLabels = "labels"
+
+// serverMetadataFields are the metadata fields of an observed resource that are set by the API server and
+// removed when the resource is copied to the desired state.
+var serverMetadataFields = []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink"}
+
+// blockLabels returns the labels declared in the supplied content, which must be a constant list of strings.
+func blockLabels(content *hcl.BodyContent, blockType string) ([]string, hcl.Diagnostics) {
+	attr, ok := content.Attributes[attrLabels]
+	if !ok {
+		return nil, nil
+	}
+	v, _ := attr.Expr.Value(nil)
+	//nolint:staticcheck // using De Morgan's law makes code unreadable
+	if !(v.IsWhollyKnown() && !v.IsNull() && (v.Type().IsTupleType() || v.Type().IsListType())) {
+		return nil, hclutils.ToErrorDiag(fmt.Sprintf("%s in %s block is not a constant list of strings", attrLabels, blockType), "", attr.Expr.Range())
+	}
+	var ret []string
+	for _, el := range v.AsValueSlice() {
+		if el.IsNull() || el.Type() != cty.String {
+			return nil, hclutils.ToErrorDiag(fmt.Sprintf("%s in %s block is not a constant list of strings", attrLabels, blockType), "", attr.Expr.Range())
+		}
+		ret = append(ret, el.AsString())
+	}
+	return ret, nil
+}
+
+// isTargeted returns true if the block with the supplied content is evaluated. All blocks are evaluated when no
+// targets are set. Otherwise, a block is only evaluated when it, or one of its enclosing groups, has a label that
+// is one of the targets.
+func (e *Evaluator) isTargeted(content *hcl.BodyContent, blockType string) (bool, hcl.Diagnostics) {
+	labels, diags := blockLabels(content, blockType)
+	if diags.HasErrors() || e.targets == nil || e.targeted {
+		return true, diags
+	}
+	for _, l := range labels {
+		if e.targets[l] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// enterTarget marks the blocks of a group as targeted while it is processed when the group is targeted. It returns
+// a function that must be called when processing of the group is done.
+func (e *Evaluator) enterTarget(content *hcl.BodyContent) (func(), hcl.Diagnostics) {
+	targeted, diags := e.isTargeted(content, blockGroup)
+	if diags.HasErrors() || e.targets == nil || e.targeted || !targeted {
+		return func() {}, diags
+	}
+	e.targeted = true
+	return func() { e.targeted = false }, nil
+}
+
+// keepUntargeted records that the resources with the supplied names were not evaluated because the block that
+// declares them is not targeted. Unless untargeted resources are skipped, the observed state of each of them is
+// copied to the desired state such that it is neither changed nor deleted.
+func (e *Evaluator) keepUntargeted(block *hcl.Block, dt DiscardType, name string, resourceNames []string) hcl.Diagnostics {
+	var kept []string
+	if !e.skipUntargeted {
+		var diags hcl.Diagnostics
+		kept, diags = e.keepObserved(block, resourceNames)
+		if diags.HasErrors() {
+			return diags
+		}
+	}
+	msg := "not targeted, skipped"
+	if len(kept) > 0 {
+		msg = fmt.Sprintf("not targeted, kept observed state of %s", strings.Join(kept, ", "))
+	}
+	e.discard(DiscardItem{
+		Type:        dt,
+		Reason:      discardReasonUntargeted,
+		Name:        name,
+		SourceRange: block.DefRange.String(),
+		Context:     []string{msg},
+	})
+	return nil
+}
+
+// keepObserved copies the observed state of the resources with the supplied names that exist and are not already
+// desired to the desired state, such that they are neither changed nor deleted. It returns the names of the
+// resources that were kept.
+func (e *Evaluator) keepObserved(block *hcl.Block, resourceNames []string) ([]string, hcl.Diagnostics) {
+	var kept []string
+	for _, resourceName := range resourceNames {
+		observed := e.observedResources[resourceName]
+		if observed == cty.NilVal || observed.IsNull() || e.desiredResources[resourceName] != nil {
+			continue
+		}
+		body, err := valueToStruct(observed)
+		if err != nil {
+			return nil, hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("unable to convert observed resource to struct: %s", resourceName),
+				Subject:  ptr(block.DefRange),
+			}}
+		}
+		delete(body.Fields, "status")
+		if meta := body.GetFields()["metadata"].GetStructValue(); meta != nil {
+			for _, f := range serverMetadataFields {
+				delete(meta.Fields, f)
+			}
+		}
+		e.desiredResources[resourceName] = body
+		kept = append(kept, resourceName)
+	}
+	return kept, nil
+}
+
+// processUntargetedResource handles a resource block that is not targeted.
+func (e *Evaluator) processUntargetedResource(ctx *hcl.EvalContext, block *hcl.Block, content *hcl.BodyContent) hcl.Diagnostics {
+	resourceName, diags := e.resourceName(ctx, block, content)
+	if diags.HasErrors() {
+		return diags
+	}
+	return diags.Extend(e.keepUntargeted(block, discardTypeResource, resourceName, []string{resourceName}))
+}
+
+// processUntargetedResources handles a resource collection that is not targeted.
+func (e *Evaluator) processUntargetedResources(block *hcl.Block, baseName string) hcl.Diagnostics {
+	return e.keepUntargeted(block, discardTypeResourceList, baseName, e.collectionResourceNames[baseName])
+}
+
+// toTargetSet converts a list of targets to a set. An empty list returns a nil set.
+func toTargetSet(targets []string) map[string]bool {
+	if len(targets) == 0 {
+		return nil
+	}
+	return toFlagSet(targets)
+}
@@ -0,0 +1,155 @@
+package evaluator_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const targetsHCL = `
+resource core {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { version = "2" }
+  }
+}
+group {
+  labels = ["network"]
+  resource vpc {
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { version = "2" }
+    }
+  }
+}
+group {
+  resource dns {
+    labels = ["network", "dns"]
+    body = {
+      apiVersion = "v1"
+      kind       = "ConfigMap"
+      data       = { version = "2" }
+    }
+  }
+  resources workers {
+    for_each = ["a", "b"]
+    template {
+      body = {
+        apiVersion = "v1"
+        kind       = "ConfigMap"
+        data       = { version = "2" }
+      }
+    }
+  }
+}
+`
+
+func targetsObserved() map[string]map[string]any {
+	ret := map[string]map[string]any{}
+	for name, base := range map[string]string{"core": "", "vpc": "", "workers-0": "workers"} {
+		ret[name] = map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "xr-" + name, "uid": "1234", "resourceVersion": "42"},
+			"data":       map[string]any{"version": "1"},
+			"status":     map[string]any{"id": "id-" + name},
+		}
+		if base != "" {
+			collectionMember(ret[name], base, "s000000")
+		}
+	}
+	return ret
+}
+
+func TestTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     evaluator.Options
+		versions map[string]string
+		discards []string
+	}{
+		{
+			name:     "no targets",
+			versions: map[string]string{"core": "2", "vpc": "2", "dns": "2", "workers-0": "2", "workers-1": "2"},
+		},
+		{
+			name:     "group and resource labels",
+			opts:     evaluator.Options{Targets: []string{"network"}},
+			versions: map[string]string{"core": "1", "vpc": "2", "dns": "2", "workers-0": "1"},
+			discards: []string{"resource core", "resources workers"},
+		},
+		{
+			name:     "resource label",
+			opts:     evaluator.Options{Targets: []string{"dns"}},
+			versions: map[string]string{"core": "1", "vpc": "1", "dns": "2", "workers-0": "1"},
+			discards: []string{"resource core", "resource vpc", "resources workers"},
+		},
+		{
+			name:     "skip untargeted",
+			opts:     evaluator.Options{Targets: []string{"dns"}, SkipUntargeted: true},
+			versions: map[string]string{"dns": "2"},
+			discards: []string{"resource core", "resource vpc", "resources workers"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.opts.DiscardsInContext = true
+			e, err := evaluator.New(test.opts)
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, targetsObserved())), evaluator.File{Name: "main.hcl", Content: targetsHCL})
+			require.NoError(t, err)
+			assert.False(t, e.Incomplete())
+
+			versions := map[string]string{}
+			for name, r := range res.GetDesired().GetResources() {
+				body := r.GetResource().AsMap()
+				versions[name] = body["data"].(map[string]any)["version"].(string)
+				assert.NotContains(t, body, "status")
+				if meta, ok := body["metadata"].(map[string]any); ok {
+					assert.NotContains(t, meta, "uid")
+					assert.NotContains(t, meta, "resourceVersion")
+				}
+			}
+			assert.Equal(t, test.versions, versions)
+
+			var discards []string
+			ctxDiscards, _ := res.GetContext().AsMap()["hcl.fn.crossplane.io/discards"].([]any)
+			for _, d := range ctxDiscards {
+				m := d.(map[string]any)
+				assert.Equal(t, "untargeted", m["reason"])
+				discards = append(discards, m["type"].(string)+" "+m["name"].(string))
+			}
+			sort.Strings(discards)
+			assert.Equal(t, test.discards, discards)
+		})
+	}
+}
+
+func TestTargetsInvalidLabels(t *testing.T) {
+	hcl := `
+resource core {
+  labels = "network"
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+`
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: hcl})
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "labels in resource block is not a constant list of strings")
+
+	e, err = evaluator.New(evaluator.Options{Targets: []string{"network"}})
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON), evaluator.File{Name: "main.hcl", Content: hcl})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "labels in resource block is not a constant list of strings")
+}
@@ -4,15 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"sort"
-	"strings"
+	"unicode/utf8"
 
+	"github.com/crossplane-contrib/function-hcl/function/internal/secrets"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/pkg/errors"
 	"github.com/zclconf/go-cty/cty"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
-	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -38,11 +39,14 @@ func makeTables(ctx *hcl.EvalContext) map[string]DynamicObject {
 }
 
 // extractSymbolTable returns a map of values keyed by symbols under a specific namespace (e.g. `self` or `req`)
-// It expects the top level entry to be an object. It will panic if this is not the case.
+// It expects the top level entry to be an object or unknown. It will panic if this is not the case.
 func extractSymbolTable(ctx *hcl.EvalContext, namespace string) DynamicObject {
 	for ctx != nil {
 		symbols, ok := ctx.Variables[namespace]
 		if ok {
+			if !symbols.IsKnown() {
+				return DynamicObject{}
+			}
 			return symbols.AsValueMap()
 		}
 		ctx = ctx.Parent()
@@ -64,17 +68,67 @@ func createSelfChildContext(ctx *hcl.EvalContext, vars DynamicObject) *hcl.EvalC
 	return child
 }
 
+// maxSafeInteger is the largest integer such that it and all smaller integers can be represented exactly as a float64.
+const maxSafeInteger = 1 << 53
+
+// unmarshalJSON unmarshals the supplied JSON into a Go value while preserving large integers. Since protobuf structs
+// store all numbers as doubles, integers with a magnitude larger than maxSafeInteger would silently lose precision.
+// Such integers are returned as strings that contain all their digits. All other numbers are returned as float64.
+func unmarshalJSON(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var result any
+	if err := dec.Decode(&result); err != nil {
+		return nil, err
+	}
+	return preserveNumbers(result)
+}
+
+// preserveNumbers replaces JSON numbers in the supplied value as described for unmarshalJSON.
+func preserveNumbers(v any) (any, error) {
+	var err error
+	switch x := v.(type) {
+	case map[string]any:
+		for k, e := range x {
+			if x[k], err = preserveNumbers(e); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, e := range x {
+			if x[i], err = preserveNumbers(e); err != nil {
+				return nil, err
+			}
+		}
+	case json.Number:
+		if i, ok := new(big.Int).SetString(x.String(), 10); ok && i.CmpAbs(big.NewInt(maxSafeInteger)) > 0 {
+			return x.String(), nil
+		}
+		return x.Float64()
+	}
+	return v, nil
+}
+
 // valueToInterface returns the supplied dynamic value as a Go type.
 func valueToInterface(val cty.Value) (any, error) {
 	jsonBytes, err := ctyjson.Marshal(val, val.Type())
 	if err != nil {
 		return nil, err
 	}
-	var result any
-	if err = json.Unmarshal(jsonBytes, &result); err != nil {
-		return nil, err
+	return unmarshalJSON(jsonBytes)
+}
+
+// objectToValue returns the supplied object as a dynamic value.
+func objectToValue(obj Object) (cty.Value, error) {
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return cty.NilVal, err
 	}
-	return result, nil
+	impliedType, err := ctyjson.ImpliedType(jsonBytes)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(jsonBytes, impliedType)
 }
 
 // valueToStruct returns the supplied value as a protobuf struct.
@@ -83,11 +137,20 @@ func valueToStruct(val cty.Value) (*structpb.Struct, error) {
 	if err != nil {
 		return nil, err
 	}
-	var result structpb.Struct
-	if err := protojson.Unmarshal(jsonBytes, &result); err != nil {
+	return jsonToStruct(jsonBytes)
+}
+
+// jsonToStruct returns a protobuf struct for the supplied JSON object, preserving large integers.
+func jsonToStruct(jsonBytes []byte) (*structpb.Struct, error) {
+	result, err := unmarshalJSON(jsonBytes)
+	if err != nil {
 		return nil, err
 	}
-	return &result, nil
+	obj, ok := result.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", result)
+	}
+	return structpb.NewStruct(obj)
 }
 
 // valueToStructWithAnnotations returns the supplied dynamic value as a protobuf struct after
@@ -102,10 +165,14 @@ func valueToStructWithAnnotations(val cty.Value, a map[string]string) (*structpb
 		return nil, errors.Wrap(err, "marshal cty to json")
 	}
 
-	var result map[string]any
-	if err = json.Unmarshal(jsonBytes, &result); err != nil {
+	v, err := unmarshalJSON(jsonBytes)
+	if err != nil {
 		return nil, errors.Wrap(err, "unmarshal cty to json")
 	}
+	result, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
 
 	meta, ok := result["metadata"]
 	if !ok {
@@ -159,9 +226,9 @@ func extractIterations(forEachValue cty.Value) ([]iteration, error) {
 		}
 	case forEachValue.Type().IsMapType() || forEachValue.Type().IsObjectType():
 		elements := forEachValue.AsValueMap()
-		for keyStr, value := range elements {
+		for _, keyStr := range sortedKeys(elements) {
 			key := cty.StringVal(keyStr)
-			ret = append(ret, iteration{key: key, value: value})
+			ret = append(ret, iteration{key: key, value: elements[keyStr]})
 		}
 	case forEachValue.Type().IsSetType():
 		// convert set to list first, then iterate
@@ -183,7 +250,8 @@ func unify(inputs ...Object) (Object, error) {
 	unifyObjects = func(path string, objects ...Object) (Object, error) {
 		ret := Object{}
 		for _, obj := range objects {
-			for k, v := range obj {
+			for _, k := range sortedKeys(obj) {
+				v := obj[k]
 				currentPath := k
 				if path != "" {
 					currentPath = fmt.Sprintf("%s.%s", path, k)
@@ -224,7 +292,8 @@ func unify(inputs ...Object) (Object, error) {
 func unifyBytes(inputs ...map[string][]byte) (map[string][]byte, error) {
 	ret := map[string][]byte{}
 	for _, input := range inputs {
-		for k, v := range input {
+		for _, k := range sortedKeys(input) {
+			v := input[k]
 			existing, ok := ret[k]
 			if !ok {
 				ret[k] = v
@@ -238,56 +307,38 @@ func unifyBytes(inputs ...map[string][]byte) (map[string][]byte, error) {
 	return ret, nil
 }
 
-// findUnknownPaths walks the value and returns a list of paths to unknown values.
-func findUnknownPaths(val cty.Value) ([]string, error) {
-	var unknownPaths []string
-	if err := cty.Walk(val, func(path cty.Path, v cty.Value) (bool, error) {
-		if !v.IsKnown() {
-			unknownPaths = append(unknownPaths, path2String(path))
-			return true, nil
-		}
-		return true, nil
-	}); err != nil {
-		return unknownPaths, err
-	}
-
-	return unknownPaths, nil
+// ptr returns a pointer to the supplied value.
+func ptr[T any](v T) *T {
+	return &v
 }
 
-// unknownSegmentMarker is used to represent segments we don't support decoding.
-const unknownSegmentMarker = "<?>"
-
-// path2String converts a cty.Path to a human-readable string.
-func path2String(path cty.Path) string {
-	segments := make([]string, 0, len(path))
+// sortedKeys returns the keys of the supplied map in sorted order, such that iterating over them produces the
+// same results and errors on every run.
+func sortedKeys[V any](m map[string]V) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
 
-	for _, p := range path {
-		switch s := p.(type) {
-		case cty.GetAttrStep:
-			segments = append(segments, fmt.Sprintf(".%s", s.Name))
-		case cty.IndexStep:
-			if !s.Key.IsKnown() {
-				segments = append(segments, unknownSegmentMarker)
-				continue
-			}
-			switch s.Key.Type() {
-			case cty.String:
-				segments = append(segments, fmt.Sprintf("[%s]", s.Key.AsString()))
-			case cty.Number:
-				segments = append(segments, fmt.Sprintf("[%s]", s.Key.AsBigFloat().Text('f', 0)))
-			default:
-				segments = append(segments, unknownSegmentMarker)
-			}
-		default:
-			segments = append(segments, unknownSegmentMarker)
-		}
+// setKeys returns the keys of the supplied set.
+func setKeys(set map[string]bool) []string {
+	var ret []string
+	for k := range set {
+		ret = append(ret, k)
 	}
-	return strings.Join(segments, "")
+	return ret
 }
 
-// ptr returns a pointer to the supplied value.
-func ptr[T any](v T) *T {
-	return &v
+// blockTypes returns the block types allowed by the supplied schema.
+func blockTypes(schema *hcl.BodySchema) []string {
+	var ret []string
+	for _, b := range schema.Blocks {
+		ret = append(ret, b.Type)
+	}
+	return ret
 }
 
 // sortDiagsBySeverity sorts the supplied diagnostics by severity.
@@ -297,3 +348,44 @@ func sortDiagsBySeverity(diags hcl.Diagnostics) hcl.Diagnostics {
 	})
 	return diags
 }
+
+// maxPreviewLength is the maximum length of a value preview in error messages.
+const maxPreviewLength = 80
+
+// previewValue returns a short JSON representation of the supplied value for use in error messages. Marked values
+// and values that look like credentials are redacted, and long values are truncated at a character boundary.
+func previewValue(v cty.Value) string {
+	if v.ContainsMarked() {
+		return redactedValue
+	}
+	if v.IsNull() {
+		return "null"
+	}
+	if !v.IsWhollyKnown() {
+		return "(unknown)"
+	}
+	b, err := ctyjson.Marshal(v, v.Type())
+	if err != nil {
+		return "(" + v.Type().FriendlyName() + ")"
+	}
+	if len(secrets.Scan(b)) > 0 {
+		return redactedValue
+	}
+	s := string(b)
+	if len(s) > maxPreviewLength {
+		end := maxPreviewLength
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		s = s[:end] + "..."
+	}
+	return s
+}
+
+// valueTypeName returns the friendly name of the type of the supplied value, or "null" for null values.
+func valueTypeName(v cty.Value) string {
+	if v.IsNull() {
+		return "null"
+	}
+	return v.Type().FriendlyName()
+}
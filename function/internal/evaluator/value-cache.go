@@ -0,0 +1,122 @@
+package evaluator
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// maxCachedBytes is the maximum total size of the JSON that the values in the conversion cache were converted
+// from. The converted values take a multiple of that, so this bounds the memory used by the cache.
+const maxCachedBytes = 32 << 20
+
+// valueCache is a concurrency-safe LRU cache of cty values keyed by a hash of the JSON they were converted from.
+// Since observed resources mostly do not change between reconciles, caching their conversions avoids the cost
+// of type inference for every request for large composites. The cache is bounded by the total size of the JSON
+// of its values, rather than by their number, such that a few large composites cannot use unbounded memory.
+type valueCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	ll       *list.List
+	items    map[[sha256.Size]byte]*list.Element
+}
+
+type cacheEntry struct {
+	key  [sha256.Size]byte
+	val  cty.Value
+	size int
+}
+
+func newValueCache(maxBytes int) *valueCache {
+	return &valueCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+func (c *valueCache) get(key [sha256.Size]byte) (cty.Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return cty.NilVal, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).val, true
+}
+
+// put adds the supplied value that was converted from JSON of the supplied size to the cache, evicting the least
+// recently used values as needed. Values larger than the cache are not added.
+func (c *valueCache) put(key [sha256.Size]byte, val cty.Value, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+	if size > c.maxBytes {
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, val: val, size: size})
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		last := c.ll.Back()
+		entry := last.Value.(*cacheEntry)
+		c.ll.Remove(last)
+		delete(c.items, entry.key)
+		c.bytes -= entry.size
+	}
+}
+
+func (c *valueCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// conversionCache is shared across evaluators such that conversions are reused between requests.
+var conversionCache = newValueCache(maxCachedBytes)
+
+// toCtyValue converts the supplied JSON-serializable value to a cty value with an implied type, reusing
+// prior conversions of identical values.
+func toCtyValue(v any) (cty.Value, error) {
+	return toCtyValueOfType(v, cty.NilType)
+}
+
+// toCtyValueOfType converts the supplied JSON-serializable value to a cty value of the supplied type, reusing
+// prior conversions of identical values. The type is inferred from the value when it is cty.NilType.
+func toCtyValueOfType(v any, t cty.Type) (cty.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	h := sha256.New()
+	if t != cty.NilType {
+		h.Write([]byte(t.GoString()))
+	}
+	h.Write([]byte{0})
+	h.Write(b)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	if val, ok := conversionCache.get(key); ok {
+		return val, nil
+	}
+	if t == cty.NilType {
+		t, err = ctyjson.ImpliedType(b)
+		if err != nil {
+			return cty.NilVal, err
+		}
+	}
+	val, err := ctyjson.Unmarshal(b, t)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	conversionCache.put(key, val, len(b))
+	return val, nil
+}
@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+func TestValueCacheEviction(t *testing.T) {
+	c := newValueCache(20)
+	key := func(i int) [sha256.Size]byte {
+		return sha256.Sum256([]byte(fmt.Sprint(i)))
+	}
+	c.put(key(1), cty.NumberIntVal(1), 10)
+	c.put(key(2), cty.NumberIntVal(2), 5)
+	_, ok := c.get(key(1)) // makes 2 the least recently used entry
+	require.True(t, ok)
+	c.put(key(3), cty.NumberIntVal(3), 5)
+	assert.Equal(t, 3, c.len())
+	c.put(key(4), cty.NumberIntVal(4), 5)
+	assert.Equal(t, 3, c.len())
+	_, ok = c.get(key(2))
+	assert.False(t, ok)
+	v, ok := c.get(key(1))
+	require.True(t, ok)
+	assert.True(t, v.RawEquals(cty.NumberIntVal(1)))
+
+	// values larger than the cache are never added
+	c.put(key(5), cty.NumberIntVal(5), 21)
+	_, ok = c.get(key(5))
+	assert.False(t, ok)
+	assert.Equal(t, 3, c.len())
+}
+
+func TestToCtyValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{name: "nil", input: nil},
+		{name: "empty map", input: map[string]any{}},
+		{name: "resource", input: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "foo", "labels": map[string]any{"a": "b"}},
+			"data":       map[string]any{"count": 10, "list": []any{"x", 1, true}},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := json.Marshal(test.input)
+			require.NoError(t, err)
+			typ, err := ctyjson.ImpliedType(b)
+			require.NoError(t, err)
+			expected, err := ctyjson.Unmarshal(b, typ)
+			require.NoError(t, err)
+
+			first, err := toCtyValue(test.input)
+			require.NoError(t, err)
+			assert.True(t, expected.RawEquals(first))
+			size := conversionCache.len()
+			second, err := toCtyValue(test.input)
+			require.NoError(t, err)
+			assert.True(t, first.RawEquals(second))
+			assert.Equal(t, size, conversionCache.len())
+		})
+	}
+}
+
+func BenchmarkToCtyValue(b *testing.B) {
+	// a large observed resource, converted the way it is for every request
+	composite, _ := largeComposite(500)
+	b.Run("uncached", func(b *testing.B) {
+		for range b.N {
+			inferredValue(b, composite)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for range b.N {
+			if _, err := toCtyValue(composite); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package evaluator
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValuesFileExtension is the extension of files that contain values for the arg namespace instead of blocks.
+const ValuesFileExtension = ".hclvars"
+
+// IsValuesFile returns true if the supplied file name is the name of a values file.
+func IsValuesFile(name string) bool {
+	return filepath.Ext(name) == ValuesFileExtension
+}
+
+// splitValuesFiles separates values files from the other supplied files, preserving their order.
+func splitValuesFiles(files []File) (hclFiles, valuesFiles []File) {
+	for _, f := range files {
+		if IsValuesFile(f.Name) {
+			valuesFiles = append(valuesFiles, f)
+			continue
+		}
+		hclFiles = append(hclFiles, f)
+	}
+	return hclFiles, valuesFiles
+}
+
+// parseValues parses the supplied values files and returns the values they assign, keyed by name.
+// Values files may only contain attributes with constant values. A value assigned in more than one file is
+// taken from the last one, such that environment specific files can override defaults.
+func (e *Evaluator) parseValues(files []File) (DynamicObject, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	parser := hclparse.NewParser()
+	ret := DynamicObject{}
+	for _, file := range files {
+		hclFile, ds := parser.ParseHCL([]byte(file.Content), file.Name)
+		if ds.HasErrors() {
+			return nil, ds
+		}
+		e.files[file.Name] = hclFile
+		attrs, ds := hclFile.Body.JustAttributes()
+		if ds.HasErrors() {
+			return nil, ds
+		}
+		for _, attr := range sortedAttributes(attrs) {
+			if len(attr.Expr.Variables()) > 0 {
+				diags = diags.Extend(hclutils.ToErrorDiag(fmt.Sprintf("value %s must be a constant", attr.Name), "", attr.Expr.Range()))
+				continue
+			}
+			v, ds := attr.Expr.Value(&hcl.EvalContext{})
+			diags = diags.Extend(ds)
+			if ds.HasErrors() {
+				continue
+			}
+			ret[attr.Name] = v
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return ret, diags
+}
+
+// argContext returns a child context that has the supplied values under the arg namespace.
+func argContext(ctx *hcl.EvalContext, values DynamicObject) *hcl.EvalContext {
+	child := ctx.NewChild()
+	child.Variables = DynamicObject{
+		reservedArg: cty.ObjectVal(values),
+	}
+	return child
+}
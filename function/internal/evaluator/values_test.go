@@ -0,0 +1,138 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const valuesHCL = `
+resource bucket {
+  body = {
+    apiVersion = "aws.com/v1"
+    kind       = "S3Bucket"
+    spec = {
+      forProvider = {
+        region = arg.region
+        tags   = arg.tags
+        size   = try(arg.size, 10)
+      }
+    }
+  }
+}
+`
+
+func TestValuesEval(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON),
+		evaluator.File{Name: "main.hcl", Content: valuesHCL},
+		evaluator.File{Name: "defaults.hclvars", Content: `
+region = "us-west-2"
+tags   = { env = "dev" }
+size   = 20
+`},
+		evaluator.File{Name: "prod.hclvars", Content: `
+region = "us-east-1"
+tags   = { env = "prod", team = "storage" }
+`},
+	)
+	require.NoError(t, err)
+	bucket := res.Desired.Resources["bucket"].Resource.AsMap()
+	assert.Equal(t, map[string]any{
+		"region": "us-east-1",
+		"tags":   map[string]any{"env": "prod", "team": "storage"},
+		"size":   float64(20),
+	}, bucket["spec"].(map[string]any)["forProvider"])
+}
+
+func TestValuesEvalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values string
+		errMsg string
+	}{
+		{
+			name:   "reference",
+			values: `region = req.composite.spec.region`,
+			errMsg: "value region must be a constant",
+		},
+		{
+			name: "block",
+			values: `locals {
+  region = "us-east-1"
+}`,
+			errMsg: "Unexpected \"locals\" block",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{})
+			require.NoError(t, err)
+			_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON),
+				evaluator.File{Name: "main.hcl", Content: valuesHCL},
+				evaluator.File{Name: "values.hclvars", Content: test.values},
+			)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errMsg)
+		})
+	}
+}
+
+func TestValuesMissing(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON),
+		evaluator.File{Name: "main.hcl", Content: valuesHCL},
+		evaluator.File{Name: "values.hclvars", Content: `tags = {}`},
+	)
+	require.NoError(t, err)
+	assert.NotContains(t, res.Desired.Resources, "bucket")
+}
+
+func TestValuesAnalyze(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: valuesHCL})
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	diags = e.Analyze(
+		evaluator.File{Name: "main.hcl", Content: valuesHCL},
+		evaluator.File{Name: "values.hclvars", Content: `region = lower("US")`},
+	)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "Function calls not allowed")
+}
+
+func TestValuesAnalyzeSeedsArg(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(
+		evaluator.File{Name: "main.hcl", Content: valuesHCL},
+		evaluator.File{Name: "values.hclvars", Content: `
+region = "us-east-1"
+tags   = {}
+`},
+	)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, diags, 1)
+	assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+	assert.Equal(t, `value "size" is not set by any values file`, diags[0].Summary)
+
+	diags = e.Analyze(
+		evaluator.File{Name: "main.hcl", Content: valuesHCL},
+		evaluator.File{Name: "values.hclvars", Content: `
+regoin = "us-east-1"
+tags   = {}
+size   = 5
+`},
+	)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, diags, 1)
+	assert.Equal(t, `value "region" is not set by any values file`, diags[0].Summary)
+	assert.Equal(t, `arg.region, did you mean "regoin"?`, diags[0].Detail)
+}
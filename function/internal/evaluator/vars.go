@@ -1,15 +1,14 @@
 package evaluator
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/pkg/errors"
 	"github.com/zclconf/go-cty/cty"
-	ctyjson "github.com/zclconf/go-cty/cty/json"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -18,6 +17,30 @@ type nameIndex struct {
 	index string
 }
 
+// splitIndex splits a collection index annotation into its prefix and the number that it ends with.
+func splitIndex(index string) (prefix string, number uint64, ok bool) {
+	pos := len(index)
+	for pos > 0 && index[pos-1] >= '0' && index[pos-1] <= '9' {
+		pos--
+	}
+	n, err := strconv.ParseUint(index[pos:], 10, 64)
+	if err != nil {
+		return index, 0, false
+	}
+	return index[:pos], n, true
+}
+
+// indexLess compares collection index annotations by the numbers they end with, such that the order does not
+// depend on the padding of the numbers. Annotations without numbers are compared as strings.
+func indexLess(a, b string) bool {
+	prefixA, numA, okA := splitIndex(a)
+	prefixB, numB, okB := splitIndex(b)
+	if !okA || !okB || prefixA != prefixB {
+		return a < b
+	}
+	return numA < numB
+}
+
 func (e *Evaluator) trackBaseNames(observedResources map[string]any) (map[string][]string, error) {
 	out := map[string][]nameIndex{}
 	for name, res := range observedResources {
@@ -41,7 +64,7 @@ func (e *Evaluator) trackBaseNames(observedResources map[string]any) (map[string
 	}
 	for _, v := range out {
 		sort.Slice(v, func(i, j int) bool {
-			return v[i].index < v[j].index
+			return indexLess(v[i].index, v[j].index)
 		})
 	}
 	ret := map[string][]string{}
@@ -65,51 +88,73 @@ func (e *Evaluator) makeVars(parent *hcl.EvalContext, in *fnv1.RunFunctionReques
 		return m
 	}
 
+	// convert every resource individually such that conversions of resources that did not change
+	// between reconciles can be reused from the cache.
 	observedResourceMap := Object{}
-	observedConnectionMap := Object{}
+	resourceValues := DynamicObject{}
+	connectionValues := DynamicObject{}
 	for name, object := range in.GetObserved().GetResources() {
-		observedResourceMap[name] = toObject(object)
-		observedConnectionMap[name] = object.GetConnectionDetails()
+		obj := toObject(object)
+		observedResourceMap[name] = obj
+		r, err := toCtyValue(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert observed resource %s", name)
+		}
+		c, err := toCtyValue(object.GetConnectionDetails())
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert connection details for %s", name)
+		}
+		resourceValues[name] = r
+		connectionValues[name] = c
 	}
-	extra := Object{}
+	extra := DynamicObject{}
 	for name, res := range in.GetExtraResources() {
-		resources := res.GetItems()
-		var coll []Object
-		for _, resource := range resources {
-			coll = append(coll, toObject(resource))
+		var coll []cty.Value
+		for _, resource := range res.GetItems() {
+			v, err := toCtyValue(toObject(resource))
+			if err != nil {
+				return nil, errors.Wrapf(err, "convert extra resource for %s", name)
+			}
+			coll = append(coll, v)
 		}
-		extra[name] = coll
+		if len(coll) == 0 {
+			extra[name] = cty.NullVal(cty.DynamicPseudoType)
+			continue
+		}
+		extra[name] = cty.TupleVal(coll)
 	}
 
 	baseNameMap, err := e.trackBaseNames(observedResourceMap)
 	if err != nil {
 		return nil, errors.Wrap(err, "get base collections")
 	}
+	e.collectionResourceNames = baseNameMap
 
-	out := Object{
-		reqContext:             in.GetContext().AsMap(),
-		reqComposite:           toObject(in.GetObserved().GetComposite()),
-		reqCompositeConnection: in.GetObserved().GetComposite().GetConnectionDetails(),
-		reqObservedResource:    observedResourceMap,
-		reqObservedConnection:  observedConnectionMap,
-		reqExtraResources:      extra,
+	topMap := DynamicObject{
+		reqObservedResource:   cty.ObjectVal(resourceValues),
+		reqObservedConnection: cty.ObjectVal(connectionValues),
+		reqExtraResources:     cty.ObjectVal(extra),
 	}
-	jsonBytes, err := json.Marshal(out)
-	if err != nil {
-		return nil, errors.Wrap(err, "marshal variables to json")
-	}
-
-	impliedType, err := ctyjson.ImpliedType(jsonBytes)
+	compositeObj := toObject(in.GetObserved().GetComposite())
+	e.observedCompositeSpec, _ = compositeObj["spec"].(Object)
+	composite, err := e.compositeToValue(compositeObj)
 	if err != nil {
-		return nil, errors.Wrap(err, "infer types from json")
+		return nil, err
 	}
-
-	varsValue, err := ctyjson.Unmarshal(jsonBytes, impliedType)
-	if err != nil {
-		return nil, errors.Wrap(err, "unmarshal json")
+	topMap[reqComposite] = composite
+	for key, v := range map[string]any{
+		reqContext:             e.requestContext(in.GetContext()).AsMap(),
+		reqCompositeConnection: in.GetObserved().GetComposite().GetConnectionDetails(),
+	} {
+		val, err := toCtyValue(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert %s", key)
+		}
+		topMap[key] = val
 	}
 
-	topMap := varsValue.AsValueMap()
+	e.observedResources = resourceValues
+	e.observedConnections = connectionValues
 	e.existingResourceMap = topMap[reqObservedResource].AsValueMap()
 	e.existingConnectionMap = topMap[reqObservedConnection].AsValueMap()
 
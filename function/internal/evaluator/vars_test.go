@@ -0,0 +1,26 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexLess(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "s000001", b: "s000002", expected: true},
+		{a: "s999999", b: "s1000000", expected: true},
+		{a: "s1000000", b: "s999999", expected: false},
+		{a: "9", b: "10", expected: true},
+		{a: "a-10", b: "b-2", expected: true},
+		{a: "foo", b: "bar", expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.a+"<"+test.b, func(t *testing.T) {
+			assert.Equal(t, test.expected, indexLess(test.a, test.b))
+		})
+	}
+}
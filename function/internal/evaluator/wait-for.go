@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator/hclutils"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// attrWaitFor is the attribute of resource, template, composite and context blocks that lists the values that must
+// be known before the block is processed, such as wait_for = [req.resource.vpc.status.atProvider.id].
+const attrWaitFor = "wait_for"
+
+// waitForExpressions returns the expressions listed in the wait_for attribute of the supplied content, which must
+// be a list.
+func waitForExpressions(content *hcl.BodyContent) ([]hcl.Expression, hcl.Diagnostics) {
+	attr, ok := content.Attributes[attrWaitFor]
+	if !ok {
+		return nil, nil
+	}
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		r := attr.Expr.Range()
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("%s must be a list of expressions", attrWaitFor),
+			Detail:   "list the values that must be known, such as [req.resource.vpc.status.atProvider.id]",
+			Subject:  r.Ptr(),
+		}}
+	}
+	return exprs, nil
+}
+
+// pendingWaits returns the source of the expressions in the wait_for attribute of the supplied content whose values
+// cannot be evaluated yet, are not known, or are null.
+func (e *Evaluator) pendingWaits(ctx *hcl.EvalContext, content *hcl.BodyContent) ([]string, hcl.Diagnostics) {
+	exprs, diags := waitForExpressions(content)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	var ret []string
+	for _, expr := range exprs {
+		// errors are expected for values of objects that do not exist yet, and are not reported
+		val, ds := expr.Value(ctx)
+		if ds.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+			ret = append(ret, e.sourceCode(expr.Range()))
+		}
+	}
+	return ret, nil
+}
+
+// discardWaiting records the block with the supplied content as discarded because of the supplied pending values,
+// and returns a warning that lists them.
+func (e *Evaluator) discardWaiting(content *hcl.BodyContent, dt DiscardType, name string, pending []string) hcl.Diagnostics {
+	attr := content.Attributes[attrWaitFor]
+	msg := fmt.Sprintf("waiting for %s", strings.Join(pending, ", "))
+	e.discard(DiscardItem{
+		Type:        dt,
+		Reason:      discardReasonIncomplete,
+		Name:        name,
+		SourceRange: attr.Range.String(),
+		Context:     []string{msg},
+	})
+	return hclutils.Categorize(hcl.Diagnostics{{
+		Severity: hcl.DiagWarning,
+		Summary:  msg,
+		Subject:  attr.Expr.Range().Ptr(),
+	}}, hclutils.CategoryIncompleteValue)
+}
+
+// waitFor returns true if all values in the wait_for attribute of the supplied content are known, and records the
+// block as discarded otherwise.
+func (e *Evaluator) waitFor(ctx *hcl.EvalContext, content *hcl.BodyContent, dt DiscardType, name string) (bool, hcl.Diagnostics) {
+	pending, diags := e.pendingWaits(ctx, content)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if len(pending) == 0 {
+		return true, nil
+	}
+	return false, e.discardWaiting(content, dt, name, pending)
+}
@@ -0,0 +1,149 @@
+package evaluator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const waitForHCL = `
+resource vpc {
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+resource subnet {
+  wait_for = [req.resource.vpc.status.id]
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    data       = { vpc = try(req.resource.vpc.status.id, "") }
+  }
+}
+composite status {
+  wait_for = [req.resource.subnet.status.id]
+  body = {
+    subnet = req.resource.subnet.status.id
+  }
+}
+context {
+  wait_for = [req.resource.subnet.status.id]
+  key      = "subnet"
+  value    = req.resource.subnet.status.id
+}
+`
+
+func waitForObserved(ids map[string]string) map[string]map[string]any {
+	ret := map[string]map[string]any{}
+	for name, id := range ids {
+		ret[name] = map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "xr-" + name},
+			"status":     map[string]any{"id": id},
+		}
+	}
+	return ret
+}
+
+func TestWaitFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		ids       map[string]string
+		resources []string
+		discards  map[string]string
+	}{
+		{
+			name:      "nothing observed",
+			resources: []string{"vpc"},
+			discards: map[string]string{
+				"resource subnet":   "waiting for req.resource.vpc.status.id",
+				"composite-status ": "waiting for req.resource.subnet.status.id",
+				"context ":          "waiting for req.resource.subnet.status.id",
+			},
+		},
+		{
+			name:      "vpc observed",
+			ids:       map[string]string{"vpc": "vpc-1"},
+			resources: []string{"subnet", "vpc"},
+			discards: map[string]string{
+				"composite-status ": "waiting for req.resource.subnet.status.id",
+				"context ":          "waiting for req.resource.subnet.status.id",
+			},
+		},
+		{
+			name:      "all observed",
+			ids:       map[string]string{"vpc": "vpc-1", "subnet": "subnet-1"},
+			resources: []string{"subnet", "vpc"},
+			discards:  map[string]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := evaluator.New(evaluator.Options{DiscardsInContext: true})
+			require.NoError(t, err)
+			res, err := e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, waitForObserved(test.ids))), evaluator.File{Name: "main.hcl", Content: waitForHCL})
+			require.NoError(t, err)
+			assert.Equal(t, len(test.discards) > 0, e.Incomplete())
+
+			var resources []string
+			for name := range res.GetDesired().GetResources() {
+				resources = append(resources, name)
+			}
+			assert.ElementsMatch(t, test.resources, resources)
+
+			discards := map[string]string{}
+			ctxDiscards, _ := res.GetContext().AsMap()["hcl.fn.crossplane.io/discards"].([]any)
+			for _, d := range ctxDiscards {
+				m := d.(map[string]any)
+				name, _ := m["name"].(string)
+				discards[m["type"].(string)+" "+name] = m["context"].([]any)[0].(string)
+			}
+			assert.Equal(t, test.discards, discards)
+			if len(test.discards) == 0 {
+				assert.Equal(t, "subnet-1", res.GetContext().AsMap()["subnet"])
+			}
+		})
+	}
+}
+
+func TestWaitForExistingResource(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	_, err = e.Eval(context.Background(), makeRequest(t, baseRequestJSON, withObserved(t, waitForObserved(map[string]string{"subnet": "subnet-1"}))), evaluator.File{Name: "main.hcl", Content: waitForHCL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "existing resource subnet could not be evaluated, abort (waiting for req.resource.vpc.status.id)")
+}
+
+func TestWaitForAnalysis(t *testing.T) {
+	e, err := evaluator.New(evaluator.Options{})
+	require.NoError(t, err)
+	diags := e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+resource foo {
+  wait_for = req.resource.bar.status.id
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+`})
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags.Error(), "wait_for must be a list of expressions")
+
+	diags = e.Analyze(evaluator.File{Name: "main.hcl", Content: `
+resource foo {
+  wait_for = [self.resource.status.id]
+  body = {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+  }
+}
+`})
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, diags, 1)
+	assert.Equal(t, "resource waits for its own observed resource, which does not exist before the first reconcile", diags[0].Summary)
+}
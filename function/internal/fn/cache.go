@@ -0,0 +1,152 @@
+package fn
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/response"
+	"google.golang.org/protobuf/proto"
+)
+
+// cacheStats are the counters of a response cache.
+type cacheStats struct {
+	hits   int64
+	misses int64
+}
+
+// hitRate returns the fraction of lookups that were served from the cache.
+func (s cacheStats) hitRate() float64 {
+	if s.hits+s.misses == 0 {
+		return 0
+	}
+	return float64(s.hits) / float64(s.hits+s.misses)
+}
+
+// defaultStatsInterval is the default minimum time between two log messages with the counters of the caches.
+const defaultStatsInterval = 5 * time.Minute
+
+// statsLog decides when the counters of the caches are logged, such that the hit rates can be monitored
+// without logging every request.
+type statsLog struct {
+	mu       sync.Mutex
+	interval time.Duration
+	now      func() time.Time
+	last     time.Time
+}
+
+// newStatsLog returns a stats log that is due once per supplied interval, starting with the first request.
+func newStatsLog(interval time.Duration) *statsLog {
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+	return &statsLog{interval: interval, now: time.Now}
+}
+
+// due returns true if the counters were not logged within the interval, and records that they are being logged.
+func (s *statsLog) due() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if !s.last.IsZero() && now.Sub(s.last) < s.interval {
+		return false
+	}
+	s.last = now
+	return true
+}
+
+type cacheEntry struct {
+	tag     string
+	res     *fnv1.RunFunctionResponse
+	expires time.Time
+}
+
+// responseCache is a least recently used cache of successful responses keyed by the request tag. Crossplane
+// computes the tag from the contents of the request, so requests with the same tag produce the same response.
+// A nil cache does not cache anything.
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	now     func() time.Time
+	order   *list.List // most recently used entries first
+	entries map[string]*list.Element
+	stats   cacheStats
+}
+
+// newResponseCache returns a cache that holds up to the supplied number of responses, or nil if the size is
+// not positive.
+func newResponseCache(size int) *responseCache {
+	if size <= 0 {
+		return nil
+	}
+	return &responseCache{
+		size:    size,
+		now:     time.Now,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// get returns a copy of the response cached for the supplied tag, if one exists and has not expired.
+func (c *responseCache) get(tag string) (*fnv1.RunFunctionResponse, bool) {
+	if c == nil || tag == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[tag]
+	if ok && c.now().After(el.Value.(*cacheEntry).expires) {
+		c.remove(el)
+		ok = false
+	}
+	if !ok {
+		c.stats.misses++
+		return nil, false
+	}
+	c.stats.hits++
+	c.order.MoveToFront(el)
+	return proto.Clone(el.Value.(*cacheEntry).res).(*fnv1.RunFunctionResponse), true
+}
+
+// put caches a copy of the supplied response for the supplied tag until the TTL of the response expires.
+func (c *responseCache) put(tag string, res *fnv1.RunFunctionResponse) {
+	if c == nil || tag == "" {
+		return
+	}
+	ttl := response.DefaultTTL
+	if t := res.GetMeta().GetTtl(); t != nil {
+		ttl = t.AsDuration()
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[tag]; ok {
+		c.remove(el)
+	}
+	c.entries[tag] = c.order.PushFront(&cacheEntry{
+		tag:     tag,
+		res:     proto.Clone(res).(*fnv1.RunFunctionResponse),
+		expires: c.now().Add(ttl),
+	})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *responseCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).tag)
+}
+
+// statistics returns the counters of the cache.
+func (c *responseCache) statistics() cacheStats {
+	if c == nil {
+		return cacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
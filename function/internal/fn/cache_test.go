@@ -0,0 +1,110 @@
+package fn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newResponseCache(2)
+	c.now = func() time.Time { return now }
+	resp := func(msg string) *fnv1.RunFunctionResponse {
+		return &fnv1.RunFunctionResponse{
+			Meta:    &fnv1.ResponseMeta{Ttl: durationpb.New(time.Minute)},
+			Results: []*fnv1.Result{{Message: msg}},
+		}
+	}
+
+	c.put("a", resp("a"))
+	c.put("b", resp("b"))
+	res, ok := c.get("a")
+	require.True(t, ok)
+	assert.Equal(t, "a", res.Results[0].Message)
+
+	// changes to a returned response do not affect the cached one
+	res.Results = nil
+	res, ok = c.get("a")
+	require.True(t, ok)
+	assert.Len(t, res.Results, 1)
+
+	// "b" is the least recently used entry
+	c.put("c", resp("c"))
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("c")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+
+	_, ok = c.get("")
+	assert.False(t, ok)
+	stats := c.statistics()
+	assert.Equal(t, cacheStats{hits: 3, misses: 2}, stats)
+	assert.Equal(t, 0.6, stats.hitRate())
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	var c *responseCache
+	assert.Nil(t, newResponseCache(0))
+	c.put("a", &fnv1.RunFunctionResponse{})
+	_, ok := c.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, cacheStats{}, c.statistics())
+}
+
+func TestStatsLog(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newStatsLog(time.Minute)
+	s.now = func() time.Time { return now }
+	assert.True(t, s.due())
+	assert.False(t, s.due())
+	now = now.Add(30 * time.Second)
+	assert.False(t, s.due())
+	now = now.Add(30 * time.Second)
+	assert.True(t, s.due())
+	assert.False(t, s.due())
+	assert.Equal(t, defaultStatsInterval, newStatsLog(0).interval)
+}
+
+func TestRunFunctionCachesResponses(t *testing.T) {
+	f, err := New(Options{CacheSize: 10})
+	require.NoError(t, err)
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	req := &fnv1.RunFunctionRequest{
+		Meta: &fnv1.RequestMeta{Tag: "tag-1"},
+		Input: toStruct(map[string]any{
+			"apiVersion": "hcl.fn.crossplane.io/v1beta1",
+			"kind":       "HclInput",
+			"hcl":        `resource bucket { body = { apiVersion = "s3.aws.upbound.io/v1beta1", kind = "Bucket" } }`,
+		}),
+		Observed: &fnv1.State{
+			Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "XBucket",
+				"metadata":   map[string]any{"name": "xr"},
+			})},
+		},
+	}
+	first, err := f.RunFunction(context.Background(), req)
+	require.NoError(t, err)
+	require.Contains(t, first.GetDesired().GetResources(), "bucket")
+
+	second, err := f.RunFunction(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, first.String(), second.String())
+	assert.Equal(t, cacheStats{hits: 1, misses: 1}, f.cache.statistics())
+}
@@ -3,6 +3,8 @@ package fn
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	input "github.com/crossplane-contrib/function-hcl/function/input/v1beta1"
 	"github.com/crossplane-contrib/function-hcl/function/internal/debug"
@@ -12,8 +14,11 @@ import (
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/crossplane/function-sdk-go/request"
 	"github.com/crossplane/function-sdk-go/response"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/pkg/errors"
 	"golang.org/x/tools/txtar"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -23,12 +28,34 @@ const debugAnnotation = "hcl.fn.crossplane.io/debug"
 type Options struct {
 	Logger logging.Logger
 	Debug  bool
+	Hooks  []evaluator.ResourceHook // hooks to check desired resources before they are returned
+	// CacheSize is the number of successful responses cached by request tag, such that identical repeated
+	// requests are answered without evaluating the script again. Caching is disabled when it is zero.
+	CacheSize int
+	// ProgramCacheSize is the number of function inputs whose compiled HCL is cached, such that the source of a
+	// composition is not parsed again for every request. Programs are not cached when it is zero.
+	ProgramCacheSize int
+	// CacheStatsInterval is the minimum time between two info messages with the hits and misses of the response
+	// and program caches. It defaults to 5 minutes.
+	CacheStatsInterval time.Duration
+	// CollectionKeyTransform transforms the keys of resource collections that are used in the default names of
+	// their resources. Keys are sanitized when not set.
+	CollectionKeyTransform func(key string) string
+	// Sandbox, when set, restricts what the expressions of all compositions may do, for platforms on which
+	// compositions are written by users who are not trusted. It cannot be changed by the function input.
+	Sandbox *evaluator.SandboxProfile
 }
 
 type Fn struct {
 	fnv1.UnimplementedFunctionRunnerServiceServer
-	log   logging.Logger
-	debug bool
+	log          logging.Logger
+	debug        bool
+	hooks        []evaluator.ResourceHook
+	cache        *responseCache
+	programs     *programCache
+	stats        *statsLog
+	keyTransform func(string) string
+	sandbox      *evaluator.SandboxProfile
 }
 
 // New creates a hcl runner.
@@ -40,14 +67,66 @@ func New(opts Options) (*Fn, error) {
 			return nil, err
 		}
 	}
+	if opts.Sandbox != nil {
+		if err := opts.Sandbox.Validate(); err != nil {
+			return nil, errors.Wrap(err, "sandbox profile")
+		}
+	}
 	return &Fn{
-		log:   opts.Logger,
-		debug: opts.Debug,
+		log:          opts.Logger,
+		debug:        opts.Debug,
+		hooks:        opts.Hooks,
+		cache:        newResponseCache(opts.CacheSize),
+		programs:     newProgramCache(opts.ProgramCacheSize),
+		stats:        newStatsLog(opts.CacheStatsInterval),
+		keyTransform: opts.CollectionKeyTransform,
+		sandbox:      opts.Sandbox,
 	}, nil
 }
 
+// parseDebugAnnotation returns whether the supplied value of the debug annotation enables debugging and the
+// debug options it sets. Debugging is only enabled for "true" and for valid settings, never for values like
+// "False" or "0", and never when the settings are invalid.
+func parseDebugAnnotation(value string) (bool, debug.Options, error) {
+	if value == "false" {
+		return false, debug.Options{}, nil
+	}
+	opts, err := debug.ParseOptions(value)
+	if err != nil {
+		return false, debug.Options{}, err
+	}
+	return true, opts, nil
+}
+
+// logCacheStats logs the hits and misses of the caches that are enabled at info level, at most once per
+// interval.
+func (f *Fn) logCacheStats() {
+	if (f.cache == nil && f.programs == nil) || !f.stats.due() {
+		return
+	}
+	var kv []any
+	if f.cache != nil {
+		s := f.cache.statistics()
+		kv = append(kv, "response-cache-hits", s.hits, "response-cache-misses", s.misses, "response-cache-hit-rate", s.hitRate())
+	}
+	if f.programs != nil {
+		s := f.programs.statistics()
+		kv = append(kv, "program-cache-hits", s.hits, "program-cache-misses", s.misses, "program-cache-hit-rate", s.hitRate())
+	}
+	f.log.Info("cache statistics", kv...)
+}
+
 // RunFunction runs the function.
-func (f *Fn) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest) (outRes *fnv1.RunFunctionResponse, finalErr error) {
+func (f *Fn) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest) (outRes *fnv1.RunFunctionResponse, finalErr error) {
+	defer f.logCacheStats()
+	tag := req.GetMeta().GetTag()
+	if res, ok := f.cache.get(tag); ok {
+		stats := f.cache.statistics()
+		f.log.Debug("returning cached response", "tag", tag, "cache-hits", stats.hits, "cache-misses", stats.misses,
+			"cache-hit-rate", stats.hitRate())
+		return res, nil
+	}
+
 	// setup response with desired state set up upstream functions
 	res := response.To(req, response.DefaultTTL)
 
@@ -57,19 +136,25 @@ func (f *Fn) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest) (outRe
 		if finalErr == nil {
 			logger.Info("hcl module executed successfully")
 			response.Normal(outRes, "hcl module executed successfully")
+			f.cache.put(tag, outRes)
 			return
 		}
 		logger.Info(finalErr.Error())
 		response.Fatal(res, finalErr)
 		outRes = res
 	}()
+	// convert panics into fatal results such that a single request cannot take down the server
+	defer func() {
+		if v := recover(); v != nil {
+			finalErr = fmt.Errorf("internal error: %v, stack: %s", v, evaluator.StackSummary())
+		}
+	}()
 
 	// setup logging and debugging
 	oxr, err := request.GetObservedCompositeResource(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "get observed composite")
 	}
-	tag := req.GetMeta().GetTag()
 	if tag != "" {
 		logger = f.log.WithValues("tag", tag)
 	}
@@ -80,9 +165,12 @@ func (f *Fn) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest) (outRe
 	)
 	logger.Info("Running Function")
 	debugThis := false
-	annotations := oxr.Resource.GetAnnotations()
-	if annotations != nil && annotations[debugAnnotation] == "true" {
-		debugThis = true
+	var debugOpts debug.Options
+	if value := oxr.Resource.GetAnnotations()[debugAnnotation]; value != "" {
+		debugThis, debugOpts, err = parseDebugAnnotation(value)
+		if err != nil {
+			logger.Info(fmt.Sprintf("ignoring invalid debug annotation value %q: %s", value, err.Error()))
+		}
 	}
 
 	// get inputs
@@ -90,21 +178,55 @@ func (f *Fn) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest) (outRe
 	if err := request.GetInput(req, in); err != nil {
 		return nil, errors.Wrap(err, "unable to get input")
 	}
-	if in.HCL == "" {
+	if in.HCL == "" && len(in.Files) == 0 {
 		return nil, fmt.Errorf("input HCL was not specified")
 	}
+	if in.HCL != "" && len(in.Files) > 0 {
+		return nil, fmt.Errorf("input must specify either hcl or files, not both")
+	}
+	var incompleteTTL time.Duration
+	if in.OnIncompleteTTL != "" {
+		incompleteTTL, err = time.ParseDuration(in.OnIncompleteTTL)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse onIncompleteTTL")
+		}
+		if incompleteTTL <= 0 {
+			return nil, fmt.Errorf("onIncompleteTTL must be positive, got %q", in.OnIncompleteTTL)
+		}
+	}
+	if in.MaxInvokeDepth < 0 {
+		return nil, fmt.Errorf("maxInvokeDepth must not be negative, got %d", in.MaxInvokeDepth)
+	}
+	if in.MaxInvokeDepth > evaluator.MaxInvokeDepthLimit {
+		return nil, fmt.Errorf("maxInvokeDepth must not be larger than %d, got %d", evaluator.MaxInvokeDepthLimit, in.MaxInvokeDepth)
+	}
+	if in.MaxDiscardsToDisplay < 0 {
+		return nil, fmt.Errorf("maxDiscardsToDisplay must not be negative, got %d", in.MaxDiscardsToDisplay)
+	}
+	if in.CollectionIndex != nil {
+		if in.CollectionIndex.Width < 0 {
+			return nil, fmt.Errorf("collectionIndex.width must not be negative, got %d", in.CollectionIndex.Width)
+		}
+		if in.CollectionIndex.Width > evaluator.MaxIndexWidth {
+			return nil, fmt.Errorf("collectionIndex.width must not be larger than %d, got %d", evaluator.MaxIndexWidth, in.CollectionIndex.Width)
+		}
+	}
 	if in.Debug || (in.DebugNew && len(req.GetObserved().GetResources()) == 0) {
 		debugThis = true
 	}
 
+	// sensitive context keys are only known after evaluation
+	var sensitiveKeys []string
 	if debugThis {
-		p := debug.New(debug.Options{})
+		p := debug.New(debugOpts)
 		err := p.Request(req)
 		if err != nil {
 			logger.Info(fmt.Sprintf("error printing request: %s", err.Error()))
 		}
 		defer func() {
 			if finalErr == nil {
+				debugOpts.SensitiveContextKeys = sensitiveKeys
+				p := debug.New(debugOpts)
 				responseErr := p.Response(req, outRes)
 				if responseErr != nil {
 					logger.Info(fmt.Sprintf("error printing response: %s", responseErr.Error()))
@@ -113,32 +235,167 @@ func (f *Fn) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest) (outRe
 		}()
 	}
 
-	var files []evaluator.File
-	archive := txtar.Parse([]byte(in.HCL))
-	for _, file := range archive.Files {
-		files = append(files, evaluator.File{Name: file.Name, Content: string(file.Data)})
+	schema, err := compositeSchema(in.CompositeSchema)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse compositeSchema")
 	}
-	if len(files) == 0 {
-		return nil, fmt.Errorf("no HCL input files found, are you using the txtar format?")
+	var indexFormat *evaluator.IndexFormat
+	if in.CollectionIndex != nil {
+		indexFormat = &evaluator.IndexFormat{Prefix: in.CollectionIndex.Prefix, Width: in.CollectionIndex.Width}
 	}
-
-	e, err := evaluator.New(evaluator.Options{
-		Logger: logger,
-		Debug:  debugThis,
+	e, files, done, err := f.newEvaluator(in, evaluator.Options{
+		Logger:                 logger,
+		Debug:                  debugThis,
+		Flags:                  in.Flags,
+		Hooks:                  f.hooks,
+		DiscardsInContext:      in.DiscardsInContext,
+		IndexFormat:            indexFormat,
+		CompositeSchema:        schema,
+		MaxInvokeDepth:         in.MaxInvokeDepth,
+		MaxDiscardsToDisplay:   in.MaxDiscardsToDisplay,
+		DiscardCountsOnly:      in.DiscardCountsOnly,
+		IsolateGroupErrors:     in.IsolateGroupErrors,
+		ContextNamespace:       in.ContextNamespace,
+		Targets:                in.Targets,
+		SkipUntargeted:         in.SkipUntargeted,
+		CollectionKeyTransform: f.keyTransform,
+		Sandbox:                f.sandbox,
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "create evaluator")
+		return nil, err
 	}
+	defer done()
 
-	evalRes, err := e.Eval(req, files...)
+	evalRes, err := e.Eval(ctx, req, files...)
+	sensitiveKeys = e.SensitiveContextKeys()
 	if err != nil {
 		return nil, errors.Wrap(err, "evaluate hcl")
 	}
-	r, err := f.mergeResponse(res, evalRes)
-	return r, err
+	r, err := f.mergeResponse(res, evalRes, in.MergeComposite)
+	if err != nil {
+		return nil, err
+	}
+	if in.ChangeSummary {
+		if err := addChangeSummary(req, r); err != nil {
+			return nil, errors.Wrap(err, "add change summary")
+		}
+	}
+	if in.ResourceSummary {
+		if err := addResourceSummary(r); err != nil {
+			return nil, errors.Wrap(err, "add resource summary")
+		}
+	}
+	if incompleteTTL > 0 && e.Incomplete() {
+		setShorterTTL(r, incompleteTTL)
+	}
+	return r, nil
+}
+
+// implicitFileName is the name of the file used when the HCL input is not in txtar format.
+const implicitFileName = "main.hcl"
+
+// sourceFiles returns the HCL files of the supplied input, from either the list of files or the txtar input.
+func sourceFiles(in *input.HclInput) ([]evaluator.File, error) {
+	if len(in.Files) == 0 {
+		return inputFiles(in.HCL)
+	}
+	seen := map[string]bool{}
+	files := make([]evaluator.File, 0, len(in.Files))
+	for i, f := range in.Files {
+		if f.Name == "" {
+			return nil, fmt.Errorf("input file at index %d has no name", i)
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("duplicate input file %q", f.Name)
+		}
+		seen[f.Name] = true
+		files = append(files, evaluator.File{Name: f.Name, Content: f.Content})
+	}
+	return files, nil
+}
+
+// inputFiles returns the files in the supplied HCL input. Input without any txtar file markers is treated
+// as a single implicit file, provided it is syntactically valid HCL.
+func inputFiles(source string) ([]evaluator.File, error) {
+	var files []evaluator.File
+	archive := txtar.Parse([]byte(source))
+	for _, file := range archive.Files {
+		files = append(files, evaluator.File{Name: file.Name, Content: string(file.Data)})
+	}
+	if len(files) > 0 {
+		return files, nil
+	}
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("no HCL input found")
+	}
+	_, diags := hclsyntax.ParseConfig([]byte(source), implicitFileName, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("HCL input has no txtar file markers and is not a valid HCL file: %s", diags.Error())
+	}
+	return []evaluator.File{{Name: implicitFileName, Content: source}}, nil
+}
+
+// valuesFiles returns the supplied values from the input as values files, named by their position in the input.
+func valuesFiles(values []string) []evaluator.File {
+	var files []evaluator.File
+	for i, v := range values {
+		files = append(files, evaluator.File{Name: fmt.Sprintf("input-values-%d%s", i, evaluator.ValuesFileExtension), Content: v})
+	}
+	return files
+}
+
+// setShorterTTL sets the TTL of the response to the supplied value if it is shorter than the current one.
+func setShorterTTL(res *fnv1.RunFunctionResponse, ttl time.Duration) {
+	if res.Meta == nil {
+		res.Meta = &fnv1.ResponseMeta{}
+	}
+	if current := res.Meta.GetTtl(); current != nil && current.AsDuration() <= ttl {
+		return
+	}
+	res.Meta.Ttl = durationpb.New(ttl)
 }
 
-func (f *Fn) mergeResponse(res *fnv1.RunFunctionResponse, hclResponse *fnv1.RunFunctionResponse) (*fnv1.RunFunctionResponse, error) {
+// mergeObjects merges the source object into the destination, recursively for nested objects. Values in the
+// source win over values in the destination.
+func mergeObjects(dst, src map[string]any) {
+	for k, v := range src {
+		srcObj, srcOK := v.(map[string]any)
+		dstObj, dstOK := dst[k].(map[string]any)
+		if srcOK && dstOK {
+			mergeObjects(dstObj, srcObj)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// mergeComposite merges the supplied composite into the desired composite of the state.
+func mergeComposite(desired *fnv1.State, composite *fnv1.Resource) error {
+	if desired.Composite == nil {
+		desired.Composite = composite
+		return nil
+	}
+	if composite.GetResource() != nil {
+		obj := desired.Composite.GetResource().AsMap()
+		mergeObjects(obj, composite.GetResource().AsMap())
+		s, err := structpb.NewStruct(obj)
+		if err != nil {
+			return errors.Wrap(err, "merge desired composite")
+		}
+		desired.Composite.Resource = s
+	}
+	if len(composite.GetConnectionDetails()) > 0 {
+		if desired.Composite.ConnectionDetails == nil {
+			desired.Composite.ConnectionDetails = map[string][]byte{}
+		}
+		for k, v := range composite.GetConnectionDetails() {
+			desired.Composite.ConnectionDetails[k] = v
+		}
+	}
+	return nil
+}
+
+func (f *Fn) mergeResponse(res *fnv1.RunFunctionResponse, hclResponse *fnv1.RunFunctionResponse, merge bool) (*fnv1.RunFunctionResponse, error) {
 	if res.Desired == nil {
 		res.Desired = &fnv1.State{}
 	}
@@ -146,10 +403,14 @@ func (f *Fn) mergeResponse(res *fnv1.RunFunctionResponse, hclResponse *fnv1.RunF
 		res.Desired.Resources = map[string]*fnv1.Resource{}
 	}
 
-	// only set desired composite if the evaluator script actually returns it.
-	// we assume that the evaluator sets the `status` attribute only.
-	if hclResponse.Desired.GetComposite() != nil {
-		res.Desired.Composite = hclResponse.Desired.GetComposite()
+	// only set desired composite if the evaluator script actually returns it. When asked to, merge its spec
+	// and status into the desired composite produced by previous functions in the pipeline instead of replacing it.
+	if composite := hclResponse.Desired.GetComposite(); composite != nil {
+		if !merge {
+			res.Desired.Composite = composite
+		} else if err := mergeComposite(res.Desired, composite); err != nil {
+			return nil, err
+		}
 	}
 
 	// set desired resources from hcl output
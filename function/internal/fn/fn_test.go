@@ -0,0 +1,286 @@
+package fn
+
+import (
+	"context"
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMergeComposite(t *testing.T) {
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	desired := &fnv1.State{
+		Composite: &fnv1.Resource{
+			Resource: toStruct(map[string]any{
+				"spec":   map[string]any{"region": "us-east-1", "size": "small"},
+				"status": map[string]any{"previous": "step"},
+			}),
+			ConnectionDetails: map[string][]byte{"user": []byte("admin")},
+		},
+	}
+	err := mergeComposite(desired, &fnv1.Resource{
+		Resource: toStruct(map[string]any{
+			"spec":   map[string]any{"size": "large"},
+			"status": map[string]any{"ready": true},
+		}),
+		ConnectionDetails: map[string][]byte{"password": []byte("secret")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"spec":   map[string]any{"region": "us-east-1", "size": "large"},
+		"status": map[string]any{"previous": "step", "ready": true},
+	}, desired.Composite.GetResource().AsMap())
+	assert.Equal(t, map[string][]byte{"user": []byte("admin"), "password": []byte("secret")}, desired.Composite.GetConnectionDetails())
+}
+
+func TestRunFunctionMergeComposite(t *testing.T) {
+	f, err := New(Options{})
+	require.NoError(t, err)
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	for _, merge := range []bool{false, true} {
+		req := &fnv1.RunFunctionRequest{
+			Input: toStruct(map[string]any{
+				"apiVersion":     "hcl.fn.crossplane.io/v1beta1",
+				"kind":           "HclInput",
+				"mergeComposite": merge,
+				"hcl": `
+composite status {
+  body = { ready = true }
+}
+`,
+			}),
+			Observed: &fnv1.State{
+				Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+					"apiVersion": "example.com/v1",
+					"kind":       "XBucket",
+					"metadata":   map[string]any{"name": "xr"},
+				})},
+			},
+			Desired: &fnv1.State{
+				Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+					"status": map[string]any{"previous": "step"},
+				})},
+			},
+		}
+		res, err := f.RunFunction(context.Background(), req)
+		require.NoError(t, err)
+		expected := map[string]any{"ready": true}
+		if merge {
+			expected["previous"] = "step"
+		}
+		assert.Equal(t, expected, res.GetDesired().GetComposite().GetResource().AsMap()["status"], "merge: %v", merge)
+	}
+}
+
+func TestRunFunctionValues(t *testing.T) {
+	f, err := New(Options{})
+	require.NoError(t, err)
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	req := &fnv1.RunFunctionRequest{
+		Input: toStruct(map[string]any{
+			"apiVersion": "hcl.fn.crossplane.io/v1beta1",
+			"kind":       "HclInput",
+			"hcl": `-- main.hcl --
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    spec       = { forProvider = { region = arg.region, acl = arg.acl } }
+  }
+}
+-- defaults.hclvars --
+region = "us-west-2"
+acl    = "private"
+`,
+			"values": []any{`region = "eu-west-1"`},
+		}),
+		Observed: &fnv1.State{
+			Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "XBucket",
+				"metadata":   map[string]any{"name": "xr"},
+			})},
+		},
+	}
+	res, err := f.RunFunction(context.Background(), req)
+	require.NoError(t, err)
+	bucket := res.GetDesired().GetResources()["bucket"].GetResource().AsMap()
+	assert.Equal(t, map[string]any{"region": "eu-west-1", "acl": "private"},
+		bucket["spec"].(map[string]any)["forProvider"])
+}
+
+func TestRunFunctionFiles(t *testing.T) {
+	f, err := New(Options{})
+	require.NoError(t, err)
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	request := func(in map[string]any) *fnv1.RunFunctionRequest {
+		in["apiVersion"] = "hcl.fn.crossplane.io/v1beta1"
+		in["kind"] = "HclInput"
+		return &fnv1.RunFunctionRequest{
+			Input: toStruct(in),
+			Observed: &fnv1.State{
+				Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+					"apiVersion": "example.com/v1",
+					"kind":       "XBucket",
+					"metadata":   map[string]any{"name": "xr"},
+				})},
+			},
+		}
+	}
+	bucket := `
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    spec       = { forProvider = { region = arg.region } }
+  }
+}
+`
+	res, err := f.RunFunction(context.Background(), request(map[string]any{
+		"files": []any{
+			map[string]any{"name": "main.hcl", "content": bucket},
+			map[string]any{"name": "defaults.hclvars", "content": `region = "us-west-2"`},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"region": "us-west-2"},
+		res.GetDesired().GetResources()["bucket"].GetResource().AsMap()["spec"].(map[string]any)["forProvider"])
+
+	tests := []struct {
+		name string
+		in   map[string]any
+		err  string
+	}{
+		{
+			name: "both",
+			in: map[string]any{
+				"hcl":   bucket,
+				"files": []any{map[string]any{"name": "main.hcl", "content": bucket}},
+			},
+			err: "input must specify either hcl or files, not both",
+		},
+		{
+			name: "no name",
+			in:   map[string]any{"files": []any{map[string]any{"content": bucket}}},
+			err:  "input file at index 0 has no name",
+		},
+		{
+			name: "duplicate",
+			in: map[string]any{"files": []any{
+				map[string]any{"name": "main.hcl", "content": bucket},
+				map[string]any{"name": "main.hcl", "content": bucket},
+			}},
+			err: `duplicate input file "main.hcl"`,
+		},
+		{
+			name: "negative index width",
+			in:   map[string]any{"hcl": bucket, "collectionIndex": map[string]any{"width": -1}},
+			err:  "collectionIndex.width must not be negative, got -1",
+		},
+		{
+			name: "large index width",
+			in:   map[string]any{"hcl": bucket, "collectionIndex": map[string]any{"width": 1000000}},
+			err:  "collectionIndex.width must not be larger than 20, got 1000000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := f.RunFunction(context.Background(), request(test.in))
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestRunFunctionCompositeSchema(t *testing.T) {
+	f, err := New(Options{})
+	require.NoError(t, err)
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	request := func(schema map[string]any) *fnv1.RunFunctionRequest {
+		return &fnv1.RunFunctionRequest{
+			Input: toStruct(map[string]any{
+				"apiVersion": "hcl.fn.crossplane.io/v1beta1",
+				"kind":       "HclInput",
+				"hcl": `
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    spec       = { forProvider = { region = req.composite.spec.region, size = try(req.composite.spec.size, 3) } }
+  }
+}
+`,
+				"compositeSchema": map[string]any{"spec": schema},
+			}),
+			Observed: &fnv1.State{
+				Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+					"apiVersion": "example.com/v1",
+					"kind":       "XBucket",
+					"metadata":   map[string]any{"name": "xr"},
+					"spec":       map[string]any{"region": "us-west-2"},
+				})},
+			},
+		}
+	}
+	res, err := f.RunFunction(context.Background(), request(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"region": map[string]any{"type": "string"},
+			"size":   map[string]any{"type": "integer"},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"region": "us-west-2", "size": float64(3)},
+		res.GetDesired().GetResources()["bucket"].GetResource().AsMap()["spec"].(map[string]any)["forProvider"])
+
+	_, err = f.RunFunction(context.Background(), request(map[string]any{
+		"properties": map[string]any{"region": map[string]any{"type": "text"}},
+	}))
+	require.Error(t, err)
+	assert.Equal(t, `parse compositeSchema: compositeSchema.spec: unsupported schema type "text" at .region`, err.Error())
+}
+
+func TestParseDebugAnnotation(t *testing.T) {
+	tests := []struct {
+		value   string
+		enabled bool
+		err     bool
+	}{
+		{value: "true", enabled: true},
+		{value: "max=2", enabled: true},
+		{value: "false"},
+		{value: "False", err: true},
+		{value: "0", err: true},
+		{value: "no", err: true},
+		{value: "max=lots", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			enabled, _, err := parseDebugAnnotation(test.value)
+			assert.Equal(t, test.enabled, enabled)
+			assert.Equal(t, test.err, err != nil)
+		})
+	}
+}
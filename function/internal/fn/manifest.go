@@ -0,0 +1,140 @@
+package fn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// manifestContextKey is the context key under which the manifest of desired resources is stored.
+const manifestContextKey = "hcl.fn.crossplane.io/manifest"
+
+// manifestStatusField is the field of the status of the composite under which the manifest of desired resources
+// is stored, such that it is available to the next reconcile. Crossplane does not carry the pipeline context
+// from one reconcile to the next.
+const manifestStatusField = "hclManifest"
+
+// manifest is a map of desired resource names to hashes of their bodies.
+type manifest map[string]string
+
+// desiredManifest returns the manifest for the desired resources in the supplied response.
+func desiredManifest(res *fnv1.RunFunctionResponse) (manifest, error) {
+	ret := manifest{}
+	for name, r := range res.GetDesired().GetResources() {
+		// JSON marshaling of maps sorts keys so the hash is stable for the same body
+		b, err := json.Marshal(r.GetResource().AsMap())
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal desired resource %s", name)
+		}
+		sum := sha256.Sum256(b)
+		ret[name] = hex.EncodeToString(sum[:8])
+	}
+	return ret, nil
+}
+
+// previousManifest returns the manifest found in the status of the observed composite or, failing that, in the
+// request context, or nil if there is none.
+func previousManifest(req *fnv1.RunFunctionRequest) manifest {
+	status := req.GetObserved().GetComposite().GetResource().GetFields()["status"].GetStructValue()
+	v, ok := status.GetFields()[manifestStatusField]
+	if !ok || v.GetStructValue() == nil {
+		v, ok = req.GetContext().GetFields()[manifestContextKey]
+	}
+	if !ok || v.GetStructValue() == nil {
+		return nil
+	}
+	ret := manifest{}
+	for name, hash := range v.GetStructValue().GetFields() {
+		ret[name] = hash.GetStringValue()
+	}
+	return ret
+}
+
+// changes returns the names of resources added, removed, and changed in the current manifest
+// when compared to the previous one, in sorted order.
+func (m manifest) changes(prev manifest) (added, removed, changed []string) {
+	for name, hash := range m {
+		prevHash, ok := prev[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case prevHash != hash:
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := m[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// addChangeSummary stores the manifest of desired resources in the status of the desired composite and in the
+// response context and, when a manifest from a previous run is present, adds a result that summarizes the
+// differences.
+func addChangeSummary(req *fnv1.RunFunctionRequest, res *fnv1.RunFunctionResponse) error {
+	current, err := desiredManifest(res)
+	if err != nil {
+		return err
+	}
+	if prev := previousManifest(req); prev != nil {
+		added, removed, changed := current.changes(prev)
+		var parts []string
+		for _, c := range []struct {
+			what  string
+			names []string
+		}{{"added", added}, {"removed", removed}, {"changed", changed}} {
+			if len(c.names) > 0 {
+				parts = append(parts, fmt.Sprintf("%s: %s", c.what, strings.Join(c.names, ", ")))
+			}
+		}
+		if len(parts) > 0 {
+			res.Results = append(res.Results, &fnv1.Result{
+				Severity: fnv1.Severity_SEVERITY_NORMAL,
+				Message:  "desired resources changed since the previous run; " + strings.Join(parts, "; "),
+			})
+		}
+	}
+	fields := map[string]any{}
+	for name, hash := range current {
+		fields[name] = hash
+	}
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		return errors.Wrap(err, "convert manifest")
+	}
+	if res.Context == nil {
+		res.Context = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	res.Context.Fields[manifestContextKey] = structpb.NewStructValue(s)
+
+	if res.Desired == nil {
+		res.Desired = &fnv1.State{}
+	}
+	if res.Desired.Composite == nil {
+		res.Desired.Composite = &fnv1.Resource{}
+	}
+	if res.Desired.Composite.Resource == nil {
+		res.Desired.Composite.Resource = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	xr := res.Desired.Composite.Resource
+	status := xr.GetFields()["status"].GetStructValue()
+	if status == nil {
+		status = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		xr.Fields["status"] = structpb.NewStructValue(status)
+	}
+	status.Fields[manifestStatusField] = structpb.NewStructValue(proto.Clone(s).(*structpb.Struct))
+	return nil
+}
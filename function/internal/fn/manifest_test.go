@@ -0,0 +1,50 @@
+package fn
+
+import (
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func desiredResponse(t *testing.T, bodies map[string]map[string]any) *fnv1.RunFunctionResponse {
+	res := &fnv1.RunFunctionResponse{Desired: &fnv1.State{Resources: map[string]*fnv1.Resource{}}}
+	for name, body := range bodies {
+		s, err := structpb.NewStruct(body)
+		require.NoError(t, err)
+		res.Desired.Resources[name] = &fnv1.Resource{Resource: s}
+	}
+	return res
+}
+
+func TestChangeSummary(t *testing.T) {
+	first := desiredResponse(t, map[string]map[string]any{
+		"bucket": {"kind": "Bucket", "spec": map[string]any{"region": "us-east-1"}},
+		"db":     {"kind": "Instance"},
+	})
+	require.NoError(t, addChangeSummary(&fnv1.RunFunctionRequest{}, first))
+	assert.Empty(t, first.Results)
+	m := first.GetContext().GetFields()[manifestContextKey].GetStructValue().AsMap()
+	assert.Len(t, m, 2)
+
+	second := desiredResponse(t, map[string]map[string]any{
+		"bucket": {"kind": "Bucket", "spec": map[string]any{"region": "us-west-2"}},
+		"cache":  {"kind": "Cluster"},
+	})
+	// the manifest is read from the status of the observed composite, which Crossplane keeps between reconciles
+	status := first.GetDesired().GetComposite().GetResource().AsMap()["status"].(map[string]any)
+	assert.Equal(t, m, status[manifestStatusField])
+	req := &fnv1.RunFunctionRequest{Observed: &fnv1.State{Composite: first.GetDesired().GetComposite()}}
+	require.NoError(t, addChangeSummary(req, second))
+	require.Len(t, second.Results, 1)
+	assert.Equal(t, "desired resources changed since the previous run; added: cache; removed: db; changed: bucket", second.Results[0].Message)
+
+	third := desiredResponse(t, map[string]map[string]any{
+		"bucket": {"spec": map[string]any{"region": "us-west-2"}, "kind": "Bucket"},
+		"cache":  {"kind": "Cluster"},
+	})
+	require.NoError(t, addChangeSummary(&fnv1.RunFunctionRequest{Context: second.GetContext()}, third))
+	assert.Empty(t, third.Results)
+}
@@ -0,0 +1,56 @@
+package fn
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	input "github.com/crossplane-contrib/function-hcl/function/input/v1beta1"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/crossplane-contrib/function-hcl/function/internal/version"
+)
+
+// Metadata describes the capabilities of a build of the function such that platform teams can audit
+// what is deployed and tooling can adapt to it.
+type Metadata struct {
+	Version      string   `json:"version"`      // function version, also checked by requires blocks
+	Commit       string   `json:"commit"`       // source commit of the build
+	BuildDate    string   `json:"buildDate"`    // date of the build
+	Blocks       []string `json:"blocks"`       // supported top-level blocks
+	Functions    []string `json:"functions"`    // built-in functions
+	RequestKeys  []string `json:"requestKeys"`  // attributes of the req variable
+	InputOptions []string `json:"inputOptions"` // supported fields of the function input
+}
+
+// NewMetadata returns the metadata for the running build.
+func NewMetadata() Metadata {
+	return Metadata{
+		Version:      version.Version,
+		Commit:       version.Commit,
+		BuildDate:    version.BuildDate,
+		Blocks:       evaluator.BlockTypes(),
+		Functions:    evaluator.BuiltinFunctions(),
+		RequestKeys:  evaluator.RequestKeys(),
+		InputOptions: inputOptions(),
+	}
+}
+
+// inputOptions returns the sorted JSON names of the fields of the function input, not including the
+// type and object metadata.
+func inputOptions() []string {
+	var ret []string
+	t := reflect.TypeOf(input.HclInput{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "metadata" {
+			continue
+		}
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,23 @@
+package fn
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	m := NewMetadata()
+	assert.Equal(t, "dev", m.Version)
+	assert.Contains(t, m.Blocks, "resource")
+	assert.Contains(t, m.Blocks, "requires")
+	assert.Contains(t, m.Functions, "invoke")
+	assert.Contains(t, m.Functions, "jsonencode")
+	assert.True(t, sort.StringsAreSorted(m.Functions))
+	assert.Contains(t, m.RequestKeys, "composite_connection")
+	assert.True(t, sort.StringsAreSorted(m.RequestKeys))
+	assert.Contains(t, m.InputOptions, "flags")
+	assert.Contains(t, m.InputOptions, "debugNew")
+	assert.NotContains(t, m.InputOptions, "metadata")
+}
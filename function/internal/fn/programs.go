@@ -0,0 +1,159 @@
+package fn
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	input "github.com/crossplane-contrib/function-hcl/function/input/v1beta1"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/pkg/errors"
+)
+
+// maxIdlePrograms is the maximum number of compiled programs kept for the same input. Programs cannot be
+// evaluated concurrently, so concurrent requests for the same input compile additional programs.
+const maxIdlePrograms = 8
+
+type programEntry struct {
+	key  string
+	idle []*evaluator.Program
+}
+
+// programCache is a least recently used cache of compiled programs keyed by a hash of the function input,
+// such that the HCL of a composition is parsed once instead of for every request. Since a program must not be
+// evaluated concurrently, programs are taken out of the cache for an evaluation and put back afterward.
+// A nil cache does not cache anything.
+type programCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used entries first
+	entries map[string]*list.Element
+	stats   cacheStats
+}
+
+// newProgramCache returns a cache that holds programs for up to the supplied number of inputs, or nil if the
+// size is not positive.
+func newProgramCache(size int) *programCache {
+	if size <= 0 {
+		return nil
+	}
+	return &programCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// programKey returns the key of the program for the supplied input. All fields of the input are part of the
+// key, since options like flags change how the source is compiled.
+func programKey(in *input.HclInput) (string, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal input")
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// get takes an idle program for the supplied key out of the cache, if there is one.
+func (c *programCache) get(key string) (*evaluator.Program, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok || len(el.Value.(*programEntry).idle) == 0 {
+		c.stats.misses++
+		return nil, false
+	}
+	c.stats.hits++
+	c.order.MoveToFront(el)
+	entry := el.Value.(*programEntry)
+	p := entry.idle[len(entry.idle)-1]
+	entry.idle = entry.idle[:len(entry.idle)-1]
+	return p, true
+}
+
+// put returns a program that is no longer being evaluated to the cache.
+func (c *programCache) put(key string, p *evaluator.Program) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		el = c.order.PushFront(&programEntry{key: key})
+		c.entries[key] = el
+	}
+	c.order.MoveToFront(el)
+	entry := el.Value.(*programEntry)
+	if len(entry.idle) < maxIdlePrograms {
+		entry.idle = append(entry.idle, p)
+	}
+	for c.order.Len() > c.size {
+		back := c.order.Back()
+		c.order.Remove(back)
+		delete(c.entries, back.Value.(*programEntry).key)
+	}
+}
+
+// statistics returns the counters of the cache.
+func (c *programCache) statistics() cacheStats {
+	if c == nil {
+		return cacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// newEvaluator returns an evaluator for the supplied input along with the files it must evaluate, and a function
+// that must be called once the evaluation is done. When programs are cached, the evaluator is created for a
+// cached program, compiling one if necessary, and the program is returned to the cache by the done function.
+func (f *Fn) newEvaluator(in *input.HclInput, opts evaluator.Options) (_ *evaluator.Evaluator, _ []evaluator.File, done func(), _ error) {
+	done = func() {}
+	var files []evaluator.File
+	loadFiles := func() error {
+		var err error
+		files, err = sourceFiles(in)
+		if err != nil {
+			return err
+		}
+		files = append(files, valuesFiles(in.Values)...)
+		return nil
+	}
+	if f.programs == nil {
+		if err := loadFiles(); err != nil {
+			return nil, nil, done, err
+		}
+		e, err := evaluator.New(opts)
+		if err != nil {
+			return nil, nil, done, errors.Wrap(err, "create evaluator")
+		}
+		return e, files, done, nil
+	}
+
+	key, err := programKey(in)
+	if err != nil {
+		return nil, nil, done, err
+	}
+	p, ok := f.programs.get(key)
+	if !ok {
+		if err := loadFiles(); err != nil {
+			return nil, nil, done, err
+		}
+		p, err = evaluator.Compile(opts, files...)
+		if err != nil {
+			return nil, nil, done, errors.Wrap(err, "evaluate hcl")
+		}
+	}
+	e, err := p.NewEvaluatorWithLogger(opts.Logger, opts.Debug)
+	if err != nil {
+		return nil, nil, done, errors.Wrap(err, "create evaluator")
+	}
+	return e, nil, func() { f.programs.put(key, p) }, nil
+}
@@ -0,0 +1,159 @@
+package fn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	input "github.com/crossplane-contrib/function-hcl/function/input/v1beta1"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProgramCache(t *testing.T) {
+	compile := func(name string) *evaluator.Program {
+		p, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: name + ".hcl", Content: "locals {}"})
+		require.NoError(t, err)
+		return p
+	}
+	c := newProgramCache(2)
+	_, ok := c.get("a")
+	assert.False(t, ok)
+
+	a1, a2 := compile("a"), compile("a")
+	c.put("a", a1)
+	c.put("a", a2)
+	c.put("b", compile("b"))
+
+	// programs are taken out of the cache until they are put back
+	p, ok := c.get("a")
+	require.True(t, ok)
+	assert.Same(t, a2, p)
+	p, ok = c.get("a")
+	require.True(t, ok)
+	assert.Same(t, a1, p)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+	c.put("a", a1)
+
+	// "b" is the least recently used entry
+	c.put("c", compile("c"))
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, cacheStats{hits: 3, misses: 3}, c.statistics())
+}
+
+func TestProgramCacheDisabled(t *testing.T) {
+	var c *programCache
+	assert.Nil(t, newProgramCache(0))
+	c.put("a", &evaluator.Program{})
+	_, ok := c.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, cacheStats{}, c.statistics())
+}
+
+func TestProgramKey(t *testing.T) {
+	a, err := programKey(&input.HclInput{HCL: "locals {}"})
+	require.NoError(t, err)
+	b, err := programKey(&input.HclInput{HCL: "locals {}", Flags: []string{"gpu"}})
+	require.NoError(t, err)
+	c, err := programKey(&input.HclInput{HCL: "locals {}"})
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, a, c)
+}
+
+const programHCL = `
+-- main.hcl --
+resources buckets {
+  for_each = req.composite.spec.regions
+  template {
+    body = {
+      apiVersion = "s3.aws.upbound.io/v1beta1"
+      kind       = "Bucket"
+      metadata   = { name = "${req.composite.metadata.name}-${each.value}" }
+      spec       = { forProvider = { region = each.value } }
+    }
+  }
+  composite status {
+    body = { buckets = length(self.items) }
+  }
+}
+`
+
+// programRequest returns a request for the supplied composite name, which is different for every request
+// such that responses cannot be reused.
+func programRequest(t testing.TB, name string) *fnv1.RunFunctionRequest {
+	toStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	return &fnv1.RunFunctionRequest{
+		Input: toStruct(map[string]any{
+			"apiVersion": "hcl.fn.crossplane.io/v1beta1",
+			"kind":       "HclInput",
+			"hcl":        programHCL,
+		}),
+		Observed: &fnv1.State{
+			Composite: &fnv1.Resource{Resource: toStruct(map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "XBuckets",
+				"metadata":   map[string]any{"name": name},
+				"spec":       map[string]any{"regions": []any{"us-east-1", "us-west-2", "eu-west-1"}},
+			})},
+		},
+	}
+}
+
+func TestRunFunctionCachesPrograms(t *testing.T) {
+	uncached, err := New(Options{})
+	require.NoError(t, err)
+	f, err := New(Options{ProgramCacheSize: 10})
+	require.NoError(t, err)
+	for _, name := range []string{"xr-1", "xr-2", "xr-3"} {
+		want, err := uncached.RunFunction(context.Background(), programRequest(t, name))
+		require.NoError(t, err)
+		got, err := f.RunFunction(context.Background(), programRequest(t, name))
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), got.String())
+		assert.Len(t, got.GetDesired().GetResources(), 3)
+	}
+	assert.Equal(t, cacheStats{hits: 2, misses: 1}, f.programs.statistics())
+
+	// compile errors are reported the same way
+	req := programRequest(t, "xr")
+	req.Input.Fields["hcl"] = structpb.NewStringValue("-- main.hcl --\nresource {\n")
+	_, err = f.RunFunction(context.Background(), req)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "evaluate hcl: "), err.Error())
+}
+
+func benchmarkRunFunction(b *testing.B, programCacheSize int) {
+	f, err := New(Options{ProgramCacheSize: programCacheSize})
+	require.NoError(b, err)
+	reqs := make([]*fnv1.RunFunctionRequest, 100)
+	for i := range reqs {
+		reqs[i] = programRequest(b, fmt.Sprintf("xr-%d", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.RunFunction(context.Background(), reqs[i%len(reqs)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRunFunction(b *testing.B) {
+	benchmarkRunFunction(b, 0)
+}
+
+func BenchmarkRunFunctionCachedPrograms(b *testing.B) {
+	benchmarkRunFunction(b, 16)
+}
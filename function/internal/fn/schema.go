@@ -0,0 +1,40 @@
+package fn
+
+import (
+	"encoding/json"
+
+	input "github.com/crossplane-contrib/function-hcl/function/input/v1beta1"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// compositeSchema returns the types of the composite for the schemas in the supplied input, or nil when the input
+// has none.
+func compositeSchema(in *input.CompositeSchema) (*evaluator.CompositeSchema, error) {
+	if in == nil {
+		return nil, nil
+	}
+	spec, err := schemaType(in.Spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "compositeSchema.spec")
+	}
+	status, err := schemaType(in.Status)
+	if err != nil {
+		return nil, errors.Wrap(err, "compositeSchema.status")
+	}
+	return &evaluator.CompositeSchema{Spec: spec, Status: status}, nil
+}
+
+// schemaType returns the type for the supplied OpenAPI schema, or cty.NilType when it is not set.
+func schemaType(raw *runtime.RawExtension) (cty.Type, error) {
+	if raw == nil || len(raw.Raw) == 0 {
+		return cty.NilType, nil
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(raw.Raw, &schema); err != nil {
+		return cty.NilType, errors.Wrap(err, "unmarshal schema")
+	}
+	return evaluator.TypeFromOpenAPISchema(schema)
+}
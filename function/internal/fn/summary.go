@@ -0,0 +1,55 @@
+package fn
+
+import (
+	"strings"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// resourceSummaryContextKey is the context key under which the summary of desired resources is stored.
+const resourceSummaryContextKey = "hcl.fn.crossplane.io/resource-summary"
+
+// coreGroup is the name used in the summary for resources in the core API group.
+const coreGroup = "core"
+
+// resourceSummary returns the number of desired resources in the supplied response, in total, by kind and by
+// API group. Kinds are qualified by their group in the same way as kubectl, e.g. "Bucket.s3.aws.upbound.io".
+func resourceSummary(res *fnv1.RunFunctionResponse) map[string]any {
+	byKind := map[string]any{}
+	byGroup := map[string]any{}
+	count := func(m map[string]any, key string) {
+		n, _ := m[key].(int)
+		m[key] = n + 1
+	}
+	for _, r := range res.GetDesired().GetResources() {
+		fields := r.GetResource().GetFields()
+		kind := fields["kind"].GetStringValue()
+		group := coreGroup
+		if apiVersion := fields["apiVersion"].GetStringValue(); strings.Contains(apiVersion, "/") {
+			group = apiVersion[:strings.LastIndex(apiVersion, "/")]
+			kind += "." + group
+		}
+		count(byKind, kind)
+		count(byGroup, group)
+	}
+	return map[string]any{
+		"total":   len(res.GetDesired().GetResources()),
+		"byKind":  byKind,
+		"byGroup": byGroup,
+	}
+}
+
+// addResourceSummary stores the summary of desired resources in the response context.
+func addResourceSummary(res *fnv1.RunFunctionResponse) error {
+	s, err := structpb.NewStruct(resourceSummary(res))
+	if err != nil {
+		return errors.Wrap(err, "convert resource summary")
+	}
+	if res.Context == nil {
+		res.Context = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	res.Context.Fields[resourceSummaryContextKey] = structpb.NewStructValue(s)
+	return nil
+}
@@ -0,0 +1,32 @@
+package fn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceSummary(t *testing.T) {
+	res := desiredResponse(t, map[string]map[string]any{
+		"bucket-0": {"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": "Bucket"},
+		"bucket-1": {"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": "Bucket"},
+		"policy":   {"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": "BucketPolicy"},
+		"config":   {"apiVersion": "v1", "kind": "ConfigMap"},
+	})
+	require.NoError(t, addResourceSummary(res))
+	assert.Empty(t, res.Results)
+	summary := res.GetContext().GetFields()[resourceSummaryContextKey].GetStructValue().AsMap()
+	assert.Equal(t, map[string]any{
+		"total": 4.0,
+		"byKind": map[string]any{
+			"Bucket.s3.aws.upbound.io":       2.0,
+			"BucketPolicy.s3.aws.upbound.io": 1.0,
+			"ConfigMap":                      1.0,
+		},
+		"byGroup": map[string]any{
+			"s3.aws.upbound.io": 3.0,
+			"core":              1.0,
+		},
+	}, summary)
+}
@@ -1,10 +1,17 @@
+//go:build !js
+
 package format
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/ignore"
+	"github.com/crossplane-contrib/function-hcl/function/internal/secrets"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 var (
@@ -12,13 +19,62 @@ var (
 	errorWriter io.Writer = os.Stderr
 )
 
+// ErrUnformatted is matched by the errors returned when checking finds files that are not formatted.
+var ErrUnformatted = errors.New("unformatted files found")
+
+// unformattedError is an error for unformatted files that matches ErrUnformatted.
+type unformattedError string
+
+func (e unformattedError) Error() string {
+	return string(e)
+}
+
+func (e unformattedError) Is(target error) bool {
+	return target == ErrUnformatted
+}
+
+// Summary has the counts of files processed by a format command.
+type Summary struct {
+	Files       int // number of files processed
+	Unformatted int // number of files that were not formatted
+	Warnings    int // number of warnings, like possible credentials in source
+}
+
 type FormatCmd struct {
 	Check     bool
+	Diff      bool // print unified diffs of the changes instead of rewriting files
+	Stdout    bool // print formatted contents instead of rewriting files
 	Recursive bool
 	Opts      Options
+	Summary   Summary // counts for the last execution
+}
+
+// unifiedDiff returns a unified diff between the original and formatted contents of the supplied file.
+func unifiedDiff(file, original, formatted string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(original),
+		B:        difflib.SplitLines(formatted),
+		FromFile: file + ".orig",
+		ToFile:   file,
+		Context:  3,
+	})
+}
+
+// warnSecrets writes warnings for what look like credentials in the supplied source and returns their number.
+func warnSecrets(file string, src []byte) int {
+	found := secrets.Scan(src)
+	for _, s := range found {
+		_, _ = fmt.Fprintf(errorWriter, "%s:%d:%d: warning: possible %s in source, add a comment with %q to allow it\n",
+			file, s.Line, s.Column, s.Kind, secrets.AllowMarker)
+	}
+	return len(found)
 }
 
 func (f *FormatCmd) Execute(args []string) error {
+	if f.Diff && f.Stdout {
+		return fmt.Errorf("cannot use --diff together with --stdout")
+	}
+	f.Summary = Summary{}
 	files, err := f.collectFiles(args)
 	if err != nil {
 		return err
@@ -28,23 +84,52 @@ func (f *FormatCmd) Execute(args []string) error {
 		if err != nil {
 			return err
 		}
+		f.Summary.Files = 1
+		f.Summary.Warnings = warnSecrets("<stdin>", b)
 		ret := Source(string(b), f.Opts)
+		if ret != string(b) {
+			f.Summary.Unformatted = 1
+		}
+		if f.Diff {
+			d, err := unifiedDiff("<stdin>", string(b), ret)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprint(outWriter, d)
+			if d != "" && f.Check {
+				return unformattedError("input is not formatted")
+			}
+			return nil
+		}
 		_, _ = fmt.Fprintln(outWriter, ret)
 		return nil
 	}
 
-	changes := 0
 	for _, file := range files {
 		b, err := os.ReadFile(file)
 		if err != nil {
 			return err
 		}
+		f.Summary.Files++
+		f.Summary.Warnings += warnSecrets(file, b)
 		ret := Source(string(b), f.Opts)
+		if f.Stdout {
+			_, _ = fmt.Fprint(outWriter, ret)
+		}
 		if ret != string(b) {
-			changes++
-			if f.Check {
+			f.Summary.Unformatted++
+			switch {
+			case f.Diff:
+				d, err := unifiedDiff(file, string(b), ret)
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprint(outWriter, d)
+			case f.Check:
 				_, _ = fmt.Fprintln(errorWriter, file)
-			} else {
+			case f.Stdout:
+				// formatted contents were already printed
+			default:
 				err = os.WriteFile(file, []byte(ret), 0o644)
 				if err != nil {
 					return err
@@ -53,8 +138,8 @@ func (f *FormatCmd) Execute(args []string) error {
 		}
 	}
 
-	if changes > 0 && f.Check {
-		return fmt.Errorf("%d unformatted files found", changes)
+	if f.Summary.Unformatted > 0 && f.Check {
+		return unformattedError(fmt.Sprintf("%d unformatted files found", f.Summary.Unformatted))
 	}
 	return nil
 }
@@ -91,10 +176,27 @@ func (f *FormatCmd) collectFileOrDir(input string) ([]string, error) {
 		return []string{input}, nil
 	}
 
+	ignored, err := ignore.Load(input)
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", ignore.FileName, err)
+	}
+
 	err = filepath.Walk(input, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if path != input {
+			rel, err := filepath.Rel(input, path)
+			if err != nil {
+				return err
+			}
+			if ignored.Match(filepath.ToSlash(rel), info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
 		if !info.IsDir() && filepath.Ext(info.Name()) == ".hcl" {
 			hclFiles = append(hclFiles, path)
 		}
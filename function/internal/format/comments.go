@@ -0,0 +1,108 @@
+package format
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// alignComments re-aligns the trailing line comments of the supplied formatted source. The formatter aligns
+// the comments of all consecutive lines that have one, which moves the comments of lines that open or close
+// nested bodies far away from their lines and aligns the comments of attributes with the ones of their
+// nested attributes. Instead:
+//
+//   - the comments of consecutive lines at the same indentation are aligned one space after the longest line.
+//   - the comment of a line that starts with a closing bracket, or opens or closes a nested body, tuple or call
+//     that spans lines, is placed one space after the line, and is not aligned with any other.
+//
+// Block comments and lines that do not parse are left as-is.
+func alignComments(src []byte) []byte {
+	file, diags := hclwrite.ParseConfig(src, "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return src
+	}
+	tokens := file.BuildTokens(nil)
+	infos := analyzeTokens(tokens)
+
+	var run []commentLine
+	flush := func() {
+		width := 0
+		for _, c := range run {
+			width = max(width, c.width)
+		}
+		for _, c := range run {
+			tokens[c.comment].SpacesBefore = width - c.width + 1
+		}
+		run = nil
+	}
+	prevLine := -1
+	for i, l := range splitLines(tokens) {
+		c, ok := trailingComment(tokens, infos, l)
+		if !ok {
+			flush()
+			continue
+		}
+		if c.bracket {
+			flush()
+			tokens[c.comment].SpacesBefore = 1
+			continue
+		}
+		if len(run) > 0 && (prevLine != i-1 || run[0].indent != c.indent) {
+			flush()
+		}
+		run = append(run, c)
+		prevLine = i
+	}
+	flush()
+
+	out := tokens.Bytes()
+	// never produce output that does not parse
+	if _, diags := hclsyntax.ParseConfig(out, "", hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		return src
+	}
+	return out
+}
+
+// commentLine is a line that ends with a line comment.
+type commentLine struct {
+	comment int  // index of the comment token
+	width   int  // width of the line before the comment
+	indent  int  // indentation of the line
+	bracket bool // whether the line opens or closes a bracket that is not closed or opened on it
+}
+
+// trailingComment returns information about the supplied line when it ends with a line comment that follows
+// other tokens, and a boolean indicating whether it does.
+func trailingComment(tokens hclwrite.Tokens, infos []tokenInfo, l line) (commentLine, bool) {
+	last := l.end - 1
+	if last <= l.start || infos[last].inString {
+		return commentLine{}, false
+	}
+	t := tokens[last]
+	if t.Type != hclsyntax.TokenComment || !bytes.HasSuffix(t.Bytes, []byte("\n")) {
+		return commentLine{}, false
+	}
+	ret := commentLine{comment: last, indent: tokens[l.start].SpacesBefore}
+	depth := 0
+	for i, t := range tokens[l.start:last] {
+		ret.width += t.SpacesBefore + len(t.Bytes)
+		if infos[l.start+i].inString {
+			continue
+		}
+		switch t.Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen:
+			depth++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen:
+			depth--
+			if i == 0 {
+				ret.bracket = true
+			}
+		}
+	}
+	if depth != 0 {
+		ret.bracket = true
+	}
+	return ret, true
+}
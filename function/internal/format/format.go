@@ -12,10 +12,15 @@ import (
 
 type Options struct {
 	StandardizeObjectLiterals bool
+	// MaxLineLength is the column limit beyond which single-line expressions are broken across lines.
+	// Zero disables wrapping.
+	MaxLineLength int
 }
 
 // Source returns the formatted source code, optionally standardizing object literals
-// to always be in key = value format, for consistency and better indentation.
+// to always be in key = value format, for consistency and better indentation, and
+// optionally wrapping lines that are longer than the maximum line length. Trailing
+// comments are aligned within runs of lines at the same indentation.
 func Source(source string, opts Options) string {
 	file, diags := hclwrite.ParseConfig([]byte(source), "", hcl.Pos{Line: 1, Column: 1})
 	if diags.HasErrors() {
@@ -25,7 +30,11 @@ func Source(source string, opts Options) string {
 		processBody(file.Body())
 	}
 	tokens := file.Body().BuildTokens(nil)
-	return string(hclwrite.Format(tokens.Bytes()))
+	out := hclwrite.Format(tokens.Bytes())
+	if opts.MaxLineLength > 0 {
+		out = wrapLongLines(out, opts.MaxLineLength)
+	}
+	return string(alignComments(out))
 }
 
 func processBody(body *hclwrite.Body) {
@@ -347,6 +347,82 @@ locals {
   foo = { name = "foo1" } /* this is a foo comment */
   bar = { name = x > 0 ? "bar1" : "bar2" } /* this is a bar comment */
 }
+`,
+		},
+		{
+			name: "aligned comments in nested literals",
+			input: `
+resource foo {
+  body = {
+    a: 1 # one
+    bbbbbb: 1000 # two
+    nested: { # nested comment
+      x: "y" # ex
+      # standalone
+      longer_name: "z"   # zed
+      list: [
+        1, # first
+        22, # second
+      ]
+      deeper: {
+        rr: [1, 2] # rr
+        s: "s" # s
+      } # end deeper
+    } # end nested
+    after = 3 // after
+  }
+}
+`,
+			expected: `
+resource foo {
+  body = {
+    a      = 1    # one
+    bbbbbb = 1000 # two
+    nested = { # nested comment
+      x = "y" # ex
+      # standalone
+      longer_name = "z" # zed
+      list = [
+        1,  # first
+        22, # second
+      ]
+      deeper = {
+        rr = [1, 2] # rr
+        s  = "s"    # s
+      } # end deeper
+    } # end nested
+    after = 3 // after
+  }
+}
+`,
+		},
+		{
+			name: "comments stay on their lines",
+			input: `
+locals {
+  x = {
+    b /* bee */ : 2 # two
+    c: { d: 1, # dee
+      e: 2 } # end c
+    f: [
+      { g: 1 }, # g
+      { hh: 2 }, # hh
+    ] # end f
+  }
+}
+`,
+			expected: `
+locals {
+  x = {
+    b /* bee */ = 2 # two
+    c = { d = 1, # dee
+    e = 2 } # end c
+    f = [
+      { g = 1 },  # g
+      { hh = 2 }, # hh
+    ] # end f
+  }
+}
 `,
 		},
 	}
@@ -361,3 +437,147 @@ locals {
 		})
 	}
 }
+
+func TestFormatterWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "short lines",
+			input: `
+locals {
+  foo = merge(a, { b = 1, c = 2 })
+  bar = x ? y : z
+}
+`,
+			expected: `
+locals {
+  foo = merge(a, { b = 1, c = 2 })
+  bar = x ? y : z
+}
+`,
+		},
+		{
+			name: "function call with object",
+			input: `
+locals {
+  foo = merge(local.defaults, { name = req.composite.metadata.name, namespace = req.composite.metadata.namespace })
+}
+`,
+			expected: `
+locals {
+  foo = merge(
+    local.defaults,
+    {
+      name      = req.composite.metadata.name
+      namespace = req.composite.metadata.namespace
+    }
+  )
+}
+`,
+		},
+		{
+			name: "nested calls",
+			input: `
+locals {
+  nested = concat(compact([req.composite.spec.a, req.composite.spec.b]), ["xxxxxxxxxxxxxx"]) # comment
+}
+`,
+			expected: `
+locals {
+  nested = concat(
+    compact([req.composite.spec.a, req.composite.spec.b]),
+    ["xxxxxxxxxxxxxx"]
+  ) # comment
+}
+`,
+		},
+		{
+			name: "conditional attribute",
+			input: `
+resource foo {
+  body = {
+    value = req.composite.spec.parameters.enabled ? format("%s-%s", req.composite.metadata.name, "on") : "off"
+  }
+}
+`,
+			expected: `
+resource foo {
+  body = {
+    value = (
+      req.composite.spec.parameters.enabled
+      ? format("%s-%s", req.composite.metadata.name, "on")
+      : "off"
+    )
+  }
+}
+`,
+		},
+		{
+			name: "conditional in parentheses",
+			input: `
+locals {
+  foo = upper((req.composite.spec.parameters.enabled ? req.composite.metadata.name : "off"))
+}
+`,
+			expected: `
+locals {
+  foo = upper(
+    (
+      req.composite.spec.parameters.enabled
+      ? req.composite.metadata.name
+      : "off"
+    )
+  )
+}
+`,
+		},
+		{
+			name: "for expression",
+			input: `
+locals {
+  foo = [for s in req.composite.spec.parameters.subnets : "${req.composite.metadata.name}-${s}"]
+}
+`,
+			expected: `
+locals {
+  foo = [
+    for s in req.composite.spec.parameters.subnets : "${req.composite.metadata.name}-${s}"
+  ]
+}
+`,
+		},
+		{
+			name: "unbreakable lines",
+			input: `
+locals {
+  foo = "this is a long string that cannot be broken by the formatter in any way"
+  bar = <<EOT
+this is a long heredoc line that cannot be broken by the formatter in any way
+EOT
+  baz = "${format("%s-%s", req.composite.metadata.name, req.composite.metadata.namespace)}"
+}
+`,
+			expected: `
+locals {
+  foo = "this is a long string that cannot be broken by the formatter in any way"
+  bar = <<EOT
+this is a long heredoc line that cannot be broken by the formatter in any way
+EOT
+  baz = "${format("%s-%s", req.composite.metadata.name, req.composite.metadata.namespace)}"
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := Source(test.input, Options{StandardizeObjectLiterals: true, MaxLineLength: 60})
+			e := strings.TrimSpace(test.expected)
+			a := strings.TrimSpace(out)
+			assert.Equal(t, e, a)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package format
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// maxWrapPasses bounds the number of breaks applied to a single file.
+const maxWrapPasses = 1000
+
+// wrapLongLines breaks lines that are longer than the supplied limit and returns the formatted result.
+// Each pass breaks one expression on the first long line that can be broken, after which the source
+// is re-formatted such that the new lines are indented consistently. Lines are broken as follows, in order
+// of preference:
+//
+//   - a conditional expression that is the value of an attribute is wrapped in parentheses and broken
+//     before its "?" and ":" operators.
+//   - the outermost function call, tuple or object on the line is broken after its opening bracket,
+//     after every element and before its closing bracket. When there are many such expressions on the line,
+//     the longest one is broken.
+//
+// Lines that cannot be broken in this way are left as-is.
+func wrapLongLines(src []byte, limit int) []byte {
+	for range maxWrapPasses {
+		file, diags := hclwrite.ParseConfig(src, "", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return src
+		}
+		tokens, ok := breakFirstLongLine(file.BuildTokens(nil), limit)
+		if !ok {
+			return src
+		}
+		next := hclwrite.Format(tokens.Bytes())
+		// never produce output that does not parse
+		if _, diags := hclsyntax.ParseConfig(next, "", hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+			return src
+		}
+		src = next
+	}
+	return src
+}
+
+// tokenInfo has information about a token computed from the tokens before it.
+type tokenInfo struct {
+	inString bool                // token is in a quoted string or heredoc
+	context  hclsyntax.TokenType // innermost bracket that is open before the token, or TokenNil at top-level
+}
+
+// analyzeTokens returns information for every supplied token.
+func analyzeTokens(tokens hclwrite.Tokens) []tokenInfo {
+	ret := make([]tokenInfo, len(tokens))
+	strDepth := 0
+	var stack []hclsyntax.TokenType
+	for i, t := range tokens {
+		info := tokenInfo{inString: strDepth > 0, context: hclsyntax.TokenNil}
+		if len(stack) > 0 {
+			info.context = stack[len(stack)-1]
+		}
+		ret[i] = info
+		switch t.Type {
+		case hclsyntax.TokenOQuote, hclsyntax.TokenOHeredoc:
+			strDepth++
+		case hclsyntax.TokenCQuote, hclsyntax.TokenCHeredoc:
+			strDepth--
+		}
+		if info.inString {
+			continue
+		}
+		switch t.Type {
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace:
+			stack = append(stack, t.Type)
+		case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return ret
+}
+
+// line is a range of tokens that appear on a single line, excluding the terminating newline.
+type line struct {
+	start, end int
+	width      int
+}
+
+// splitLines returns the lines of the supplied tokens.
+func splitLines(tokens hclwrite.Tokens) []line {
+	var ret []line
+	cur := line{}
+	for i, t := range tokens {
+		switch {
+		case t.Type == hclsyntax.TokenNewline:
+			cur.end = i
+			ret = append(ret, cur)
+			cur = line{start: i + 1}
+		case t.Type == hclsyntax.TokenComment && bytes.HasSuffix(t.Bytes, []byte("\n")):
+			cur.width += t.SpacesBefore + len(t.Bytes) - 1
+			cur.end = i + 1
+			ret = append(ret, cur)
+			cur = line{start: i + 1}
+		default:
+			cur.width += t.SpacesBefore + len(t.Bytes)
+		}
+	}
+	if cur.start < len(tokens) {
+		cur.end = len(tokens)
+		ret = append(ret, cur)
+	}
+	return ret
+}
+
+// breakFirstLongLine returns the tokens with newlines inserted to break the first line that is longer
+// than the limit and can be broken, and a boolean indicating whether such a line was found.
+func breakFirstLongLine(tokens hclwrite.Tokens, limit int) (hclwrite.Tokens, bool) {
+	infos := analyzeTokens(tokens)
+	for _, l := range splitLines(tokens) {
+		if l.width <= limit || hasHeredoc(tokens[l.start:l.end]) || infos[l.start].inString {
+			continue
+		}
+		if ret, ok := breakConditional(tokens, infos, l); ok {
+			return ret, true
+		}
+		if ret, ok := breakGroup(tokens, infos, l); ok {
+			return ret, true
+		}
+	}
+	return nil, false
+}
+
+func hasHeredoc(tokens hclwrite.Tokens) bool {
+	for _, t := range tokens {
+		if t.Type == hclsyntax.TokenOHeredoc || t.Type == hclsyntax.TokenCHeredoc {
+			return true
+		}
+	}
+	return false
+}
+
+// lineDepths returns the bracket depth of every token in the line relative to the start of the line.
+// Tokens in strings have a depth of -1.
+func lineDepths(tokens hclwrite.Tokens, infos []tokenInfo, l line) []int {
+	ret := make([]int, l.end-l.start)
+	depth := 0
+	for i := l.start; i < l.end; i++ {
+		if infos[i].inString {
+			ret[i-l.start] = -1
+			continue
+		}
+		switch tokens[i].Type {
+		case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace:
+			depth--
+		}
+		ret[i-l.start] = depth
+		switch tokens[i].Type {
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace:
+			depth++
+		}
+	}
+	return ret
+}
+
+// breakConditional breaks a conditional expression that is not nested in brackets on the supplied line.
+// When the line is not in a context where newlines are ignored, the line must be an attribute whose value
+// is then wrapped in parentheses.
+func breakConditional(tokens hclwrite.Tokens, infos []tokenInfo, l line) (hclwrite.Tokens, bool) {
+	lineTokens := tokens[l.start:l.end]
+	if len(lineTokens) == 0 || isFor(lineTokens[0]) {
+		return nil, false
+	}
+	depths := lineDepths(tokens, infos, l)
+	var breaks []int
+	for i, t := range lineTokens {
+		if i > 0 && depths[i] == 0 && (t.Type == hclsyntax.TokenQuestion || t.Type == hclsyntax.TokenColon) {
+			breaks = append(breaks, l.start+i)
+		}
+	}
+	if len(breaks) == 0 || tokens[breaks[0]].Type != hclsyntax.TokenQuestion {
+		return nil, false
+	}
+	ctx := infos[l.start].context
+	if ctx == hclsyntax.TokenOParen || ctx == hclsyntax.TokenOBrack {
+		return insertNewlines(tokens, breaks, nil), true
+	}
+	if len(lineTokens) < 3 || lineTokens[0].Type != hclsyntax.TokenIdent || lineTokens[1].Type != hclsyntax.TokenEqual {
+		return nil, false
+	}
+	end := l.end
+	if lineTokens[len(lineTokens)-1].Type == hclsyntax.TokenComment {
+		end--
+	}
+	var ret hclwrite.Tokens
+	ret = append(ret, tokens[:l.start+2]...)
+	ret = append(ret, &hclwrite.Token{Type: hclsyntax.TokenOParen, Bytes: []byte("(")}, newline())
+	ret = append(ret, insertNewlines(tokens[l.start+2:end], shift(breaks, -(l.start+2)), nil)...)
+	ret = append(ret, newline(), &hclwrite.Token{Type: hclsyntax.TokenCParen, Bytes: []byte(")")})
+	return append(ret, tokens[end:]...), true
+}
+
+// group is a bracketed expression that starts and ends on the same line.
+type group struct {
+	open, close int
+	depth       int
+	width       int
+}
+
+// breakGroup breaks the outermost function call, tuple or object on the supplied line.
+func breakGroup(tokens hclwrite.Tokens, infos []tokenInfo, l line) (hclwrite.Tokens, bool) {
+	depths := lineDepths(tokens, infos, l)
+	var best *group
+	var stack []int
+	for i := l.start; i < l.end; i++ {
+		if infos[i].inString {
+			continue
+		}
+		switch tokens[i].Type {
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace:
+			stack = append(stack, i)
+		case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace:
+			if len(stack) == 0 {
+				continue
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if i == open+1 {
+				continue
+			}
+			g := &group{open: open, close: i, depth: depths[open-l.start]}
+			for j := open; j <= i; j++ {
+				g.width += tokens[j].SpacesBefore + len(tokens[j].Bytes)
+			}
+			if best == nil || g.depth < best.depth || (g.depth == best.depth && g.width > best.width) {
+				best = g
+			}
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	breaks := []int{best.open + 1}
+	var drop []int
+	inner := depths[best.open-l.start] + 1
+	open := tokens[best.open].Type
+	switch {
+	case isFor(tokens[best.open+1]):
+		// for expressions are only broken at their brackets
+	case open == hclsyntax.TokenOParen && !isFunctionCall(tokens, best.open):
+		for i := best.open + 1; i < best.close; i++ {
+			t := tokens[i].Type
+			if depths[i-l.start] == inner && (t == hclsyntax.TokenQuestion || t == hclsyntax.TokenColon) {
+				breaks = append(breaks, i)
+			}
+		}
+	default:
+		for i := best.open + 1; i < best.close-1; i++ {
+			if depths[i-l.start] == inner && tokens[i].Type == hclsyntax.TokenComma {
+				breaks = append(breaks, i+1)
+				// objects do not need commas between items on separate lines
+				if open == hclsyntax.TokenOBrace {
+					drop = append(drop, i)
+				}
+			}
+		}
+	}
+	breaks = append(breaks, best.close)
+	return insertNewlines(tokens, breaks, drop), true
+}
+
+// isFunctionCall returns true if the parenthesis at the supplied index starts the arguments of a function call.
+func isFunctionCall(tokens hclwrite.Tokens, open int) bool {
+	return open > 0 && tokens[open-1].Type == hclsyntax.TokenIdent
+}
+
+func isFor(t *hclwrite.Token) bool {
+	return t.Type == hclsyntax.TokenIdent && string(t.Bytes) == "for"
+}
+
+func newline() *hclwrite.Token {
+	return &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}
+}
+
+func shift(indexes []int, delta int) []int {
+	ret := make([]int, len(indexes))
+	for i, index := range indexes {
+		ret[i] = index + delta
+	}
+	return ret
+}
+
+// insertNewlines returns a copy of the supplied tokens with a newline inserted before every token at the
+// break indexes and the tokens at the drop indexes removed. Both sets of indexes must be sorted.
+func insertNewlines(tokens hclwrite.Tokens, breaks []int, drop []int) hclwrite.Tokens {
+	ret := make(hclwrite.Tokens, 0, len(tokens)+len(breaks))
+	for i, t := range tokens {
+		if len(drop) > 0 && drop[0] == i {
+			drop = drop[1:]
+			continue
+		}
+		if len(breaks) > 0 && breaks[0] == i {
+			breaks = breaks[1:]
+			ret = append(ret, newline())
+		}
+		ret = append(ret, t)
+	}
+	return ret
+}
@@ -0,0 +1,154 @@
+// Package ignore implements matching of paths against patterns in an ignore file that uses gitignore syntax.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of the ignore file that is looked up in the directories that are processed.
+const FileName = ".fnhclignore"
+
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool // pattern starts with "!" and re-includes matching paths
+	dirOnly bool // pattern ends with "/" and only matches directories
+}
+
+// Matcher matches slash-separated paths relative to the directory of the ignore file. The zero value and a nil
+// matcher do not match anything.
+type Matcher struct {
+	patterns []pattern
+}
+
+// Parse returns a matcher for the supplied ignore file contents. Blank lines and lines starting with "#" are
+// skipped. Patterns support "*", "?", "[...]" and "**" in the same way as gitignore.
+func Parse(data []byte) *Matcher {
+	m := &Matcher{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p pattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		// a pattern with a slash anywhere but at the end is relative to the directory of the ignore file,
+		// otherwise it matches at any level.
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		prefix := "^(?:.*/)?"
+		if anchored {
+			prefix = "^"
+		}
+		re, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+		if err != nil {
+			continue // malformed character class, ignored like git does
+		}
+		p.re = re
+		m.patterns = append(m.patterns, p)
+	}
+	return m
+}
+
+// Load returns a matcher for the ignore file in the supplied directory. A matcher that does not match anything is
+// returned when the directory has no ignore file.
+func Load(dir string) (*Matcher, error) {
+	b, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Matcher{}, nil
+		}
+		return nil, err
+	}
+	return Parse(b), nil
+}
+
+// globToRegexp converts a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+func (m *Matcher) match(path string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(path) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Match returns true if the supplied path is ignored. As with git, a path is also ignored when any of its parent
+// directories is ignored, even if a later pattern would re-include it.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+	path = strings.Trim(path, "/")
+	if path == "" || path == "." {
+		return false
+	}
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(path, isDir)
+}
@@ -0,0 +1,73 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	m := Parse([]byte(`
+# comments and blank lines are skipped
+
+*.gen.hcl
+/top.hcl
+build/
+vendor/**/*.hcl
+!vendor/keep.hcl
+docs/*.hcl
+\!bang.hcl
+`))
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "main.hcl"},
+		{path: "a.gen.hcl", ignored: true},
+		{path: "sub/a.gen.hcl", ignored: true},
+		{path: "top.hcl", ignored: true},
+		{path: "sub/top.hcl"},
+		{path: "build", isDir: true, ignored: true},
+		{path: "build"},
+		{path: "build/main.hcl", ignored: true},
+		{path: "sub/build/main.hcl", ignored: true},
+		{path: "vendor/a.hcl", ignored: true},
+		{path: "vendor/x/y/a.hcl", ignored: true},
+		{path: "vendor/keep.hcl"},
+		{path: "docs/a.hcl", ignored: true},
+		{path: "docs/sub/a.hcl"},
+		{path: "!bang.hcl", ignored: true},
+		{path: "bang.hcl"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.ignored, m.Match(test.path, test.isDir))
+		})
+	}
+}
+
+func TestMatchParentCannotBeReIncluded(t *testing.T) {
+	m := Parse([]byte("generated/\n!generated/keep.hcl\n"))
+	assert.True(t, m.Match("generated/keep.hcl", false))
+}
+
+func TestMatchNil(t *testing.T) {
+	var m *Matcher
+	assert.False(t, m.Match("main.hcl", false))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(dir)
+	require.NoError(t, err)
+	assert.False(t, m.Match("main.hcl", false))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("main.hcl\n"), 0o644))
+	m, err = Load(dir)
+	require.NoError(t, err)
+	assert.True(t, m.Match("main.hcl", false))
+}
@@ -0,0 +1,338 @@
+// Package rename renames resources, resource collections and locals across all files of a composition,
+// updating every reference to them.
+package rename
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Kind is the kind of object that is renamed.
+type Kind string
+
+const (
+	KindResource   Kind = "resource"
+	KindCollection Kind = "collection"
+	KindLocal      Kind = "local"
+)
+
+// Kinds are all kinds of objects that can be renamed.
+var Kinds = []Kind{KindResource, KindCollection, KindLocal}
+
+// Options control what is renamed.
+type Options struct {
+	Kind Kind   // the kind of object to rename
+	From string // the current name
+	To   string // the new name
+}
+
+// Run renames an object across all HCL files of the composition in the supplied directory, including
+// library files, and writes a summary of the changes to the supplied writer. Changed files are written to
+// temporary files first and only replace the originals once all of them have been written.
+func Run(dir string, opts Options, w io.Writer) error {
+	_, files, err := composition.LoadFiles(dir)
+	if err != nil {
+		return err
+	}
+	changed, counts, err := Files(files, opts)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := writeAll(dir, names, changed); err != nil {
+		return err
+	}
+	total := 0
+	for _, name := range names {
+		total += counts[name]
+		_, _ = fmt.Fprintf(w, "%s: %d changes\n", name, counts[name])
+	}
+	_, _ = fmt.Fprintf(w, "renamed %s %s to %s: %d changes in %d files\n", opts.Kind, opts.From, opts.To, total, len(names))
+	return nil
+}
+
+// writeAll replaces the supplied files in the directory with their new contents.
+func writeAll(dir string, names []string, contents map[string]string) (finalErr error) {
+	temps := map[string]string{}
+	defer func() {
+		if finalErr == nil {
+			return
+		}
+		for _, tmp := range temps {
+			_ = os.Remove(tmp)
+		}
+	}()
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		st, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+		if err != nil {
+			return err
+		}
+		temps[file] = f.Name()
+		_, err = f.WriteString(contents[name])
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return errors.Wrapf(err, "write %s", f.Name())
+		}
+		if err := os.Chmod(f.Name(), st.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		if err := os.Rename(temps[file], file); err != nil {
+			return err
+		}
+		delete(temps, file)
+	}
+	return nil
+}
+
+// Files renames an object in the supplied files and returns the new contents of the files that changed, along
+// with the number of changes in each of them. It fails when the object is not declared, when an object of the
+// same kind with the new name already exists, or when a file cannot be parsed.
+func Files(files []evaluator.File, opts Options) (map[string]string, map[string]int, error) {
+	if !isKind(opts.Kind) {
+		return nil, nil, fmt.Errorf("unsupported kind %q, must be one of %v", opts.Kind, Kinds)
+	}
+	if !hclexpr.IsIdentifier(opts.To) {
+		return nil, nil, fmt.Errorf("%q is not a valid name", opts.To)
+	}
+	if opts.From == opts.To {
+		return nil, nil, fmt.Errorf("old and new names are the same")
+	}
+	var bodies []*hclsyntax.Body
+	var parsed []evaluator.File
+	for _, f := range files {
+		if evaluator.IsValuesFile(f.Name) {
+			continue
+		}
+		file, diags := hclsyntax.ParseConfig([]byte(f.Content), f.Name, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, nil, diags
+		}
+		bodies = append(bodies, file.Body.(*hclsyntax.Body)) //nolint:forcetypeassert
+		parsed = append(parsed, f)
+	}
+	declared := map[string]bool{}
+	for _, body := range bodies {
+		collectDeclarations(body, opts.Kind, declared)
+	}
+	if !declared[opts.From] {
+		return nil, nil, fmt.Errorf("%s %s not found", opts.Kind, opts.From)
+	}
+	if declared[opts.To] {
+		return nil, nil, fmt.Errorf("%s %s already exists", opts.Kind, opts.To)
+	}
+
+	changed := map[string]string{}
+	counts := map[string]int{}
+	for i, body := range bodies {
+		r := &renamer{opts: opts, src: []byte(parsed[i].Content)}
+		_ = hclsyntax.Walk(body, r)
+		if len(r.edits) == 0 {
+			continue
+		}
+		changed[parsed[i].Name] = r.apply()
+		counts[parsed[i].Name] = len(r.edits)
+	}
+	return changed, counts, nil
+}
+
+func isKind(k Kind) bool {
+	for _, kind := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// declaringBlock returns the type of block that declares objects of the supplied kind by label.
+func declaringBlock(k Kind) string {
+	switch k {
+	case KindResource:
+		return "resource"
+	case KindCollection:
+		return "resources"
+	default:
+		return ""
+	}
+}
+
+// isLocalsBlock returns true if the supplied block declares locals as attributes.
+func isLocalsBlock(b *hclsyntax.Block) bool {
+	return b.Type == "locals" || b.Type == "file_locals"
+}
+
+// collectDeclarations adds the names of all objects of the supplied kind declared in the body to the supplied set.
+func collectDeclarations(body *hclsyntax.Body, k Kind, names map[string]bool) {
+	for _, b := range body.Blocks {
+		switch {
+		case b.Type == "function":
+			continue
+		case k == KindLocal && isLocalsBlock(b):
+			for name := range b.Body.Attributes {
+				names[name] = true
+			}
+		case b.Type == declaringBlock(k) && len(b.Labels) > 0:
+			names[b.Labels[0]] = true
+		}
+		collectDeclarations(b.Body, k, names)
+	}
+}
+
+// edit replaces the text in a range of the source.
+type edit struct {
+	r    hcl.Range
+	text string
+}
+
+// renamer collects the edits for a rename while walking a body.
+type renamer struct {
+	opts     Options
+	src      []byte
+	edits    []edit
+	skip     int  // depth of function blocks and for expressions in which the name refers to something else
+	inLocals bool // whether the walk is in a block that declares locals
+}
+
+func (r *renamer) add(rng hcl.Range, text string) {
+	r.edits = append(r.edits, edit{r: rng, text: text})
+}
+
+// shadows returns true if the supplied node hides the object being renamed from the nodes within it.
+func (r *renamer) shadows(node hclsyntax.Node) bool {
+	// object keys that are bare identifiers look like traversals but are literal strings
+	if key, ok := node.(*hclsyntax.ObjectConsKeyExpr); ok && !key.ForceNonLiteral {
+		return hcl.ExprAsKeyword(key.Wrapped) != ""
+	}
+	if r.opts.Kind != KindLocal {
+		return false
+	}
+	switch n := node.(type) {
+	case *hclsyntax.Block:
+		return n.Type == "function"
+	case *hclsyntax.ForExpr:
+		return n.KeyVar == r.opts.From || n.ValVar == r.opts.From
+	}
+	return false
+}
+
+func (r *renamer) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	if r.shadows(node) {
+		r.skip++
+	}
+	if r.skip > 0 {
+		return nil
+	}
+	switch n := node.(type) {
+	case *hclsyntax.Block:
+		r.inLocals = isLocalsBlock(n)
+		if n.Type == declaringBlock(r.opts.Kind) && len(n.Labels) > 0 && n.Labels[0] == r.opts.From {
+			r.renameLabel(n.LabelRanges[0])
+		}
+	case *hclsyntax.Attribute:
+		if r.opts.Kind == KindLocal && r.inLocals && n.Name == r.opts.From {
+			r.add(n.NameRange, r.opts.To)
+		}
+	case *hclsyntax.ScopeTraversalExpr:
+		r.renameTraversal(n.Traversal)
+	}
+	return nil
+}
+
+func (r *renamer) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	if r.shadows(node) {
+		r.skip--
+	}
+	if _, ok := node.(*hclsyntax.Block); ok {
+		r.inLocals = false
+	}
+	return nil
+}
+
+// renameLabel renames a block label, keeping its quotes if it has them.
+func (r *renamer) renameLabel(rng hcl.Range) {
+	if r.src[rng.Start.Byte] == '"' {
+		r.add(rng, fmt.Sprintf("%q", r.opts.To))
+		return
+	}
+	r.add(rng, r.opts.To)
+}
+
+// renameTraversal renames the object in the supplied traversal if it refers to it.
+func (r *renamer) renameTraversal(t hcl.Traversal) {
+	if r.opts.Kind == KindLocal {
+		if t.RootName() == r.opts.From {
+			r.add(t[0].SourceRange(), r.opts.To)
+		}
+		return
+	}
+	if t.RootName() != "req" || len(t) < 3 {
+		return
+	}
+	attr, ok := hclexpr.NormalizeTraversal(t[1:2])[0].(hcl.TraverseAttr)
+	if !ok || !refersTo(r.opts.Kind, attr.Name) {
+		return
+	}
+	switch step := t[2].(type) {
+	case hcl.TraverseAttr:
+		if step.Name == r.opts.From {
+			r.add(step.SrcRange, "."+r.opts.To)
+		}
+	case hcl.TraverseIndex:
+		if step.Key.Type() == cty.String && step.Key.AsString() == r.opts.From {
+			r.add(step.SrcRange, fmt.Sprintf("[%q]", r.opts.To))
+		}
+	}
+}
+
+// refersTo returns true if the supplied attribute under req has objects of the supplied kind by name.
+func refersTo(k Kind, attr string) bool {
+	switch k {
+	case KindResource:
+		return attr == "resource" || attr == "connection"
+	case KindCollection:
+		return attr == "resources" || attr == "connections"
+	}
+	return false
+}
+
+// apply returns the source with all edits applied.
+func (r *renamer) apply() string {
+	sort.Slice(r.edits, func(i, j int) bool {
+		return r.edits[i].r.Start.Byte < r.edits[j].r.Start.Byte
+	})
+	var b strings.Builder
+	pos := 0
+	for _, e := range r.edits {
+		b.Write(r.src[pos:e.r.Start.Byte])
+		b.WriteString(e.text)
+		pos = e.r.End.Byte
+	}
+	b.Write(r.src[pos:])
+	return b.String()
+}
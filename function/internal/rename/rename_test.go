@@ -0,0 +1,157 @@
+package rename
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mainHCL = `locals {
+  region = req.composite.spec.region
+  names  = [for region in ["a", "b"] : region]
+}
+
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    spec       = { forProvider = { region = region, bucket = "x" } }
+  }
+  ready {
+    value = req.resource.bucket.status.ready ? "READY_TRUE" : "READY_FALSE"
+  }
+}
+
+resources "replicas" {
+  for_each = names
+  template {
+    body = {
+      apiVersion = "s3.aws.upbound.io/v1beta1"
+      kind       = "Bucket"
+      spec       = { forProvider = { region = each.value, source = req.resource["bucket"].metadata.name } }
+    }
+  }
+}
+
+function double {
+  arg region {}
+  body = region * 2
+}
+`
+
+const statusHCL = `composite status {
+  body = {
+    bucket   = "${req.resource.bucket.status.arn}-${region}"
+    replicas = length(req.resources.replicas)
+    password = req.connection.bucket.password
+  }
+}
+`
+
+func TestFiles(t *testing.T) {
+	files := []evaluator.File{
+		{Name: "main.hcl", Content: mainHCL},
+		{Name: "status.hcl", Content: statusHCL},
+		{Name: "values.hclvars", Content: `bucket = "x"`},
+	}
+	tests := []struct {
+		name     string
+		opts     Options
+		expected map[string]string
+		counts   map[string]int
+	}{
+		{
+			name: "resource",
+			opts: Options{Kind: KindResource, From: "bucket", To: "primary-bucket"},
+			expected: map[string]string{
+				"main.hcl": `resource primary-bucket {`,
+				"status.hcl": `    bucket   = "${req.resource.primary-bucket.status.arn}-${region}"
+    replicas = length(req.resources.replicas)
+    password = req.connection.primary-bucket.password`,
+			},
+			counts: map[string]int{"main.hcl": 3, "status.hcl": 2},
+		},
+		{
+			name: "collection",
+			opts: Options{Kind: KindCollection, From: "replicas", To: "copies"},
+			expected: map[string]string{
+				"main.hcl":   `resources "copies" {`,
+				"status.hcl": `replicas = length(req.resources.copies)`,
+			},
+			counts: map[string]int{"main.hcl": 1, "status.hcl": 1},
+		},
+		{
+			name: "local",
+			opts: Options{Kind: KindLocal, From: "region", To: "xr_region"},
+			expected: map[string]string{
+				"main.hcl": `  xr_region = req.composite.spec.region
+  names  = [for region in ["a", "b"] : region]`,
+				"status.hcl": `-${xr_region}"`,
+			},
+			counts: map[string]int{"main.hcl": 2, "status.hcl": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changed, counts, err := Files(files, test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.counts, counts)
+			require.Len(t, changed, len(test.expected))
+			for name, snippet := range test.expected {
+				assert.Contains(t, changed[name], snippet)
+			}
+		})
+	}
+	changed, _, err := Files(files, Options{Kind: KindResource, From: "bucket", To: "primary"})
+	require.NoError(t, err)
+	assert.Contains(t, changed["main.hcl"], `source = req.resource["primary"].metadata.name`)
+	assert.Contains(t, changed["main.hcl"], `region = region, bucket = "x"`)
+	assert.Contains(t, changed["main.hcl"], `arg region {}`)
+}
+
+func TestFilesErrors(t *testing.T) {
+	files := []evaluator.File{{Name: "main.hcl", Content: mainHCL}}
+	tests := []struct {
+		name string
+		opts Options
+		err  string
+	}{
+		{name: "bad kind", opts: Options{Kind: "group", From: "a", To: "b"}, err: `unsupported kind "group", must be one of [resource collection local]`},
+		{name: "bad name", opts: Options{Kind: KindResource, From: "bucket", To: "1bucket"}, err: `"1bucket" is not a valid name`},
+		{name: "not found", opts: Options{Kind: KindResource, From: "missing", To: "other"}, err: "resource missing not found"},
+		{name: "exists", opts: Options{Kind: KindLocal, From: "region", To: "names"}, err: "local names already exists"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := Files(files, test.opts)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(mainHCL), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "status.hcl"), []byte(statusHCL), 0o600))
+	var buf bytes.Buffer
+	err := Run(dir, Options{Kind: KindCollection, From: "replicas", To: "copies"}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, `main.hcl: 1 changes
+status.hcl: 1 changes
+renamed collection replicas to copies: 2 changes in 2 files
+`, buf.String())
+	b, err := os.ReadFile(filepath.Join(dir, "status.hcl"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "req.resources.copies")
+	st, err := os.Stat(filepath.Join(dir, "status.hcl"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
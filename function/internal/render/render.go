@@ -0,0 +1,265 @@
+// Package render evaluates a composition against composite resources stored in files and writes the desired
+// state for each of them, such that a change to a composition can be validated against a corpus of
+// representative composites.
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	"github.com/crossplane-contrib/function-hcl/function/internal/debug"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// compositeFile is the name of the file in an output directory that has the desired state of the composite.
+const compositeFile = "composite.yaml"
+
+// names of the files in an output directory that have the request and response as protobuf JSON.
+const (
+	requestFile  = "request.json"
+	responseFile = "response.json"
+)
+
+// Options control the composites that are rendered and where the output is written.
+type Options struct {
+	XRs         string   // directory with one composite resource per YAML file
+	Output      string   // directory under which a directory is written for every composite
+	Flags       []string // feature flags to evaluate with
+	Targets     []string // labels of the blocks to evaluate, all blocks are evaluated when empty
+	Concurrency int      // number of composites evaluated at the same time, at least 1
+	Proto       bool     // also write the whole request and response of every composite as protobuf JSON
+}
+
+// composite is a composite resource read from a file.
+type composite struct {
+	name   string         // base name of the file without extension, used as the name of the output directory
+	object map[string]any // the composite resource
+}
+
+// result is the outcome of rendering a single composite.
+type result struct {
+	name      string // name of the composite
+	resources int    // number of desired resources
+	warnings  int    // number of warning results
+	err       error  // evaluation error, if any
+}
+
+// Run evaluates the composition in the supplied directory against every composite in the directory of composites
+// and writes the desired resources of each to a directory named after its file under the output directory. It
+// writes a summary table of all composites to the supplied writer and returns an error when the composition could
+// not be evaluated for any composite.
+func Run(ctx context.Context, dir string, opts Options, w io.Writer) error {
+	if opts.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, found %d", opts.Concurrency)
+	}
+	_, files, err := composition.LoadFiles(dir)
+	if err != nil {
+		return err
+	}
+	xrs, err := loadComposites(opts.XRs)
+	if err != nil {
+		return err
+	}
+	if len(xrs) == 0 {
+		return fmt.Errorf("no composites found in %s", opts.XRs)
+	}
+	results, err := renderAll(ctx, files, xrs, opts)
+	if err != nil {
+		return err
+	}
+	failed := writeSummary(w, results)
+	if failed > 0 {
+		return fmt.Errorf("evaluation failed for %d of %d composites", failed, len(results))
+	}
+	return nil
+}
+
+// loadComposites reads the composites in the YAML files of the supplied directory, in name order.
+func loadComposites(dir string) ([]composite, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ret []composite
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var obj map[string]any
+		if err := yaml.Unmarshal(b, &obj); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal composite from %s", file)
+		}
+		if obj == nil {
+			return nil, errors.Errorf("no composite found in %s", file)
+		}
+		ret = append(ret, composite{name: strings.TrimSuffix(entry.Name(), ext), object: obj})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].name < ret[j].name
+	})
+	for i := 1; i < len(ret); i++ {
+		if ret[i].name == ret[i-1].name {
+			return nil, errors.Errorf("more than one file for composite %s in %s", ret[i].name, dir)
+		}
+	}
+	return ret, nil
+}
+
+// renderAll renders the supplied composites using the configured number of workers and returns the results in the
+// order of the composites. A program must not be evaluated concurrently, so every worker compiles its own.
+func renderAll(ctx context.Context, files []evaluator.File, xrs []composite, opts Options) ([]result, error) {
+	workers := min(opts.Concurrency, len(xrs))
+	programs := make([]*evaluator.Program, workers)
+	for i := range programs {
+		p, err := evaluator.Compile(evaluator.Options{Flags: opts.Flags, Targets: opts.Targets}, files...)
+		if err != nil {
+			return nil, err
+		}
+		programs[i] = p
+	}
+
+	results := make([]result, len(xrs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for _, p := range programs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = renderOne(ctx, p, xrs[i], opts)
+			}
+		}()
+	}
+	for i := range xrs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, nil
+}
+
+// renderOne evaluates the program against the supplied composite and writes its output directory.
+func renderOne(ctx context.Context, p *evaluator.Program, xr composite, opts Options) result {
+	r := result{name: xr.name}
+	if err := ctx.Err(); err != nil {
+		r.err = err
+		return r
+	}
+	s, err := structpb.NewStruct(xr.object)
+	if err != nil {
+		r.err = errors.Wrap(err, "convert composite")
+		return r
+	}
+	req := &fnv1.RunFunctionRequest{
+		Observed: &fnv1.State{Composite: &fnv1.Resource{Resource: s}},
+		Desired:  &fnv1.State{},
+	}
+	e, err := p.NewEvaluator()
+	if err != nil {
+		r.err = err
+		return r
+	}
+	res, err := e.Eval(ctx, req)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	for _, result := range res.GetResults() {
+		// diagnostic summaries are skipped since the diagnostics are already part of discard messages
+		if result.GetSeverity() == fnv1.Severity_SEVERITY_WARNING && !evaluator.IsDiagnosticsResult(result) {
+			r.warnings++
+		}
+	}
+	r.resources = len(res.GetDesired().GetResources())
+	dir := filepath.Join(opts.Output, xr.name)
+	if r.err = writeOutput(dir, res.GetDesired()); r.err != nil || !opts.Proto {
+		return r
+	}
+	r.err = writeProto(dir, req, res)
+	return r
+}
+
+// writeOutput replaces the contents of the supplied directory with a file for every desired resource, named after
+// the resource, and a file for the desired state of the composite when there is one.
+func writeOutput(dir string, desired *fnv1.State) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	write := func(file string, s *structpb.Struct) error {
+		b, err := yaml.Marshal(s.AsMap())
+		if err != nil {
+			return errors.Wrapf(err, "marshal %s", file)
+		}
+		return os.WriteFile(filepath.Join(dir, file), b, 0o644)
+	}
+	if c := desired.GetComposite().GetResource(); len(c.GetFields()) > 0 {
+		if err := write(compositeFile, c); err != nil {
+			return err
+		}
+	}
+	for name, r := range desired.GetResources() {
+		if err := write(name+".yaml", r.GetResource()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProto writes the supplied request and response as protobuf JSON to the supplied directory, such that the
+// exact behavior of the function can be compared across versions or attached to bug reports.
+func writeProto(dir string, req *fnv1.RunFunctionRequest, res *fnv1.RunFunctionResponse) error {
+	for file, m := range map[string]proto.Message{requestFile: req, responseFile: res} {
+		b, err := debug.ProtoJSON(m)
+		if err != nil {
+			return errors.Wrapf(err, "marshal %s", file)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSummary writes a table with a row for every composite and returns the number of composites for which
+// evaluation failed.
+func writeSummary(w io.Writer, results []result) int {
+	failed, warned := 0, 0
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "COMPOSITE\tRESOURCES\tWARNINGS\tERROR")
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			_, _ = fmt.Fprintf(tw, "%s\t-\t-\t%s\n", r.name, strings.ReplaceAll(r.err.Error(), "\n", "; "))
+			continue
+		}
+		if r.warnings > 0 {
+			warned++
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t\n", r.name, r.resources, r.warnings)
+	}
+	_ = tw.Flush()
+	_, _ = fmt.Fprintf(w, "rendered %d composites: %d with warnings, %d failed\n", len(results), warned, failed)
+	return failed
+}
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const renderHCL = `
+resource bucket {
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "Bucket"
+    spec = {
+      forProvider = {
+        region = req.composite.spec.region
+      }
+    }
+  }
+}
+
+resource policy {
+  condition = req.composite.spec.public
+  body = {
+    apiVersion = "s3.aws.upbound.io/v1beta1"
+    kind       = "BucketPolicy"
+  }
+}
+
+composite status {
+  body = {
+    region = req.composite.spec.region
+  }
+}
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+}
+
+func xr(name, spec string) string {
+	return "apiVersion: example.com/v1\nkind: XBucket\nmetadata:\n  name: " + name + "\nspec:\n" + spec
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	compDir, xrDir, outDir := filepath.Join(root, "comp"), filepath.Join(root, "xrs"), filepath.Join(root, "out")
+	writeFiles(t, compDir, map[string]string{"main.hcl": renderHCL})
+	writeFiles(t, xrDir, map[string]string{
+		"private.yaml": xr("private", "  region: us-east-1\n  public: false\n"),
+		"public.yml":   xr("public", "  region: eu-west-1\n  public: true\n"),
+		"broken.yaml":  xr("broken", "  region: us-east-1\n  public: maybe\n"),
+		"partial.yaml": xr("partial", "  public: false\n"),
+		"README.md":    "not a composite",
+	})
+	// stale files from a previous run are removed
+	writeFiles(t, filepath.Join(outDir, "private"), map[string]string{"policy.yaml": "stale"})
+
+	var out bytes.Buffer
+	err := Run(context.Background(), compDir, Options{XRs: xrDir, Output: outDir, Concurrency: 2}, &out)
+	require.Error(t, err)
+	assert.Equal(t, "evaluation failed for 1 of 4 composites", err.Error())
+
+	lines := out.String()
+	assert.Contains(t, lines, "COMPOSITE  RESOURCES  WARNINGS  ERROR\n")
+	assert.Regexp(t, `broken\s+-\s+-\s+\S+`, lines)
+	assert.Contains(t, lines, "partial    0          2         \n")
+	assert.Contains(t, lines, "private    1          0         \n")
+	assert.Contains(t, lines, "public     2          0         \n")
+	assert.Contains(t, lines, "rendered 4 composites: 1 with warnings, 1 failed\n")
+
+	entries, err := os.ReadDir(filepath.Join(outDir, "private"))
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"bucket.yaml", "composite.yaml"}, names)
+
+	b, err := os.ReadFile(filepath.Join(outDir, "public", "bucket.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "region: eu-west-1")
+	b, err = os.ReadFile(filepath.Join(outDir, "public", "composite.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "region: eu-west-1")
+	_, err = os.Stat(filepath.Join(outDir, "public", "policy.yaml"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(outDir, "broken"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunProto(t *testing.T) {
+	root := t.TempDir()
+	compDir, xrDir, outDir := filepath.Join(root, "comp"), filepath.Join(root, "xrs"), filepath.Join(root, "out")
+	writeFiles(t, compDir, map[string]string{"main.hcl": renderHCL})
+	writeFiles(t, xrDir, map[string]string{"private.yaml": xr("private", "  region: us-east-1\n  public: false\n")})
+
+	var out bytes.Buffer
+	err := Run(context.Background(), compDir, Options{XRs: xrDir, Output: outDir, Concurrency: 1, Proto: true}, &out)
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(filepath.Join(outDir, "private", "request.json"))
+	require.NoError(t, err)
+	var req fnv1.RunFunctionRequest
+	require.NoError(t, protojson.Unmarshal(b, &req))
+	assert.Equal(t, "private", req.GetObserved().GetComposite().GetResource().AsMap()["metadata"].(map[string]any)["name"])
+
+	b, err = os.ReadFile(filepath.Join(outDir, "private", "response.json"))
+	require.NoError(t, err)
+	var res fnv1.RunFunctionResponse
+	require.NoError(t, protojson.Unmarshal(b, &res))
+	assert.Contains(t, res.GetDesired().GetResources(), "bucket")
+	assert.Contains(t, string(b), "\n  \"desired\": {\n")
+}
+
+func TestRunNegative(t *testing.T) {
+	root := t.TempDir()
+	compDir := filepath.Join(root, "comp")
+	writeFiles(t, compDir, map[string]string{"main.hcl": renderHCL})
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		opts   Options
+		errMsg string
+	}{
+		{
+			name:   "no composites",
+			files:  map[string]string{"README.md": "none"},
+			opts:   Options{Concurrency: 1},
+			errMsg: "no composites found in",
+		},
+		{
+			name:   "duplicate names",
+			files:  map[string]string{"a.yaml": xr("a", "  region: r\n"), "a.yml": xr("a", "  region: r\n")},
+			opts:   Options{Concurrency: 1},
+			errMsg: "more than one file for composite a in",
+		},
+		{
+			name:   "empty file",
+			files:  map[string]string{"a.yaml": ""},
+			opts:   Options{Concurrency: 1},
+			errMsg: "no composite found in",
+		},
+		{
+			name:   "bad concurrency",
+			files:  map[string]string{"a.yaml": xr("a", "  region: r\n")},
+			errMsg: "concurrency must be at least 1, found 0",
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			xrDir := filepath.Join(root, "xrs", string(rune('a'+i)))
+			writeFiles(t, xrDir, test.files)
+			test.opts.XRs, test.opts.Output = xrDir, filepath.Join(root, "out")
+			err := Run(context.Background(), compDir, test.opts, &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errMsg)
+		})
+	}
+}
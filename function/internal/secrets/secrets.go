@@ -0,0 +1,66 @@
+// Package secrets finds what look like credentials in source code, so that they can be reported before they are
+// committed into a composition.
+package secrets
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AllowMarker is the marker that allows a line to contain what looks like a credential. It is typically used in a
+// comment at the end of the line, or in a comment on the line before it.
+const AllowMarker = "fn-hcl:allow-secret"
+
+// Finding is a possible credential found in source code.
+type Finding struct {
+	Line   int    // 1-based line number
+	Column int    // 1-based column number of the start of the match
+	Offset int    // 0-based byte offset of the start of the match in the source
+	Length int    // length of the match in bytes
+	Kind   string // kind of credential
+}
+
+type detector struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var detectors = []detector{
+	{kind: "AWS access key ID", re: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[A-Z0-9]{16}\b`)},
+	{kind: "AWS secret access key", re: regexp.MustCompile(`(?i)aws_?secret_?access_?key"?\s*[=:]\s*"[A-Za-z0-9/+]{40}"`)},
+	{kind: "private key", re: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----`)},
+	{kind: "GitHub token", re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b`)},
+}
+
+// isAllowMarkerLine returns true if the supplied line is a comment that allows the next line.
+func isAllowMarkerLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return (strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")) && strings.Contains(line, AllowMarker)
+}
+
+// Scan returns the possible credentials in the supplied source, in order of their lines. Lines that contain the
+// allow marker, or follow a comment line that contains it, are skipped.
+func Scan(src []byte) []Finding {
+	var ret []Finding
+	offset, previous := 0, ""
+	for i, raw := range strings.Split(string(src), "\n") {
+		line := strings.TrimSuffix(raw, "\r")
+		allowed := strings.Contains(line, AllowMarker) || isAllowMarkerLine(previous)
+		previous = line
+		if !allowed {
+			for _, d := range detectors {
+				for _, loc := range d.re.FindAllStringIndex(line, -1) {
+					ret = append(ret, Finding{
+						Line:   i + 1,
+						Column: loc[0] + 1,
+						Offset: offset + loc[0],
+						Length: loc[1] - loc[0],
+						Kind:   d.kind,
+					})
+				}
+			}
+		}
+		offset += len(raw) + 1
+	}
+	return ret
+}
@@ -0,0 +1,65 @@
+// Package simulate evaluates a composition against the composite resources in a live cluster and reports
+// how their composed resources would change, such that the impact of a change to a composition can be
+// checked before it is rolled out.
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Options control the composites that are simulated.
+type Options struct {
+	Kubeconfig string   // path to the kubeconfig file, the default loading rules are used when empty
+	XRD        string   // kind of the composites, optionally qualified by its group as kind.group
+	Namespace  string   // namespace of the composites, all namespaces when empty
+	Flags      []string // feature flags to evaluate with
+	Targets    []string // labels of the blocks to evaluate, all blocks are evaluated when empty
+}
+
+// Source provides composite resources along with the objects that they depend on.
+type Source interface {
+	// Composites returns the composite resources to simulate.
+	Composites(ctx context.Context) ([]*unstructured.Unstructured, error)
+	// Composed returns the resources composed for the supplied composite keyed by their composition resource name.
+	Composed(ctx context.Context, xr *unstructured.Unstructured) (map[string]*unstructured.Unstructured, error)
+	// Extra returns the objects that match the supplied selector.
+	Extra(ctx context.Context, selector *fnv1.ResourceSelector) ([]*unstructured.Unstructured, error)
+}
+
+// Run evaluates the composition in the supplied directory against the composites in the cluster and writes a
+// report of the changes for every composite to the supplied writer. It returns an error when the composition
+// could not be evaluated for any composite.
+func Run(ctx context.Context, dir string, opts Options, w io.Writer) error {
+	cfg, files, err := composition.LoadFiles(dir)
+	if err != nil {
+		return err
+	}
+	gk, versions, err := compositeKind(opts.XRD, cfg)
+	if err != nil {
+		return err
+	}
+	p, err := evaluator.Compile(evaluator.Options{Flags: opts.Flags, Targets: opts.Targets}, files...)
+	if err != nil {
+		return err
+	}
+	src, err := newClusterSource(opts.Kubeconfig, gk, versions, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	reports, err := simulate(ctx, p, src)
+	if err != nil {
+		return err
+	}
+	failed := writeReports(w, reports)
+	if failed > 0 {
+		return fmt.Errorf("evaluation failed for %d of %d composites", failed, len(reports))
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// CaptureOptions control the composite that is captured.
+type CaptureOptions struct {
+	Kubeconfig string   // path to the kubeconfig file, the default loading rules are used when empty
+	XRD        string   // kind of the composite, optionally qualified by its group as kind.group
+	Namespace  string   // namespace of the composite, all namespaces when empty
+	Name       string   // name of the composite
+	Flags      []string // feature flags to evaluate with when resolving extra resources
+	Format     string   // format of the request, json or yaml
+}
+
+// redactedSecretValue replaces the values of secrets in captured requests, encoded in base64.
+const redactedSecretValue = "cmVkYWN0ZWQ=" // "redacted"
+
+// Capture writes a RunFunctionRequest for the named composite in the cluster to the supplied writer. The request
+// has the composite and its composed resources as observed state, and the extra resources that the composition
+// in the supplied directory requires. Fields that only matter to the API server are removed and the values of
+// secrets are redacted, such that the request can be checked in as a test fixture.
+func Capture(ctx context.Context, dir string, opts CaptureOptions, w io.Writer) error {
+	if opts.Format != formatJSON && opts.Format != formatYAML {
+		return fmt.Errorf("unsupported format %q, must be %s or %s", opts.Format, formatJSON, formatYAML)
+	}
+	cfg, files, err := composition.LoadFiles(dir)
+	if err != nil {
+		return err
+	}
+	gk, versions, err := compositeKind(opts.XRD, cfg)
+	if err != nil {
+		return err
+	}
+	p, err := evaluator.Compile(evaluator.Options{Flags: opts.Flags}, files...)
+	if err != nil {
+		return err
+	}
+	src, err := newClusterSource(opts.Kubeconfig, gk, versions, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	req, evalErr := capture(ctx, p, src, opts.Name)
+	if req == nil {
+		return evalErr
+	}
+	if err := writeRequest(w, req, opts.Format); err != nil {
+		return err
+	}
+	if evalErr != nil {
+		return errors.Wrap(evalErr, "evaluate composition, extra resources may be missing")
+	}
+	return nil
+}
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// capture returns the sanitized request for the named composite. When the composition cannot be evaluated to
+// find the extra resources it requires, the request is returned along with the error.
+func capture(ctx context.Context, p *evaluator.Program, src Source, name string) (*fnv1.RunFunctionRequest, error) {
+	xrs, err := src.Composites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	var req *fnv1.RunFunctionRequest
+	for _, xr := range xrs {
+		if xr.GetName() != name {
+			continue
+		}
+		matches = append(matches, displayName(xr))
+		if req, err = toRequest(ctx, src, xr); err != nil {
+			return nil, err
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("composite %s not found", name)
+	case 1:
+	default:
+		return nil, fmt.Errorf("found composites %v, specify a namespace", matches)
+	}
+	_, _, evalErr := evaluate(ctx, p, src, req)
+	if err := sanitizeRequest(req); err != nil {
+		return nil, err
+	}
+	return req, evalErr
+}
+
+// sanitizeRequest sanitizes all objects in the supplied request.
+func sanitizeRequest(req *fnv1.RunFunctionRequest) error {
+	resources := []*fnv1.Resource{req.GetObserved().GetComposite()}
+	for _, r := range req.GetObserved().GetResources() {
+		resources = append(resources, r)
+	}
+	for _, extra := range req.GetExtraResources() {
+		resources = append(resources, extra.GetItems()...)
+	}
+	for _, r := range resources {
+		obj := sanitize(r.GetResource().AsMap())
+		s, err := structpb.NewStruct(obj)
+		if err != nil {
+			return errors.Wrap(err, "convert sanitized object")
+		}
+		r.Resource = s
+	}
+	return nil
+}
+
+// serverFields are the metadata fields that are set by the API server and are not needed by compositions.
+var serverFields = []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink"}
+
+// sanitize removes fields of the supplied object that are set by the API server and redacts the values of secrets.
+func sanitize(obj map[string]any) map[string]any {
+	if meta, ok := obj["metadata"].(map[string]any); ok {
+		for _, f := range serverFields {
+			delete(meta, f)
+		}
+		if annotations, ok := meta["annotations"].(map[string]any); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+	if obj["apiVersion"] == "v1" && obj["kind"] == "Secret" {
+		for _, f := range []string{"data", "stringData"} {
+			data, ok := obj[f].(map[string]any)
+			if !ok {
+				continue
+			}
+			for k := range data {
+				data[k] = redactedSecretValue
+			}
+		}
+	}
+	return obj
+}
+
+// writeRequest writes the supplied request in the supplied format.
+func writeRequest(w io.Writer, req *fnv1.RunFunctionRequest, format string) error {
+	b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "marshal request")
+	}
+	if format == formatYAML {
+		if b, err = yaml.JSONToYAML(b); err != nil {
+			return errors.Wrap(err, "convert request to YAML")
+		}
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,102 @@
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCapture(t *testing.T) {
+	serverMetadata := func(name string) map[string]any {
+		return map[string]any{
+			"name":              name,
+			"uid":               "1234",
+			"resourceVersion":   "42",
+			"generation":        int64(3),
+			"creationTimestamp": "2024-01-01T00:00:00Z",
+			"managedFields":     []any{map[string]any{"manager": "crossplane"}},
+			"annotations": map[string]any{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		}
+	}
+	src := &fakeSource{
+		xrs: []*unstructured.Unstructured{
+			object(map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "XNetwork",
+				"metadata":   map[string]any{"name": "other"},
+			}),
+			object(map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "XNetwork",
+				"metadata":   serverMetadata("net"),
+				"spec":       map[string]any{"region": "us-east-1", "subnets": false},
+			}),
+		},
+		composed: map[string]map[string]*unstructured.Unstructured{
+			"net": {"vpc": object(map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "VPC",
+				"metadata":   serverMetadata("net-vpc"),
+			})},
+		},
+		extra: map[string][]*unstructured.Unstructured{
+			"network": {object(map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]any{"name": "network"},
+				"data":       map[string]any{"cidr": "MTAuMC4wLjAvMTY="},
+			})},
+		},
+	}
+	p, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: "main.hcl", Content: simulateHCL})
+	require.NoError(t, err)
+	req, err := capture(context.Background(), p, src, "net")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "XNetwork",
+		"metadata":   map[string]any{"name": "net"},
+		"spec":       map[string]any{"region": "us-east-1", "subnets": false},
+	}, req.GetObserved().GetComposite().GetResource().AsMap())
+	assert.Equal(t, map[string]any{"name": "net-vpc"}, req.GetObserved().GetResources()["vpc"].GetResource().AsMap()["metadata"])
+	extra := req.GetExtraResources()["network"].GetItems()
+	require.Len(t, extra, 1)
+	assert.Equal(t, map[string]any{"cidr": redactedSecretValue}, extra[0].GetResource().AsMap()["data"])
+
+	var buf bytes.Buffer
+	require.NoError(t, writeRequest(&buf, req, formatYAML))
+	assert.Contains(t, buf.String(), "observed:\n")
+	var loaded fnv1.RunFunctionRequest
+	buf.Reset()
+	require.NoError(t, writeRequest(&buf, req, formatJSON))
+	require.NoError(t, protojson.Unmarshal(buf.Bytes(), &loaded))
+	assert.Equal(t, "net", loaded.GetObserved().GetComposite().GetResource().AsMap()["metadata"].(map[string]any)["name"])
+}
+
+func TestCaptureErrors(t *testing.T) {
+	xr := func(namespace string) *unstructured.Unstructured {
+		return object(map[string]any{
+			"apiVersion": "example.com/v1",
+			"kind":       "XNetwork",
+			"metadata":   map[string]any{"name": "net", "namespace": namespace},
+		})
+	}
+	p, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: "main.hcl", Content: simulateHCL})
+	require.NoError(t, err)
+
+	_, err = capture(context.Background(), p, &fakeSource{}, "net")
+	require.EqualError(t, err, "composite net not found")
+
+	_, err = capture(context.Background(), p, &fakeSource{xrs: []*unstructured.Unstructured{xr("a"), xr("b")}}, "net")
+	require.EqualError(t, err, "found composites [a/net b/net], specify a namespace")
+}
@@ -0,0 +1,175 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/composition"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// annotationCompositionResourceName is the annotation that Crossplane sets on composed resources to record the
+// name of the resource in the composition.
+const annotationCompositionResourceName = "crossplane.io/composition-resource-name"
+
+// compositeKind returns the group and kind of the composites to simulate along with the preferred versions,
+// using the XRD in the composition configuration when none is supplied.
+func compositeKind(xrd string, cfg *composition.Config) (schema.GroupKind, []string, error) {
+	if xrd != "" {
+		return schema.ParseGroupKind(xrd), nil, nil
+	}
+	if cfg == nil || cfg.XRD.Kind == "" {
+		return schema.GroupKind{}, nil, fmt.Errorf("no composite kind, specify one using --xrd or in %s", composition.ConfigFile)
+	}
+	gv, err := schema.ParseGroupVersion(cfg.XRD.APIVersion)
+	if err != nil {
+		return schema.GroupKind{}, nil, errors.Wrapf(err, "parse XRD API version in %s", composition.ConfigFile)
+	}
+	return schema.GroupKind{Group: gv.Group, Kind: cfg.XRD.Kind}, []string{gv.Version}, nil
+}
+
+// clusterSource is a source that reads objects from a cluster.
+type clusterSource struct {
+	client    dynamic.Interface
+	mapper    meta.RESTMapper
+	mapping   *meta.RESTMapping // mapping for the composites
+	namespace string
+}
+
+func newClusterSource(kubeconfig string, gk schema.GroupKind, versions []string, namespace string) (*clusterSource, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "load kubeconfig")
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create client")
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create discovery client")
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	mapping, err := mapper.RESTMapping(gk, versions...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "find composite resource %s", gk)
+	}
+	return &clusterSource{client: client, mapper: mapper, mapping: mapping, namespace: namespace}, nil
+}
+
+// resource returns the client for the supplied mapping, scoped to the supplied namespace for namespaced resources.
+func (c *clusterSource) resource(mapping *meta.RESTMapping, namespace string) dynamic.ResourceInterface {
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return c.client.Resource(mapping.Resource).Namespace(namespace)
+	}
+	return c.client.Resource(mapping.Resource)
+}
+
+func (c *clusterSource) mappingFor(apiVersion, kind string) (*meta.RESTMapping, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	return c.mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: kind}, gv.Version)
+}
+
+func (c *clusterSource) Composites(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	list, err := c.resource(c.mapping, c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "list %s", c.mapping.Resource)
+	}
+	var ret []*unstructured.Unstructured
+	for i := range list.Items {
+		ret = append(ret, &list.Items[i])
+	}
+	return ret, nil
+}
+
+// resourceRefs returns the references to composed resources of the supplied composite. Crossplane v2 records
+// these under spec.crossplane, older versions directly under spec.
+func resourceRefs(xr *unstructured.Unstructured) []map[string]any {
+	refs, found, _ := unstructured.NestedSlice(xr.Object, "spec", "crossplane", "resourceRefs")
+	if !found {
+		refs, _, _ = unstructured.NestedSlice(xr.Object, "spec", "resourceRefs")
+	}
+	var ret []map[string]any
+	for _, r := range refs {
+		if m, ok := r.(map[string]any); ok {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+func (c *clusterSource) Composed(ctx context.Context, xr *unstructured.Unstructured) (map[string]*unstructured.Unstructured, error) {
+	ret := map[string]*unstructured.Unstructured{}
+	for _, ref := range resourceRefs(xr) {
+		apiVersion, _ := ref["apiVersion"].(string)
+		kind, _ := ref["kind"].(string)
+		name, _ := ref["name"].(string)
+		namespace, _ := ref["namespace"].(string)
+		if namespace == "" {
+			namespace = xr.GetNamespace()
+		}
+		mapping, err := c.mappingFor(apiVersion, kind)
+		if err != nil {
+			return nil, errors.Wrapf(err, "find composed resource %s %s", apiVersion, kind)
+		}
+		obj, err := c.resource(mapping, namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get composed resource %s %s", kind, name)
+		}
+		resourceName := obj.GetAnnotations()[annotationCompositionResourceName]
+		if resourceName == "" {
+			continue
+		}
+		ret[resourceName] = obj
+	}
+	return ret, nil
+}
+
+func (c *clusterSource) Extra(ctx context.Context, selector *fnv1.ResourceSelector) ([]*unstructured.Unstructured, error) {
+	mapping, err := c.mappingFor(selector.GetApiVersion(), selector.GetKind())
+	if err != nil {
+		return nil, errors.Wrapf(err, "find extra resource %s %s", selector.GetApiVersion(), selector.GetKind())
+	}
+	ri := c.resource(mapping, "")
+	if name := selector.GetMatchName(); name != "" {
+		obj, err := ri.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get extra resource %s %s", selector.GetKind(), name)
+		}
+		return []*unstructured.Unstructured{obj}, nil
+	}
+	list, err := ri.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector.GetMatchLabels().GetLabels()).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "list extra resources %s", selector.GetKind())
+	}
+	var ret []*unstructured.Unstructured
+	for i := range list.Items {
+		ret = append(ret, &list.Items[i])
+	}
+	return ret, nil
+}
@@ -0,0 +1,292 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxRequirementRounds is the maximum number of times a composite is evaluated to satisfy requirements for
+// extra resources, matching what Crossplane does for a single function.
+const maxRequirementRounds = 5
+
+// report is the outcome of the simulation for a single composite.
+type report struct {
+	name       string              // namespaced name of the composite
+	added      []string            // desired resources that do not exist yet
+	removed    []string            // existing resources that are no longer desired
+	changed    map[string][]string // paths of changed fields keyed by resource name
+	incomplete bool                // whether any item was discarded because of incomplete values
+	err        error               // evaluation error, if any
+}
+
+// simulate evaluates the program for every composite in the source, in name order.
+func simulate(ctx context.Context, p *evaluator.Program, src Source) ([]report, error) {
+	xrs, err := src.Composites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(xrs, func(i, j int) bool {
+		return displayName(xrs[i]) < displayName(xrs[j])
+	})
+	var ret []report
+	for _, xr := range xrs {
+		r := report{name: displayName(xr)}
+		req, err := toRequest(ctx, src, xr)
+		if err != nil {
+			r.err = err
+			ret = append(ret, r)
+			continue
+		}
+		res, incomplete, err := evaluate(ctx, p, src, req)
+		if err != nil {
+			r.err = err
+			ret = append(ret, r)
+			continue
+		}
+		r.incomplete = incomplete
+		r.added, r.removed, r.changed = diffResources(req.GetObserved().GetResources(), res.GetDesired().GetResources())
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+func displayName(xr *unstructured.Unstructured) string {
+	if xr.GetNamespace() == "" {
+		return xr.GetName()
+	}
+	return xr.GetNamespace() + "/" + xr.GetName()
+}
+
+func toResource(obj *unstructured.Unstructured) (*fnv1.Resource, error) {
+	s, err := structpb.NewStruct(obj.Object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "convert %s %s", obj.GetKind(), obj.GetName())
+	}
+	return &fnv1.Resource{Resource: s}, nil
+}
+
+// toRequest returns a request with the supplied composite and its composed resources as observed state.
+func toRequest(ctx context.Context, src Source, xr *unstructured.Unstructured) (*fnv1.RunFunctionRequest, error) {
+	composite, err := toResource(xr)
+	if err != nil {
+		return nil, err
+	}
+	composed, err := src.Composed(ctx, xr)
+	if err != nil {
+		return nil, err
+	}
+	resources := map[string]*fnv1.Resource{}
+	for name, obj := range composed {
+		r, err := toResource(obj)
+		if err != nil {
+			return nil, err
+		}
+		resources[name] = r
+	}
+	return &fnv1.RunFunctionRequest{
+		Observed: &fnv1.State{Composite: composite, Resources: resources},
+		Desired:  &fnv1.State{},
+	}, nil
+}
+
+// evaluate evaluates the program against the supplied request, fetching the extra resources that are required
+// and evaluating again until the requirements are stable.
+func evaluate(ctx context.Context, p *evaluator.Program, src Source, req *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, bool, error) {
+	run := func(req *fnv1.RunFunctionRequest) (*evaluator.Evaluator, *fnv1.RunFunctionResponse, error) {
+		e, err := p.NewEvaluator()
+		if err != nil {
+			return nil, nil, err
+		}
+		res, err := e.Eval(ctx, req)
+		return e, res, err
+	}
+
+	// Existing resources that cannot be evaluated are errors, so the composition would fail for existing
+	// composites before it has a chance to request extra resources. Resolve requirements without any composed
+	// resources first.
+	var fetched map[string]*fnv1.ResourceSelector
+	probe := proto.Clone(req).(*fnv1.RunFunctionRequest)
+	probe.Observed.Resources = nil
+	for i := 0; i < maxRequirementRounds; i++ {
+		_, res, err := run(probe)
+		if err != nil {
+			break
+		}
+		next := res.GetRequirements().GetExtraResources()
+		if requirementsEqual(fetched, next) {
+			break
+		}
+		extra, err := fetchExtra(ctx, src, next)
+		if err != nil {
+			return nil, false, err
+		}
+		fetched, probe.ExtraResources, req.ExtraResources = next, extra, extra
+	}
+
+	for i := 0; ; i++ {
+		e, res, err := run(req)
+		if err != nil {
+			return nil, false, err
+		}
+		next := res.GetRequirements().GetExtraResources()
+		if i == maxRequirementRounds-1 || requirementsEqual(fetched, next) {
+			return res, e.Incomplete(), nil
+		}
+		extra, err := fetchExtra(ctx, src, next)
+		if err != nil {
+			return nil, false, err
+		}
+		fetched, req.ExtraResources = next, extra
+	}
+}
+
+// fetchExtra returns the extra resources for the supplied requirements.
+func fetchExtra(ctx context.Context, src Source, requirements map[string]*fnv1.ResourceSelector) (map[string]*fnv1.Resources, error) {
+	ret := map[string]*fnv1.Resources{}
+	for name, selector := range requirements {
+		objs, err := src.Extra(ctx, selector)
+		if err != nil {
+			return nil, err
+		}
+		var items []*fnv1.Resource
+		for _, obj := range objs {
+			r, err := toResource(obj)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, r)
+		}
+		ret[name] = &fnv1.Resources{Items: items}
+	}
+	return ret, nil
+}
+
+func requirementsEqual(a, b map[string]*fnv1.ResourceSelector) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, s := range a {
+		if !proto.Equal(s, b[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffResources compares desired resources with observed ones. Fields that are only present in observed resources
+// are ignored since they are usually set by providers or the API server.
+func diffResources(observed, desired map[string]*fnv1.Resource) (added, removed []string, changed map[string][]string) {
+	changed = map[string][]string{}
+	for name, d := range desired {
+		o, ok := observed[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		if paths := diffPaths("", d.GetResource().AsMap(), o.GetResource().AsMap()); len(paths) > 0 {
+			changed[name] = paths
+		}
+	}
+	for name := range observed {
+		if _, ok := desired[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, changed
+}
+
+// diffPaths returns the paths of the values in the desired value that are not present in the observed value.
+func diffPaths(path string, desired, observed any) []string {
+	switch d := desired.(type) {
+	case map[string]any:
+		o, ok := observed.(map[string]any)
+		if !ok {
+			return []string{path}
+		}
+		var keys []string
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var ret []string
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			ov, ok := o[k]
+			if !ok {
+				ret = append(ret, p)
+				continue
+			}
+			ret = append(ret, diffPaths(p, d[k], ov)...)
+		}
+		return ret
+	case []any:
+		o, ok := observed.([]any)
+		if !ok || len(o) != len(d) {
+			return []string{path}
+		}
+		var ret []string
+		for i := range d {
+			ret = append(ret, diffPaths(fmt.Sprintf("%s[%d]", path, i), d[i], o[i])...)
+		}
+		return ret
+	default:
+		if !reflect.DeepEqual(desired, observed) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+// writeReports writes the supplied reports and returns the number of composites for which evaluation failed.
+func writeReports(w io.Writer, reports []report) int {
+	failed, changes := 0, 0
+	for _, r := range reports {
+		if r.err != nil {
+			failed++
+			_, _ = fmt.Fprintf(w, "%s: error: %v\n", r.name, r.err)
+			continue
+		}
+		suffix := ""
+		if r.incomplete {
+			suffix = " (incomplete)"
+		}
+		if len(r.added)+len(r.removed)+len(r.changed) == 0 {
+			_, _ = fmt.Fprintf(w, "%s: no changes%s\n", r.name, suffix)
+			continue
+		}
+		changes++
+		_, _ = fmt.Fprintf(w, "%s: %d added, %d removed, %d changed%s\n", r.name, len(r.added), len(r.removed), len(r.changed), suffix)
+		for _, name := range r.added {
+			_, _ = fmt.Fprintf(w, "  + %s\n", name)
+		}
+		for _, name := range r.removed {
+			_, _ = fmt.Fprintf(w, "  - %s\n", name)
+		}
+		var names []string
+		for name := range r.changed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			_, _ = fmt.Fprintf(w, "  ~ %s: %s\n", name, strings.Join(r.changed[name], ", "))
+		}
+	}
+	_, _ = fmt.Fprintf(w, "simulated %d composites: %d with changes, %d failed\n", len(reports), changes, failed)
+	return failed
+}
@@ -0,0 +1,132 @@
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type fakeSource struct {
+	xrs      []*unstructured.Unstructured
+	composed map[string]map[string]*unstructured.Unstructured
+	extra    map[string][]*unstructured.Unstructured // keyed by name to match
+}
+
+func (f *fakeSource) Composites(context.Context) ([]*unstructured.Unstructured, error) {
+	return f.xrs, nil
+}
+
+func (f *fakeSource) Composed(_ context.Context, xr *unstructured.Unstructured) (map[string]*unstructured.Unstructured, error) {
+	return f.composed[xr.GetName()], nil
+}
+
+func (f *fakeSource) Extra(_ context.Context, selector *fnv1.ResourceSelector) ([]*unstructured.Unstructured, error) {
+	return f.extra[selector.GetMatchName()], nil
+}
+
+func object(o map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: o}
+}
+
+const simulateHCL = `
+requirement network {
+  select {
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    matchName  = "network"
+  }
+}
+
+resource vpc {
+  body = {
+    apiVersion = "ec2.aws.upbound.io/v1beta1"
+    kind       = "VPC"
+    spec = {
+      forProvider = {
+        region    = req.composite.spec.region
+        cidrBlock = req.extra_resources.network[0].data.cidr
+      }
+    }
+  }
+}
+
+resource subnet {
+  condition = req.composite.spec.subnets
+  body = {
+    apiVersion = "ec2.aws.upbound.io/v1beta1"
+    kind       = "Subnet"
+  }
+}
+`
+
+func TestSimulate(t *testing.T) {
+	xr := func(name string, subnets bool) *unstructured.Unstructured {
+		return object(map[string]any{
+			"apiVersion": "example.com/v1",
+			"kind":       "XNetwork",
+			"metadata":   map[string]any{"name": name},
+			"spec":       map[string]any{"region": "us-east-1", "subnets": subnets},
+		})
+	}
+	vpc := func(region string) *unstructured.Unstructured {
+		return object(map[string]any{
+			"apiVersion": "ec2.aws.upbound.io/v1beta1",
+			"kind":       "VPC",
+			"spec": map[string]any{
+				"forProvider": map[string]any{"region": region, "cidrBlock": "10.0.0.0/16"},
+			},
+			"status": map[string]any{"id": "vpc-1234"},
+		})
+	}
+	src := &fakeSource{
+		xrs: []*unstructured.Unstructured{xr("b", true), xr("a", false)},
+		composed: map[string]map[string]*unstructured.Unstructured{
+			"a": {"vpc": vpc("us-east-1")},
+			"b": {"vpc": vpc("us-west-2"), "gateway": object(map[string]any{"kind": "InternetGateway"})},
+		},
+		extra: map[string][]*unstructured.Unstructured{
+			"network": {object(map[string]any{"data": map[string]any{"cidr": "10.0.0.0/16"}})},
+		},
+	}
+	p, err := evaluator.Compile(evaluator.Options{}, evaluator.File{Name: "main.hcl", Content: simulateHCL})
+	require.NoError(t, err)
+	reports, err := simulate(context.Background(), p, src)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	failed := writeReports(&buf, reports)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, `a: no changes
+b: 1 added, 1 removed, 1 changed
+  + subnet
+  - gateway
+  ~ vpc: spec.forProvider.region
+simulated 2 composites: 1 with changes, 0 failed
+`, buf.String())
+}
+
+func TestDiffPaths(t *testing.T) {
+	desired := map[string]any{
+		"spec": map[string]any{
+			"tags":  []any{"a", "b"},
+			"ports": []any{map[string]any{"port": 80.0}, map[string]any{"port": 443.0}},
+			"name":  "foo",
+			"new":   true,
+		},
+	}
+	observed := map[string]any{
+		"spec": map[string]any{
+			"tags":  []any{"a"},
+			"ports": []any{map[string]any{"port": 80.0}, map[string]any{"port": 8443.0, "protocol": "TCP"}},
+			"name":  "foo",
+			"extra": "ignored",
+		},
+	}
+	assert.Equal(t, []string{"spec.new", "spec.ports[1].port", "spec.tags"}, diffPaths("", desired, observed))
+}
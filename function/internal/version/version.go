@@ -0,0 +1,9 @@
+// Package version provides build information for the function and its tools.
+package version
+
+// Build information. Populated at build-time.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
@@ -2,30 +2,81 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/alecthomas/kong"
+	"github.com/crossplane-contrib/function-hcl/function/internal/evaluator"
 	"github.com/crossplane-contrib/function-hcl/function/internal/fn"
 	"github.com/crossplane/function-sdk-go"
 )
 
 // CLI of this Function.
 type CLI struct {
-	Debug       bool   `short:"d" help:"Emit debug logs in addition to info logs."`
-	Network     string `help:"Network on which to listen for gRPC connections." default:"tcp"`
-	Address     string `help:"Address at which to listen for gRPC connections." default:":9443"`
-	TLSCertsDir string `help:"Directory containing server certs (tls.key, tls.crt) and the CA used to verify client certificates (ca.crt)" env:"TLS_SERVER_CERTS_DIR"`
-	Insecure    bool   `help:"Run without mTLS credentials. If you supply this flag --tls-server-certs-dir will be ignored."`
+	Debug              bool          `short:"d" help:"Emit debug logs in addition to info logs."`
+	Network            string        `help:"Network on which to listen for gRPC connections." default:"tcp"`
+	Address            string        `help:"Address at which to listen for gRPC connections." default:":9443"`
+	TLSCertsDir        string        `help:"Directory containing server certs (tls.key, tls.crt) and the CA used to verify client certificates (ca.crt)" env:"TLS_SERVER_CERTS_DIR"`
+	Insecure           bool          `help:"Run without mTLS credentials. If you supply this flag --tls-server-certs-dir will be ignored."`
+	Metadata           bool          `help:"Print the version, supported blocks, built-in functions and input options as JSON and exit."`
+	CacheSize          int           `help:"Number of responses to cache by request tag, such that identical repeated requests are not evaluated again. Zero disables caching." default:"0"`
+	ProgramCacheSize   int           `help:"Number of function inputs whose parsed HCL is cached across requests. Zero disables caching." default:"16"`
+	CacheStatsInterval time.Duration `help:"Minimum time between two info logs of the hits and misses of the response and program caches." default:"5m"`
+
+	Sandbox                  string   `help:"Name of a sandbox profile to enforce for all compositions, for platforms on which composition authors are not trusted. The restricted profile disables time functions and limits collection sizes and call depth, other names start without restrictions."`
+	SandboxDisabledFunctions []string `help:"Built-in functions disabled by the sandbox profile, instead of the ones of the named profile."`
+	SandboxMaxCollectionSize int      `help:"Maximum number of elements of collections returned by functions and of resource collection iterations, instead of the one of the named profile."`
+	SandboxMaxInvokeDepth    int      `help:"Maximum depth of nested user function and lambda calls, instead of the one of the named profile."`
+}
+
+// sandbox returns the sandbox profile configured by the flags, or nil when none is enforced.
+func (c *CLI) sandbox() *evaluator.SandboxProfile {
+	if c.Sandbox == "" {
+		return nil
+	}
+	p := evaluator.SandboxProfile{Name: c.Sandbox}
+	if c.Sandbox == evaluator.DefaultSandboxProfile.Name {
+		p = evaluator.DefaultSandboxProfile
+	}
+	if c.SandboxDisabledFunctions != nil {
+		p.DisabledFunctions = c.SandboxDisabledFunctions
+	}
+	if c.SandboxMaxCollectionSize != 0 {
+		p.MaxCollectionSize = c.SandboxMaxCollectionSize
+	}
+	if c.SandboxMaxInvokeDepth != 0 {
+		p.MaxInvokeDepth = c.SandboxMaxInvokeDepth
+	}
+	return &p
 }
 
 // Run this Function.
 func (c *CLI) Run() error {
+	m := fn.NewMetadata()
+	if c.Metadata {
+		b, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
 	l, err := function.NewLogger(c.Debug)
 	if err != nil {
 		return err
 	}
+	l.Info("starting function", "version", m.Version, "commit", m.Commit, "buildDate", m.BuildDate,
+		"blocks", m.Blocks, "functions", m.Functions, "inputOptions", m.InputOptions)
 
 	f, err := fn.New(fn.Options{
-		Logger: l,
-		Debug:  c.Debug,
+		Logger:             l,
+		Debug:              c.Debug,
+		CacheSize:          c.CacheSize,
+		ProgramCacheSize:   c.ProgramCacheSize,
+		CacheStatsInterval: c.CacheStatsInterval,
+		Sandbox:            c.sandbox(),
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,161 @@
+// Package hclexpr provides utilities for walking HCL expressions and values that are used by the function-hcl
+// evaluator and analyzer. They are exposed such that linters, editors and other tools can interpret expressions
+// in the same way as the function.
+package hclexpr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// reIdent is a regular expression that can test for HCL identifiers that are allowed to contain dashes.
+var reIdent = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// IsIdentifier returns true if the supplied string can be interpreted as an HCL identifier.
+func IsIdentifier(s string) bool {
+	return reIdent.MatchString(s)
+}
+
+// NormalizeTraversal normalizes an index traversal to an attribute traversal for known cases.
+// (i.e. x["foo"] is effectively turned to x.foo).
+func NormalizeTraversal(t hcl.Traversal) hcl.Traversal {
+	var ret hcl.Traversal
+loop:
+	for _, item := range t {
+		switch item := item.(type) {
+		case hcl.TraverseRoot:
+			ret = append(ret, item)
+		case hcl.TraverseAttr:
+			ret = append(ret, item)
+		case hcl.TraverseIndex:
+			k := item.Key
+			if k.Type() == cty.String && IsIdentifier(k.AsString()) {
+				ret = append(ret, hcl.TraverseAttr{
+					Name:     k.AsString(),
+					SrcRange: item.SrcRange,
+				})
+				continue loop
+			}
+			ret = append(ret, item)
+		default:
+			panic(fmt.Errorf("unexpected traversal type: %T", item))
+		}
+	}
+	return ret
+}
+
+// UnguardedTraversals returns the traversals in the supplied expression that are not wrapped in a
+// try or can call. References wrapped in these calls already handle the case of the value not existing.
+func UnguardedTraversals(expr hcl.Expression) []hcl.Traversal {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return expr.Variables()
+	}
+	var safe []hcl.Range
+	var travs []*hclsyntax.ScopeTraversalExpr
+	_ = hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		switch x := n.(type) {
+		case *hclsyntax.FunctionCallExpr:
+			if x.Name == "try" || x.Name == "can" {
+				safe = append(safe, x.Range())
+			}
+		case *hclsyntax.ScopeTraversalExpr:
+			travs = append(travs, x)
+		}
+		return nil
+	})
+	var ret []hcl.Traversal
+outer:
+	for _, t := range travs {
+		for _, r := range safe {
+			if r.Filename == t.SrcRange.Filename && r.ContainsOffset(t.SrcRange.Start.Byte) {
+				continue outer
+			}
+		}
+		ret = append(ret, t.Traversal)
+	}
+	return ret
+}
+
+// Reference is a reference to a named object in the request, such as req.resource.<name>.
+type Reference struct {
+	Kind  string    // the attribute under req, e.g. "resource", "connections" or "extra_resources"
+	Name  string    // the name of the object
+	Range hcl.Range // source range of the traversal making the reference
+}
+
+// References returns the references to named objects in the request made by the supplied expression, in
+// source order. Index traversals with constant keys such as req.resource["my-bucket"] are treated the same
+// as attribute traversals.
+func References(expr hcl.Expression) []Reference {
+	var ret []Reference
+	for _, t := range expr.Variables() {
+		r := t.SourceRange()
+		t = NormalizeTraversal(t)
+		if t.RootName() != "req" || len(t) < 3 {
+			continue
+		}
+		kind, ok := t[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		name, ok := t[2].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		ret = append(ret, Reference{Kind: kind.Name, Name: name.Name, Range: r})
+	}
+	return ret
+}
+
+// FindUnknownPaths walks the value and returns a list of paths to unknown values, formatted using PathString.
+func FindUnknownPaths(val cty.Value) ([]string, error) {
+	var unknownPaths []string
+	if err := cty.Walk(val, func(path cty.Path, v cty.Value) (bool, error) {
+		if !v.IsKnown() {
+			unknownPaths = append(unknownPaths, PathString(path))
+			return true, nil
+		}
+		return true, nil
+	}); err != nil {
+		return unknownPaths, err
+	}
+
+	return unknownPaths, nil
+}
+
+// unknownSegmentMarker is used to represent segments we don't support decoding.
+const unknownSegmentMarker = "<?>"
+
+// PathString converts a cty.Path to a human-readable string, e.g. ".spec.ports[0]".
+func PathString(path cty.Path) string {
+	segments := make([]string, 0, len(path))
+
+	for _, p := range path {
+		switch s := p.(type) {
+		case cty.GetAttrStep:
+			segments = append(segments, fmt.Sprintf(".%s", s.Name))
+		case cty.IndexStep:
+			if !s.Key.IsKnown() {
+				segments = append(segments, unknownSegmentMarker)
+				continue
+			}
+			switch s.Key.Type() {
+			case cty.String:
+				segments = append(segments, fmt.Sprintf("[%s]", s.Key.AsString()))
+			case cty.Number:
+				segments = append(segments, fmt.Sprintf("[%s]", s.Key.AsBigFloat().Text('f', 0)))
+			default:
+				segments = append(segments, unknownSegmentMarker)
+			}
+		default:
+			segments = append(segments, unknownSegmentMarker)
+		}
+	}
+	return strings.Join(segments, "")
+}
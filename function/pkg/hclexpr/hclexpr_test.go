@@ -0,0 +1,78 @@
+package hclexpr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/crossplane-contrib/function-hcl/function/pkg/hclexpr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func parseExpr(t *testing.T, src string) hcl.Expression {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	return expr
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := []struct {
+		ident string
+		want  bool
+	}{
+		{"foo", true},
+		{"fooBar", true},
+		{"_fooBar", true},
+		{"foo-bar", true},
+		{"-foo-bar", false},
+		{"a b", false},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("index-%d", i), func(t *testing.T) {
+			assert.Equal(t, test.want, hclexpr.IsIdentifier(test.ident))
+		})
+	}
+}
+
+func TestNormalizeTraversal(t *testing.T) {
+	expr := parseExpr(t, `req.resource["my-bucket"]["not an identifier"][0]`)
+	trav := hclexpr.NormalizeTraversal(expr.Variables()[0])
+	require.Len(t, trav, 5)
+	assert.Equal(t, "my-bucket", trav[2].(hcl.TraverseAttr).Name)
+	assert.Equal(t, cty.StringVal("not an identifier"), trav[3].(hcl.TraverseIndex).Key)
+	assert.True(t, trav[4].(hcl.TraverseIndex).Key.Equals(cty.NumberIntVal(0)).True())
+}
+
+func TestUnguardedTraversals(t *testing.T) {
+	expr := parseExpr(t, `try(req.resource.a.status, "") != "" ? req.resource.b : can(req.resource.c)`)
+	var ranges []string
+	for _, trav := range hclexpr.UnguardedTraversals(expr) {
+		ranges = append(ranges, trav.SourceRange().String())
+	}
+	assert.Equal(t, []string{"test.hcl:1,40-54"}, ranges)
+}
+
+func TestReferences(t *testing.T) {
+	expr := parseExpr(t, `[req.resource.vpc.status, req.connection["db"].password, req.composite.spec, local.x, req.resource]`)
+	var refs []string
+	for _, r := range hclexpr.References(expr) {
+		refs = append(refs, r.Kind+":"+r.Name)
+	}
+	assert.Equal(t, []string{"resource:vpc", "connection:db", "composite:spec"}, refs)
+}
+
+func TestFindUnknownPaths(t *testing.T) {
+	val := cty.ObjectVal(map[string]cty.Value{
+		"spec": cty.ObjectVal(map[string]cty.Value{
+			"name":  cty.StringVal("foo"),
+			"ports": cty.TupleVal([]cty.Value{cty.NumberIntVal(80), cty.UnknownVal(cty.Number)}),
+			"tags":  cty.MapVal(map[string]cty.Value{"env": cty.UnknownVal(cty.String)}),
+		}),
+	})
+	paths, err := hclexpr.FindUnknownPaths(val)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".spec.ports[1]", ".spec.tags[env]"}, paths)
+}
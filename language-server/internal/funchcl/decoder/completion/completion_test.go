@@ -44,7 +44,7 @@ func expectCandidateLabels(t *testing.T, text string, xrd *xrd, pos hcl.Pos, exp
 func TestCompletionEmptyFile(t *testing.T) {
 	candidates := expectCandidateLabels(t, "", nil,
 		hcl.Pos{Line: 1, Column: 1},
-		[]string{"composite", "context", "function", "group", "locals", "requirement", "resource", "resources"},
+		[]string{"alias", "composite", "context", "contexts", "default_ready", "doc", "export_connection", "file_locals", "function", "group", "locals", "observe", "policy", "requirement", "requires", "resource", "resources"},
 	)
 	for _, c := range candidates.List {
 		assert.Equal(t, lang.BlockCandidateKind, c.Kind,
@@ -63,7 +63,7 @@ resource vpc {
 `
 		expectCandidateLabels(t, text, nil,
 			hcl.Pos{Line: 2, Column: 1},
-			[]string{"body", "composite", "condition", "context", "locals", "ready"})
+			[]string{"allow_external_name_change", "body", "composite", "condition", "context", "contexts", "deterministic", "enabled", "external_name", "labels", "locals", "ready", "ready_from_condition", "resource_name", "wait_for", "when"})
 	})
 
 	t.Run("with prefix", func(t *testing.T) {
@@ -74,7 +74,7 @@ resource vpc {
 `
 		expectCandidateLabels(t, text, nil,
 			hcl.Pos{Line: 2, Column: 4},
-			[]string{"composite", "condition", "context"})
+			[]string{"composite", "condition", "context", "contexts"})
 	})
 
 	t.Run("with prefix in name range", func(t *testing.T) {
@@ -85,7 +85,7 @@ resource vpc {
 `
 		candidates := expectCandidateLabels(t, text, nil,
 			hcl.Pos{Line: 2, Column: 4},
-			[]string{"condition", "context"})
+			[]string{"condition", "context", "contexts"})
 		expectedRange := hcl.Range{
 			Filename: testFileName,
 			Start:    hcl.Pos{Line: 2, Column: 3, Byte: 17},
@@ -102,7 +102,7 @@ resource vpc {
 `
 		candidates := expectCandidateLabels(t, text, nil,
 			hcl.Pos{Line: 2, Column: 5},
-			[]string{"composite", "condition", "context"},
+			[]string{"composite", "condition", "context", "contexts"},
 		) // includes composite since cursor is after co
 		// should fully edit the prefix and the whole freaking line
 		expectedRange := hcl.Range{
@@ -121,7 +121,7 @@ resource vpc {
 `
 		candidates := expectCandidateLabels(t, text, nil,
 			hcl.Pos{Line: 2, Column: 6},
-			[]string{"condition", "context"},
+			[]string{"condition", "context", "contexts"},
 		) // includes composite since cursor is after co
 		// should fully edit the prefix and the whole freaking line
 		expectedRange := hcl.Range{
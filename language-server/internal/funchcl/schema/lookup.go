@@ -58,6 +58,14 @@ func (l *lookup) BodySchema(bs schema.BlockStack) *schema.BodySchema {
 }
 
 func (l *lookup) compositeStatusSchema() *schema.AttributeSchema {
+	return l.compositeAttributeSchema("status")
+}
+
+func (l *lookup) compositeSpecSchema() *schema.AttributeSchema {
+	return l.compositeAttributeSchema("spec")
+}
+
+func (l *lookup) compositeAttributeSchema(name string) *schema.AttributeSchema {
 	cs, ok := l.dyn.(CompositeSchemaLookup)
 	if !ok {
 		return nil
@@ -70,7 +78,7 @@ func (l *lookup) compositeStatusSchema() *schema.AttributeSchema {
 	if !ok {
 		return nil
 	}
-	return cons.Attributes["status"]
+	return cons.Attributes[name]
 }
 
 func (l *lookup) AttributeSchema(bs schema.BlockStack, attrName string) *schema.AttributeSchema {
@@ -90,6 +98,12 @@ func (l *lookup) AttributeSchema(bs schema.BlockStack, attrName string) *schema.
 				return cs
 			}
 			return anyRequiredObjAttribute
+		case "spec":
+			cs := l.compositeSpecSchema()
+			if cs != nil {
+				return cs
+			}
+			return anyRequiredObjAttribute
 		case "connection":
 			return requiredMapStringAttribute
 		default:
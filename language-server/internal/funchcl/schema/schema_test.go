@@ -21,14 +21,22 @@ func TestTopLevelBlocks(t *testing.T) {
 	require.NotNil(t, rootSchema)
 
 	expectedBlocks := []string{
-		"resource",    // Create a resource (spec section)
-		"resources",   // Create list of resources (spec section)
-		"group",       // Groups of resources (spec section)
-		"locals",      // Local variables (spec section)
-		"composite",   // Write composite status/connection (spec section)
-		"context",     // Write to context (spec section)
-		"requirement", // Extra resources requirements (spec section)
-		"function",    // User-defined functions (spec section)
+		"resource",          // Create a resource (spec section)
+		"resources",         // Create list of resources (spec section)
+		"group",             // Groups of resources (spec section)
+		"locals",            // Local variables (spec section)
+		"composite",         // Write composite status/connection (spec section)
+		"context",           // Write to context (spec section)
+		"requirement",       // Extra resources requirements (spec section)
+		"function",          // User-defined functions (spec section)
+		"default_ready",     // Default ready values (spec section)
+		"policy",            // Policies (spec section)
+		"requires",          // Version requirements (spec section)
+		"alias",             // Reference aliases (spec section)
+		"file_locals",       // File-scoped local variables (spec section)
+		"observe",           // Observed objects (spec section)
+		"export_connection", // Connection detail exports (spec section)
+		"doc",               // Composition documentation (spec section)
 	}
 
 	for _, blockType := range expectedBlocks {
@@ -81,6 +89,12 @@ func TestResourcesBlockSchema(t *testing.T) {
 	assert.True(t, nameAttr.IsOptional,
 		"name attribute should be optional per spec (defaults to ${self.basename}-${each.key})")
 
+	// Per spec: resources blocks should have optional rename_from attribute
+	assert.Contains(t, resourcesSchema.Attributes, "rename_from",
+		"resources block should have optional 'rename_from' attribute per spec")
+	assert.True(t, resourcesSchema.Attributes["rename_from"].IsOptional,
+		"rename_from attribute should be optional per spec")
+
 	// Per spec: resources blocks support condition
 	assert.Contains(t, resourcesSchema.Attributes, "condition",
 		"resources block should support 'condition' attribute per spec")
@@ -99,6 +113,10 @@ func TestGroupBlockSchema(t *testing.T) {
 	assert.Contains(t, groupSchema.Attributes, "condition",
 		"group block should support 'condition' attribute per spec")
 
+	// Per spec: group blocks can prefix the names of their resources
+	assert.Contains(t, groupSchema.Attributes, "name_prefix",
+		"group block should support 'name_prefix' attribute per spec")
+
 	// Per spec: group blocks can contain resources, resource, locals, composite, context, requirement
 	expectedNested := []string{"resource", "resources", "group", "locals", "composite", "context", "requirement"}
 	for _, nested := range expectedNested {
@@ -141,6 +159,12 @@ func TestContextBlockSchema(t *testing.T) {
 	assert.True(t, keyAttr.IsRequired, "key attribute should be required per spec")
 	assert.True(t, valueAttr.IsRequired, "value attribute should be required per spec")
 
+	// Per spec: context and contexts blocks can mark their values as sensitive
+	for _, blockType := range []string{"context", "contexts"} {
+		assert.Contains(t, std[blockType].Attributes, "sensitive",
+			"%s block should support 'sensitive' attribute per spec", blockType)
+	}
+
 	// Per spec: context blocks can have locals
 	assert.Contains(t, contextSchema.NestedBlocks, "locals",
 		"context block should support 'locals' nested block per spec")
@@ -229,6 +253,19 @@ func TestFunctionBlockSchema(t *testing.T) {
 	argBlock := functionSchema.NestedBlocks["arg"]
 	assert.True(t, argBlock.AllowMultiple,
 		"arg blocks should allow multiple instances per spec")
+
+	// Per spec: multiple validate blocks with a required condition and message are allowed
+	require.Contains(t, functionSchema.NestedBlocks, "validate",
+		"function block should support 'validate' nested block per spec")
+	assert.True(t, functionSchema.NestedBlocks["validate"].AllowMultiple,
+		"validate blocks should allow multiple instances per spec")
+	validateSchema := std["validate"]
+	require.NotNil(t, validateSchema, "validate block should have schema")
+	for _, name := range []string{"condition", "message"} {
+		require.Contains(t, validateSchema.Attributes, name)
+		assert.True(t, validateSchema.Attributes[name].IsRequired,
+			"%s attribute of validate block should be required per spec", name)
+	}
 }
 
 // TestArgBlockSchema verifies function argument block schema per spec
@@ -282,12 +319,12 @@ func TestTemplateBlockSchema(t *testing.T) {
 	templateSchema := std["template"]
 	require.NotNil(t, templateSchema, "template block should have schema")
 
-	// Per spec: template blocks behave like resource blocks
-	// Should have same attributes and nested blocks as resource
+	// Per spec: template blocks have the body and nested blocks of resource blocks
+	// but conditions are set on the enclosing resources block
 	assert.Contains(t, templateSchema.Attributes, "body",
 		"template block should have 'body' attribute like resource block per spec")
-	assert.Contains(t, templateSchema.Attributes, "condition",
-		"template block should support 'condition' like resource block per spec")
+	assert.NotContains(t, templateSchema.Attributes, "condition",
+		"template block should not support 'condition' per spec")
 
 	// Should have same nested blocks
 	expectedNested := []string{"locals", "composite", "context", "ready"}
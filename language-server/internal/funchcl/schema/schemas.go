@@ -76,6 +76,75 @@ func init() {
 			Constraint:  schema.Bool{},
 		}
 	}
+	whenAttributeSchema := func() *schema.AttributeSchema {
+		return &schema.AttributeSchema{
+			Description: lang.Markdown("feature flag condition, the block is removed from the composition when false"),
+			IsOptional:  true,
+			Constraint:  schema.Bool{},
+		}
+	}
+	enabledAttributeSchema := func() *schema.AttributeSchema {
+		return &schema.AttributeSchema{
+			Description: lang.Markdown("constant `false` to skip the block without deleting its existing resources"),
+			IsOptional:  true,
+			Constraint:  schema.LiteralType{Type: cty.Bool},
+		}
+	}
+	deterministicAttributeSchema := func() *schema.AttributeSchema {
+		return &schema.AttributeSchema{
+			Description: lang.Markdown("constant `false` to allow calls to non-deterministic functions such as `timestamp()`"),
+			IsOptional:  true,
+			Constraint:  schema.LiteralType{Type: cty.Bool},
+		}
+	}
+	labelsAttributeSchema := func() *schema.AttributeSchema {
+		return &schema.AttributeSchema{
+			Description: lang.Markdown("constant list of labels used to restrict evaluation to some blocks"),
+			IsOptional:  true,
+			Constraint:  schema.List{Elem: schema.LiteralType{Type: cty.String}},
+		}
+	}
+	waitForAttributeSchema := func() *schema.AttributeSchema {
+		return &schema.AttributeSchema{
+			Description: lang.Markdown("values that must be known before the block is processed"),
+			IsOptional:  true,
+			Constraint:  schema.List{Elem: schema.Any{}},
+		}
+	}
+	sensitiveAttributeSchema := func() *schema.AttributeSchema {
+		return &schema.AttributeSchema{
+			Description: lang.Markdown("hide the values in debug output and error details, they are still written to the context"),
+			IsOptional:  true,
+			Constraint:  schema.Bool{},
+		}
+	}
+	selectAttributes := func() map[string]*schema.AttributeSchema {
+		return map[string]*schema.AttributeSchema{
+			"apiVersion": {
+				Description: lang.PlainText("k8s api version"),
+				IsRequired:  true,
+				Constraint:  schema.String{},
+			},
+			"kind": {
+				Description: lang.PlainText("k8s kind"),
+				IsRequired:  true,
+				Constraint:  schema.String{},
+			},
+			"matchName": {
+				Description: lang.PlainText("k8s object name to match"),
+				IsOptional:  true,
+				Constraint:  schema.String{},
+			},
+			"matchLabels": {
+				Description: lang.PlainText("k8s labels to match"),
+				IsOptional:  true,
+				Constraint: schema.Map{
+					Name: "label",
+					Elem: schema.String{},
+				},
+			},
+		}
+	}
 	localsBlock := func() *schema.BasicBlockSchema {
 		return &schema.BasicBlockSchema{
 			Description: lang.PlainText("local variables"),
@@ -83,12 +152,12 @@ func init() {
 	}
 	compositeBlock := func() *schema.BasicBlockSchema {
 		return &schema.BasicBlockSchema{
-			Description: lang.PlainText("composite status or connection"),
+			Description: lang.PlainText("composite status, spec or connection"),
 			Labels: []*schema.LabelSchema{
 				{
 					Name:          "what",
-					Description:   lang.PlainText("whether status or connection"),
-					AllowedValues: []string{"status", "connection"},
+					Description:   lang.PlainText("whether status, spec or connection"),
+					AllowedValues: []string{"status", "spec", "connection"},
 				},
 			},
 		}
@@ -98,6 +167,11 @@ func init() {
 			Description: lang.PlainText("assign a value in the context"),
 		}
 	}
+	contextsBlock := func() *schema.BasicBlockSchema {
+		return &schema.BasicBlockSchema{
+			Description: lang.PlainText("assign multiple values in the context"),
+		}
+	}
 	groupBlocks := func() map[string]*schema.BasicBlockSchema {
 		return map[string]*schema.BasicBlockSchema{
 			"locals": localsBlock(),
@@ -124,6 +198,7 @@ func init() {
 			},
 			"composite": compositeBlock(),
 			"context":   contextBlock(),
+			"contexts":  contextsBlock(),
 			"requirement": {
 				Description: lang.PlainText("require an existing resource"),
 				Labels: []*schema.LabelSchema{
@@ -133,6 +208,18 @@ func init() {
 					},
 				},
 			},
+			"observe": {
+				Description: lang.PlainText("read an existing object that is not managed by the composition"),
+				Labels: []*schema.LabelSchema{
+					{
+						Name:        "name",
+						Description: lang.PlainText("observed object name"),
+					},
+				},
+			},
+			"export_connection": {
+				Description: lang.PlainText("copy connection details of a resource to the composite connection"),
+			},
 		}
 	}
 	topLevelBlocks := func() map[string]*schema.BasicBlockSchema {
@@ -146,6 +233,30 @@ func init() {
 				},
 			},
 		}
+		g["default_ready"] = &schema.BasicBlockSchema{
+			Description: lang.PlainText("default ready value for resources of an api version and kind"),
+		}
+		g["policy"] = &schema.BasicBlockSchema{
+			Description: lang.PlainText("assertion checked against desired resources or the desired composite"),
+			Labels: []*schema.LabelSchema{
+				{
+					Name:        "name",
+					Description: lang.PlainText("policy name"),
+				},
+			},
+		}
+		g["requires"] = &schema.BasicBlockSchema{
+			Description: lang.PlainText("versions of the function that the composition requires"),
+		}
+		g["alias"] = &schema.BasicBlockSchema{
+			Description: lang.PlainText("short names for references under req"),
+		}
+		g["file_locals"] = &schema.BasicBlockSchema{
+			Description: lang.PlainText("local variables visible to the blocks of the same file"),
+		}
+		g["doc"] = &schema.BasicBlockSchema{
+			Description: lang.PlainText("composition documentation"),
+		}
 		return g
 	}
 	resChildren := func() map[string]*schema.BasicBlockSchema {
@@ -154,8 +265,12 @@ func init() {
 			"ready": {
 				Description: lang.PlainText("set ready condition"),
 			},
+			"ready_from_condition": {
+				Description: lang.PlainText("set ready condition from a condition of the observed resource"),
+			},
 			"composite": compositeBlock(),
 			"context":   contextBlock(),
+			"contexts":  contextsBlock(),
 		}
 	}
 
@@ -163,36 +278,74 @@ func init() {
 		"": {
 			NestedBlocks: topLevelBlocks(),
 		},
+		"alias": {
+			Description: lang.PlainText("aliases for static references under req"),
+		},
 		"group": {
 			Description: lang.PlainText("resource group"),
 			Attributes: map[string]*schema.AttributeSchema{
-				"condition": conditionAttributeSchema(),
+				"condition":     conditionAttributeSchema(),
+				"when":          whenAttributeSchema(),
+				"deterministic": deterministicAttributeSchema(),
+				"labels":        labelsAttributeSchema(),
+				"name_prefix": {
+					IsOptional:  true,
+					Description: lang.Markdown("prefix for the crossplane names of resources in the group, including nested groups"),
+					Constraint:  schema.String{},
+				},
 			},
 			NestedBlocks: groupBlocks(),
 		},
 		"locals": {
 			Description: lang.PlainText("local variables"),
 		},
+		"file_locals": {
+			Description: lang.PlainText("file local variables"),
+		},
 		"resource": {
 			Description: lang.PlainText("resource declaration"),
 			Attributes: map[string]*schema.AttributeSchema{
-				"condition": conditionAttributeSchema(),
-				"body":      basicBodyAttributeSchema(),
+				"condition":     conditionAttributeSchema(),
+				"when":          whenAttributeSchema(),
+				"deterministic": deterministicAttributeSchema(),
+				"labels":        labelsAttributeSchema(),
+				"enabled":       enabledAttributeSchema(),
+				"body":          basicBodyAttributeSchema(),
+				"resource_name": {
+					IsOptional:  true,
+					Description: lang.Markdown("the crossplane name of the resource, defaults to the block label"),
+					Constraint:  schema.String{},
+				},
+				"external_name": {
+					IsOptional:  true,
+					Description: lang.Markdown("the `crossplane.io/external-name` annotation of the resource"),
+					Constraint:  schema.String{},
+				},
+				"allow_external_name_change": {
+					IsOptional:  true,
+					Description: lang.Markdown("allow the external name of an existing resource to change"),
+					Constraint:  schema.Bool{},
+				},
+				"wait_for": waitForAttributeSchema(),
 			},
 			NestedBlocks: resChildren(),
 		},
 		"template": {
 			Description: lang.PlainText("template resource declaration"),
 			Attributes: map[string]*schema.AttributeSchema{
-				"condition": conditionAttributeSchema(),
-				"body":      basicBodyAttributeSchema(),
+				"body":     basicBodyAttributeSchema(),
+				"wait_for": waitForAttributeSchema(),
 			},
 			NestedBlocks: resChildren(),
 		},
 		"resources": {
 			Description: lang.PlainText("resource collection declaration"),
 			Attributes: map[string]*schema.AttributeSchema{
-				"condition": conditionAttributeSchema(),
+				"condition":     conditionAttributeSchema(),
+				"when":          whenAttributeSchema(),
+				"deterministic": deterministicAttributeSchema(),
+				"labels":        labelsAttributeSchema(),
+				"enabled":       enabledAttributeSchema(),
 				"for_each": {
 					IsOptional:  false,
 					Description: lang.Markdown("the collection to iterate over"),
@@ -203,6 +356,11 @@ func init() {
 					Description: lang.Markdown("the template for the crossplane name of individual resources"),
 					Constraint:  schema.String{},
 				},
+				"rename_from": {
+					IsOptional:  true,
+					Description: lang.Markdown("the previous crossplane name of individual resources, to keep existing resources after a rename"),
+					Constraint:  schema.String{},
+				},
 			},
 			NestedBlocks: map[string]*schema.BasicBlockSchema{
 				"template": {
@@ -211,6 +369,7 @@ func init() {
 				"locals":    localsBlock(),
 				"composite": compositeBlock(),
 				"context":   contextBlock(),
+				"contexts":  contextsBlock(),
 			},
 		},
 		"composite": {
@@ -225,6 +384,7 @@ func init() {
 						AnyAttribute:          schema.Any{},
 					},
 				},
+				"wait_for": waitForAttributeSchema(),
 			},
 			NestedBlocks: map[string]*schema.BasicBlockSchema{
 				"locals": localsBlock(),
@@ -243,6 +403,27 @@ func init() {
 					IsRequired:  true,
 					Constraint:  schema.Any{},
 				},
+				"sensitive": sensitiveAttributeSchema(),
+				"wait_for":  waitForAttributeSchema(),
+			},
+			NestedBlocks: map[string]*schema.BasicBlockSchema{
+				"locals": localsBlock(),
+			},
+		},
+		"contexts": {
+			Description: lang.PlainText("multiple context values declaration"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"body": {
+					Description: lang.PlainText("context keys and values"),
+					IsRequired:  true,
+					Constraint: schema.Object{
+						Description:           lang.PlainText("context keys and values"),
+						AllowInterpolatedKeys: true,
+						AnyAttribute:          schema.Any{},
+					},
+				},
+				"sensitive": sensitiveAttributeSchema(),
+				"wait_for":  waitForAttributeSchema(),
 			},
 			NestedBlocks: map[string]*schema.BasicBlockSchema{
 				"locals": localsBlock(),
@@ -260,34 +441,58 @@ func init() {
 				},
 			},
 		},
-		"select": {
-			Description: lang.PlainText("selection"),
+		"observe": {
+			Description: lang.PlainText("observed object declaration"),
+			Attributes: func() map[string]*schema.AttributeSchema {
+				attrs := selectAttributes()
+				attrs["condition"] = conditionAttributeSchema()
+				return attrs
+			}(),
+			NestedBlocks: map[string]*schema.BasicBlockSchema{
+				"locals":    localsBlock(),
+				"composite": compositeBlock(),
+				"context":   contextBlock(),
+				"contexts":  contextsBlock(),
+			},
+		},
+		"export_connection": {
+			Description: lang.PlainText("connection details export"),
 			Attributes: map[string]*schema.AttributeSchema{
-				"apiVersion": {
-					Description: lang.PlainText("k8s api version"),
+				"condition": conditionAttributeSchema(),
+				"from": {
+					Description: lang.PlainText("crossplane name of the resource whose connection details are exported"),
 					IsRequired:  true,
 					Constraint:  schema.String{},
 				},
-				"kind": {
-					Description: lang.PlainText("k8s kind"),
+				"map": {
+					Description: lang.PlainText("composite connection keys keyed by the connection keys of the resource"),
 					IsRequired:  true,
-					Constraint:  schema.String{},
+					Constraint:  schema.Map{Elem: schema.String{}},
 				},
-				"matchName": {
-					Description: lang.PlainText("k8s object name to match"),
+			},
+			NestedBlocks: map[string]*schema.BasicBlockSchema{
+				"locals": localsBlock(),
+			},
+		},
+		"doc": {
+			Description: lang.PlainText("documentation rendered into the composition annotations"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"title": {
+					Description: lang.PlainText("composition title"),
 					IsOptional:  true,
-					Constraint:  schema.String{},
+					Constraint:  schema.LiteralType{Type: cty.String},
 				},
-				"matchLabels": {
-					Description: lang.PlainText("k8s labels to match"),
+				"description": {
+					Description: lang.PlainText("composition description"),
 					IsOptional:  true,
-					Constraint: schema.Map{
-						Name: "label",
-						Elem: schema.String{},
-					},
+					Constraint:  schema.LiteralType{Type: cty.String},
 				},
 			},
 		},
+		"select": {
+			Description: lang.PlainText("selection"),
+			Attributes:  selectAttributes(),
+		},
 		"function": {
 			Description: lang.PlainText("function definition"),
 			Attributes: map[string]*schema.AttributeSchema{
@@ -314,6 +519,25 @@ func init() {
 					},
 					AllowMultiple: true,
 				},
+				"validate": {
+					Description:   lang.PlainText("argument validation"),
+					AllowMultiple: true,
+				},
+			},
+		},
+		"validate": {
+			Description: lang.PlainText("condition that the arguments of a function call must satisfy"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"condition": {
+					Description: lang.PlainText("condition that must be true for the call to succeed"),
+					IsRequired:  true,
+					Constraint:  schema.Bool{},
+				},
+				"message": {
+					Description: lang.PlainText("error message when the condition is false"),
+					IsRequired:  true,
+					Constraint:  schema.String{},
+				},
 			},
 		},
 		"arg": {
@@ -341,5 +565,91 @@ func init() {
 				},
 			},
 		},
+		"default_ready": {
+			Description: lang.PlainText("default ready value for resources without a ready block"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"apiVersion": {
+					Description: lang.PlainText("k8s api version of the resources, matches all api versions if not set"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"kind": {
+					Description: lang.PlainText("k8s kind of the resources, matches all kinds if not set"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"value": {
+					Description: lang.PlainText("ready status value (READY_UNSPECIFIED, READY_TRUE, or READY_FALSE)"),
+					IsRequired:  true,
+					Constraint:  schema.String{},
+				},
+			},
+			NestedBlocks: map[string]*schema.BasicBlockSchema{
+				"locals": localsBlock(),
+			},
+		},
+		"policy": {
+			Description: lang.PlainText("policy declaration"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"target": {
+					Description: lang.PlainText("what the policy is checked against (resources or composite), defaults to resources"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"severity": {
+					Description: lang.PlainText("severity of violations (error or warning), defaults to error"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"apiVersion": {
+					Description: lang.PlainText("k8s api version of the resources to check, matches all api versions if not set"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"kind": {
+					Description: lang.PlainText("k8s kind of the resources to check, matches all kinds if not set"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"assert": {
+					Description: lang.PlainText("condition that must be true for the policy to pass"),
+					IsRequired:  true,
+					Constraint:  schema.Bool{},
+				},
+				"message": {
+					Description: lang.PlainText("message reported when the policy fails"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+			},
+			NestedBlocks: map[string]*schema.BasicBlockSchema{
+				"locals": localsBlock(),
+			},
+		},
+		"requires": {
+			Description: lang.PlainText("version requirements"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"function_hcl": {
+					Description: lang.Markdown("semantic version constraint for the function, for example `>= 0.5`"),
+					IsRequired:  true,
+					Constraint:  schema.LiteralType{Type: cty.String},
+				},
+			},
+		},
+		"ready_from_condition": {
+			Description: lang.PlainText("ready condition from a condition of the observed resource"),
+			Attributes: map[string]*schema.AttributeSchema{
+				"type": {
+					Description: lang.PlainText("condition type, defaults to Ready"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+				"status": {
+					Description: lang.PlainText("condition status for which the resource is ready, defaults to True"),
+					IsOptional:  true,
+					Constraint:  schema.String{},
+				},
+			},
+		},
 	}
 }
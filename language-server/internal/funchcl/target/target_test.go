@@ -160,6 +160,58 @@ resource beta {
 		assert.Contains(t, resourceObj.Attributes, "alpha")
 		assert.Contains(t, resourceObj.Attributes, "beta")
 	})
+
+	t.Run("resource name", func(t *testing.T) {
+		text := `
+resource alpha {
+  resource_name = "primary-vpc"
+  body = {
+    apiVersion = "ec2.aws.upbound.io/v1beta1"
+    kind       = "VPC"
+  }
+}
+`
+		files := parseFiles(t, text)
+		targets := BuildTargets(files, nilDyn{}, nil)
+		s := targets.globals.AsSchema()
+		resourceObj := assertObjectSchema(t, SubSchema(s, "req", "resource"), "req.resource")
+		assert.Contains(t, resourceObj.Attributes, "primary-vpc")
+		assert.NotContains(t, resourceObj.Attributes, "alpha")
+	})
+
+	t.Run("group name prefix", func(t *testing.T) {
+		text := `
+group {
+  name_prefix = "net-"
+  group {
+    name_prefix = "a-"
+    resource vpc {
+      body = {
+        apiVersion = "ec2.aws.upbound.io/v1beta1"
+        kind       = "VPC"
+      }
+    }
+    resources subnets {
+      for_each = ["x", "y"]
+      template {
+        body = {
+          apiVersion = "ec2.aws.upbound.io/v1beta1"
+          kind       = "Subnet"
+        }
+      }
+    }
+  }
+}
+`
+		files := parseFiles(t, text)
+		targets := BuildTargets(files, nilDyn{}, nil)
+		s := targets.globals.AsSchema()
+		resourceObj := assertObjectSchema(t, SubSchema(s, "req", "resource"), "req.resource")
+		assert.Contains(t, resourceObj.Attributes, "net-a-vpc")
+		assert.NotContains(t, resourceObj.Attributes, "vpc")
+		collectionObj := assertObjectSchema(t, SubSchema(s, "req", "resources"), "req.resources")
+		assert.Contains(t, collectionObj.Attributes, "net-a-subnets")
+	})
 }
 
 // --- BuildTargets: locals schema inference ---
@@ -9,6 +9,7 @@ import (
 	"github.com/crossplane-contrib/function-hcl/language-server/internal/langhcl/schema"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func (t *Tree) add(node *Node, pathElements ...string) {
@@ -126,14 +127,15 @@ func buildTargets(files map[string]*hcl.File, dyn ourschema.DynamicLookup, compo
 				defRange := hcl.RangeBetween(current.TypeRange, current.OpenBraceRange)
 				ret.declarationRanges = append(ret.declarationRanges, defRange)
 				// add a req.resource.<foo> entry and a req.connection.<foo> entry
+				name := namePrefix(bs) + resourceName(current)
 				t.add(&Node{
-					Name:       current.Labels[0],
+					Name:       name,
 					Schema:     sch,
 					Definition: defRange,
 					NameRange:  current.LabelRanges[0],
 				}, "req", "resource")
 				t.add(&Node{
-					Name:       current.Labels[0],
+					Name:       name,
 					Schema:     &schema.AttributeSchema{Constraint: schema.Map{Elem: schema.String{}}},
 					Definition: defRange,
 					NameRange:  current.LabelRanges[0],
@@ -160,8 +162,9 @@ func buildTargets(files map[string]*hcl.File, dyn ourschema.DynamicLookup, compo
 				sch := ourschema.DependentSchemaOrDefault(dyn, current)
 				defRange := hcl.RangeBetween(parent.TypeRange, parent.OpenBraceRange)
 				// add a req.resources.<foo> entry and a req.connections.<foo> entry
+				collName := namePrefix(bs) + parent.Labels[0]
 				t.add(&Node{
-					Name: parent.Labels[0],
+					Name: collName,
 					Schema: &schema.AttributeSchema{
 						Constraint: schema.List{Elem: sch.Constraint},
 					},
@@ -169,7 +172,7 @@ func buildTargets(files map[string]*hcl.File, dyn ourschema.DynamicLookup, compo
 					NameRange:  parent.LabelRanges[0],
 				}, "req", "resources")
 				t.add(&Node{
-					Name: parent.Labels[0],
+					Name: collName,
 					Schema: &schema.AttributeSchema{
 						Constraint: schema.List{Elem: schema.Map{Elem: schema.String{}}},
 					},
@@ -406,3 +409,36 @@ func (t *Targets) enhanceLocalSchemas(coll *localsCollection, globalSchema *sche
 		t.enhanceLocalSchemas(child, globalSchema, fileSource)
 	}
 }
+
+// resourceName returns the name of the resource declared by the supplied block. This is the value of the
+// resource_name attribute when it is a constant string, and the label of the block otherwise.
+func resourceName(block *hclsyntax.Block) string {
+	if attr, ok := block.Body.Attributes["resource_name"]; ok && len(attr.Expr.Variables()) == 0 {
+		v, diags := attr.Expr.Value(nil)
+		if !diags.HasErrors() && v.IsWhollyKnown() && !v.IsNull() && v.Type() == cty.String && v.AsString() != "" {
+			return v.AsString()
+		}
+	}
+	return block.Labels[0]
+}
+
+// namePrefix returns the combined name_prefix of all groups enclosing the current block of the supplied stack,
+// outermost first. Prefixes that are not constant strings are ignored.
+func namePrefix(bs schema.BlockStack) string {
+	prefix := ""
+	for n := 1; ; n++ {
+		b := bs.Peek(n)
+		if b.Type == "" {
+			return prefix
+		}
+		if b.Type != "group" {
+			continue
+		}
+		if attr, ok := b.Body.Attributes["name_prefix"]; ok && len(attr.Expr.Variables()) == 0 {
+			v, diags := attr.Expr.Value(nil)
+			if !diags.HasErrors() && v.IsWhollyKnown() && !v.IsNull() && v.Type() == cty.String {
+				prefix = v.AsString() + prefix
+			}
+		}
+	}
+}
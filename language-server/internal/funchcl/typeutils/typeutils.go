@@ -5,7 +5,8 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
-// KVSchema returns a schema for the `each` variable. It is an object with a key and value property.
+// KVSchema returns a schema for the `each` variable. It is an object with a key and value property as well
+// as the index, first, last and count properties of the iteration.
 // If this cannot be derived, the function returns nil.
 func KVSchema(base *schema.AttributeSchema) *schema.AttributeSchema {
 	if base == nil {
@@ -60,45 +61,33 @@ func TypeConstraint(t cty.Type) schema.Constraint {
 }
 
 func collectionSchemaFromType(cType cty.Type) *schema.AttributeSchema {
-	var inferredSchema *schema.AttributeSchema
 	switch {
 	case cType.IsMapType() && cType.MapElementType() != nil:
-		inferredSchema = &schema.AttributeSchema{
-			Constraint: schema.Object{
-				Attributes: map[string]*schema.AttributeSchema{
-					"key":   {Constraint: schema.String{}},
-					"value": {Constraint: TypeConstraint(*cType.MapElementType())},
-				},
-			},
-		}
+		return iteratorSchema(schema.String{}, TypeConstraint(*cType.MapElementType()))
 	case cType.IsListType() && cType.ListElementType() != nil:
-		inferredSchema = &schema.AttributeSchema{
-			Constraint: schema.Object{
-				Attributes: map[string]*schema.AttributeSchema{
-					"key":   {Constraint: schema.Number{}},
-					"value": {Constraint: TypeConstraint(*cType.ListElementType())},
-				},
-			},
-		}
+		return iteratorSchema(schema.Number{}, TypeConstraint(*cType.ListElementType()))
 	case cType.IsTupleType() && len(cType.TupleElementTypes()) == 1:
-		inferredSchema = &schema.AttributeSchema{
-			Constraint: schema.Object{
-				Attributes: map[string]*schema.AttributeSchema{
-					"key":   {Constraint: schema.Number{}},
-					"value": {Constraint: TypeConstraint(cType.TupleElementTypes()[0])},
-				},
-			},
-		}
+		return iteratorSchema(schema.Number{}, TypeConstraint(cType.TupleElementTypes()[0]))
 	case cType.IsSetType() && cType.SetElementType() != nil:
 		cons := TypeConstraint(*cType.SetElementType())
-		inferredSchema = &schema.AttributeSchema{
-			Constraint: schema.Object{
-				Attributes: map[string]*schema.AttributeSchema{
-					"key":   {Constraint: cons},
-					"value": {Constraint: cons},
-				},
+		return iteratorSchema(cons, cons)
+	}
+	return nil
+}
+
+// iteratorSchema returns the schema of the `each` variable with the supplied key and value constraints
+// and the iteration metadata that is the same for all collections.
+func iteratorSchema(key, value schema.Constraint) *schema.AttributeSchema {
+	return &schema.AttributeSchema{
+		Constraint: schema.Object{
+			Attributes: map[string]*schema.AttributeSchema{
+				"key":   {Constraint: key},
+				"value": {Constraint: value},
+				"index": {Constraint: schema.Number{}},
+				"first": {Constraint: schema.Bool{}},
+				"last":  {Constraint: schema.Bool{}},
+				"count": {Constraint: schema.Number{}},
 			},
-		}
+		},
 	}
-	return inferredSchema
 }
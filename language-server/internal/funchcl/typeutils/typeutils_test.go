@@ -60,6 +60,9 @@ func TestKvSchema(t *testing.T) {
 		kvObj := assertObjectSchema(t, kv, "kv")
 		assert.Contains(t, kvObj.Attributes, "key")
 		assert.Contains(t, kvObj.Attributes, "value")
+		for _, name := range []string{"index", "first", "last", "count"} {
+			assert.Contains(t, kvObj.Attributes, name)
+		}
 	})
 
 	t.Run("map type produces string key", func(t *testing.T) {
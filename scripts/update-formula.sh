@@ -107,9 +107,9 @@ class $class < Formula
   def install
     cd "function" do
       ldflags = %W[
-        -X main.Version=${VERSION}
-        -X main.Commit=${COMMIT}
-        -X main.BuildDate=${BUILD_DATE}
+        -X github.com/crossplane-contrib/function-hcl/function/internal/version.Version=${VERSION}
+        -X github.com/crossplane-contrib/function-hcl/function/internal/version.Commit=${COMMIT}
+        -X github.com/crossplane-contrib/function-hcl/function/internal/version.BuildDate=${BUILD_DATE}
       ]
       system "go", "build", *std_go_args(ldflags:, output: bin/"fn-hcl-tools"), "./cmd/fn-hcl-tools"
     end